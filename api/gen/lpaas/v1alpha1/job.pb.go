@@ -13,6 +13,8 @@ package lpaasv1alpha1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -25,29 +27,2519 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Output streams of a job.
+type OutputStream int32
+
+const (
+	OutputStream_OUTPUT_STREAM_BOTH   OutputStream = 0
+	OutputStream_OUTPUT_STREAM_STDOUT OutputStream = 1
+	OutputStream_OUTPUT_STREAM_STDERR OutputStream = 2
+)
+
+// Enum value maps for OutputStream.
+var (
+	OutputStream_name = map[int32]string{
+		0: "OUTPUT_STREAM_BOTH",
+		1: "OUTPUT_STREAM_STDOUT",
+		2: "OUTPUT_STREAM_STDERR",
+	}
+	OutputStream_value = map[string]int32{
+		"OUTPUT_STREAM_BOTH":   0,
+		"OUTPUT_STREAM_STDOUT": 1,
+		"OUTPUT_STREAM_STDERR": 2,
+	}
+)
+
+func (x OutputStream) Enum() *OutputStream {
+	p := new(OutputStream)
+	*p = x
+	return p
+}
+
+func (x OutputStream) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OutputStream) Descriptor() protoreflect.EnumDescriptor {
+	return file_lpaas_v1alpha1_job_proto_enumTypes[0].Descriptor()
+}
+
+func (OutputStream) Type() protoreflect.EnumType {
+	return &file_lpaas_v1alpha1_job_proto_enumTypes[0]
+}
+
+func (x OutputStream) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OutputStream.Descriptor instead.
+func (OutputStream) EnumDescriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{0}
+}
+
+// Orders of the jobs listed by ListJobs. Pages list the jobs after the last
+// job of the previous page in this order, so jobs added or removed meanwhile
+// do not cause other jobs to be skipped or listed twice. Only
+// LIST_JOBS_SORT_STATUS orders by something that changes; see there.
+type ListJobsSort int32
+
+const (
+	// By owner, then as LIST_JOBS_SORT_CREATED.
+	ListJobsSort_LIST_JOBS_SORT_UNSPECIFIED ListJobsSort = 0
+	// By when StartJob started or queued the jobs, oldest first.
+	ListJobsSort_LIST_JOBS_SORT_CREATED ListJobsSort = 1
+	// By status, in alphabetical order, then as LIST_JOBS_SORT_CREATED.
+	//
+	// This order is not stable across pages: a job whose status changes
+	// between two pages moves in it, so it is skipped if it moves before the
+	// page token and listed again if it moves after it. List all jobs at once,
+	// or page by LIST_JOBS_SORT_CREATED and sort by status on the client, to
+	// see every job exactly once.
+	ListJobsSort_LIST_JOBS_SORT_STATUS ListJobsSort = 2
+)
+
+// Enum value maps for ListJobsSort.
+var (
+	ListJobsSort_name = map[int32]string{
+		0: "LIST_JOBS_SORT_UNSPECIFIED",
+		1: "LIST_JOBS_SORT_CREATED",
+		2: "LIST_JOBS_SORT_STATUS",
+	}
+	ListJobsSort_value = map[string]int32{
+		"LIST_JOBS_SORT_UNSPECIFIED": 0,
+		"LIST_JOBS_SORT_CREATED":     1,
+		"LIST_JOBS_SORT_STATUS":      2,
+	}
+)
+
+func (x ListJobsSort) Enum() *ListJobsSort {
+	p := new(ListJobsSort)
+	*p = x
+	return p
+}
+
+func (x ListJobsSort) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ListJobsSort) Descriptor() protoreflect.EnumDescriptor {
+	return file_lpaas_v1alpha1_job_proto_enumTypes[1].Descriptor()
+}
+
+func (ListJobsSort) Type() protoreflect.EnumType {
+	return &file_lpaas_v1alpha1_job_proto_enumTypes[1]
+}
+
+func (x ListJobsSort) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ListJobsSort.Descriptor instead.
+func (ListJobsSort) EnumDescriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{1}
+}
+
 type StartJobRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Command string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Args    []string               `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// Maximum number of open files (RLIMIT_NOFILE) for the job.
+	// 0 keeps the limit inherited from the worker.
+	NofileLimit uint64 `protobuf:"varint,3,opt,name=nofile_limit,json=nofileLimit,proto3" json:"nofile_limit,omitempty"`
+	// Wait until the process is confirmed running in its cgroup before returning.
+	ConfirmRunning bool `protobuf:"varint,4,opt,name=confirm_running,json=confirmRunning,proto3" json:"confirm_running,omitempty"`
+	// KEY=VALUE entries added to the environment inherited from the worker.
+	// They override worker variables; for duplicate keys the last entry wins.
+	Env []string `protobuf:"bytes,5,rep,name=env,proto3" json:"env,omitempty"`
+	// Absolute path of an existing directory to run the job in.
+	// Empty runs the job in the worker's working directory.
+	WorkingDir string `protobuf:"bytes,6,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
+	// Stop the job once its last streaming client disconnects and none
+	// reconnects within the server's grace period.
+	KillOnDisconnect bool `protobuf:"varint,7,opt,name=kill_on_disconnect,json=killOnDisconnect,proto3" json:"kill_on_disconnect,omitempty"`
+	// Host paths to expose read-only inside the job's own mount namespace.
+	// Requires the worker to run as root.
+	BindMounts []*BindMount `protobuf:"bytes,8,rep,name=bind_mounts,json=bindMounts,proto3" json:"bind_mounts,omitempty"`
+	// Cgroup limits of the job. Unset fields use the owner's default profile.
+	Resources *ResourceProfile `protobuf:"bytes,9,opt,name=resources,proto3" json:"resources,omitempty"`
+	// Kill the job once it has run this long; it then reports status TimedOut.
+	// Unset lets the job run until it exits or is stopped.
+	Timeout *durationpb.Duration `protobuf:"bytes,10,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Client-chosen key making retries safe: a request reusing the key of an
+	// earlier request by the same client returns the job that request started.
+	// Keys of different clients never collide.
+	IdempotencyKey string `protobuf:"bytes,11,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// Program name passed to the command as argv[0], for tools that behave
+	// differently depending on it. Empty uses the command.
+	Argv0 string `protobuf:"bytes,12,opt,name=argv0,proto3" json:"argv0,omitempty"`
+	// Give the job a private temporary directory, exposed as TMPDIR and
+	// removed along with the job.
+	PrivateTmp bool `protobuf:"varint,13,opt,name=private_tmp,json=privateTmp,proto3" json:"private_tmp,omitempty"`
+	// Run the job in its private temporary directory. Requires private_tmp
+	// and excludes working_dir.
+	WorkInPrivateTmp bool `protobuf:"varint,14,opt,name=work_in_private_tmp,json=workInPrivateTmp,proto3" json:"work_in_private_tmp,omitempty"`
+	// Queue the job if the running jobs limits are reached, instead of failing
+	// with RESOURCE_EXHAUSTED. A queued job has status "Queued" and starts once
+	// a running job finishes, in the order jobs were queued.
+	Queue bool `protobuf:"varint,15,opt,name=queue,proto3" json:"queue,omitempty"`
+	// Issue a lease token for the job, returned in the response. Presenting it
+	// to StopJob, GetStatus or StreamOutput grants access to the job regardless
+	// of the client certificate's CN, e.g. from a later CI stage with a
+	// different certificate.
+	Lease bool `protobuf:"varint,16,opt,name=lease,proto3" json:"lease,omitempty"`
+	// Labels tagging the job, e.g. pipeline=build, returned in GetStatus and
+	// ListJobs and selectable in ListJobs. At most 64; keys and values are up
+	// to 63 letters, digits and ".-_/", and keys start with a letter or digit.
+	Labels map[string]string `protobuf:"bytes,17,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Connect the job's stdin to a pipe fed with WriteStdin, instead of
+	// /dev/null.
+	Stdin bool `protobuf:"varint,18,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	// User and group to run the job as, e.g. an unprivileged user, instead of
+	// the worker's. Set both or neither. Fails with PERMISSION_DENIED if the
+	// worker lacks the privileges to change them.
+	Uid *uint32 `protobuf:"varint,19,opt,name=uid,proto3,oneof" json:"uid,omitempty"`
+	Gid *uint32 `protobuf:"varint,20,opt,name=gid,proto3,oneof" json:"gid,omitempty"`
+	// Supplementary groups of the job, with uid and gid set. Empty drops the
+	// worker's supplementary groups.
+	Groups []uint32 `protobuf:"varint,21,rep,packed,name=groups,proto3" json:"groups,omitempty"`
+	// Namespaces isolating the job from the host and from other jobs.
+	// Requires the worker to run as root; fails with PERMISSION_DENIED
+	// otherwise.
+	Namespaces *Namespaces `protobuf:"bytes,22,opt,name=namespaces,proto3" json:"namespaces,omitempty"`
+	// How much of the job's output the server retains for streaming, on top
+	// of its own limits. Unset retains as much as the server does.
+	OutputRetention *OutputRetention `protobuf:"bytes,23,opt,name=output_retention,json=outputRetention,proto3" json:"output_retention,omitempty"`
+	// Scheduling niceness of the job, from -20 (highest priority) to 19
+	// (lowest). 0 keeps the worker's niceness. Negative values require a
+	// certificate with the admin OU; fails with PERMISSION_DENIED otherwise.
+	Nice int32 `protobuf:"varint,24,opt,name=nice,proto3" json:"nice,omitempty"`
+	// Kill the job once it has written more than this many bytes of output in
+	// total, counting output the server no longer retains; it then reports
+	// status OutputLimitExceeded. Catches jobs stuck printing in a loop. 0
+	// sets no limit.
+	MaxOutputBytes uint64 `protobuf:"varint,25,opt,name=max_output_bytes,json=maxOutputBytes,proto3" json:"max_output_bytes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StartJobRequest) Reset() {
+	*x = StartJobRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartJobRequest) ProtoMessage() {}
+
+func (x *StartJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartJobRequest.ProtoReflect.Descriptor instead.
+func (*StartJobRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartJobRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetNofileLimit() uint64 {
+	if x != nil {
+		return x.NofileLimit
+	}
+	return 0
+}
+
+func (x *StartJobRequest) GetConfirmRunning() bool {
+	if x != nil {
+		return x.ConfirmRunning
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetKillOnDisconnect() bool {
+	if x != nil {
+		return x.KillOnDisconnect
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetBindMounts() []*BindMount {
+	if x != nil {
+		return x.BindMounts
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetResources() *ResourceProfile {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetArgv0() string {
+	if x != nil {
+		return x.Argv0
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetPrivateTmp() bool {
+	if x != nil {
+		return x.PrivateTmp
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetWorkInPrivateTmp() bool {
+	if x != nil {
+		return x.WorkInPrivateTmp
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetQueue() bool {
+	if x != nil {
+		return x.Queue
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetLease() bool {
+	if x != nil {
+		return x.Lease
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetStdin() bool {
+	if x != nil {
+		return x.Stdin
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetUid() uint32 {
+	if x != nil && x.Uid != nil {
+		return *x.Uid
+	}
+	return 0
+}
+
+func (x *StartJobRequest) GetGid() uint32 {
+	if x != nil && x.Gid != nil {
+		return *x.Gid
+	}
+	return 0
+}
+
+func (x *StartJobRequest) GetGroups() []uint32 {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetNamespaces() *Namespaces {
+	if x != nil {
+		return x.Namespaces
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetOutputRetention() *OutputRetention {
+	if x != nil {
+		return x.OutputRetention
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetNice() int32 {
+	if x != nil {
+		return x.Nice
+	}
+	return 0
+}
+
+func (x *StartJobRequest) GetMaxOutputBytes() uint64 {
+	if x != nil {
+		return x.MaxOutputBytes
+	}
+	return 0
+}
+
+// Limits on the output of a job the server retains. Older output is
+// discarded; streams that had not read it yet get a chunk with discarded set.
+type OutputRetention struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keep at most the last max_bytes of output. 0 sets no size limit.
+	MaxBytes uint64 `protobuf:"varint,1,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	// Keep only the output written within max_age, e.g. for compliance. Unset
+	// or 0 sets no age limit.
+	MaxAge        *durationpb.Duration `protobuf:"bytes,2,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputRetention) Reset() {
+	*x = OutputRetention{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputRetention) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputRetention) ProtoMessage() {}
+
+func (x *OutputRetention) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputRetention.ProtoReflect.Descriptor instead.
+func (*OutputRetention) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OutputRetention) GetMaxBytes() uint64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *OutputRetention) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+// Cgroup limits of a job. Zero values use the server's defaults.
+type ResourceProfile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Hard cap on the CPU time the job may use, in percent of one CPU, even if
+	// other CPUs are idle. Set cpu_percent for a hard cap, cpu_weight for
+	// proportional sharing, or both. With neither, the server's defaults
+	// apply; with only cpu_weight, the job is not capped.
+	CpuPercent uint64 `protobuf:"varint,1,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"`
+	// Memory limit in bytes.
+	MemoryBytes uint64 `protobuf:"varint,2,opt,name=memory_bytes,json=memoryBytes,proto3" json:"memory_bytes,omitempty"`
+	// Read and write bandwidth limit on the I/O devices, in bytes per second.
+	IoBytesPerSec uint64 `protobuf:"varint,3,opt,name=io_bytes_per_sec,json=ioBytesPerSec,proto3" json:"io_bytes_per_sec,omitempty"`
+	// Maximum number of processes and threads of the job. Not enforced on
+	// workers whose kernel lacks the pids controller.
+	MaxPids uint64 `protobuf:"varint,4,opt,name=max_pids,json=maxPids,proto3" json:"max_pids,omitempty"`
+	// Read and write bandwidth limits on the I/O devices, in bytes per second,
+	// taking precedence over io_bytes_per_sec.
+	IoReadBytesPerSec  uint64 `protobuf:"varint,5,opt,name=io_read_bytes_per_sec,json=ioReadBytesPerSec,proto3" json:"io_read_bytes_per_sec,omitempty"`
+	IoWriteBytesPerSec uint64 `protobuf:"varint,6,opt,name=io_write_bytes_per_sec,json=ioWriteBytesPerSec,proto3" json:"io_write_bytes_per_sec,omitempty"`
+	// Read and write operations per second allowed on the I/O devices. 0
+	// leaves them unlimited unless the server sets a default.
+	IoReadIops  uint64 `protobuf:"varint,7,opt,name=io_read_iops,json=ioReadIops,proto3" json:"io_read_iops,omitempty"`
+	IoWriteIops uint64 `protobuf:"varint,8,opt,name=io_write_iops,json=ioWriteIops,proto3" json:"io_write_iops,omitempty"`
+	// Block devices the I/O limits apply to, as major:minor, e.g. "8:0". A
+	// partition stands for its disk. Devices missing on the worker are skipped
+	// with a limit warning. Empty limits the disk backing the root filesystem.
+	IoDevices []string `protobuf:"bytes,9,rep,name=io_devices,json=ioDevices,proto3" json:"io_devices,omitempty"`
+	// Share of the CPU the job gets while jobs contend for it, in proportion to
+	// the weights of the others, from 1 to 10000 (default 100). Idle CPU is
+	// left to jobs that can use it.
+	CpuWeight     uint64 `protobuf:"varint,10,opt,name=cpu_weight,json=cpuWeight,proto3" json:"cpu_weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceProfile) Reset() {
+	*x = ResourceProfile{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceProfile) ProtoMessage() {}
+
+func (x *ResourceProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceProfile.ProtoReflect.Descriptor instead.
+func (*ResourceProfile) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ResourceProfile) GetCpuPercent() uint64 {
+	if x != nil {
+		return x.CpuPercent
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetMemoryBytes() uint64 {
+	if x != nil {
+		return x.MemoryBytes
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetIoBytesPerSec() uint64 {
+	if x != nil {
+		return x.IoBytesPerSec
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetMaxPids() uint64 {
+	if x != nil {
+		return x.MaxPids
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetIoReadBytesPerSec() uint64 {
+	if x != nil {
+		return x.IoReadBytesPerSec
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetIoWriteBytesPerSec() uint64 {
+	if x != nil {
+		return x.IoWriteBytesPerSec
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetIoReadIops() uint64 {
+	if x != nil {
+		return x.IoReadIops
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetIoWriteIops() uint64 {
+	if x != nil {
+		return x.IoWriteIops
+	}
+	return 0
+}
+
+func (x *ResourceProfile) GetIoDevices() []string {
+	if x != nil {
+		return x.IoDevices
+	}
+	return nil
+}
+
+func (x *ResourceProfile) GetCpuWeight() uint64 {
+	if x != nil {
+		return x.CpuWeight
+	}
+	return 0
+}
+
+// Namespaces a job runs in, instead of the worker's.
+type Namespaces struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Own PID namespace, in which the job is PID 1 and sees only its own
+	// processes in /proc. Implies mount. As PID 1, the job ignores signals it
+	// has no handler for, so StopJob may only end it after the grace period.
+	Pid bool `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	// Own mount namespace, so mounts made by the job stay invisible to the host.
+	Mount bool `protobuf:"varint,2,opt,name=mount,proto3" json:"mount,omitempty"`
+	// Own network namespace with only a loopback interface, cutting the job
+	// off from the host's network and from other jobs.
+	Network       bool `protobuf:"varint,3,opt,name=network,proto3" json:"network,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Namespaces) Reset() {
+	*x = Namespaces{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Namespaces) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Namespaces) ProtoMessage() {}
+
+func (x *Namespaces) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Namespaces.ProtoReflect.Descriptor instead.
+func (*Namespaces) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Namespaces) GetPid() bool {
+	if x != nil {
+		return x.Pid
+	}
+	return false
+}
+
+func (x *Namespaces) GetMount() bool {
+	if x != nil {
+		return x.Mount
+	}
+	return false
+}
+
+func (x *Namespaces) GetNetwork() bool {
+	if x != nil {
+		return x.Network
+	}
+	return false
+}
+
+// A host path bind mounted read-only into a job.
+type BindMount struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Absolute path on the host.
+	Source string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// Absolute path of an existing file or directory to mount over.
+	Target        string `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BindMount) Reset() {
+	*x = BindMount{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BindMount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BindMount) ProtoMessage() {}
+
+func (x *BindMount) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BindMount.ProtoReflect.Descriptor instead.
+func (*BindMount) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BindMount) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *BindMount) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type StartJobResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Opaque token granting access to the job, if the request asked for a
+	// lease. Keep it secret like a credential.
+	LeaseToken    string `protobuf:"bytes,2,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartJobResponse) Reset() {
+	*x = StartJobResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartJobResponse) ProtoMessage() {}
+
+func (x *StartJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartJobResponse.ProtoReflect.Descriptor instead.
+func (*StartJobResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StartJobResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StartJobResponse) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+// Request message for RestartJob.
+type RestartJobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the finished job to restart.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Issue a lease token for the new job, as with StartJob.
+	Lease         bool `protobuf:"varint,2,opt,name=lease,proto3" json:"lease,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestartJobRequest) Reset() {
+	*x = RestartJobRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestartJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestartJobRequest) ProtoMessage() {}
+
+func (x *RestartJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestartJobRequest.ProtoReflect.Descriptor instead.
+func (*RestartJobRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RestartJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RestartJobRequest) GetLease() bool {
+	if x != nil {
+		return x.Lease
+	}
+	return false
+}
+
+type JobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Lease token of the job, used instead of the client certificate's CN to
+	// authorize the request. Honored by GetStatus only.
+	LeaseToken    string `protobuf:"bytes,2,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *JobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *JobRequest) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+// Request message for StopJob.
+type StopJobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// How long to wait after SIGTERM before killing the job.
+	// Unset uses the server default, zero kills the job immediately.
+	GracePeriod *durationpb.Duration `protobuf:"bytes,2,opt,name=grace_period,json=gracePeriod,proto3" json:"grace_period,omitempty"`
+	// Lease token of the job, used instead of the client certificate's CN to
+	// authorize the request.
+	LeaseToken    string `protobuf:"bytes,3,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopJobRequest) Reset() {
+	*x = StopJobRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopJobRequest) ProtoMessage() {}
+
+func (x *StopJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopJobRequest.ProtoReflect.Descriptor instead.
+func (*StopJobRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StopJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StopJobRequest) GetGracePeriod() *durationpb.Duration {
+	if x != nil {
+		return x.GracePeriod
+	}
+	return nil
+}
+
+func (x *StopJobRequest) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+// Request message for SendSignal.
+type SendSignalRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Signal name ("SIGHUP" or "HUP") or number ("1").
+	Signal        string `protobuf:"bytes,2,opt,name=signal,proto3" json:"signal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendSignalRequest) Reset() {
+	*x = SendSignalRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendSignalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendSignalRequest) ProtoMessage() {}
+
+func (x *SendSignalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendSignalRequest.ProtoReflect.Descriptor instead.
+func (*SendSignalRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SendSignalRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SendSignalRequest) GetSignal() string {
+	if x != nil {
+		return x.Signal
+	}
+	return ""
+}
+
+// Empty message for SendSignalResponse
+type SendSignalResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendSignalResponse) Reset() {
+	*x = SendSignalResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendSignalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendSignalResponse) ProtoMessage() {}
+
+func (x *SendSignalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendSignalResponse.ProtoReflect.Descriptor instead.
+func (*SendSignalResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{10}
+}
+
+// Response for GetStatus.
+type StatusJobResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Current status of the job.
+	// Values: "Pending", "Queued", "Running", "Stopped", "Exited", "Failed",
+	// "TimedOut", "OOMKilled", "OutputLimitExceeded", "Orphaned". Orphaned
+	// jobs were still running when the worker restarted, so their outcome is
+	// unknown.
+	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	// Exit code of the command.
+	ExitCode *int32 `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
+	// Error message: exit_error and cleanup_error joined, for display.
+	Error *string `protobuf:"bytes,4,opt,name=error,proto3,oneof" json:"error,omitempty"`
+	// When the job's process started.
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// When the job finished. Unset while the job is running.
+	FinishedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	// Peak memory usage of the job in bytes, if the server tracks it.
+	PeakMemoryBytes *uint64 `protobuf:"varint,7,opt,name=peak_memory_bytes,json=peakMemoryBytes,proto3,oneof" json:"peak_memory_bytes,omitempty"`
+	// Number of clients currently streaming the job's output.
+	ActiveStreams uint32 `protobuf:"varint,8,opt,name=active_streams,json=activeStreams,proto3" json:"active_streams,omitempty"`
+	// Name of the signal that terminated the job, e.g. "SIGKILL".
+	// Unset if the job is running or exited on its own.
+	Signal *string `protobuf:"bytes,9,opt,name=signal,proto3,oneof" json:"signal,omitempty"`
+	// Resource limits the kernel applied differently than requested, e.g.
+	// rounded, or that could not be applied at all.
+	LimitWarnings []string `protobuf:"bytes,10,rep,name=limit_warnings,json=limitWarnings,proto3" json:"limit_warnings,omitempty"`
+	// How the job was started, to start it again.
+	Invocation *JobInvocation `protobuf:"bytes,11,opt,name=invocation,proto3" json:"invocation,omitempty"`
+	// Absolute path of the binary the job runs, resolved when it was started.
+	CommandPath string `protobuf:"bytes,12,opt,name=command_path,json=commandPath,proto3" json:"command_path,omitempty"`
+	// Labels the job was started with.
+	Labels map[string]string `protobuf:"bytes,13,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Command the job runs, as given when it was started.
+	Command string `protobuf:"bytes,14,opt,name=command,proto3" json:"command,omitempty"`
+	// Arguments of the command. Empty if args_redacted is set.
+	Args []string `protobuf:"bytes,15,rep,name=args,proto3" json:"args,omitempty"`
+	// Set if the server does not return job arguments, as they may hold
+	// secrets. The arguments are left out of invocation as well.
+	ArgsRedacted bool `protobuf:"varint,16,opt,name=args_redacted,json=argsRedacted,proto3" json:"args_redacted,omitempty"`
+	// Number of the signal that terminated the job, set along with signal.
+	SignalNumber *int32 `protobuf:"varint,17,opt,name=signal_number,json=signalNumber,proto3,oneof" json:"signal_number,omitempty"`
+	// Bytes of output the job has written so far, to stdout and stderr
+	// together. Output discarded by the retention policy still counts, so it
+	// only grows while the job runs.
+	OutputBytes *uint64 `protobuf:"varint,18,opt,name=output_bytes,json=outputBytes,proto3,oneof" json:"output_bytes,omitempty"`
+	// Why the job itself failed, e.g. its command could not be started or it
+	// exited with a non-zero code.
+	ExitError *string `protobuf:"bytes,19,opt,name=exit_error,json=exitError,proto3,oneof" json:"exit_error,omitempty"`
+	// Why the worker failed to clean up after the job, e.g. its cgroup could
+	// not be deleted. It does not change the job's own outcome.
+	CleanupError  *string `protobuf:"bytes,20,opt,name=cleanup_error,json=cleanupError,proto3,oneof" json:"cleanup_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusJobResponse) Reset() {
+	*x = StatusJobResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusJobResponse) ProtoMessage() {}
+
+func (x *StatusJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusJobResponse.ProtoReflect.Descriptor instead.
+func (*StatusJobResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatusJobResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StatusJobResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *StatusJobResponse) GetExitCode() int32 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+func (x *StatusJobResponse) GetError() string {
+	if x != nil && x.Error != nil {
+		return *x.Error
+	}
+	return ""
+}
+
+func (x *StatusJobResponse) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *StatusJobResponse) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *StatusJobResponse) GetPeakMemoryBytes() uint64 {
+	if x != nil && x.PeakMemoryBytes != nil {
+		return *x.PeakMemoryBytes
+	}
+	return 0
+}
+
+func (x *StatusJobResponse) GetActiveStreams() uint32 {
+	if x != nil {
+		return x.ActiveStreams
+	}
+	return 0
+}
+
+func (x *StatusJobResponse) GetSignal() string {
+	if x != nil && x.Signal != nil {
+		return *x.Signal
+	}
+	return ""
+}
+
+func (x *StatusJobResponse) GetLimitWarnings() []string {
+	if x != nil {
+		return x.LimitWarnings
+	}
+	return nil
+}
+
+func (x *StatusJobResponse) GetInvocation() *JobInvocation {
+	if x != nil {
+		return x.Invocation
+	}
+	return nil
+}
+
+func (x *StatusJobResponse) GetCommandPath() string {
+	if x != nil {
+		return x.CommandPath
+	}
+	return ""
+}
+
+func (x *StatusJobResponse) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *StatusJobResponse) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *StatusJobResponse) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *StatusJobResponse) GetArgsRedacted() bool {
+	if x != nil {
+		return x.ArgsRedacted
+	}
+	return false
+}
+
+func (x *StatusJobResponse) GetSignalNumber() int32 {
+	if x != nil && x.SignalNumber != nil {
+		return *x.SignalNumber
+	}
+	return 0
+}
+
+func (x *StatusJobResponse) GetOutputBytes() uint64 {
+	if x != nil && x.OutputBytes != nil {
+		return *x.OutputBytes
+	}
+	return 0
+}
+
+func (x *StatusJobResponse) GetExitError() string {
+	if x != nil && x.ExitError != nil {
+		return *x.ExitError
+	}
+	return ""
+}
+
+func (x *StatusJobResponse) GetCleanupError() string {
+	if x != nil && x.CleanupError != nil {
+		return *x.CleanupError
+	}
+	return ""
+}
+
+// The invocation of a job, as set in its StartJobRequest. Starting a job
+// with the same fields reproduces it, except for the redacted environment.
+type JobInvocation struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Command string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Args    []string               `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	Argv0   string                 `protobuf:"bytes,3,opt,name=argv0,proto3" json:"argv0,omitempty"`
+	// Environment entries of the job, without those that may hold secrets.
+	Env []string `protobuf:"bytes,4,rep,name=env,proto3" json:"env,omitempty"`
+	// Keys of the environment entries left out of env as they may hold secrets,
+	// e.g. API_TOKEN.
+	RedactedEnvKeys  []string     `protobuf:"bytes,5,rep,name=redacted_env_keys,json=redactedEnvKeys,proto3" json:"redacted_env_keys,omitempty"`
+	WorkingDir       string       `protobuf:"bytes,6,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
+	NofileLimit      uint64       `protobuf:"varint,7,opt,name=nofile_limit,json=nofileLimit,proto3" json:"nofile_limit,omitempty"`
+	ConfirmRunning   bool         `protobuf:"varint,8,opt,name=confirm_running,json=confirmRunning,proto3" json:"confirm_running,omitempty"`
+	KillOnDisconnect bool         `protobuf:"varint,9,opt,name=kill_on_disconnect,json=killOnDisconnect,proto3" json:"kill_on_disconnect,omitempty"`
+	BindMounts       []*BindMount `protobuf:"bytes,10,rep,name=bind_mounts,json=bindMounts,proto3" json:"bind_mounts,omitempty"`
+	// Cgroup limits in effect, including the defaults applied by the server.
+	Resources *ResourceProfile `protobuf:"bytes,11,opt,name=resources,proto3" json:"resources,omitempty"`
+	// Unset if the job had no timeout.
+	Timeout          *durationpb.Duration `protobuf:"bytes,12,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	PrivateTmp       bool                 `protobuf:"varint,13,opt,name=private_tmp,json=privateTmp,proto3" json:"private_tmp,omitempty"`
+	WorkInPrivateTmp bool                 `protobuf:"varint,14,opt,name=work_in_private_tmp,json=workInPrivateTmp,proto3" json:"work_in_private_tmp,omitempty"`
+	// Set if args were left out as the server redacts job arguments.
+	ArgsRedacted bool `protobuf:"varint,15,opt,name=args_redacted,json=argsRedacted,proto3" json:"args_redacted,omitempty"`
+	// User, group and supplementary groups the job runs as, if set.
+	Uid            *uint32     `protobuf:"varint,16,opt,name=uid,proto3,oneof" json:"uid,omitempty"`
+	Gid            *uint32     `protobuf:"varint,17,opt,name=gid,proto3,oneof" json:"gid,omitempty"`
+	Groups         []uint32    `protobuf:"varint,18,rep,packed,name=groups,proto3" json:"groups,omitempty"`
+	Namespaces     *Namespaces `protobuf:"bytes,19,opt,name=namespaces,proto3" json:"namespaces,omitempty"`
+	Nice           int32       `protobuf:"varint,20,opt,name=nice,proto3" json:"nice,omitempty"`
+	MaxOutputBytes uint64      `protobuf:"varint,21,opt,name=max_output_bytes,json=maxOutputBytes,proto3" json:"max_output_bytes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *JobInvocation) Reset() {
+	*x = JobInvocation{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobInvocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobInvocation) ProtoMessage() {}
+
+func (x *JobInvocation) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobInvocation.ProtoReflect.Descriptor instead.
+func (*JobInvocation) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *JobInvocation) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *JobInvocation) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetArgv0() string {
+	if x != nil {
+		return x.Argv0
+	}
+	return ""
+}
+
+func (x *JobInvocation) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetRedactedEnvKeys() []string {
+	if x != nil {
+		return x.RedactedEnvKeys
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *JobInvocation) GetNofileLimit() uint64 {
+	if x != nil {
+		return x.NofileLimit
+	}
+	return 0
+}
+
+func (x *JobInvocation) GetConfirmRunning() bool {
+	if x != nil {
+		return x.ConfirmRunning
+	}
+	return false
+}
+
+func (x *JobInvocation) GetKillOnDisconnect() bool {
+	if x != nil {
+		return x.KillOnDisconnect
+	}
+	return false
+}
+
+func (x *JobInvocation) GetBindMounts() []*BindMount {
+	if x != nil {
+		return x.BindMounts
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetResources() *ResourceProfile {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetPrivateTmp() bool {
+	if x != nil {
+		return x.PrivateTmp
+	}
+	return false
+}
+
+func (x *JobInvocation) GetWorkInPrivateTmp() bool {
+	if x != nil {
+		return x.WorkInPrivateTmp
+	}
+	return false
+}
+
+func (x *JobInvocation) GetArgsRedacted() bool {
+	if x != nil {
+		return x.ArgsRedacted
+	}
+	return false
+}
+
+func (x *JobInvocation) GetUid() uint32 {
+	if x != nil && x.Uid != nil {
+		return *x.Uid
+	}
+	return 0
+}
+
+func (x *JobInvocation) GetGid() uint32 {
+	if x != nil && x.Gid != nil {
+		return *x.Gid
+	}
+	return 0
+}
+
+func (x *JobInvocation) GetGroups() []uint32 {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetNamespaces() *Namespaces {
+	if x != nil {
+		return x.Namespaces
+	}
+	return nil
+}
+
+func (x *JobInvocation) GetNice() int32 {
+	if x != nil {
+		return x.Nice
+	}
+	return 0
+}
+
+func (x *JobInvocation) GetMaxOutputBytes() uint64 {
+	if x != nil {
+		return x.MaxOutputBytes
+	}
+	return 0
+}
+
+// Response message for the resource usage of a job.
+type StatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Total CPU time consumed, the sum of user and system CPU time.
+	CpuUsage  *durationpb.Duration `protobuf:"bytes,2,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`
+	UserCpu   *durationpb.Duration `protobuf:"bytes,3,opt,name=user_cpu,json=userCpu,proto3" json:"user_cpu,omitempty"`
+	SystemCpu *durationpb.Duration `protobuf:"bytes,4,opt,name=system_cpu,json=systemCpu,proto3" json:"system_cpu,omitempty"`
+	// Memory in use in bytes.
+	MemoryCurrentBytes uint64 `protobuf:"varint,5,opt,name=memory_current_bytes,json=memoryCurrentBytes,proto3" json:"memory_current_bytes,omitempty"`
+	// Highest memory usage in bytes, 0 if the kernel does not record it.
+	MemoryPeakBytes uint64 `protobuf:"varint,6,opt,name=memory_peak_bytes,json=memoryPeakBytes,proto3" json:"memory_peak_bytes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StatsResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StatsResponse) GetCpuUsage() *durationpb.Duration {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetUserCpu() *durationpb.Duration {
+	if x != nil {
+		return x.UserCpu
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetSystemCpu() *durationpb.Duration {
+	if x != nil {
+		return x.SystemCpu
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetMemoryCurrentBytes() uint64 {
+	if x != nil {
+		return x.MemoryCurrentBytes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetMemoryPeakBytes() uint64 {
+	if x != nil {
+		return x.MemoryPeakBytes
+	}
+	return 0
+}
+
+// Request message for GetOwnerStats.
+type OwnerStatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Owner to report on, as named by the server's identity source.
+	Owner         string `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OwnerStatsRequest) Reset() {
+	*x = OwnerStatsRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OwnerStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OwnerStatsRequest) ProtoMessage() {}
+
+func (x *OwnerStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OwnerStatsRequest.ProtoReflect.Descriptor instead.
+func (*OwnerStatsRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *OwnerStatsRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type OwnerStatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Owner string                 `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	// Jobs whose process started.
+	JobsStarted uint64 `protobuf:"varint,2,opt,name=jobs_started,json=jobsStarted,proto3" json:"jobs_started,omitempty"`
+	// Jobs still running.
+	JobsRunning uint64 `protobuf:"varint,3,opt,name=jobs_running,json=jobsRunning,proto3" json:"jobs_running,omitempty"`
+	// Finished jobs that failed, timed out or were killed for running out of
+	// memory. Stopped jobs are not counted.
+	JobsFailed uint64 `protobuf:"varint,4,opt,name=jobs_failed,json=jobsFailed,proto3" json:"jobs_failed,omitempty"`
+	// CPU time of all finished jobs, as recorded when they exited.
+	CpuUsage      *durationpb.Duration `protobuf:"bytes,5,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OwnerStatsResponse) Reset() {
+	*x = OwnerStatsResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OwnerStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OwnerStatsResponse) ProtoMessage() {}
+
+func (x *OwnerStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OwnerStatsResponse.ProtoReflect.Descriptor instead.
+func (*OwnerStatsResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *OwnerStatsResponse) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *OwnerStatsResponse) GetJobsStarted() uint64 {
+	if x != nil {
+		return x.JobsStarted
+	}
+	return 0
+}
+
+func (x *OwnerStatsResponse) GetJobsRunning() uint64 {
+	if x != nil {
+		return x.JobsRunning
+	}
+	return 0
+}
+
+func (x *OwnerStatsResponse) GetJobsFailed() uint64 {
+	if x != nil {
+		return x.JobsFailed
+	}
+	return 0
+}
+
+func (x *OwnerStatsResponse) GetCpuUsage() *durationpb.Duration {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return nil
+}
+
+// Request message for Streaming Output.
+type StreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Output stream to return. Defaults to both, interleaved as written.
+	Stream OutputStream `protobuf:"varint,2,opt,name=stream,proto3,enum=lpaas.v1alpha1.OutputStream" json:"stream,omitempty"`
+	// Start at the last tail_lines lines of the selected stream instead of the
+	// beginning, then follow new output. 0 returns the full history.
+	TailLines uint32 `protobuf:"varint,3,opt,name=tail_lines,json=tailLines,proto3" json:"tail_lines,omitempty"`
+	// Keep streaming new output until the job finishes. When false, only the
+	// output produced so far is returned. Defaults to true.
+	Follow *bool `protobuf:"varint,4,opt,name=follow,proto3,oneof" json:"follow,omitempty"`
+	// Start at this offset in the job's output instead of the beginning, e.g.
+	// the offset following the last byte received before a disconnect. An
+	// offset past the end waits for more output. Cannot be combined with
+	// tail_lines.
+	StartOffset uint64 `protobuf:"varint,5,opt,name=start_offset,json=startOffset,proto3" json:"start_offset,omitempty"`
+	// Lease token of the job, used instead of the client certificate's CN to
+	// authorize the request.
+	LeaseToken string `protobuf:"bytes,6,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	// Send one line per chunk instead of raw slices of output, so lines and
+	// UTF-8 characters are never split across chunks. A line without its
+	// newline, e.g. one longer than 64 KiB or cut short by output of the other
+	// stream, is sent with partial set. A trailing line still being written is
+	// only sent once it is complete or the output ends.
+	Lines bool `protobuf:"varint,7,opt,name=lines,proto3" json:"lines,omitempty"`
+	// Largest chunk of raw output to send, in bytes, between 1 KiB and 1 MiB.
+	// Larger chunks stream bulk output with less overhead. 0 uses the server's
+	// default. Ignored with lines.
+	ChunkSize uint32 `protobuf:"varint,8,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	// Largest number of bytes the stream may fall behind the job's newest
+	// output, e.g. for a client that cannot keep up with a chatty job. A job is
+	// never slowed down by its streams: once the stream is further behind, the
+	// output in between is dropped, the stream continues max_lag_bytes before
+	// the newest output and the next chunk reports the dropped bytes in
+	// lagged_bytes. 0 never drops output.
+	MaxLagBytes   uint64 `protobuf:"varint,9,opt,name=max_lag_bytes,json=maxLagBytes,proto3" json:"max_lag_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StreamRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetStream() OutputStream {
+	if x != nil {
+		return x.Stream
+	}
+	return OutputStream_OUTPUT_STREAM_BOTH
+}
+
+func (x *StreamRequest) GetTailLines() uint32 {
+	if x != nil {
+		return x.TailLines
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetFollow() bool {
+	if x != nil && x.Follow != nil {
+		return *x.Follow
+	}
+	return false
+}
+
+func (x *StreamRequest) GetStartOffset() uint64 {
+	if x != nil {
+		return x.StartOffset
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetLines() bool {
+	if x != nil {
+		return x.Lines
+	}
+	return false
+}
+
+func (x *StreamRequest) GetChunkSize() uint32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxLagBytes() uint64 {
+	if x != nil {
+		return x.MaxLagBytes
+	}
+	return 0
+}
+
+// The bytes chunk of the stream.
+type StreamChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Stream that produced the data, either stdout or stderr.
+	Stream OutputStream `protobuf:"varint,2,opt,name=stream,proto3,enum=lpaas.v1alpha1.OutputStream" json:"stream,omitempty"`
+	// Set on a chunk without data once the job closed the requested output
+	// streams while it keeps running. No more output will arrive; the stream
+	// stays open until the job finishes.
+	OutputClosed bool `protobuf:"varint,3,opt,name=output_closed,json=outputClosed,proto3" json:"output_closed,omitempty"`
+	// Offset of the first byte of data in the job's output, counting both
+	// streams. Resume a stream at offset plus the length of data.
+	Offset uint64 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Set if output between the requested start, or the previous chunk, and
+	// this chunk was discarded by the server's output cap or the job's output
+	// retention and is lost.
+	Discarded bool `protobuf:"varint,5,opt,name=discarded,proto3" json:"discarded,omitempty"`
+	// Set in line mode if data is not a complete line: it does not end in a
+	// newline and the rest of the line, if any, follows in the next chunk of
+	// the same stream.
+	Partial bool `protobuf:"varint,6,opt,name=partial,proto3" json:"partial,omitempty"`
+	// Bytes of output dropped between the previous chunk and this one as the
+	// stream fell more than the requested max_lag_bytes behind.
+	LaggedBytes   uint64 `protobuf:"varint,7,opt,name=lagged_bytes,json=laggedBytes,proto3" json:"lagged_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamChunk) Reset() {
+	*x = StreamChunk{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamChunk) ProtoMessage() {}
+
+func (x *StreamChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamChunk.ProtoReflect.Descriptor instead.
+func (*StreamChunk) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *StreamChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *StreamChunk) GetStream() OutputStream {
+	if x != nil {
+		return x.Stream
+	}
+	return OutputStream_OUTPUT_STREAM_BOTH
+}
+
+func (x *StreamChunk) GetOutputClosed() bool {
+	if x != nil {
+		return x.OutputClosed
+	}
+	return false
+}
+
+func (x *StreamChunk) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *StreamChunk) GetDiscarded() bool {
+	if x != nil {
+		return x.Discarded
+	}
+	return false
+}
+
+func (x *StreamChunk) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *StreamChunk) GetLaggedBytes() uint64 {
+	if x != nil {
+		return x.LaggedBytes
+	}
+	return 0
+}
+
+// Request message for streaming the output of multiple jobs.
+type StreamJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job IDs
+	Ids           []string `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamJobsRequest) Reset() {
+	*x = StreamJobsRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamJobsRequest) ProtoMessage() {}
+
+func (x *StreamJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamJobsRequest.ProtoReflect.Descriptor instead.
+func (*StreamJobsRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StreamJobsRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+// A bytes chunk of a multiplexed stream, tagged with its job ID.
+type JobStreamChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID
+	Id   string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Set when the job could not be streamed, e.g. it was not found.
+	Error         *string `protobuf:"bytes,3,opt,name=error,proto3,oneof" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobStreamChunk) Reset() {
+	*x = JobStreamChunk{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobStreamChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStreamChunk) ProtoMessage() {}
+
+func (x *JobStreamChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStreamChunk.ProtoReflect.Descriptor instead.
+func (*JobStreamChunk) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *JobStreamChunk) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *JobStreamChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *JobStreamChunk) GetError() string {
+	if x != nil && x.Error != nil {
+		return *x.Error
+	}
+	return ""
+}
+
+// Request message for streaming the output of all jobs.
+type StreamAllOutputRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
-	Args          []string               `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StartJobRequest) Reset() {
-	*x = StartJobRequest{}
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[0]
+func (x *StreamAllOutputRequest) Reset() {
+	*x = StreamAllOutputRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamAllOutputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAllOutputRequest) ProtoMessage() {}
+
+func (x *StreamAllOutputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAllOutputRequest.ProtoReflect.Descriptor instead.
+func (*StreamAllOutputRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{20}
+}
+
+// A bytes chunk of the audit stream, tagged with the owner and ID of its job.
+type AuditChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Owner of the job, as named by the server's identity source.
+	Owner string `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	// Job ID
+	Id string `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	// Stream the job wrote data to, stdout or stderr.
+	Source OutputStream `protobuf:"varint,3,opt,name=source,proto3,enum=lpaas.v1alpha1.OutputStream" json:"source,omitempty"`
+	Data   []byte       `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// Bytes of output, of any job, the server dropped for this stream between
+	// the previous chunk and this one because the client fell behind.
+	DroppedBytes  uint64 `protobuf:"varint,5,opt,name=dropped_bytes,json=droppedBytes,proto3" json:"dropped_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditChunk) Reset() {
+	*x = AuditChunk{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditChunk) ProtoMessage() {}
+
+func (x *AuditChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditChunk.ProtoReflect.Descriptor instead.
+func (*AuditChunk) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AuditChunk) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *AuditChunk) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AuditChunk) GetSource() OutputStream {
+	if x != nil {
+		return x.Source
+	}
+	return OutputStream_OUTPUT_STREAM_BOTH
+}
+
+func (x *AuditChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *AuditChunk) GetDroppedBytes() uint64 {
+	if x != nil {
+		return x.DroppedBytes
+	}
+	return 0
+}
+
+// Request message for downloading the output of a finished job.
+type DownloadOutputRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Offset in bytes into the output to start downloading from.
+	Offset        uint64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadOutputRequest) Reset() {
+	*x = DownloadOutputRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadOutputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadOutputRequest) ProtoMessage() {}
+
+func (x *DownloadOutputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadOutputRequest.ProtoReflect.Descriptor instead.
+func (*DownloadOutputRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DownloadOutputRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DownloadOutputRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// A chunk of a job's output, or the trailer ending the download.
+type DownloadChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Offset in bytes of data into the output.
+	Offset uint64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Set on the last message only: size in bytes and SHA-256 of the complete output.
+	TotalBytes    uint64 `protobuf:"varint,3,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	Sha256        []byte `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadChunk) Reset() {
+	*x = DownloadChunk{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadChunk) ProtoMessage() {}
+
+func (x *DownloadChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadChunk.ProtoReflect.Descriptor instead.
+func (*DownloadChunk) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DownloadChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DownloadChunk) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *DownloadChunk) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *DownloadChunk) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
+// Request message for listing jobs.
+type ListJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// List the jobs of all owners instead of the caller's own. Only clients
+	// whose certificate has the admin organizational unit may set it.
+	AllOwners bool `protobuf:"varint,1,opt,name=all_owners,json=allOwners,proto3" json:"all_owners,omitempty"`
+	// List only jobs that have all these labels with the same values.
+	LabelSelector map[string]string `protobuf:"bytes,2,rep,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Most jobs to return, at most 1000. 0 returns all jobs at once.
+	PageSize uint32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// The next_page_token of the previous page, to list the jobs after it. The
+	// request must use the same sort as the one returning the token.
+	PageToken string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Order of the jobs.
+	Sort          ListJobsSort `protobuf:"varint,5,opt,name=sort,proto3,enum=lpaas.v1alpha1.ListJobsSort" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListJobsRequest) GetAllOwners() bool {
+	if x != nil {
+		return x.AllOwners
+	}
+	return false
+}
+
+func (x *ListJobsRequest) GetLabelSelector() map[string]string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return nil
+}
+
+func (x *ListJobsRequest) GetPageSize() uint32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListJobsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetSort() ListJobsSort {
+	if x != nil {
+		return x.Sort
+	}
+	return ListJobsSort_LIST_JOBS_SORT_UNSPECIFIED
+}
+
+// A job as listed by ListJobs.
+type JobSummary struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Certificate CN of the client that started the job.
+	Owner  string `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Absolute path of the binary the job runs.
+	CommandPath string `protobuf:"bytes,4,opt,name=command_path,json=commandPath,proto3" json:"command_path,omitempty"`
+	// Labels the job was started with.
+	Labels map[string]string `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Command the job runs, as given when it was started.
+	Command string `protobuf:"bytes,6,opt,name=command,proto3" json:"command,omitempty"`
+	// Arguments of the command. Empty if args_redacted is set.
+	Args []string `protobuf:"bytes,7,rep,name=args,proto3" json:"args,omitempty"`
+	// Set if the server does not return job arguments, as they may hold secrets.
+	ArgsRedacted bool `protobuf:"varint,8,opt,name=args_redacted,json=argsRedacted,proto3" json:"args_redacted,omitempty"`
+	// When StartJob started or queued the job.
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobSummary) Reset() {
+	*x = JobSummary{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobSummary) ProtoMessage() {}
+
+func (x *JobSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobSummary.ProtoReflect.Descriptor instead.
+func (*JobSummary) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *JobSummary) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *JobSummary) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *JobSummary) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *JobSummary) GetCommandPath() string {
+	if x != nil {
+		return x.CommandPath
+	}
+	return ""
+}
+
+func (x *JobSummary) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *JobSummary) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *JobSummary) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *JobSummary) GetArgsRedacted() bool {
+	if x != nil {
+		return x.ArgsRedacted
+	}
+	return false
+}
+
+func (x *JobSummary) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Response message for ListJobs, in the order the request asked for.
+type ListJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Jobs  []*JobSummary          `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// Token to pass as page_token to list the next page. Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StartJobRequest) String() string {
+func (x *ListJobsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartJobRequest) ProtoMessage() {}
+func (*ListJobsResponse) ProtoMessage() {}
 
-func (x *StartJobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[0]
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -58,48 +2550,47 @@ func (x *StartJobRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartJobRequest.ProtoReflect.Descriptor instead.
-func (*StartJobRequest) Descriptor() ([]byte, []int) {
-	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *StartJobRequest) GetCommand() string {
+func (x *ListJobsResponse) GetJobs() []*JobSummary {
 	if x != nil {
-		return x.Command
+		return x.Jobs
 	}
-	return ""
+	return nil
 }
 
-func (x *StartJobRequest) GetArgs() []string {
+func (x *ListJobsResponse) GetNextPageToken() string {
 	if x != nil {
-		return x.Args
+		return x.NextPageToken
 	}
-	return nil
+	return ""
 }
 
-type StartJobResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Job ID
-	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+// Empty message for StopJobResponse
+type StopJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StartJobResponse) Reset() {
-	*x = StartJobResponse{}
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[1]
+func (x *StopJobResponse) Reset() {
+	*x = StopJobResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StartJobResponse) String() string {
+func (x *StopJobResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartJobResponse) ProtoMessage() {}
+func (*StopJobResponse) ProtoMessage() {}
 
-func (x *StartJobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[1]
+func (x *StopJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -110,41 +2601,75 @@ func (x *StartJobResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartJobResponse.ProtoReflect.Descriptor instead.
-func (*StartJobResponse) Descriptor() ([]byte, []int) {
-	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use StopJobResponse.ProtoReflect.Descriptor instead.
+func (*StopJobResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *StartJobResponse) GetId() string {
+// Empty message for RemoveJobResponse
+type RemoveJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveJobResponse) Reset() {
+	*x = RemoveJobResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveJobResponse) ProtoMessage() {}
+
+func (x *RemoveJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[28]
 	if x != nil {
-		return x.Id
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-type JobRequest struct {
+// Deprecated: Use RemoveJobResponse.ProtoReflect.Descriptor instead.
+func (*RemoveJobResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{28}
+}
+
+// Request message for WaitJob.
+type WaitJobRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Job ID
-	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// How long to wait at most. Unset, zero or longer than the server's
+	// maximum wait uses the maximum.
+	Timeout       *durationpb.Duration `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *JobRequest) Reset() {
-	*x = JobRequest{}
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[2]
+func (x *WaitJobRequest) Reset() {
+	*x = WaitJobRequest{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *JobRequest) String() string {
+func (x *WaitJobRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*JobRequest) ProtoMessage() {}
+func (*WaitJobRequest) ProtoMessage() {}
 
-func (x *JobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[2]
+func (x *WaitJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -155,49 +2680,67 @@ func (x *JobRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
-func (*JobRequest) Descriptor() ([]byte, []int) {
-	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use WaitJobRequest.ProtoReflect.Descriptor instead.
+func (*WaitJobRequest) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *JobRequest) GetId() string {
+func (x *WaitJobRequest) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-// Response for GetStatus.
-type StatusJobResponse struct {
+func (x *WaitJobRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+// Response message for WaitJob.
+type WaitJobResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Job ID
 	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	// Current status of the job.
-	// Values: "Pending", "Running", "Stopped", "Exited", "Failed".
-	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
-	// Exit code of the command.
-	ExitCode *int32 `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
-	// Error message.
-	Error         *string `protobuf:"bytes,4,opt,name=error,proto3,oneof" json:"error,omitempty"`
+	// Whether the job finished. Unset if the wait timed out first.
+	Finished bool `protobuf:"varint,2,opt,name=finished,proto3" json:"finished,omitempty"`
+	// Status of the job, e.g. "Exited" or "OOMKilled", or its current status
+	// if it has not finished.
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Exit code of the command, -1 if it did not exit on its own.
+	ExitCode *int32 `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
+	// Name of the signal that terminated the job, e.g. "SIGKILL".
+	Signal *string `protobuf:"bytes,5,opt,name=signal,proto3,oneof" json:"signal,omitempty"`
+	// Number of the signal that terminated the job, set along with signal.
+	SignalNumber *int32 `protobuf:"varint,6,opt,name=signal_number,json=signalNumber,proto3,oneof" json:"signal_number,omitempty"`
+	// Error message: exit_error and cleanup_error joined, for display.
+	Error *string `protobuf:"bytes,7,opt,name=error,proto3,oneof" json:"error,omitempty"`
+	// Why the job itself failed, as in StatusJobResponse.
+	ExitError *string `protobuf:"bytes,8,opt,name=exit_error,json=exitError,proto3,oneof" json:"exit_error,omitempty"`
+	// Why the worker failed to clean up after the job, as in
+	// StatusJobResponse.
+	CleanupError  *string `protobuf:"bytes,9,opt,name=cleanup_error,json=cleanupError,proto3,oneof" json:"cleanup_error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StatusJobResponse) Reset() {
-	*x = StatusJobResponse{}
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[3]
+func (x *WaitJobResponse) Reset() {
+	*x = WaitJobResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatusJobResponse) String() string {
+func (x *WaitJobResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusJobResponse) ProtoMessage() {}
+func (*WaitJobResponse) ProtoMessage() {}
 
-func (x *StatusJobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[3]
+func (x *WaitJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -208,107 +2751,100 @@ func (x *StatusJobResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusJobResponse.ProtoReflect.Descriptor instead.
-func (*StatusJobResponse) Descriptor() ([]byte, []int) {
-	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use WaitJobResponse.ProtoReflect.Descriptor instead.
+func (*WaitJobResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *StatusJobResponse) GetId() string {
+func (x *WaitJobResponse) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *StatusJobResponse) GetStatus() string {
+func (x *WaitJobResponse) GetFinished() bool {
+	if x != nil {
+		return x.Finished
+	}
+	return false
+}
+
+func (x *WaitJobResponse) GetStatus() string {
 	if x != nil {
 		return x.Status
 	}
 	return ""
 }
 
-func (x *StatusJobResponse) GetExitCode() int32 {
+func (x *WaitJobResponse) GetExitCode() int32 {
 	if x != nil && x.ExitCode != nil {
 		return *x.ExitCode
 	}
 	return 0
 }
 
-func (x *StatusJobResponse) GetError() string {
-	if x != nil && x.Error != nil {
-		return *x.Error
+func (x *WaitJobResponse) GetSignal() string {
+	if x != nil && x.Signal != nil {
+		return *x.Signal
 	}
 	return ""
 }
 
-// Request message for Streaming Output.
-type StreamRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *StreamRequest) Reset() {
-	*x = StreamRequest{}
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[4]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *StreamRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *WaitJobResponse) GetSignalNumber() int32 {
+	if x != nil && x.SignalNumber != nil {
+		return *x.SignalNumber
+	}
+	return 0
 }
 
-func (*StreamRequest) ProtoMessage() {}
-
-func (x *StreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[4]
-	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *WaitJobResponse) GetError() string {
+	if x != nil && x.Error != nil {
+		return *x.Error
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
-func (*StreamRequest) Descriptor() ([]byte, []int) {
-	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{4}
+func (x *WaitJobResponse) GetExitError() string {
+	if x != nil && x.ExitError != nil {
+		return *x.ExitError
+	}
+	return ""
 }
 
-func (x *StreamRequest) GetId() string {
-	if x != nil {
-		return x.Id
+func (x *WaitJobResponse) GetCleanupError() string {
+	if x != nil && x.CleanupError != nil {
+		return *x.CleanupError
 	}
 	return ""
 }
 
-// The bytes chunk of the stream.
-type StreamChunk struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+// Request message for WriteStdin.
+type StdinChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Job ID, required in the first message and ignored afterwards.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Input to write to the job's stdin.
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamChunk) Reset() {
-	*x = StreamChunk{}
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[5]
+func (x *StdinChunk) Reset() {
+	*x = StdinChunk{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamChunk) String() string {
+func (x *StdinChunk) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamChunk) ProtoMessage() {}
+func (*StdinChunk) ProtoMessage() {}
 
-func (x *StreamChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[5]
+func (x *StdinChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -319,40 +2855,49 @@ func (x *StreamChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamChunk.ProtoReflect.Descriptor instead.
-func (*StreamChunk) Descriptor() ([]byte, []int) {
-	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use StdinChunk.ProtoReflect.Descriptor instead.
+func (*StdinChunk) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *StreamChunk) GetData() []byte {
+func (x *StdinChunk) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StdinChunk) GetData() []byte {
 	if x != nil {
 		return x.Data
 	}
 	return nil
 }
 
-// Empty message for StopJobResponse
-type StopJobResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+// Response message for WriteStdin.
+type WriteStdinResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of bytes written to the job's stdin.
+	BytesWritten  uint64 `protobuf:"varint,1,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StopJobResponse) Reset() {
-	*x = StopJobResponse{}
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[6]
+func (x *WriteStdinResponse) Reset() {
+	*x = WriteStdinResponse{}
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StopJobResponse) String() string {
+func (x *WriteStdinResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopJobResponse) ProtoMessage() {}
+func (*WriteStdinResponse) ProtoMessage() {}
 
-func (x *StopJobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[6]
+func (x *WriteStdinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lpaas_v1alpha1_job_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -363,42 +2908,326 @@ func (x *StopJobResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopJobResponse.ProtoReflect.Descriptor instead.
-func (*StopJobResponse) Descriptor() ([]byte, []int) {
-	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use WriteStdinResponse.ProtoReflect.Descriptor instead.
+func (*WriteStdinResponse) Descriptor() ([]byte, []int) {
+	return file_lpaas_v1alpha1_job_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *WriteStdinResponse) GetBytesWritten() uint64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
 }
 
 var File_lpaas_v1alpha1_job_proto protoreflect.FileDescriptor
 
 const file_lpaas_v1alpha1_job_proto_rawDesc = "" +
 	"\n" +
-	"\x18lpaas/v1alpha1/job.proto\x12\x0elpaas.v1alpha1\"?\n" +
+	"\x18lpaas/v1alpha1/job.proto\x12\x0elpaas.v1alpha1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x89\b\n" +
 	"\x0fStartJobRequest\x12\x18\n" +
 	"\acommand\x18\x01 \x01(\tR\acommand\x12\x12\n" +
-	"\x04args\x18\x02 \x03(\tR\x04args\"\"\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x12!\n" +
+	"\fnofile_limit\x18\x03 \x01(\x04R\vnofileLimit\x12'\n" +
+	"\x0fconfirm_running\x18\x04 \x01(\bR\x0econfirmRunning\x12\x10\n" +
+	"\x03env\x18\x05 \x03(\tR\x03env\x12\x1f\n" +
+	"\vworking_dir\x18\x06 \x01(\tR\n" +
+	"workingDir\x12,\n" +
+	"\x12kill_on_disconnect\x18\a \x01(\bR\x10killOnDisconnect\x12:\n" +
+	"\vbind_mounts\x18\b \x03(\v2\x19.lpaas.v1alpha1.BindMountR\n" +
+	"bindMounts\x12=\n" +
+	"\tresources\x18\t \x01(\v2\x1f.lpaas.v1alpha1.ResourceProfileR\tresources\x123\n" +
+	"\atimeout\x18\n" +
+	" \x01(\v2\x19.google.protobuf.DurationR\atimeout\x12'\n" +
+	"\x0fidempotency_key\x18\v \x01(\tR\x0eidempotencyKey\x12\x14\n" +
+	"\x05argv0\x18\f \x01(\tR\x05argv0\x12\x1f\n" +
+	"\vprivate_tmp\x18\r \x01(\bR\n" +
+	"privateTmp\x12-\n" +
+	"\x13work_in_private_tmp\x18\x0e \x01(\bR\x10workInPrivateTmp\x12\x14\n" +
+	"\x05queue\x18\x0f \x01(\bR\x05queue\x12\x14\n" +
+	"\x05lease\x18\x10 \x01(\bR\x05lease\x12C\n" +
+	"\x06labels\x18\x11 \x03(\v2+.lpaas.v1alpha1.StartJobRequest.LabelsEntryR\x06labels\x12\x14\n" +
+	"\x05stdin\x18\x12 \x01(\bR\x05stdin\x12\x15\n" +
+	"\x03uid\x18\x13 \x01(\rH\x00R\x03uid\x88\x01\x01\x12\x15\n" +
+	"\x03gid\x18\x14 \x01(\rH\x01R\x03gid\x88\x01\x01\x12\x16\n" +
+	"\x06groups\x18\x15 \x03(\rR\x06groups\x12:\n" +
+	"\n" +
+	"namespaces\x18\x16 \x01(\v2\x1a.lpaas.v1alpha1.NamespacesR\n" +
+	"namespaces\x12J\n" +
+	"\x10output_retention\x18\x17 \x01(\v2\x1f.lpaas.v1alpha1.OutputRetentionR\x0foutputRetention\x12\x12\n" +
+	"\x04nice\x18\x18 \x01(\x05R\x04nice\x12(\n" +
+	"\x10max_output_bytes\x18\x19 \x01(\x04R\x0emaxOutputBytes\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x06\n" +
+	"\x04_uidB\x06\n" +
+	"\x04_gid\"b\n" +
+	"\x0fOutputRetention\x12\x1b\n" +
+	"\tmax_bytes\x18\x01 \x01(\x04R\bmaxBytes\x122\n" +
+	"\amax_age\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x06maxAge\"\x83\x03\n" +
+	"\x0fResourceProfile\x12\x1f\n" +
+	"\vcpu_percent\x18\x01 \x01(\x04R\n" +
+	"cpuPercent\x12!\n" +
+	"\fmemory_bytes\x18\x02 \x01(\x04R\vmemoryBytes\x12'\n" +
+	"\x10io_bytes_per_sec\x18\x03 \x01(\x04R\rioBytesPerSec\x12\x19\n" +
+	"\bmax_pids\x18\x04 \x01(\x04R\amaxPids\x120\n" +
+	"\x15io_read_bytes_per_sec\x18\x05 \x01(\x04R\x11ioReadBytesPerSec\x122\n" +
+	"\x16io_write_bytes_per_sec\x18\x06 \x01(\x04R\x12ioWriteBytesPerSec\x12 \n" +
+	"\fio_read_iops\x18\a \x01(\x04R\n" +
+	"ioReadIops\x12\"\n" +
+	"\rio_write_iops\x18\b \x01(\x04R\vioWriteIops\x12\x1d\n" +
+	"\n" +
+	"io_devices\x18\t \x03(\tR\tioDevices\x12\x1d\n" +
+	"\n" +
+	"cpu_weight\x18\n" +
+	" \x01(\x04R\tcpuWeight\"N\n" +
+	"\n" +
+	"Namespaces\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\bR\x03pid\x12\x14\n" +
+	"\x05mount\x18\x02 \x01(\bR\x05mount\x12\x18\n" +
+	"\anetwork\x18\x03 \x01(\bR\anetwork\";\n" +
+	"\tBindMount\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\"C\n" +
 	"\x10StartJobResponse\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\x1c\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vlease_token\x18\x02 \x01(\tR\n" +
+	"leaseToken\"9\n" +
+	"\x11RestartJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05lease\x18\x02 \x01(\bR\x05lease\"=\n" +
 	"\n" +
 	"JobRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\x90\x01\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vlease_token\x18\x02 \x01(\tR\n" +
+	"leaseToken\"\x7f\n" +
+	"\x0eStopJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12<\n" +
+	"\fgrace_period\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\vgracePeriod\x12\x1f\n" +
+	"\vlease_token\x18\x03 \x01(\tR\n" +
+	"leaseToken\";\n" +
+	"\x11SendSignalRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06signal\x18\x02 \x01(\tR\x06signal\"\x14\n" +
+	"\x12SendSignalResponse\"\xe0\a\n" +
 	"\x11StatusJobResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x12 \n" +
 	"\texit_code\x18\x03 \x01(\x05H\x00R\bexitCode\x88\x01\x01\x12\x19\n" +
-	"\x05error\x18\x04 \x01(\tH\x01R\x05error\x88\x01\x01B\f\n" +
+	"\x05error\x18\x04 \x01(\tH\x01R\x05error\x88\x01\x01\x129\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12/\n" +
+	"\x11peak_memory_bytes\x18\a \x01(\x04H\x02R\x0fpeakMemoryBytes\x88\x01\x01\x12%\n" +
+	"\x0eactive_streams\x18\b \x01(\rR\ractiveStreams\x12\x1b\n" +
+	"\x06signal\x18\t \x01(\tH\x03R\x06signal\x88\x01\x01\x12%\n" +
+	"\x0elimit_warnings\x18\n" +
+	" \x03(\tR\rlimitWarnings\x12=\n" +
+	"\n" +
+	"invocation\x18\v \x01(\v2\x1d.lpaas.v1alpha1.JobInvocationR\n" +
+	"invocation\x12!\n" +
+	"\fcommand_path\x18\f \x01(\tR\vcommandPath\x12E\n" +
+	"\x06labels\x18\r \x03(\v2-.lpaas.v1alpha1.StatusJobResponse.LabelsEntryR\x06labels\x12\x18\n" +
+	"\acommand\x18\x0e \x01(\tR\acommand\x12\x12\n" +
+	"\x04args\x18\x0f \x03(\tR\x04args\x12#\n" +
+	"\rargs_redacted\x18\x10 \x01(\bR\fargsRedacted\x12(\n" +
+	"\rsignal_number\x18\x11 \x01(\x05H\x04R\fsignalNumber\x88\x01\x01\x12&\n" +
+	"\foutput_bytes\x18\x12 \x01(\x04H\x05R\voutputBytes\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"exit_error\x18\x13 \x01(\tH\x06R\texitError\x88\x01\x01\x12(\n" +
+	"\rcleanup_error\x18\x14 \x01(\tH\aR\fcleanupError\x88\x01\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\f\n" +
 	"\n" +
 	"_exit_codeB\b\n" +
-	"\x06_error\"\x1f\n" +
+	"\x06_errorB\x14\n" +
+	"\x12_peak_memory_bytesB\t\n" +
+	"\a_signalB\x10\n" +
+	"\x0e_signal_numberB\x0f\n" +
+	"\r_output_bytesB\r\n" +
+	"\v_exit_errorB\x10\n" +
+	"\x0e_cleanup_error\"\xa1\x06\n" +
+	"\rJobInvocation\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x12\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x12\x14\n" +
+	"\x05argv0\x18\x03 \x01(\tR\x05argv0\x12\x10\n" +
+	"\x03env\x18\x04 \x03(\tR\x03env\x12*\n" +
+	"\x11redacted_env_keys\x18\x05 \x03(\tR\x0fredactedEnvKeys\x12\x1f\n" +
+	"\vworking_dir\x18\x06 \x01(\tR\n" +
+	"workingDir\x12!\n" +
+	"\fnofile_limit\x18\a \x01(\x04R\vnofileLimit\x12'\n" +
+	"\x0fconfirm_running\x18\b \x01(\bR\x0econfirmRunning\x12,\n" +
+	"\x12kill_on_disconnect\x18\t \x01(\bR\x10killOnDisconnect\x12:\n" +
+	"\vbind_mounts\x18\n" +
+	" \x03(\v2\x19.lpaas.v1alpha1.BindMountR\n" +
+	"bindMounts\x12=\n" +
+	"\tresources\x18\v \x01(\v2\x1f.lpaas.v1alpha1.ResourceProfileR\tresources\x123\n" +
+	"\atimeout\x18\f \x01(\v2\x19.google.protobuf.DurationR\atimeout\x12\x1f\n" +
+	"\vprivate_tmp\x18\r \x01(\bR\n" +
+	"privateTmp\x12-\n" +
+	"\x13work_in_private_tmp\x18\x0e \x01(\bR\x10workInPrivateTmp\x12#\n" +
+	"\rargs_redacted\x18\x0f \x01(\bR\fargsRedacted\x12\x15\n" +
+	"\x03uid\x18\x10 \x01(\rH\x00R\x03uid\x88\x01\x01\x12\x15\n" +
+	"\x03gid\x18\x11 \x01(\rH\x01R\x03gid\x88\x01\x01\x12\x16\n" +
+	"\x06groups\x18\x12 \x03(\rR\x06groups\x12:\n" +
+	"\n" +
+	"namespaces\x18\x13 \x01(\v2\x1a.lpaas.v1alpha1.NamespacesR\n" +
+	"namespaces\x12\x12\n" +
+	"\x04nice\x18\x14 \x01(\x05R\x04nice\x12(\n" +
+	"\x10max_output_bytes\x18\x15 \x01(\x04R\x0emaxOutputBytesB\x06\n" +
+	"\x04_uidB\x06\n" +
+	"\x04_gid\"\xa5\x02\n" +
+	"\rStatsResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x126\n" +
+	"\tcpu_usage\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bcpuUsage\x124\n" +
+	"\buser_cpu\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\auserCpu\x128\n" +
+	"\n" +
+	"system_cpu\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\tsystemCpu\x120\n" +
+	"\x14memory_current_bytes\x18\x05 \x01(\x04R\x12memoryCurrentBytes\x12*\n" +
+	"\x11memory_peak_bytes\x18\x06 \x01(\x04R\x0fmemoryPeakBytes\")\n" +
+	"\x11OwnerStatsRequest\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\tR\x05owner\"\xc9\x01\n" +
+	"\x12OwnerStatsResponse\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\tR\x05owner\x12!\n" +
+	"\fjobs_started\x18\x02 \x01(\x04R\vjobsStarted\x12!\n" +
+	"\fjobs_running\x18\x03 \x01(\x04R\vjobsRunning\x12\x1f\n" +
+	"\vjobs_failed\x18\x04 \x01(\x04R\n" +
+	"jobsFailed\x126\n" +
+	"\tcpu_usage\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\bcpuUsage\"\xb9\x02\n" +
 	"\rStreamRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"!\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x124\n" +
+	"\x06stream\x18\x02 \x01(\x0e2\x1c.lpaas.v1alpha1.OutputStreamR\x06stream\x12\x1d\n" +
+	"\n" +
+	"tail_lines\x18\x03 \x01(\rR\ttailLines\x12\x1b\n" +
+	"\x06follow\x18\x04 \x01(\bH\x00R\x06follow\x88\x01\x01\x12!\n" +
+	"\fstart_offset\x18\x05 \x01(\x04R\vstartOffset\x12\x1f\n" +
+	"\vlease_token\x18\x06 \x01(\tR\n" +
+	"leaseToken\x12\x14\n" +
+	"\x05lines\x18\a \x01(\bR\x05lines\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\b \x01(\rR\tchunkSize\x12\"\n" +
+	"\rmax_lag_bytes\x18\t \x01(\x04R\vmaxLagBytesB\t\n" +
+	"\a_follow\"\xef\x01\n" +
 	"\vStreamChunk\x12\x12\n" +
-	"\x04data\x18\x01 \x01(\fR\x04data\"\x11\n" +
-	"\x0fStopJobResponse2\xb8\x02\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x124\n" +
+	"\x06stream\x18\x02 \x01(\x0e2\x1c.lpaas.v1alpha1.OutputStreamR\x06stream\x12#\n" +
+	"\routput_closed\x18\x03 \x01(\bR\foutputClosed\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x04R\x06offset\x12\x1c\n" +
+	"\tdiscarded\x18\x05 \x01(\bR\tdiscarded\x12\x18\n" +
+	"\apartial\x18\x06 \x01(\bR\apartial\x12!\n" +
+	"\flagged_bytes\x18\a \x01(\x04R\vlaggedBytes\"%\n" +
+	"\x11StreamJobsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"Y\n" +
+	"\x0eJobStreamChunk\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x19\n" +
+	"\x05error\x18\x03 \x01(\tH\x00R\x05error\x88\x01\x01B\b\n" +
+	"\x06_error\"\x18\n" +
+	"\x16StreamAllOutputRequest\"\xa1\x01\n" +
+	"\n" +
+	"AuditChunk\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\tR\x05owner\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x124\n" +
+	"\x06source\x18\x03 \x01(\x0e2\x1c.lpaas.v1alpha1.OutputStreamR\x06source\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12#\n" +
+	"\rdropped_bytes\x18\x05 \x01(\x04R\fdroppedBytes\"?\n" +
+	"\x15DownloadOutputRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\"t\n" +
+	"\rDownloadChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x1f\n" +
+	"\vtotal_bytes\x18\x03 \x01(\x04R\n" +
+	"totalBytes\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\fR\x06sha256\"\xbb\x02\n" +
+	"\x0fListJobsRequest\x12\x1d\n" +
+	"\n" +
+	"all_owners\x18\x01 \x01(\bR\tallOwners\x12Y\n" +
+	"\x0elabel_selector\x18\x02 \x03(\v22.lpaas.v1alpha1.ListJobsRequest.LabelSelectorEntryR\rlabelSelector\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\rR\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\x120\n" +
+	"\x04sort\x18\x05 \x01(\x0e2\x1c.lpaas.v1alpha1.ListJobsSortR\x04sort\x1a@\n" +
+	"\x12LabelSelectorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf6\x02\n" +
+	"\n" +
+	"JobSummary\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12!\n" +
+	"\fcommand_path\x18\x04 \x01(\tR\vcommandPath\x12>\n" +
+	"\x06labels\x18\x05 \x03(\v2&.lpaas.v1alpha1.JobSummary.LabelsEntryR\x06labels\x12\x18\n" +
+	"\acommand\x18\x06 \x01(\tR\acommand\x12\x12\n" +
+	"\x04args\x18\a \x03(\tR\x04args\x12#\n" +
+	"\rargs_redacted\x18\b \x01(\bR\fargsRedacted\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"j\n" +
+	"\x10ListJobsResponse\x12.\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x1a.lpaas.v1alpha1.JobSummaryR\x04jobs\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x11\n" +
+	"\x0fStopJobResponse\"\x13\n" +
+	"\x11RemoveJobResponse\"U\n" +
+	"\x0eWaitJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x123\n" +
+	"\atimeout\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\atimeout\"\xfd\x02\n" +
+	"\x0fWaitJobResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bfinished\x18\x02 \x01(\bR\bfinished\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12 \n" +
+	"\texit_code\x18\x04 \x01(\x05H\x00R\bexitCode\x88\x01\x01\x12\x1b\n" +
+	"\x06signal\x18\x05 \x01(\tH\x01R\x06signal\x88\x01\x01\x12(\n" +
+	"\rsignal_number\x18\x06 \x01(\x05H\x02R\fsignalNumber\x88\x01\x01\x12\x19\n" +
+	"\x05error\x18\a \x01(\tH\x03R\x05error\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"exit_error\x18\b \x01(\tH\x04R\texitError\x88\x01\x01\x12(\n" +
+	"\rcleanup_error\x18\t \x01(\tH\x05R\fcleanupError\x88\x01\x01B\f\n" +
+	"\n" +
+	"_exit_codeB\t\n" +
+	"\a_signalB\x10\n" +
+	"\x0e_signal_numberB\b\n" +
+	"\x06_errorB\r\n" +
+	"\v_exit_errorB\x10\n" +
+	"\x0e_cleanup_error\"0\n" +
+	"\n" +
+	"StdinChunk\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"9\n" +
+	"\x12WriteStdinResponse\x12#\n" +
+	"\rbytes_written\x18\x01 \x01(\x04R\fbytesWritten*Z\n" +
+	"\fOutputStream\x12\x16\n" +
+	"\x12OUTPUT_STREAM_BOTH\x10\x00\x12\x18\n" +
+	"\x14OUTPUT_STREAM_STDOUT\x10\x01\x12\x18\n" +
+	"\x14OUTPUT_STREAM_STDERR\x10\x02*e\n" +
+	"\fListJobsSort\x12\x1e\n" +
+	"\x1aLIST_JOBS_SORT_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16LIST_JOBS_SORT_CREATED\x10\x01\x12\x19\n" +
+	"\x15LIST_JOBS_SORT_STATUS\x10\x022\xc0\t\n" +
 	"\x05Lpaas\x12M\n" +
-	"\bStartJob\x12\x1f.lpaas.v1alpha1.StartJobRequest\x1a .lpaas.v1alpha1.StartJobResponse\x12F\n" +
-	"\aStopJob\x12\x1a.lpaas.v1alpha1.JobRequest\x1a\x1f.lpaas.v1alpha1.StopJobResponse\x12J\n" +
-	"\tGetStatus\x12\x1a.lpaas.v1alpha1.JobRequest\x1a!.lpaas.v1alpha1.StatusJobResponse\x12L\n" +
-	"\fStreamOutput\x12\x1d.lpaas.v1alpha1.StreamRequest\x1a\x1b.lpaas.v1alpha1.StreamChunk0\x01BCZAgithub.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1;lpaasv1alpha1b\x06proto3"
+	"\bStartJob\x12\x1f.lpaas.v1alpha1.StartJobRequest\x1a .lpaas.v1alpha1.StartJobResponse\x12J\n" +
+	"\aStopJob\x12\x1e.lpaas.v1alpha1.StopJobRequest\x1a\x1f.lpaas.v1alpha1.StopJobResponse\x12S\n" +
+	"\n" +
+	"SendSignal\x12!.lpaas.v1alpha1.SendSignalRequest\x1a\".lpaas.v1alpha1.SendSignalResponse\x12J\n" +
+	"\tGetStatus\x12\x1a.lpaas.v1alpha1.JobRequest\x1a!.lpaas.v1alpha1.StatusJobResponse\x12E\n" +
+	"\bGetStats\x12\x1a.lpaas.v1alpha1.JobRequest\x1a\x1d.lpaas.v1alpha1.StatsResponse\x12L\n" +
+	"\fStreamOutput\x12\x1d.lpaas.v1alpha1.StreamRequest\x1a\x1b.lpaas.v1alpha1.StreamChunk0\x01\x12Q\n" +
+	"\n" +
+	"StreamJobs\x12!.lpaas.v1alpha1.StreamJobsRequest\x1a\x1e.lpaas.v1alpha1.JobStreamChunk0\x01\x12J\n" +
+	"\tRemoveJob\x12\x1a.lpaas.v1alpha1.JobRequest\x1a!.lpaas.v1alpha1.RemoveJobResponse\x12X\n" +
+	"\x0eDownloadOutput\x12%.lpaas.v1alpha1.DownloadOutputRequest\x1a\x1d.lpaas.v1alpha1.DownloadChunk0\x01\x12M\n" +
+	"\bListJobs\x12\x1f.lpaas.v1alpha1.ListJobsRequest\x1a .lpaas.v1alpha1.ListJobsResponse\x12J\n" +
+	"\aWaitJob\x12\x1e.lpaas.v1alpha1.WaitJobRequest\x1a\x1f.lpaas.v1alpha1.WaitJobResponse\x12N\n" +
+	"\n" +
+	"WriteStdin\x12\x1a.lpaas.v1alpha1.StdinChunk\x1a\".lpaas.v1alpha1.WriteStdinResponse(\x01\x12Q\n" +
+	"\n" +
+	"RestartJob\x12!.lpaas.v1alpha1.RestartJobRequest\x1a .lpaas.v1alpha1.StartJobResponse\x12V\n" +
+	"\rGetOwnerStats\x12!.lpaas.v1alpha1.OwnerStatsRequest\x1a\".lpaas.v1alpha1.OwnerStatsResponse\x12W\n" +
+	"\x0fStreamAllOutput\x12&.lpaas.v1alpha1.StreamAllOutputRequest\x1a\x1a.lpaas.v1alpha1.AuditChunk0\x01BCZAgithub.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1;lpaasv1alpha1b\x06proto3"
 
 var (
 	file_lpaas_v1alpha1_job_proto_rawDescOnce sync.Once
@@ -412,30 +3241,116 @@ func file_lpaas_v1alpha1_job_proto_rawDescGZIP() []byte {
 	return file_lpaas_v1alpha1_job_proto_rawDescData
 }
 
-var file_lpaas_v1alpha1_job_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_lpaas_v1alpha1_job_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_lpaas_v1alpha1_job_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_lpaas_v1alpha1_job_proto_goTypes = []any{
-	(*StartJobRequest)(nil),   // 0: lpaas.v1alpha1.StartJobRequest
-	(*StartJobResponse)(nil),  // 1: lpaas.v1alpha1.StartJobResponse
-	(*JobRequest)(nil),        // 2: lpaas.v1alpha1.JobRequest
-	(*StatusJobResponse)(nil), // 3: lpaas.v1alpha1.StatusJobResponse
-	(*StreamRequest)(nil),     // 4: lpaas.v1alpha1.StreamRequest
-	(*StreamChunk)(nil),       // 5: lpaas.v1alpha1.StreamChunk
-	(*StopJobResponse)(nil),   // 6: lpaas.v1alpha1.StopJobResponse
+	(OutputStream)(0),              // 0: lpaas.v1alpha1.OutputStream
+	(ListJobsSort)(0),              // 1: lpaas.v1alpha1.ListJobsSort
+	(*StartJobRequest)(nil),        // 2: lpaas.v1alpha1.StartJobRequest
+	(*OutputRetention)(nil),        // 3: lpaas.v1alpha1.OutputRetention
+	(*ResourceProfile)(nil),        // 4: lpaas.v1alpha1.ResourceProfile
+	(*Namespaces)(nil),             // 5: lpaas.v1alpha1.Namespaces
+	(*BindMount)(nil),              // 6: lpaas.v1alpha1.BindMount
+	(*StartJobResponse)(nil),       // 7: lpaas.v1alpha1.StartJobResponse
+	(*RestartJobRequest)(nil),      // 8: lpaas.v1alpha1.RestartJobRequest
+	(*JobRequest)(nil),             // 9: lpaas.v1alpha1.JobRequest
+	(*StopJobRequest)(nil),         // 10: lpaas.v1alpha1.StopJobRequest
+	(*SendSignalRequest)(nil),      // 11: lpaas.v1alpha1.SendSignalRequest
+	(*SendSignalResponse)(nil),     // 12: lpaas.v1alpha1.SendSignalResponse
+	(*StatusJobResponse)(nil),      // 13: lpaas.v1alpha1.StatusJobResponse
+	(*JobInvocation)(nil),          // 14: lpaas.v1alpha1.JobInvocation
+	(*StatsResponse)(nil),          // 15: lpaas.v1alpha1.StatsResponse
+	(*OwnerStatsRequest)(nil),      // 16: lpaas.v1alpha1.OwnerStatsRequest
+	(*OwnerStatsResponse)(nil),     // 17: lpaas.v1alpha1.OwnerStatsResponse
+	(*StreamRequest)(nil),          // 18: lpaas.v1alpha1.StreamRequest
+	(*StreamChunk)(nil),            // 19: lpaas.v1alpha1.StreamChunk
+	(*StreamJobsRequest)(nil),      // 20: lpaas.v1alpha1.StreamJobsRequest
+	(*JobStreamChunk)(nil),         // 21: lpaas.v1alpha1.JobStreamChunk
+	(*StreamAllOutputRequest)(nil), // 22: lpaas.v1alpha1.StreamAllOutputRequest
+	(*AuditChunk)(nil),             // 23: lpaas.v1alpha1.AuditChunk
+	(*DownloadOutputRequest)(nil),  // 24: lpaas.v1alpha1.DownloadOutputRequest
+	(*DownloadChunk)(nil),          // 25: lpaas.v1alpha1.DownloadChunk
+	(*ListJobsRequest)(nil),        // 26: lpaas.v1alpha1.ListJobsRequest
+	(*JobSummary)(nil),             // 27: lpaas.v1alpha1.JobSummary
+	(*ListJobsResponse)(nil),       // 28: lpaas.v1alpha1.ListJobsResponse
+	(*StopJobResponse)(nil),        // 29: lpaas.v1alpha1.StopJobResponse
+	(*RemoveJobResponse)(nil),      // 30: lpaas.v1alpha1.RemoveJobResponse
+	(*WaitJobRequest)(nil),         // 31: lpaas.v1alpha1.WaitJobRequest
+	(*WaitJobResponse)(nil),        // 32: lpaas.v1alpha1.WaitJobResponse
+	(*StdinChunk)(nil),             // 33: lpaas.v1alpha1.StdinChunk
+	(*WriteStdinResponse)(nil),     // 34: lpaas.v1alpha1.WriteStdinResponse
+	nil,                            // 35: lpaas.v1alpha1.StartJobRequest.LabelsEntry
+	nil,                            // 36: lpaas.v1alpha1.StatusJobResponse.LabelsEntry
+	nil,                            // 37: lpaas.v1alpha1.ListJobsRequest.LabelSelectorEntry
+	nil,                            // 38: lpaas.v1alpha1.JobSummary.LabelsEntry
+	(*durationpb.Duration)(nil),    // 39: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),  // 40: google.protobuf.Timestamp
 }
 var file_lpaas_v1alpha1_job_proto_depIdxs = []int32{
-	0, // 0: lpaas.v1alpha1.Lpaas.StartJob:input_type -> lpaas.v1alpha1.StartJobRequest
-	2, // 1: lpaas.v1alpha1.Lpaas.StopJob:input_type -> lpaas.v1alpha1.JobRequest
-	2, // 2: lpaas.v1alpha1.Lpaas.GetStatus:input_type -> lpaas.v1alpha1.JobRequest
-	4, // 3: lpaas.v1alpha1.Lpaas.StreamOutput:input_type -> lpaas.v1alpha1.StreamRequest
-	1, // 4: lpaas.v1alpha1.Lpaas.StartJob:output_type -> lpaas.v1alpha1.StartJobResponse
-	6, // 5: lpaas.v1alpha1.Lpaas.StopJob:output_type -> lpaas.v1alpha1.StopJobResponse
-	3, // 6: lpaas.v1alpha1.Lpaas.GetStatus:output_type -> lpaas.v1alpha1.StatusJobResponse
-	5, // 7: lpaas.v1alpha1.Lpaas.StreamOutput:output_type -> lpaas.v1alpha1.StreamChunk
-	4, // [4:8] is the sub-list for method output_type
-	0, // [0:4] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	6,  // 0: lpaas.v1alpha1.StartJobRequest.bind_mounts:type_name -> lpaas.v1alpha1.BindMount
+	4,  // 1: lpaas.v1alpha1.StartJobRequest.resources:type_name -> lpaas.v1alpha1.ResourceProfile
+	39, // 2: lpaas.v1alpha1.StartJobRequest.timeout:type_name -> google.protobuf.Duration
+	35, // 3: lpaas.v1alpha1.StartJobRequest.labels:type_name -> lpaas.v1alpha1.StartJobRequest.LabelsEntry
+	5,  // 4: lpaas.v1alpha1.StartJobRequest.namespaces:type_name -> lpaas.v1alpha1.Namespaces
+	3,  // 5: lpaas.v1alpha1.StartJobRequest.output_retention:type_name -> lpaas.v1alpha1.OutputRetention
+	39, // 6: lpaas.v1alpha1.OutputRetention.max_age:type_name -> google.protobuf.Duration
+	39, // 7: lpaas.v1alpha1.StopJobRequest.grace_period:type_name -> google.protobuf.Duration
+	40, // 8: lpaas.v1alpha1.StatusJobResponse.started_at:type_name -> google.protobuf.Timestamp
+	40, // 9: lpaas.v1alpha1.StatusJobResponse.finished_at:type_name -> google.protobuf.Timestamp
+	14, // 10: lpaas.v1alpha1.StatusJobResponse.invocation:type_name -> lpaas.v1alpha1.JobInvocation
+	36, // 11: lpaas.v1alpha1.StatusJobResponse.labels:type_name -> lpaas.v1alpha1.StatusJobResponse.LabelsEntry
+	6,  // 12: lpaas.v1alpha1.JobInvocation.bind_mounts:type_name -> lpaas.v1alpha1.BindMount
+	4,  // 13: lpaas.v1alpha1.JobInvocation.resources:type_name -> lpaas.v1alpha1.ResourceProfile
+	39, // 14: lpaas.v1alpha1.JobInvocation.timeout:type_name -> google.protobuf.Duration
+	5,  // 15: lpaas.v1alpha1.JobInvocation.namespaces:type_name -> lpaas.v1alpha1.Namespaces
+	39, // 16: lpaas.v1alpha1.StatsResponse.cpu_usage:type_name -> google.protobuf.Duration
+	39, // 17: lpaas.v1alpha1.StatsResponse.user_cpu:type_name -> google.protobuf.Duration
+	39, // 18: lpaas.v1alpha1.StatsResponse.system_cpu:type_name -> google.protobuf.Duration
+	39, // 19: lpaas.v1alpha1.OwnerStatsResponse.cpu_usage:type_name -> google.protobuf.Duration
+	0,  // 20: lpaas.v1alpha1.StreamRequest.stream:type_name -> lpaas.v1alpha1.OutputStream
+	0,  // 21: lpaas.v1alpha1.StreamChunk.stream:type_name -> lpaas.v1alpha1.OutputStream
+	0,  // 22: lpaas.v1alpha1.AuditChunk.source:type_name -> lpaas.v1alpha1.OutputStream
+	37, // 23: lpaas.v1alpha1.ListJobsRequest.label_selector:type_name -> lpaas.v1alpha1.ListJobsRequest.LabelSelectorEntry
+	1,  // 24: lpaas.v1alpha1.ListJobsRequest.sort:type_name -> lpaas.v1alpha1.ListJobsSort
+	38, // 25: lpaas.v1alpha1.JobSummary.labels:type_name -> lpaas.v1alpha1.JobSummary.LabelsEntry
+	40, // 26: lpaas.v1alpha1.JobSummary.created_at:type_name -> google.protobuf.Timestamp
+	27, // 27: lpaas.v1alpha1.ListJobsResponse.jobs:type_name -> lpaas.v1alpha1.JobSummary
+	39, // 28: lpaas.v1alpha1.WaitJobRequest.timeout:type_name -> google.protobuf.Duration
+	2,  // 29: lpaas.v1alpha1.Lpaas.StartJob:input_type -> lpaas.v1alpha1.StartJobRequest
+	10, // 30: lpaas.v1alpha1.Lpaas.StopJob:input_type -> lpaas.v1alpha1.StopJobRequest
+	11, // 31: lpaas.v1alpha1.Lpaas.SendSignal:input_type -> lpaas.v1alpha1.SendSignalRequest
+	9,  // 32: lpaas.v1alpha1.Lpaas.GetStatus:input_type -> lpaas.v1alpha1.JobRequest
+	9,  // 33: lpaas.v1alpha1.Lpaas.GetStats:input_type -> lpaas.v1alpha1.JobRequest
+	18, // 34: lpaas.v1alpha1.Lpaas.StreamOutput:input_type -> lpaas.v1alpha1.StreamRequest
+	20, // 35: lpaas.v1alpha1.Lpaas.StreamJobs:input_type -> lpaas.v1alpha1.StreamJobsRequest
+	9,  // 36: lpaas.v1alpha1.Lpaas.RemoveJob:input_type -> lpaas.v1alpha1.JobRequest
+	24, // 37: lpaas.v1alpha1.Lpaas.DownloadOutput:input_type -> lpaas.v1alpha1.DownloadOutputRequest
+	26, // 38: lpaas.v1alpha1.Lpaas.ListJobs:input_type -> lpaas.v1alpha1.ListJobsRequest
+	31, // 39: lpaas.v1alpha1.Lpaas.WaitJob:input_type -> lpaas.v1alpha1.WaitJobRequest
+	33, // 40: lpaas.v1alpha1.Lpaas.WriteStdin:input_type -> lpaas.v1alpha1.StdinChunk
+	8,  // 41: lpaas.v1alpha1.Lpaas.RestartJob:input_type -> lpaas.v1alpha1.RestartJobRequest
+	16, // 42: lpaas.v1alpha1.Lpaas.GetOwnerStats:input_type -> lpaas.v1alpha1.OwnerStatsRequest
+	22, // 43: lpaas.v1alpha1.Lpaas.StreamAllOutput:input_type -> lpaas.v1alpha1.StreamAllOutputRequest
+	7,  // 44: lpaas.v1alpha1.Lpaas.StartJob:output_type -> lpaas.v1alpha1.StartJobResponse
+	29, // 45: lpaas.v1alpha1.Lpaas.StopJob:output_type -> lpaas.v1alpha1.StopJobResponse
+	12, // 46: lpaas.v1alpha1.Lpaas.SendSignal:output_type -> lpaas.v1alpha1.SendSignalResponse
+	13, // 47: lpaas.v1alpha1.Lpaas.GetStatus:output_type -> lpaas.v1alpha1.StatusJobResponse
+	15, // 48: lpaas.v1alpha1.Lpaas.GetStats:output_type -> lpaas.v1alpha1.StatsResponse
+	19, // 49: lpaas.v1alpha1.Lpaas.StreamOutput:output_type -> lpaas.v1alpha1.StreamChunk
+	21, // 50: lpaas.v1alpha1.Lpaas.StreamJobs:output_type -> lpaas.v1alpha1.JobStreamChunk
+	30, // 51: lpaas.v1alpha1.Lpaas.RemoveJob:output_type -> lpaas.v1alpha1.RemoveJobResponse
+	25, // 52: lpaas.v1alpha1.Lpaas.DownloadOutput:output_type -> lpaas.v1alpha1.DownloadChunk
+	28, // 53: lpaas.v1alpha1.Lpaas.ListJobs:output_type -> lpaas.v1alpha1.ListJobsResponse
+	32, // 54: lpaas.v1alpha1.Lpaas.WaitJob:output_type -> lpaas.v1alpha1.WaitJobResponse
+	34, // 55: lpaas.v1alpha1.Lpaas.WriteStdin:output_type -> lpaas.v1alpha1.WriteStdinResponse
+	7,  // 56: lpaas.v1alpha1.Lpaas.RestartJob:output_type -> lpaas.v1alpha1.StartJobResponse
+	17, // 57: lpaas.v1alpha1.Lpaas.GetOwnerStats:output_type -> lpaas.v1alpha1.OwnerStatsResponse
+	23, // 58: lpaas.v1alpha1.Lpaas.StreamAllOutput:output_type -> lpaas.v1alpha1.AuditChunk
+	44, // [44:59] is the sub-list for method output_type
+	29, // [29:44] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
 }
 
 func init() { file_lpaas_v1alpha1_job_proto_init() }
@@ -443,19 +3358,25 @@ func file_lpaas_v1alpha1_job_proto_init() {
 	if File_lpaas_v1alpha1_job_proto != nil {
 		return
 	}
-	file_lpaas_v1alpha1_job_proto_msgTypes[3].OneofWrappers = []any{}
+	file_lpaas_v1alpha1_job_proto_msgTypes[0].OneofWrappers = []any{}
+	file_lpaas_v1alpha1_job_proto_msgTypes[11].OneofWrappers = []any{}
+	file_lpaas_v1alpha1_job_proto_msgTypes[12].OneofWrappers = []any{}
+	file_lpaas_v1alpha1_job_proto_msgTypes[16].OneofWrappers = []any{}
+	file_lpaas_v1alpha1_job_proto_msgTypes[19].OneofWrappers = []any{}
+	file_lpaas_v1alpha1_job_proto_msgTypes[30].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_lpaas_v1alpha1_job_proto_rawDesc), len(file_lpaas_v1alpha1_job_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      2,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_lpaas_v1alpha1_job_proto_goTypes,
 		DependencyIndexes: file_lpaas_v1alpha1_job_proto_depIdxs,
+		EnumInfos:         file_lpaas_v1alpha1_job_proto_enumTypes,
 		MessageInfos:      file_lpaas_v1alpha1_job_proto_msgTypes,
 	}.Build()
 	File_lpaas_v1alpha1_job_proto = out.File
@@ -23,10 +23,21 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Lpaas_StartJob_FullMethodName     = "/lpaas.v1alpha1.Lpaas/StartJob"
-	Lpaas_StopJob_FullMethodName      = "/lpaas.v1alpha1.Lpaas/StopJob"
-	Lpaas_GetStatus_FullMethodName    = "/lpaas.v1alpha1.Lpaas/GetStatus"
-	Lpaas_StreamOutput_FullMethodName = "/lpaas.v1alpha1.Lpaas/StreamOutput"
+	Lpaas_StartJob_FullMethodName        = "/lpaas.v1alpha1.Lpaas/StartJob"
+	Lpaas_StopJob_FullMethodName         = "/lpaas.v1alpha1.Lpaas/StopJob"
+	Lpaas_SendSignal_FullMethodName      = "/lpaas.v1alpha1.Lpaas/SendSignal"
+	Lpaas_GetStatus_FullMethodName       = "/lpaas.v1alpha1.Lpaas/GetStatus"
+	Lpaas_GetStats_FullMethodName        = "/lpaas.v1alpha1.Lpaas/GetStats"
+	Lpaas_StreamOutput_FullMethodName    = "/lpaas.v1alpha1.Lpaas/StreamOutput"
+	Lpaas_StreamJobs_FullMethodName      = "/lpaas.v1alpha1.Lpaas/StreamJobs"
+	Lpaas_RemoveJob_FullMethodName       = "/lpaas.v1alpha1.Lpaas/RemoveJob"
+	Lpaas_DownloadOutput_FullMethodName  = "/lpaas.v1alpha1.Lpaas/DownloadOutput"
+	Lpaas_ListJobs_FullMethodName        = "/lpaas.v1alpha1.Lpaas/ListJobs"
+	Lpaas_WaitJob_FullMethodName         = "/lpaas.v1alpha1.Lpaas/WaitJob"
+	Lpaas_WriteStdin_FullMethodName      = "/lpaas.v1alpha1.Lpaas/WriteStdin"
+	Lpaas_RestartJob_FullMethodName      = "/lpaas.v1alpha1.Lpaas/RestartJob"
+	Lpaas_GetOwnerStats_FullMethodName   = "/lpaas.v1alpha1.Lpaas/GetOwnerStats"
+	Lpaas_StreamAllOutput_FullMethodName = "/lpaas.v1alpha1.Lpaas/StreamAllOutput"
 )
 
 // LpaasClient is the client API for Lpaas service.
@@ -40,13 +51,56 @@ type LpaasClient interface {
 	// Returns the unique ID of the job.
 	StartJob(ctx context.Context, in *StartJobRequest, opts ...grpc.CallOption) (*StartJobResponse, error)
 	// Stops a running job by its ID.
-	// Returns the status of the job.
-	StopJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*StopJobResponse, error)
+	// The job is sent SIGTERM and killed if it has not exited after the grace period.
+	StopJob(ctx context.Context, in *StopJobRequest, opts ...grpc.CallOption) (*StopJobResponse, error)
+	// Deliver a signal to a running job, e.g. SIGHUP to reload its config.
+	SendSignal(ctx context.Context, in *SendSignalRequest, opts ...grpc.CallOption) (*SendSignalResponse, error)
 	// Query the status of a job.
 	// Returns current status and error details if any.
 	GetStatus(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*StatusJobResponse, error)
-	// Stream output from a running or completed job.
+	// Query the CPU and memory usage of a job. Finished jobs report the
+	// usage recorded when they exited.
+	GetStats(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	// Stream output from a running or completed job. Clients may request gzip
+	// compression (grpc-encoding: gzip); each chunk is compressed as it is
+	// sent, so following a job is not delayed.
 	StreamOutput(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamChunk], error)
+	// Stream output from several jobs at once.
+	// Each chunk is tagged with the ID of the job it came from.
+	StreamJobs(ctx context.Context, in *StreamJobsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobStreamChunk], error)
+	// Remove a finished job and release its resources.
+	// Fails with FAILED_PRECONDITION if the job is still running.
+	RemoveJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*RemoveJobResponse, error)
+	// Download the complete output of a finished job, starting at an offset so
+	// an interrupted download can be resumed. The last message carries the
+	// size and checksum of the complete output.
+	DownloadOutput(ctx context.Context, in *DownloadOutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadChunk], error)
+	// List the jobs of the caller, or of all owners for admins, optionally a
+	// page at a time.
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	// Wait until a job finished and return its final status. The wait ends
+	// early, with finished unset, once the timeout or the server's maximum
+	// wait has passed.
+	WaitJob(ctx context.Context, in *WaitJobRequest, opts ...grpc.CallOption) (*WaitJobResponse, error)
+	// Write to the stdin of a running job started with stdin set. The first
+	// message names the job; the data of all messages is written in order.
+	// Closing the stream closes the job's stdin, so the job reads EOF. Fails
+	// with FAILED_PRECONDITION if the job has no open stdin or another client
+	// is writing to it, or once the job finishes.
+	WriteStdin(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[StdinChunk, WriteStdinResponse], error)
+	// Start a new job with the same command, arguments, environment, limits
+	// and labels as a finished job of the caller, and return its ID. The
+	// finished job is kept. Fails with FAILED_PRECONDITION if the job is still
+	// running.
+	RestartJob(ctx context.Context, in *RestartJobRequest, opts ...grpc.CallOption) (*StartJobResponse, error)
+	// Query the totals of all jobs an owner has run since the server started,
+	// including removed jobs. Requires a certificate with the admin OU.
+	GetOwnerStats(ctx context.Context, in *OwnerStatsRequest, opts ...grpc.CallOption) (*OwnerStatsResponse, error)
+	// Stream the output that the running jobs of all owners write from now on,
+	// for auditing. Each chunk is tagged with the owner and ID of its job.
+	// Requires a certificate with the admin OU and a server started with the
+	// audit stream enabled; fails with FAILED_PRECONDITION otherwise.
+	StreamAllOutput(ctx context.Context, in *StreamAllOutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditChunk], error)
 }
 
 type lpaasClient struct {
@@ -67,7 +121,7 @@ func (c *lpaasClient) StartJob(ctx context.Context, in *StartJobRequest, opts ..
 	return out, nil
 }
 
-func (c *lpaasClient) StopJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*StopJobResponse, error) {
+func (c *lpaasClient) StopJob(ctx context.Context, in *StopJobRequest, opts ...grpc.CallOption) (*StopJobResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(StopJobResponse)
 	err := c.cc.Invoke(ctx, Lpaas_StopJob_FullMethodName, in, out, cOpts...)
@@ -77,6 +131,16 @@ func (c *lpaasClient) StopJob(ctx context.Context, in *JobRequest, opts ...grpc.
 	return out, nil
 }
 
+func (c *lpaasClient) SendSignal(ctx context.Context, in *SendSignalRequest, opts ...grpc.CallOption) (*SendSignalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendSignalResponse)
+	err := c.cc.Invoke(ctx, Lpaas_SendSignal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lpaasClient) GetStatus(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*StatusJobResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(StatusJobResponse)
@@ -87,6 +151,16 @@ func (c *lpaasClient) GetStatus(ctx context.Context, in *JobRequest, opts ...grp
 	return out, nil
 }
 
+func (c *lpaasClient) GetStats(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, Lpaas_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lpaasClient) StreamOutput(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamChunk], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &Lpaas_ServiceDesc.Streams[0], Lpaas_StreamOutput_FullMethodName, cOpts...)
@@ -106,6 +180,126 @@ func (c *lpaasClient) StreamOutput(ctx context.Context, in *StreamRequest, opts
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Lpaas_StreamOutputClient = grpc.ServerStreamingClient[StreamChunk]
 
+func (c *lpaasClient) StreamJobs(ctx context.Context, in *StreamJobsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobStreamChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Lpaas_ServiceDesc.Streams[1], Lpaas_StreamJobs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamJobsRequest, JobStreamChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_StreamJobsClient = grpc.ServerStreamingClient[JobStreamChunk]
+
+func (c *lpaasClient) RemoveJob(ctx context.Context, in *JobRequest, opts ...grpc.CallOption) (*RemoveJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveJobResponse)
+	err := c.cc.Invoke(ctx, Lpaas_RemoveJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lpaasClient) DownloadOutput(ctx context.Context, in *DownloadOutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Lpaas_ServiceDesc.Streams[2], Lpaas_DownloadOutput_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadOutputRequest, DownloadChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_DownloadOutputClient = grpc.ServerStreamingClient[DownloadChunk]
+
+func (c *lpaasClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, Lpaas_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lpaasClient) WaitJob(ctx context.Context, in *WaitJobRequest, opts ...grpc.CallOption) (*WaitJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WaitJobResponse)
+	err := c.cc.Invoke(ctx, Lpaas_WaitJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lpaasClient) WriteStdin(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[StdinChunk, WriteStdinResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Lpaas_ServiceDesc.Streams[3], Lpaas_WriteStdin_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StdinChunk, WriteStdinResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_WriteStdinClient = grpc.ClientStreamingClient[StdinChunk, WriteStdinResponse]
+
+func (c *lpaasClient) RestartJob(ctx context.Context, in *RestartJobRequest, opts ...grpc.CallOption) (*StartJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartJobResponse)
+	err := c.cc.Invoke(ctx, Lpaas_RestartJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lpaasClient) GetOwnerStats(ctx context.Context, in *OwnerStatsRequest, opts ...grpc.CallOption) (*OwnerStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OwnerStatsResponse)
+	err := c.cc.Invoke(ctx, Lpaas_GetOwnerStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lpaasClient) StreamAllOutput(ctx context.Context, in *StreamAllOutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Lpaas_ServiceDesc.Streams[4], Lpaas_StreamAllOutput_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamAllOutputRequest, AuditChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_StreamAllOutputClient = grpc.ServerStreamingClient[AuditChunk]
+
 // LpaasServer is the server API for Lpaas service.
 // All implementations must embed UnimplementedLpaasServer
 // for forward compatibility.
@@ -117,13 +311,56 @@ type LpaasServer interface {
 	// Returns the unique ID of the job.
 	StartJob(context.Context, *StartJobRequest) (*StartJobResponse, error)
 	// Stops a running job by its ID.
-	// Returns the status of the job.
-	StopJob(context.Context, *JobRequest) (*StopJobResponse, error)
+	// The job is sent SIGTERM and killed if it has not exited after the grace period.
+	StopJob(context.Context, *StopJobRequest) (*StopJobResponse, error)
+	// Deliver a signal to a running job, e.g. SIGHUP to reload its config.
+	SendSignal(context.Context, *SendSignalRequest) (*SendSignalResponse, error)
 	// Query the status of a job.
 	// Returns current status and error details if any.
 	GetStatus(context.Context, *JobRequest) (*StatusJobResponse, error)
-	// Stream output from a running or completed job.
+	// Query the CPU and memory usage of a job. Finished jobs report the
+	// usage recorded when they exited.
+	GetStats(context.Context, *JobRequest) (*StatsResponse, error)
+	// Stream output from a running or completed job. Clients may request gzip
+	// compression (grpc-encoding: gzip); each chunk is compressed as it is
+	// sent, so following a job is not delayed.
 	StreamOutput(*StreamRequest, grpc.ServerStreamingServer[StreamChunk]) error
+	// Stream output from several jobs at once.
+	// Each chunk is tagged with the ID of the job it came from.
+	StreamJobs(*StreamJobsRequest, grpc.ServerStreamingServer[JobStreamChunk]) error
+	// Remove a finished job and release its resources.
+	// Fails with FAILED_PRECONDITION if the job is still running.
+	RemoveJob(context.Context, *JobRequest) (*RemoveJobResponse, error)
+	// Download the complete output of a finished job, starting at an offset so
+	// an interrupted download can be resumed. The last message carries the
+	// size and checksum of the complete output.
+	DownloadOutput(*DownloadOutputRequest, grpc.ServerStreamingServer[DownloadChunk]) error
+	// List the jobs of the caller, or of all owners for admins, optionally a
+	// page at a time.
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	// Wait until a job finished and return its final status. The wait ends
+	// early, with finished unset, once the timeout or the server's maximum
+	// wait has passed.
+	WaitJob(context.Context, *WaitJobRequest) (*WaitJobResponse, error)
+	// Write to the stdin of a running job started with stdin set. The first
+	// message names the job; the data of all messages is written in order.
+	// Closing the stream closes the job's stdin, so the job reads EOF. Fails
+	// with FAILED_PRECONDITION if the job has no open stdin or another client
+	// is writing to it, or once the job finishes.
+	WriteStdin(grpc.ClientStreamingServer[StdinChunk, WriteStdinResponse]) error
+	// Start a new job with the same command, arguments, environment, limits
+	// and labels as a finished job of the caller, and return its ID. The
+	// finished job is kept. Fails with FAILED_PRECONDITION if the job is still
+	// running.
+	RestartJob(context.Context, *RestartJobRequest) (*StartJobResponse, error)
+	// Query the totals of all jobs an owner has run since the server started,
+	// including removed jobs. Requires a certificate with the admin OU.
+	GetOwnerStats(context.Context, *OwnerStatsRequest) (*OwnerStatsResponse, error)
+	// Stream the output that the running jobs of all owners write from now on,
+	// for auditing. Each chunk is tagged with the owner and ID of its job.
+	// Requires a certificate with the admin OU and a server started with the
+	// audit stream enabled; fails with FAILED_PRECONDITION otherwise.
+	StreamAllOutput(*StreamAllOutputRequest, grpc.ServerStreamingServer[AuditChunk]) error
 	mustEmbedUnimplementedLpaasServer()
 }
 
@@ -137,15 +374,48 @@ type UnimplementedLpaasServer struct{}
 func (UnimplementedLpaasServer) StartJob(context.Context, *StartJobRequest) (*StartJobResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StartJob not implemented")
 }
-func (UnimplementedLpaasServer) StopJob(context.Context, *JobRequest) (*StopJobResponse, error) {
+func (UnimplementedLpaasServer) StopJob(context.Context, *StopJobRequest) (*StopJobResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StopJob not implemented")
 }
+func (UnimplementedLpaasServer) SendSignal(context.Context, *SendSignalRequest) (*SendSignalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendSignal not implemented")
+}
 func (UnimplementedLpaasServer) GetStatus(context.Context, *JobRequest) (*StatusJobResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
 }
+func (UnimplementedLpaasServer) GetStats(context.Context, *JobRequest) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
 func (UnimplementedLpaasServer) StreamOutput(*StreamRequest, grpc.ServerStreamingServer[StreamChunk]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamOutput not implemented")
 }
+func (UnimplementedLpaasServer) StreamJobs(*StreamJobsRequest, grpc.ServerStreamingServer[JobStreamChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamJobs not implemented")
+}
+func (UnimplementedLpaasServer) RemoveJob(context.Context, *JobRequest) (*RemoveJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveJob not implemented")
+}
+func (UnimplementedLpaasServer) DownloadOutput(*DownloadOutputRequest, grpc.ServerStreamingServer[DownloadChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadOutput not implemented")
+}
+func (UnimplementedLpaasServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedLpaasServer) WaitJob(context.Context, *WaitJobRequest) (*WaitJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitJob not implemented")
+}
+func (UnimplementedLpaasServer) WriteStdin(grpc.ClientStreamingServer[StdinChunk, WriteStdinResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WriteStdin not implemented")
+}
+func (UnimplementedLpaasServer) RestartJob(context.Context, *RestartJobRequest) (*StartJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestartJob not implemented")
+}
+func (UnimplementedLpaasServer) GetOwnerStats(context.Context, *OwnerStatsRequest) (*OwnerStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOwnerStats not implemented")
+}
+func (UnimplementedLpaasServer) StreamAllOutput(*StreamAllOutputRequest, grpc.ServerStreamingServer[AuditChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAllOutput not implemented")
+}
 func (UnimplementedLpaasServer) mustEmbedUnimplementedLpaasServer() {}
 func (UnimplementedLpaasServer) testEmbeddedByValue()               {}
 
@@ -186,7 +456,7 @@ func _Lpaas_StartJob_Handler(srv interface{}, ctx context.Context, dec func(inte
 }
 
 func _Lpaas_StopJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(JobRequest)
+	in := new(StopJobRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -198,7 +468,25 @@ func _Lpaas_StopJob_Handler(srv interface{}, ctx context.Context, dec func(inter
 		FullMethod: Lpaas_StopJob_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(LpaasServer).StopJob(ctx, req.(*JobRequest))
+		return srv.(LpaasServer).StopJob(ctx, req.(*StopJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lpaas_SendSignal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendSignalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LpaasServer).SendSignal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lpaas_SendSignal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LpaasServer).SendSignal(ctx, req.(*SendSignalRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -221,6 +509,24 @@ func _Lpaas_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Lpaas_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LpaasServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lpaas_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LpaasServer).GetStats(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Lpaas_StreamOutput_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(StreamRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -232,6 +538,136 @@ func _Lpaas_StreamOutput_Handler(srv interface{}, stream grpc.ServerStream) erro
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Lpaas_StreamOutputServer = grpc.ServerStreamingServer[StreamChunk]
 
+func _Lpaas_StreamJobs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamJobsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LpaasServer).StreamJobs(m, &grpc.GenericServerStream[StreamJobsRequest, JobStreamChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_StreamJobsServer = grpc.ServerStreamingServer[JobStreamChunk]
+
+func _Lpaas_RemoveJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LpaasServer).RemoveJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lpaas_RemoveJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LpaasServer).RemoveJob(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lpaas_DownloadOutput_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadOutputRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LpaasServer).DownloadOutput(m, &grpc.GenericServerStream[DownloadOutputRequest, DownloadChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_DownloadOutputServer = grpc.ServerStreamingServer[DownloadChunk]
+
+func _Lpaas_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LpaasServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lpaas_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LpaasServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lpaas_WaitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WaitJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LpaasServer).WaitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lpaas_WaitJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LpaasServer).WaitJob(ctx, req.(*WaitJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lpaas_WriteStdin_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LpaasServer).WriteStdin(&grpc.GenericServerStream[StdinChunk, WriteStdinResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_WriteStdinServer = grpc.ClientStreamingServer[StdinChunk, WriteStdinResponse]
+
+func _Lpaas_RestartJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestartJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LpaasServer).RestartJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lpaas_RestartJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LpaasServer).RestartJob(ctx, req.(*RestartJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lpaas_GetOwnerStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LpaasServer).GetOwnerStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lpaas_GetOwnerStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LpaasServer).GetOwnerStats(ctx, req.(*OwnerStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lpaas_StreamAllOutput_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAllOutputRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LpaasServer).StreamAllOutput(m, &grpc.GenericServerStream[StreamAllOutputRequest, AuditChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Lpaas_StreamAllOutputServer = grpc.ServerStreamingServer[AuditChunk]
+
 // Lpaas_ServiceDesc is the grpc.ServiceDesc for Lpaas service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -247,10 +683,38 @@ var Lpaas_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "StopJob",
 			Handler:    _Lpaas_StopJob_Handler,
 		},
+		{
+			MethodName: "SendSignal",
+			Handler:    _Lpaas_SendSignal_Handler,
+		},
 		{
 			MethodName: "GetStatus",
 			Handler:    _Lpaas_GetStatus_Handler,
 		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Lpaas_GetStats_Handler,
+		},
+		{
+			MethodName: "RemoveJob",
+			Handler:    _Lpaas_RemoveJob_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _Lpaas_ListJobs_Handler,
+		},
+		{
+			MethodName: "WaitJob",
+			Handler:    _Lpaas_WaitJob_Handler,
+		},
+		{
+			MethodName: "RestartJob",
+			Handler:    _Lpaas_RestartJob_Handler,
+		},
+		{
+			MethodName: "GetOwnerStats",
+			Handler:    _Lpaas_GetOwnerStats_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -258,6 +722,26 @@ var Lpaas_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Lpaas_StreamOutput_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamJobs",
+			Handler:       _Lpaas_StreamJobs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DownloadOutput",
+			Handler:       _Lpaas_DownloadOutput_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteStdin",
+			Handler:       _Lpaas_WriteStdin_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamAllOutput",
+			Handler:       _Lpaas_StreamAllOutput_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "lpaas/v1alpha1/job.proto",
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const archiveRetryDelay = time.Second
+
+var (
+	archiveDir     string
+	archiveRetries int
+)
+
+// downloadClient is the part of the LPaaS client used to archive output.
+type downloadClient interface {
+	DownloadOutput(ctx context.Context, in *pb.DownloadOutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.DownloadChunk], error)
+}
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <job-id>",
+	Short: "Download the complete output of a finished job to a file",
+	Long: "Download the complete output of a finished job to <dir>/<job-id>.log.\n" +
+		"An interrupted download is resumed from the partial <job-id>.log.part file.",
+	Args: cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		path, err := archiveOutput(cmd.Context(), client, jobID, archiveDir, archiveRetries, archiveRetryDelay)
+		if err != nil {
+			return fmt.Errorf("failed to archive job: %w", err)
+		}
+
+		fmt.Printf("Output of job %s archived to %s\n", jobID, path)
+		return nil
+	},
+}
+
+// archiveOutput downloads the output of jobID to dir/<jobID>.log and returns
+// its path. Data is appended to a .part file first, whose size is the offset a
+// later download resumes from. Transient errors are retried up to retries
+// times, waiting delay in between. The file is only renamed into place once
+// its size and checksum match the ones reported by the server.
+func archiveOutput(ctx context.Context, client downloadClient, jobID, dir string, retries int, delay time.Duration) (string, error) {
+	path := filepath.Join(dir, jobID+".log")
+	partPath := path + ".part"
+
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("open partial download: %w", err)
+	}
+	defer part.Close()
+
+	var trailer *pb.DownloadChunk
+	for attempt := 0; ; attempt++ {
+		trailer, err = downloadOutput(ctx, client, jobID, part)
+		if err == nil {
+			break
+		}
+		if !retryableDownloadErr(err) || attempt >= retries {
+			return "", err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	if err := part.Close(); err != nil {
+		return "", fmt.Errorf("close partial download: %w", err)
+	}
+	if err := verifyDownload(partPath, trailer); err != nil {
+		os.Remove(partPath)
+		return "", fmt.Errorf("%w; partial download discarded", err)
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		return "", fmt.Errorf("move download into place: %w", err)
+	}
+
+	return path, nil
+}
+
+// downloadOutput appends the output of jobID that is not yet in part to it and
+// returns the trailer ending the download.
+func downloadOutput(ctx context.Context, client downloadClient, jobID string, part *os.File) (*pb.DownloadChunk, error) {
+	info, err := part.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat partial download: %w", err)
+	}
+	offset := uint64(info.Size())
+
+	stream, err := client.DownloadOutput(ctx, &pb.DownloadOutputRequest{Id: jobID, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("download ended at offset %d: %w", offset, io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(chunk.Sha256) > 0 {
+			return chunk, nil
+		}
+		if chunk.Offset != offset {
+			return nil, fmt.Errorf("received chunk at offset %d, expected %d", chunk.Offset, offset)
+		}
+
+		if _, err := part.Write(chunk.Data); err != nil {
+			return nil, fmt.Errorf("write partial download: %w", err)
+		}
+		offset += uint64(len(chunk.Data))
+	}
+}
+
+// retryableDownloadErr reports whether a download may succeed when resumed.
+func retryableDownloadErr(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// verifyDownload checks the file at path against the size and checksum in trailer.
+func verifyDownload(path string, trailer *pb.DownloadChunk) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open download: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, f)
+	if err != nil {
+		return fmt.Errorf("read download: %w", err)
+	}
+
+	if uint64(n) != trailer.TotalBytes {
+		return fmt.Errorf("downloaded %d bytes, expected %d", n, trailer.TotalBytes)
+	}
+	if !bytes.Equal(hash.Sum(nil), trailer.Sha256) {
+		return errors.New("checksum mismatch")
+	}
+	return nil
+}
+
+func init() {
+	archiveCmd.Flags().StringVar(&archiveDir, "to", ".", "Directory to write the output file to")
+	archiveCmd.Flags().IntVar(&archiveRetries, "retries", 5, "Number of times to resume after a transient error")
+	RootCmd.AddCommand(archiveCmd)
+}
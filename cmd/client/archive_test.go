@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDownloadClient serves output in chunks and breaks the first download
+// after failAfter chunks.
+type fakeDownloadClient struct {
+	output    []byte
+	chunkSize int
+	failAfter int
+	offsets   []uint64 // offset requested by each download
+}
+
+func (f *fakeDownloadClient) DownloadOutput(_ context.Context, in *pb.DownloadOutputRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[pb.DownloadChunk], error) {
+	f.offsets = append(f.offsets, in.Offset)
+
+	var chunks []*pb.DownloadChunk
+	for off := int(in.Offset); off < len(f.output); off += f.chunkSize {
+		end := min(off+f.chunkSize, len(f.output))
+		chunks = append(chunks, &pb.DownloadChunk{Data: f.output[off:end], Offset: uint64(off)})
+	}
+
+	s := &fakeDownloadStream{chunks: chunks, failAfter: -1}
+	if len(f.offsets) == 1 {
+		s.failAfter = f.failAfter
+	} else {
+		sum := sha256.Sum256(f.output)
+		s.chunks = append(s.chunks, &pb.DownloadChunk{
+			Offset:     uint64(len(f.output)),
+			TotalBytes: uint64(len(f.output)),
+			Sha256:     sum[:],
+		})
+	}
+	return s, nil
+}
+
+type fakeDownloadStream struct {
+	grpc.ServerStreamingClient[pb.DownloadChunk]
+	chunks    []*pb.DownloadChunk
+	failAfter int
+	sent      int
+}
+
+func (s *fakeDownloadStream) Recv() (*pb.DownloadChunk, error) {
+	if s.sent == s.failAfter {
+		return nil, status.Error(codes.Unavailable, "connection reset")
+	}
+	if s.sent == len(s.chunks) {
+		return nil, status.Error(codes.Internal, "no trailer")
+	}
+	s.sent++
+	return s.chunks[s.sent-1], nil
+}
+
+func TestArchiveOutput_ResumesInterruptedDownload(t *testing.T) {
+	dir := t.TempDir()
+	output := []byte(strings.Repeat("line of output\n", 100))
+	client := &fakeDownloadClient{output: output, chunkSize: 64, failAfter: 3}
+
+	path, err := archiveOutput(context.Background(), client, "job-1", dir, 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(client.offsets) != 2 || client.offsets[0] != 0 || client.offsets[1] != 3*64 {
+		t.Fatalf("expected a download resumed at offset 192, got offsets %v", client.offsets)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	if string(data) != string(output) {
+		t.Fatalf("archive does not match output: got %d bytes, want %d", len(data), len(output))
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("expected partial file to be gone, got %v", err)
+	}
+}
+
+func TestArchiveOutput_ResumesFromPartialFile(t *testing.T) {
+	dir := t.TempDir()
+	output := []byte("0123456789abcdef")
+	client := &fakeDownloadClient{output: output, chunkSize: 4, offsets: []uint64{0}}
+
+	// Left behind by an earlier, interrupted run.
+	if err := os.WriteFile(filepath.Join(dir, "job-1.log.part"), output[:10], 0o644); err != nil {
+		t.Fatalf("write partial file: %v", err)
+	}
+
+	path, err := archiveOutput(context.Background(), client, "job-1", dir, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.offsets[1] != 10 {
+		t.Fatalf("expected download to resume at offset 10, got %d", client.offsets[1])
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(output) {
+		t.Fatalf("unexpected archive %q", data)
+	}
+}
+
+func TestArchiveOutput_ChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	output := []byte("0123456789abcdef")
+	client := &fakeDownloadClient{output: output, chunkSize: 4, offsets: []uint64{0}}
+
+	// A stale partial file that doesn't match the job's output.
+	partPath := filepath.Join(dir, "job-1.log.part")
+	if err := os.WriteFile(partPath, []byte("XXXX"), 0o644); err != nil {
+		t.Fatalf("write partial file: %v", err)
+	}
+
+	if _, err := archiveOutput(context.Background(), client, "job-1", dir, 0, 0); err == nil {
+		t.Fatalf("expected checksum mismatch")
+	}
+	if _, err := os.Stat(partPath); !os.IsNotExist(err) {
+		t.Fatalf("expected corrupt partial file to be discarded, got %v", err)
+	}
+}
+
+func TestArchiveOutput_GivesUpAfterRetries(t *testing.T) {
+	client := &fakeDownloadClient{output: []byte("data"), chunkSize: 1, failAfter: 1}
+
+	_, err := archiveOutput(context.Background(), client, "job-1", t.TempDir(), 0, 0)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable error without retries, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout bounds the ListJobs call completing job IDs, so an
+// unreachable server does not hang the shell.
+const completionTimeout = 3 * time.Second
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish>",
+	Short: "Generate a shell completion script",
+	Long: "Generate a completion script for the given shell and write it to stdout, e.g.\n" +
+		"  source <(lpaas completion bash)\n" +
+		"Job IDs are completed by listing your jobs on the server, using the --addr,\n" +
+		"--ca, --cert and --key flags given on the command line being completed.",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return RootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return RootCmd.GenZshCompletion(out)
+		case "fish":
+			return RootCmd.GenFishCompletion(out, true)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
+
+// completeJobIDs is a cobra.Command.ValidArgsFunction completing the job ID
+// given as the first argument of a command.
+func completeJobIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	conn, client, err := NewLpaasClient()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), completionTimeout)
+	defer cancel()
+
+	ids, err := jobIDCompletions(ctx, client, toComplete)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// jobIDCompletions returns the IDs of the caller's jobs starting with prefix,
+// described by their status and command.
+func jobIDCompletions(ctx context.Context, client listClient, prefix string) ([]string, error) {
+	resp, err := client.ListJobs(ctx, &pb.ListJobsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	var ids []string
+	for _, job := range resp.Jobs {
+		if !strings.HasPrefix(job.Id, prefix) {
+			continue
+		}
+		command := cmp.Or(formatCommandLine(job.Command, job.Args, job.ArgsRedacted), job.CommandPath)
+		ids = append(ids, fmt.Sprintf("%s\t%s %s", job.Id, job.Status, command))
+	}
+	return ids, nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{
+		statusCmd, stopCmd, logsCmd, watchCmd, waitCmd, statsCmd, signalCmd,
+		writeStdinCmd, restartCmd, replayCmd, archiveCmd, removeCmd,
+	} {
+		cmd.ValidArgsFunction = completeJobIDs
+	}
+	RootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+type fakeListClient struct {
+	resp *pb.ListJobsResponse
+	err  error
+}
+
+func (f *fakeListClient) ListJobs(context.Context, *pb.ListJobsRequest, ...grpc.CallOption) (*pb.ListJobsResponse, error) {
+	return f.resp, f.err
+}
+
+func TestJobIDCompletions(t *testing.T) {
+	client := &fakeListClient{resp: &pb.ListJobsResponse{Jobs: []*pb.JobSummary{
+		{Id: "job-a1", Status: "Running", Command: "sleep", Args: []string{"10"}},
+		{Id: "job-b2", Status: "Exited", CommandPath: "/usr/bin/true"},
+		{Id: "job-a3", Status: "Stopped", Command: "bash", Args: []string{"-c", "echo hi"}},
+	}}}
+
+	got, err := jobIDCompletions(context.Background(), client, "job-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"job-a1\tRunning sleep 10", "job-a3\tStopped bash -c 'echo hi'"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	got, _ = jobIDCompletions(context.Background(), client, "")
+	if len(got) != 3 || got[1] != "job-b2\tExited /usr/bin/true" {
+		t.Fatalf("expected all jobs, falling back to the command path, got %q", got)
+	}
+}
+
+func TestJobIDCompletions_Error(t *testing.T) {
+	client := &fakeListClient{err: errors.New("unavailable")}
+	if _, err := jobIDCompletions(context.Background(), client, ""); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Fatalf("expected list error, got %v", err)
+	}
+}
+
+func TestCompletionCmd_Shells(t *testing.T) {
+	defer RootCmd.SetArgs(nil)
+	defer RootCmd.SetOut(nil)
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		RootCmd.SetOut(&buf)
+		RootCmd.SetArgs([]string{"completion", shell})
+		if err := RootCmd.Execute(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", shell, err)
+		}
+		if !strings.Contains(buf.String(), "lpaas") {
+			t.Fatalf("%s: expected a completion script, got %q", shell, buf.String())
+		}
+	}
+	RootCmd.SetArgs([]string{"completion", "powershell"})
+	if err := RootCmd.Execute(); err == nil {
+		t.Fatalf("expected an error for an unsupported shell")
+	}
+}
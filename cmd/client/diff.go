@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+var (
+	diffMaxBytes int
+	diffLines    int
+)
+
+// outputClient is the part of the LPaaS client used to fetch job output.
+type outputClient interface {
+	StreamOutput(ctx context.Context, in *pb.StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.StreamChunk], error)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <job-a> <job-b>",
+	Short: "Show a unified diff of the outputs of two jobs",
+	Long: "Fetch the output produced so far by two jobs and print a unified diff of it.\n" +
+		"Only the first --lines lines of each output are compared.",
+	Args: cobra.ExactArgs(2),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		var outputs [2][]string
+		for i, jobID := range args {
+			data, err := fetchOutput(cmd.Context(), client, jobID, diffMaxBytes)
+			if err != nil {
+				return err
+			}
+			lines := splitLines(string(data))
+			if len(lines) > diffLines {
+				fmt.Fprintf(os.Stderr, "Comparing only the first %d of %d lines of job %s\n", diffLines, len(lines), jobID)
+				lines = lines[:diffLines]
+			}
+			outputs[i] = lines
+		}
+
+		fmt.Print(unifiedDiff(args[0], args[1], outputs[0], outputs[1], diffContext))
+		return nil
+	},
+}
+
+// fetchOutput returns the output jobID produced so far, stdout and stderr
+// combined, failing if it is larger than maxBytes.
+func fetchOutput(ctx context.Context, client outputClient, jobID string, maxBytes int) ([]byte, error) {
+	stream, err := client.StreamOutput(ctx, &pb.StreamRequest{Id: jobID, Follow: proto.Bool(false)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch output of job %s: %w", jobID, err)
+	}
+
+	var out []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch output of job %s: %w", jobID, err)
+		}
+
+		out = append(out, chunk.Data...)
+		if len(out) > maxBytes {
+			return nil, fmt.Errorf("output of job %s is larger than %d bytes; raise --max-bytes to compare it", jobID, maxBytes)
+		}
+	}
+}
+
+// splitLines splits s into lines, without their line terminators. A final
+// line without a newline is kept.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffOp is one line of a line diff: kept (' '), removed ('-') or added ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLineOps returns the edit script turning a into b, based on their
+// longest common subsequence. Lines shared by the start and end of both
+// inputs are matched up front, so similar outputs are cheap to compare.
+func diffLineOps(a, b []string) []diffOp {
+	var prefix, suffix []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffOp{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append(suffix, diffOp{' ', a[len(a)-1]})
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := prefix
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for k := len(suffix) - 1; k >= 0; k-- {
+		ops = append(ops, suffix[k])
+	}
+	return ops
+}
+
+// unifiedDiff renders the differences between a and b as a unified diff with
+// context unchanged lines around each change. It is empty if they are equal.
+func unifiedDiff(nameA, nameB string, a, b []string, context int) string {
+	ops := diffLineOps(a, b)
+
+	var sb strings.Builder
+	posA, posB := make([]int, len(ops)), make([]int, len(ops)) // line numbers before each op
+	lineA, lineB := 1, 1
+	for k, op := range ops {
+		posA[k], posB[k] = lineA, lineB
+		if op.kind != '+' {
+			lineA++
+		}
+		if op.kind != '-' {
+			lineB++
+		}
+	}
+
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+
+		// A hunk extends until more than 2*context unchanged lines follow a change.
+		start := max(k-context, 0)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = next
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		var countA, countB int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(posA[start], countA), hunkRange(posB[start], countB))
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&sb, "%c%s\n", op.kind, op.line)
+		}
+		k = end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the line range of one side of a hunk. An empty range
+// refers to the line before it, as in diff -u.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func init() {
+	diffCmd.Flags().IntVar(&diffMaxBytes, "max-bytes", 16<<20, "Refuse to compare outputs larger than this many bytes")
+	diffCmd.Flags().IntVar(&diffLines, "lines", 2000, "Compare at most this many lines of each output")
+	RootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal outputs",
+			a:    "same\noutput\n",
+			b:    "same\noutput\n",
+			want: "",
+		},
+		{
+			name: "separate hunks",
+			a:    "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n",
+			b:    "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\no\n",
+			want: "--- job-a\n+++ job-b\n" +
+				"@@ -1,7 +1,7 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n" +
+				"@@ -12,3 +12,4 @@\n l\n m\n n\n+o\n",
+		},
+		{
+			name: "nearby changes share a hunk",
+			a:    "1\n2\n3\n4\n5\n6\n",
+			b:    "one\n2\n3\n4\n5\nsix\n",
+			want: "--- job-a\n+++ job-b\n" +
+				"@@ -1,6 +1,6 @@\n-1\n+one\n 2\n 3\n 4\n 5\n-6\n+six\n",
+		},
+		{
+			name: "empty output",
+			a:    "",
+			b:    "x\n",
+			want: "--- job-a\n+++ job-b\n@@ -0,0 +1 @@\n+x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got := unifiedDiff("job-a", "job-b", splitLines(tt.a), splitLines(tt.b), diffContext)
+		if got != tt.want {
+			t.Fatalf("%s: expected\n%s\ngot\n%s", tt.name, tt.want, got)
+		}
+	}
+}
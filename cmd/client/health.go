@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var healthService string
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Check whether the LPaaS worker is serving",
+	Long: "Query the gRPC health service of the worker. The LPaaS service is reported\n" +
+		"NOT_SERVING when the worker cannot apply resource limits to jobs.\n" +
+		"Exits with an error unless the service is SERVING.",
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := dialServer()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		return checkHealth(cmd.Context(), healthpb.NewHealthClient(conn), healthService)
+	},
+}
+
+// checkHealth prints the serving status of service and returns an error
+// unless it is SERVING.
+func checkHealth(ctx context.Context, client healthpb.HealthClient, service string) error {
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+
+	fmt.Printf("%s\n", resp.Status)
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return errors.New("worker is not serving")
+	}
+	return nil
+}
+
+func init() {
+	healthCmd.Flags().StringVar(&healthService, "service", pb.Lpaas_ServiceDesc.ServiceName, "Service to check (empty checks the worker as a whole)")
+	RootCmd.AddCommand(healthCmd)
+}
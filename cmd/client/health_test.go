@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// fakeHealthClient reports the status of the services it knows about.
+type fakeHealthClient struct {
+	healthpb.HealthClient
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (f *fakeHealthClient) Check(_ context.Context, in *healthpb.HealthCheckRequest, _ ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	st, ok := f.statuses[in.Service]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+func TestCheckHealth(t *testing.T) {
+	client := &fakeHealthClient{statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":                     healthpb.HealthCheckResponse_SERVING,
+		"lpaas.v1alpha1.Lpaas": healthpb.HealthCheckResponse_NOT_SERVING,
+	}}
+
+	tests := []struct {
+		service string
+		healthy bool
+	}{
+		{"", true},
+		{"lpaas.v1alpha1.Lpaas", false},
+		{"unknown", false},
+	}
+
+	for _, tt := range tests {
+		err := checkHealth(context.Background(), client, tt.service)
+		if healthy := err == nil; healthy != tt.healthy {
+			t.Fatalf("service %q: expected healthy=%v, got error %v", tt.service, tt.healthy, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+var (
+	listAllOwners bool
+	listLabels    []string
+	listSort      string
+	listPageSize  uint32
+)
+
+// listClient is the part of the LPaaS client used to list jobs.
+type listClient interface {
+	ListJobs(ctx context.Context, in *pb.ListJobsRequest, opts ...grpc.CallOption) (*pb.ListJobsResponse, error)
+}
+
+// listSorts maps the values of --sort to the orders of ListJobs.
+var listSorts = map[string]pb.ListJobsSort{
+	"":        pb.ListJobsSort_LIST_JOBS_SORT_UNSPECIFIED,
+	"created": pb.ListJobsSort_LIST_JOBS_SORT_CREATED,
+	"status":  pb.ListJobsSort_LIST_JOBS_SORT_STATUS,
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List jobs on the LPaaS worker",
+	Long: "List your jobs on the LPaaS worker. With --all, clients whose certificate has\n" +
+		"the admin organizational unit list the jobs of all owners.",
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selector, err := parseLabels(listLabels)
+		if err != nil {
+			return err
+		}
+		sort, ok := listSorts[listSort]
+		if !ok {
+			return fmt.Errorf("invalid --sort %q: must be created or status", listSort)
+		}
+
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		resp, err := listJobs(cmd.Context(), client, &pb.ListJobsRequest{
+			AllOwners:     listAllOwners,
+			LabelSelector: selector,
+			Sort:          sort,
+			PageSize:      listPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		if outputFmt == outputJSON {
+			return writeJSON(os.Stdout, resp)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tOWNER\tSTATUS\tCOMMAND\tLABELS")
+		for _, job := range resp.Jobs {
+			// Servers that do not return the command report its path only.
+			command := cmp.Or(formatCommandLine(job.Command, job.Args, job.ArgsRedacted), job.CommandPath)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.Id, job.Owner, job.Status, command, formatLabels(job.Labels))
+		}
+		return w.Flush()
+	},
+}
+
+// listJobs lists all jobs matching req, a page of req.PageSize jobs per call,
+// and returns them in one response.
+func listJobs(ctx context.Context, client listClient, req *pb.ListJobsRequest) (*pb.ListJobsResponse, error) {
+	all := &pb.ListJobsResponse{}
+	for {
+		resp, err := client.ListJobs(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		all.Jobs = append(all.Jobs, resp.Jobs...)
+		if resp.NextPageToken == "" {
+			return all, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+func init() {
+	listCmd.Flags().StringArrayVarP(&listLabels, "label", "l", nil, "List only jobs with label KEY=VALUE (repeatable, all must match)")
+	listCmd.Flags().BoolVar(&listAllOwners, "all", false, "List the jobs of all owners (admin certificates only)")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "Order of the jobs: created (oldest first) or status (default by owner, then created)")
+	listCmd.Flags().Uint32Var(&listPageSize, "page-size", 0, "Fetch the jobs this many at a time, to keep each response small (0 fetches all at once)")
+	RootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// fakePagedListClient returns the page of jobs for each page token.
+type fakePagedListClient struct {
+	pages    map[string]*pb.ListJobsResponse
+	requests []*pb.ListJobsRequest
+}
+
+func (f *fakePagedListClient) ListJobs(_ context.Context, in *pb.ListJobsRequest, _ ...grpc.CallOption) (*pb.ListJobsResponse, error) {
+	f.requests = append(f.requests, &pb.ListJobsRequest{PageToken: in.PageToken, PageSize: in.PageSize, Sort: in.Sort})
+	resp, ok := f.pages[in.PageToken]
+	if !ok {
+		return nil, errors.New("invalid page token")
+	}
+	return resp, nil
+}
+
+func TestListJobs_FollowsPages(t *testing.T) {
+	client := &fakePagedListClient{pages: map[string]*pb.ListJobsResponse{
+		"":   {Jobs: []*pb.JobSummary{{Id: "job-1"}, {Id: "job-2"}}, NextPageToken: "p2"},
+		"p2": {Jobs: []*pb.JobSummary{{Id: "job-3"}, {Id: "job-4"}}, NextPageToken: "p3"},
+		"p3": {Jobs: []*pb.JobSummary{{Id: "job-5"}}},
+	}}
+
+	req := &pb.ListJobsRequest{PageSize: 2, Sort: pb.ListJobsSort_LIST_JOBS_SORT_STATUS}
+	resp, err := listJobs(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, job := range resp.Jobs {
+		ids = append(ids, job.Id)
+	}
+	if want := []string{"job-1", "job-2", "job-3", "job-4", "job-5"}; !slices.Equal(ids, want) {
+		t.Fatalf("expected %v, got %v", want, ids)
+	}
+	if resp.NextPageToken != "" {
+		t.Fatalf("expected no next page token, got %q", resp.NextPageToken)
+	}
+
+	for i, token := range []string{"", "p2", "p3"} {
+		got := client.requests[i]
+		if got.PageToken != token || got.PageSize != 2 || got.Sort != req.Sort {
+			t.Fatalf("request %d: expected token %q with the same size and sort, got %v", i, token, got)
+		}
+	}
+}
+
+func TestListJobs_Error(t *testing.T) {
+	client := &fakePagedListClient{pages: map[string]*pb.ListJobsResponse{
+		"": {Jobs: []*pb.JobSummary{{Id: "job-1"}}, NextPageToken: "expired"},
+	}}
+	if _, err := listJobs(context.Background(), client, &pb.ListJobsRequest{PageSize: 1}); err == nil {
+		t.Fatalf("expected the error of the second page")
+	}
+}
@@ -1,14 +1,55 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+const (
+	logsRetryDelay    = 500 * time.Millisecond
+	logsMaxRetryDelay = 30 * time.Second
+)
+
+var (
+	logsStream   string
+	logsTail     uint32
+	logsNoFollow bool
+	logsOffset   uint64
+	logsLease    string
+	logsRetries  int
+	logsChunk    uint32
+	logsMaxLag   uint64
+	logsCompress bool
+)
+
+// errStreamIdle is returned by streamLogs when the server closed the stream as
+// the job produced no output within its idle timeout.
+var errStreamIdle = errors.New("no output within the server's idle timeout")
+
+// streamClient is the part of the LPaaS client used to stream job output.
+type streamClient interface {
+	StreamOutput(ctx context.Context, in *pb.StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.StreamChunk], error)
+}
+
+// outputStreams maps the --stream flag values to the API output streams.
+var outputStreams = map[string]pb.OutputStream{
+	"both":   pb.OutputStream_OUTPUT_STREAM_BOTH,
+	"stdout": pb.OutputStream_OUTPUT_STREAM_STDOUT,
+	"stderr": pb.OutputStream_OUTPUT_STREAM_STDERR,
+}
+
 var logsCmd = &cobra.Command{
 	Use:   "stream-logs <job-id>",
 	Short: "Stream the output of a running or completed job",
@@ -16,37 +57,144 @@ var logsCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		jobID := args[0]
 
+		sel, ok := outputStreams[logsStream]
+		if !ok {
+			return fmt.Errorf("invalid --stream %q: must be stdout, stderr or both", logsStream)
+		}
+
 		conn, client, err := NewLpaasClient()
 		if err != nil {
 			return err
 		}
 		defer conn.Close()
 
-		stream, err := client.StreamOutput(cmd.Context(), &pb.StreamRequest{Id: jobID})
+		req := &pb.StreamRequest{
+			Id:          jobID,
+			Stream:      sel,
+			TailLines:   logsTail,
+			StartOffset: logsOffset,
+			Follow:      proto.Bool(!logsNoFollow),
+			LeaseToken:  logsLease,
+			ChunkSize:   logsChunk,
+			MaxLagBytes: logsMaxLag,
+		}
+		var opts []grpc.CallOption
+		if logsCompress {
+			// gRPC decompresses each chunk as it is received.
+			opts = append(opts, grpc.UseCompressor(gzip.Name))
+		}
+		fmt.Printf("Streaming logs for job %s...\n", jobID)
+		err = streamLogs(cmd.Context(), client, req, os.Stdout, os.Stderr, logsRetries, logsRetryDelay, opts...)
+		if errors.Is(err, errStreamIdle) {
+			fmt.Printf("\nStream closed: job %s produced no output within the server's idle timeout; run stream-logs again with --offset %d to reconnect.\n", jobID, req.StartOffset)
+			return nil
+		}
 		if err != nil {
-			return fmt.Errorf("stream start error: %w", err)
+			return err
 		}
+		fmt.Println("\nStream ended.")
+		return nil
+	},
+}
 
-		fmt.Printf("Streaming logs for job %s...\n", jobID)
+// streamLogs writes the output of the job streamed for req to stdout and
+// stderr. On a transient error it reconnects at the offset following the last
+// byte received, up to retries times in a row, waiting delay before the first
+// reconnect and twice as long before each further one, up to
+// logsMaxRetryDelay. req is advanced past the output received, so it resumes
+// the stream once streamLogs returned errStreamIdle. opts apply to every
+// call streaming the output.
+func streamLogs(ctx context.Context, client streamClient, req *pb.StreamRequest, stdout, stderr io.Writer, retries int, delay time.Duration, opts ...grpc.CallOption) error {
+	next := req.StartOffset // offset to resume from after the data received so far
+	failures := 0
+	wait := delay
+	for {
+		err := streamLogsOnce(ctx, client, req, stdout, stderr, &next, opts...)
+		if next != req.StartOffset {
+			// Output was received, so only the errors since then count, and
+			// the stream resumes after it rather than at the tail.
+			failures, wait = 0, delay
+			req.StartOffset, req.TailLines = next, 0
+		}
+		if err == nil || errors.Is(err, errStreamIdle) {
+			return err
+		}
+		if !retryableStreamErr(err) || failures >= retries {
+			return fmt.Errorf("stream error (resume with --offset %d): %w", next, err)
+		}
 
-		for {
-			chunk, err := stream.Recv()
-			if err == io.EOF {
-				fmt.Println("\nStream ended.")
-				return nil
-			}
-			if err != nil {
-				return fmt.Errorf("stream recv error: %w", err)
-			}
-
-			_, writeErr := os.Stdout.Write(chunk.Data)
-			if writeErr != nil {
-				return fmt.Errorf("stdout write error: %w", writeErr)
-			}
+		failures++
+		fmt.Fprintf(stderr, "\nStream interrupted: %v; reconnecting at offset %d in %s (attempt %d of %d)\n", err, next, wait, failures, retries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
 		}
-	},
+		wait = min(2*wait, logsMaxRetryDelay)
+	}
+}
+
+// streamLogsOnce streams the output of the job for req, advancing next past
+// each chunk written, until the stream ends or fails.
+func streamLogsOnce(ctx context.Context, client streamClient, req *pb.StreamRequest, stdout, stderr io.Writer, next *uint64, opts ...grpc.CallOption) error {
+	stream, err := client.StreamOutput(ctx, req, opts...)
+	if err != nil {
+		return err
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if status.Code(err) == codes.DeadlineExceeded {
+			return errStreamIdle
+		}
+		if err != nil {
+			return err
+		}
+
+		if chunk.OutputClosed {
+			fmt.Fprintf(stderr, "\nJob %s closed its output but is still running; waiting for it to finish.\n", req.Id)
+			continue
+		}
+
+		if chunk.Discarded {
+			fmt.Fprintf(stderr, "\n[output before offset %d was discarded by the server]\n", chunk.Offset)
+		}
+		if chunk.LaggedBytes > 0 {
+			fmt.Fprintf(stderr, "\n[skipped %d bytes of output before offset %d to catch up with the job]\n", chunk.LaggedBytes, chunk.Offset)
+		}
+		*next = chunk.Offset + uint64(len(chunk.Data))
+
+		out, name := stdout, "stdout"
+		if chunk.Stream == pb.OutputStream_OUTPUT_STREAM_STDERR {
+			out, name = stderr, "stderr"
+		}
+		if _, err := out.Write(chunk.Data); err != nil {
+			return fmt.Errorf("%s write error: %w", name, err)
+		}
+	}
+}
+
+// retryableStreamErr reports whether a stream may succeed when reconnected.
+func retryableStreamErr(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Aborted, codes.ResourceExhausted:
+		return true
+	}
+	return false
 }
 
 func init() {
+	logsCmd.Flags().StringVar(&logsLease, "lease-token", "", "Lease token of the job, to stream a job started with a different certificate")
+	logsCmd.Flags().StringVar(&logsStream, "stream", "both", "Output stream to show: stdout, stderr or both")
+	logsCmd.Flags().Uint32Var(&logsTail, "tail", 0, "Show only the last N lines of output before following (0 shows all)")
+	logsCmd.Flags().Uint64Var(&logsOffset, "offset", 0, "Start at this byte offset of the output, e.g. to resume an interrupted stream")
+	logsCmd.Flags().IntVar(&logsRetries, "retry", 5, "Number of times in a row to reconnect after a transient error, resuming where the stream broke")
+	logsCmd.Flags().Uint32Var(&logsChunk, "chunk-size", 0, "Largest chunk of output the server sends at once, in bytes between 1024 and 1048576, e.g. larger for bulk logs (0 uses the server's default)")
+	logsCmd.Flags().Uint64Var(&logsMaxLag, "max-lag", 0, "Skip output to stay at most this many bytes behind a running job, instead of falling ever further behind a job faster than the connection (0 never skips)")
+	logsCmd.Flags().BoolVar(&logsCompress, "compress", false, "Ask the server to gzip the output, cutting the bandwidth of text logs several times over at some CPU cost on both ends")
+	logsCmd.Flags().BoolVar(&logsNoFollow, "no-follow", false, "Print the output produced so far and exit instead of following it")
 	RootCmd.AddCommand(logsCmd)
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStreamClient serves output in chunks and breaks each stream with err
+// after failAfter chunks, for the first failures streams.
+type fakeStreamClient struct {
+	output    []byte
+	chunkSize int
+	failAfter int
+	failures  int
+	err       error
+	requests  []*pb.StreamRequest // copy of each request
+	opts      [][]grpc.CallOption // call options of each request
+}
+
+func (f *fakeStreamClient) StreamOutput(_ context.Context, in *pb.StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.StreamChunk], error) {
+	f.requests = append(f.requests, &pb.StreamRequest{Id: in.Id, TailLines: in.TailLines, StartOffset: in.StartOffset})
+	f.opts = append(f.opts, opts)
+
+	var chunks []*pb.StreamChunk
+	for off := int(in.StartOffset); off < len(f.output); off += f.chunkSize {
+		end := min(off+f.chunkSize, len(f.output))
+		chunks = append(chunks, &pb.StreamChunk{Data: f.output[off:end], Offset: uint64(off)})
+	}
+
+	s := &fakeStreamChunks{chunks: chunks, failAfter: -1, err: f.err}
+	if len(f.requests) <= f.failures {
+		s.failAfter = f.failAfter
+	}
+	return s, nil
+}
+
+type fakeStreamChunks struct {
+	grpc.ServerStreamingClient[pb.StreamChunk]
+	chunks    []*pb.StreamChunk
+	failAfter int
+	err       error
+	sent      int
+}
+
+func (s *fakeStreamChunks) Recv() (*pb.StreamChunk, error) {
+	if s.sent == s.failAfter {
+		return nil, s.err
+	}
+	if s.sent == len(s.chunks) {
+		return nil, io.EOF
+	}
+	s.sent++
+	return s.chunks[s.sent-1], nil
+}
+
+func TestStreamLogs_ReconnectsAtOffset(t *testing.T) {
+	output := []byte(strings.Repeat("line of output\n", 20))
+	client := &fakeStreamClient{output: output, chunkSize: 64, failAfter: 2, failures: 2, err: status.Error(codes.Unavailable, "connection reset")}
+
+	var stdout, stderr bytes.Buffer
+	req := &pb.StreamRequest{Id: "job-1", TailLines: 5}
+	if err := streamLogs(context.Background(), client, req, &stdout, &stderr, 1, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Each stream delivered output, so each error counts as the first in a row.
+	if len(client.requests) != 3 || client.requests[1].StartOffset != 128 || client.requests[2].StartOffset != 256 {
+		t.Fatalf("expected streams resumed at offsets 128 and 256, got %v", client.requests)
+	}
+	if client.requests[0].TailLines != 5 || client.requests[1].TailLines != 0 {
+		t.Fatalf("expected only the first stream to start at the tail, got %v", client.requests)
+	}
+	if stdout.String() != string(output) {
+		t.Fatalf("expected the output once without gaps, got %q", stdout.String())
+	}
+	if n := strings.Count(stderr.String(), "reconnecting at offset"); n != 2 {
+		t.Fatalf("expected a message for each of the 2 reconnects, got %q", stderr.String())
+	}
+}
+
+func TestStreamLogs_ReconnectsWithCallOptions(t *testing.T) {
+	client := &fakeStreamClient{output: []byte("output\n"), chunkSize: 4, failAfter: 1, failures: 1, err: status.Error(codes.Unavailable, "connection reset")}
+
+	var stdout, stderr bytes.Buffer
+	err := streamLogs(context.Background(), client, &pb.StreamRequest{Id: "job-1"}, &stdout, &stderr, 1, 0, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.opts) != 2 {
+		t.Fatalf("expected 2 streams, got %d", len(client.opts))
+	}
+	for i, opts := range client.opts {
+		if len(opts) != 1 || opts[0].(grpc.CompressorCallOption).CompressorType != gzip.Name {
+			t.Fatalf("expected stream %d to ask for gzip, got %v", i, opts)
+		}
+	}
+}
+
+func TestStreamLogs_GivesUpAfterRetries(t *testing.T) {
+	client := &fakeStreamClient{output: []byte("output\n"), chunkSize: 64, failAfter: 0, failures: 10, err: status.Error(codes.Unavailable, "connection refused")}
+
+	var stdout, stderr bytes.Buffer
+	err := streamLogs(context.Background(), client, &pb.StreamRequest{Id: "job-1"}, &stdout, &stderr, 3, 0)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the stream error after retrying, got %v", err)
+	}
+	if len(client.requests) != 4 {
+		t.Fatalf("expected 1 stream and 3 retries, got %d streams", len(client.requests))
+	}
+}
+
+func TestStreamLogs_DoesNotRetryPermanentErrors(t *testing.T) {
+	client := &fakeStreamClient{output: []byte("output\n"), chunkSize: 64, failAfter: 0, failures: 1, err: status.Error(codes.NotFound, "job not found")}
+
+	var stdout, stderr bytes.Buffer
+	err := streamLogs(context.Background(), client, &pb.StreamRequest{Id: "job-1"}, &stdout, &stderr, 3, 0)
+	if status.Code(err) != codes.NotFound || len(client.requests) != 1 {
+		t.Fatalf("expected NotFound without retrying, got %v after %d streams", err, len(client.requests))
+	}
+}
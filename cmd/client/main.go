@@ -19,20 +19,29 @@ func main() {
 
 // NewLpaasClient establishes an mTLS gRPC connection using global flags.
 func NewLpaasClient() (*grpc.ClientConn, pb.LpaasClient, error) {
+	conn, err := dialServer()
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, pb.NewLpaasClient(conn), nil
+}
+
+// dialServer establishes an mTLS gRPC connection to the worker using global flags.
+func dialServer() (*grpc.ClientConn, error) {
 	// Load client certificate
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed loading client cert/key: %w", err)
+		return nil, fmt.Errorf("failed loading client cert/key: %w", err)
 	}
 
 	// Load CA
 	caPEM, err := os.ReadFile(caFile)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed reading CA file: %w", err)
+		return nil, fmt.Errorf("failed reading CA file: %w", err)
 	}
 	certPool := x509.NewCertPool()
 	if !certPool.AppendCertsFromPEM(caPEM) {
-		return nil, nil, errors.New("failed adding CA certificate to pool")
+		return nil, errors.New("failed adding CA certificate to pool")
 	}
 
 	serverName := "localhost"
@@ -53,8 +62,8 @@ func NewLpaasClient() (*grpc.ClientConn, pb.LpaasClient, error) {
 		grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)),
 	)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to connect to %s: %w", serverAddr, err)
+		return nil, fmt.Errorf("failed to connect to %s: %w", serverAddr, err)
 	}
 
-	return conn, pb.NewLpaasClient(conn), nil
+	return conn, nil
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeJSON writes m to w as indented JSON for --output json. All fields are
+// included, zero-valued or not, so scripts can rely on them being present;
+// fields the server left unset, such as the exit code of a running job, are
+// omitted.
+func writeJSON(w io.Writer, m proto.Message) error {
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "  ", EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+// renderOwnerStats writes a human readable breakdown of an owner's job totals to w.
+func renderOwnerStats(w io.Writer, resp *pb.OwnerStatsResponse) {
+	fmt.Fprintf(w, "Owner %s:\n", resp.Owner)
+	fmt.Fprintf(w, "  Started: %d\n", resp.JobsStarted)
+	fmt.Fprintf(w, "  Running: %d\n", resp.JobsRunning)
+	fmt.Fprintf(w, "  Failed: %d\n", resp.JobsFailed)
+	fmt.Fprintf(w, "  CPU: %s\n", resp.CpuUsage.AsDuration().Round(time.Millisecond))
+}
+
+var ownerStatsCmd = &cobra.Command{
+	Use:   "owner-stats <owner>",
+	Short: "Get the totals of all jobs an owner has run",
+	Long: "Print how many jobs an owner has started, how many are running and have failed,\n" +
+		"and the CPU time of their finished jobs, including removed jobs. Requires a\n" +
+		"certificate with the admin organizational unit.",
+	Args: cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		resp, err := client.GetOwnerStats(cmd.Context(), &pb.OwnerStatsRequest{Owner: args[0]})
+		if err != nil {
+			return fmt.Errorf("failed to get owner stats: %w", err)
+		}
+
+		if outputFmt == outputJSON {
+			return writeJSON(os.Stdout, resp)
+		}
+		renderOwnerStats(os.Stdout, resp)
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(ownerStatsCmd)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <job-id>",
+	Short: "Remove a finished job and its output from the LPaaS worker",
+	Args:  cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		_, err = client.RemoveJob(cmd.Context(), &pb.JobRequest{Id: jobID})
+		if err != nil {
+			return fmt.Errorf("failed to remove job: %w", err)
+		}
+
+		fmt.Printf("Job %s removed\n", jobID)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(removeCmd)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+var replayEnv []string
+
+// replayClient is the part of the LPaaS client used to replay a job.
+type replayClient interface {
+	GetStatus(ctx context.Context, in *pb.JobRequest, opts ...grpc.CallOption) (*pb.StatusJobResponse, error)
+	StartJob(ctx context.Context, in *pb.StartJobRequest, opts ...grpc.CallOption) (*pb.StartJobResponse, error)
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <job-id>",
+	Short: "Start a new job with the same invocation as an earlier job",
+	Long: "Start a new job with the command, arguments, environment and limits the worker\n" +
+		"recorded for an earlier job. Environment variables that may hold secrets are not\n" +
+		"recorded; pass them again with --env.",
+	Args: cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		id, missing, err := replayJob(cmd.Context(), client, args[0], replayEnv)
+		if err != nil {
+			return err
+		}
+
+		if len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: started without redacted environment variables %s; pass them with --env\n", strings.Join(missing, ", "))
+		}
+		fmt.Printf("Job started with ID: %s\n", id)
+		return nil
+	},
+}
+
+// replayJob starts a new job from the recorded invocation of jobID, with env
+// added to its environment. It returns the new job's ID and the keys of the
+// redacted environment variables env did not supply.
+func replayJob(ctx context.Context, client replayClient, jobID string, env []string) (string, []string, error) {
+	st, err := client.GetStatus(ctx, &pb.JobRequest{Id: jobID})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if st.Invocation == nil {
+		return "", nil, fmt.Errorf("the worker did not record the invocation of job %s", jobID)
+	}
+	if st.Invocation.ArgsRedacted {
+		return "", nil, fmt.Errorf("the worker redacts job arguments, so job %s cannot be replayed", jobID)
+	}
+
+	req := startRequestFromInvocation(st.Invocation)
+	req.Env = append(req.Env, env...)
+	req.Labels = st.Labels
+
+	var missing []string
+	for _, key := range st.Invocation.RedactedEnvKeys {
+		supplied := slices.ContainsFunc(env, func(kv string) bool { return strings.HasPrefix(kv, key+"=") })
+		if !supplied && !slices.Contains(missing, key) {
+			missing = append(missing, key)
+		}
+	}
+
+	resp, err := client.StartJob(ctx, req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start job: %w", err)
+	}
+	return resp.Id, missing, nil
+}
+
+// startRequestFromInvocation returns a request starting a job like inv.
+func startRequestFromInvocation(inv *pb.JobInvocation) *pb.StartJobRequest {
+	return &pb.StartJobRequest{
+		Command:          inv.Command,
+		Args:             inv.Args,
+		Argv0:            inv.Argv0,
+		Env:              slices.Clone(inv.Env),
+		WorkingDir:       inv.WorkingDir,
+		NofileLimit:      inv.NofileLimit,
+		Nice:             inv.Nice,
+		ConfirmRunning:   inv.ConfirmRunning,
+		KillOnDisconnect: inv.KillOnDisconnect,
+		BindMounts:       inv.BindMounts,
+		Resources:        inv.Resources,
+		Timeout:          inv.Timeout,
+		MaxOutputBytes:   inv.MaxOutputBytes,
+		PrivateTmp:       inv.PrivateTmp,
+		WorkInPrivateTmp: inv.WorkInPrivateTmp,
+		Uid:              inv.Uid,
+		Gid:              inv.Gid,
+		Groups:           inv.Groups,
+		Namespaces:       inv.Namespaces,
+	}
+}
+
+func init() {
+	replayCmd.Flags().StringArrayVarP(&replayEnv, "env", "e", nil, "Environment variable KEY=VALUE added to the replayed job, e.g. a redacted secret (repeatable)")
+	RootCmd.AddCommand(replayCmd)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// fakeReplayClient reports a finished job and records the jobs started.
+type fakeReplayClient struct {
+	status  *pb.StatusJobResponse
+	started []*pb.StartJobRequest
+}
+
+func (f *fakeReplayClient) GetStatus(_ context.Context, _ *pb.JobRequest, _ ...grpc.CallOption) (*pb.StatusJobResponse, error) {
+	return f.status, nil
+}
+
+func (f *fakeReplayClient) StartJob(_ context.Context, in *pb.StartJobRequest, _ ...grpc.CallOption) (*pb.StartJobResponse, error) {
+	f.started = append(f.started, in)
+	return &pb.StartJobResponse{Id: "job-2"}, nil
+}
+
+func TestReplayJob_ReproducesInvocation(t *testing.T) {
+	inv := &pb.JobInvocation{
+		Command:         "bash",
+		Args:            []string{"-c", "make test"},
+		Env:             []string{"GOFLAGS=-race"},
+		RedactedEnvKeys: []string{"API_TOKEN", "DB_PASSWORD"},
+		WorkingDir:      "/src",
+		NofileLimit:     1024,
+		Nice:            10,
+		Resources:       &pb.ResourceProfile{CpuPercent: 50, MemoryBytes: 256 << 20, IoBytesPerSec: 10 << 20},
+		Timeout:         durationpb.New(90 * time.Second),
+		Uid:             proto.Uint32(1000),
+		Gid:             proto.Uint32(1000),
+		Namespaces:      &pb.Namespaces{Pid: true, Network: true},
+	}
+	client := &fakeReplayClient{status: &pb.StatusJobResponse{Id: "job-1", Status: "Exited", Invocation: inv}}
+
+	id, missing, err := replayJob(context.Background(), client, "job-1", []string{"API_TOKEN=t0k3n"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "job-2" || len(client.started) != 1 {
+		t.Fatalf("expected a single replayed job job-2, got %q after %d starts", id, len(client.started))
+	}
+	if len(missing) != 1 || missing[0] != "DB_PASSWORD" {
+		t.Fatalf("expected DB_PASSWORD to be reported missing, got %v", missing)
+	}
+
+	want := &pb.StartJobRequest{
+		Command:     "bash",
+		Args:        []string{"-c", "make test"},
+		Env:         []string{"GOFLAGS=-race", "API_TOKEN=t0k3n"},
+		WorkingDir:  "/src",
+		NofileLimit: 1024,
+		Nice:        10,
+		Resources:   &pb.ResourceProfile{CpuPercent: 50, MemoryBytes: 256 << 20, IoBytesPerSec: 10 << 20},
+		Timeout:     durationpb.New(90 * time.Second),
+		Uid:         proto.Uint32(1000),
+		Gid:         proto.Uint32(1000),
+		Namespaces:  &pb.Namespaces{Pid: true, Network: true},
+	}
+	if got := client.started[0]; !proto.Equal(got, want) {
+		t.Fatalf("expected replay request %v, got %v", want, got)
+	}
+}
+
+func TestReplayJob_RedactedArgs(t *testing.T) {
+	inv := &pb.JobInvocation{Command: "deploy", ArgsRedacted: true}
+	client := &fakeReplayClient{status: &pb.StatusJobResponse{Id: "job-1", Status: "Exited", Invocation: inv}}
+
+	if _, _, err := replayJob(context.Background(), client, "job-1", nil); err == nil {
+		t.Fatalf("expected an error replaying a job without its args")
+	}
+	if len(client.started) != 0 {
+		t.Fatalf("expected no job started, got %d", len(client.started))
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+var restartLease bool
+
+var restartCmd = &cobra.Command{
+	Use:   "restart <job-id>",
+	Short: "Start a finished job again with the same parameters",
+	Long: "Start a new job with the command, arguments, environment, limits and labels of a\n" +
+		"finished job. Unlike replay, the worker uses the parameters it kept, so\n" +
+		"environment variables holding secrets need not be passed again. The finished job\n" +
+		"is kept.",
+	Args: cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		resp, err := client.RestartJob(cmd.Context(), &pb.RestartJobRequest{Id: args[0], Lease: restartLease})
+		if err != nil {
+			return fmt.Errorf("failed to restart job: %w", err)
+		}
+
+		fmt.Printf("Job started with ID: %s\n", resp.Id)
+		if resp.LeaseToken != "" {
+			fmt.Printf("Lease token: %s\n", resp.LeaseToken)
+		}
+		return nil
+	},
+}
+
+func init() {
+	restartCmd.Flags().BoolVar(&restartLease, "lease", false, "Print a lease token granting access to the new job with any certificate (see --lease-token of stop, status and stream-logs)")
+	RootCmd.AddCommand(restartCmd)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -14,29 +15,62 @@ var (
 	caFile     string
 	certFile   string
 	keyFile    string
+	outputFmt  string
+)
+
+// Output formats of the status, list and owner-stats commands.
+const (
+	outputText = "text"
+	outputJSON = "json"
 )
 
 var RootCmd = &cobra.Command{
-	Use:           "lpaas",
-	Short:         "LPaaS CLI — Linux job processes via gRPC",
-	Long:          "A CLI client to start, stop, and manage Linux processes via the LPaaS gRPC worker service.",
+	Use:   "lpaas",
+	Short: "LPaaS CLI — Linux job processes via gRPC",
+	Long: "A CLI client to start, stop, and manage Linux processes via the LPaaS gRPC worker service.\n\n" +
+		"Exit status: run, wait and status exit with the exit code of the job once it has\n" +
+		"finished, or 128 plus the signal number if a signal terminated it, like a shell.\n" +
+		"Jobs that ended without either, e.g. failing to start, exit with 1. Errors of the\n" +
+		"CLI itself also exit with 1 and print a message to stderr.",
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: true,
 	},
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		switch outputFmt {
+		case outputText, outputJSON:
+			return nil
+		}
+		return fmt.Errorf("invalid --output %q: must be %s or %s", outputFmt, outputText, outputJSON)
+	},
 }
 
 func Execute() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	if err := RootCmd.ExecuteContext(ctx); err != nil {
+	err := RootCmd.ExecuteContext(ctx)
+	var exit exitCodeError
+	if errors.As(err, &exit) {
+		os.Exit(exit.code)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// exitCodeError makes the CLI exit with code without printing an error, e.g.
+// to pass on the exit code of a job.
+type exitCodeError struct {
+	code int
+}
+
+func (e exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", e.code)
+}
+
 func init() {
 	flags := RootCmd.PersistentFlags()
 
@@ -44,4 +78,5 @@ func init() {
 	flags.StringVar(&caFile, "ca", "certs/ca.crt", "CA certificate")
 	flags.StringVar(&certFile, "cert", "certs/client.crt", "Client certificate")
 	flags.StringVar(&keyFile, "key", "certs/client.key", "Client private key")
+	flags.StringVarP(&outputFmt, "output", "o", outputText, "Output format of status, list and owner-stats: text or json")
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// interruptedExitCode is the exit code of run after Ctrl-C stopped the job,
+// following the shell convention for SIGINT.
+const interruptedExitCode = 130
+
+var runRetries int
+
+// runClient is the part of the LPaaS client used to run a job.
+type runClient interface {
+	streamClient
+	waitClient
+	StartJob(ctx context.Context, in *pb.StartJobRequest, opts ...grpc.CallOption) (*pb.StartJobResponse, error)
+	StopJob(ctx context.Context, in *pb.StopJobRequest, opts ...grpc.CallOption) (*pb.StopJobResponse, error)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [--] <command> [args...]",
+	Short: "Start a job, stream its output and exit with its exit code",
+	Long: "Start a job, stream its output until it finishes, print its final status and\n" +
+		"exit with the job's exit code, like wait. Ctrl-C stops the job before exiting.",
+	Args: cobra.MinimumNArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req, err := newStartRequest(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		code, err := runJob(cmd.Context(), client, req, os.Stdout, os.Stderr, runRetries)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return exitCodeError{code: code}
+		}
+		return nil
+	},
+}
+
+// runJob starts the job for req, writes its output to stdout and stderr until
+// it finishes and returns the exit code of the CLI for it, as wait does. Once
+// ctx is done, e.g. on Ctrl-C, the job is stopped and interruptedExitCode is
+// returned. Status messages go to stderr, so stdout carries only the job's
+// output.
+func runJob(ctx context.Context, client runClient, req *pb.StartJobRequest, stdout, stderr io.Writer, retries int) (int, error) {
+	resp, err := client.StartJob(ctx, req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start job: %w", err)
+	}
+	jobID := resp.Id
+	fmt.Fprintf(stderr, "Job started with ID: %s\n", jobID)
+	if resp.LeaseToken != "" {
+		fmt.Fprintf(stderr, "Lease token: %s\n", resp.LeaseToken)
+	}
+
+	streamReq := &pb.StreamRequest{Id: jobID, LeaseToken: resp.LeaseToken}
+	for {
+		err = streamLogs(ctx, client, streamReq, stdout, stderr, retries, logsRetryDelay)
+		// A quiet job is still running; keep following it.
+		if !errors.Is(err, errStreamIdle) {
+			break
+		}
+	}
+
+	var result *pb.WaitJobResponse
+	if err == nil {
+		result, err = waitJob(ctx, client, jobID)
+	}
+	if ctx.Err() != nil {
+		return stopInterruptedJob(ctx, client, jobID, resp.LeaseToken, stderr)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	renderWaitResult(stderr, result)
+	return waitExitCode(result), nil
+}
+
+// stopInterruptedJob stops jobID after ctx was canceled and returns
+// interruptedExitCode.
+func stopInterruptedJob(ctx context.Context, client runClient, jobID, leaseToken string, stderr io.Writer) (int, error) {
+	fmt.Fprintf(stderr, "\nInterrupted, stopping job %s...\n", jobID)
+	if _, err := client.StopJob(context.WithoutCancel(ctx), &pb.StopJobRequest{Id: jobID, LeaseToken: leaseToken}); err != nil {
+		return 0, fmt.Errorf("failed to stop job %s: %w", jobID, err)
+	}
+	fmt.Fprintf(stderr, "Job %s stopped\n", jobID)
+	return interruptedExitCode, nil
+}
+
+func init() {
+	addStartFlags(runCmd)
+	runCmd.Flags().IntVar(&runRetries, "retry", 5, "Number of times in a row to reconnect the output stream after a transient error")
+	RootCmd.AddCommand(runCmd)
+}
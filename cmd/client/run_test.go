@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeRunClient starts job-1, streams output as fakeStreamClient, waits as
+// fakeWaitClient and records the jobs stopped.
+type fakeRunClient struct {
+	fakeStreamClient
+	fakeWaitClient
+	stopped []string
+}
+
+func (f *fakeRunClient) StartJob(context.Context, *pb.StartJobRequest, ...grpc.CallOption) (*pb.StartJobResponse, error) {
+	return &pb.StartJobResponse{Id: "job-1"}, nil
+}
+
+func (f *fakeRunClient) StopJob(_ context.Context, in *pb.StopJobRequest, _ ...grpc.CallOption) (*pb.StopJobResponse, error) {
+	f.stopped = append(f.stopped, in.Id)
+	return &pb.StopJobResponse{}, nil
+}
+
+func TestRunJob_StreamsAndReturnsExitCode(t *testing.T) {
+	client := &fakeRunClient{
+		fakeStreamClient: fakeStreamClient{output: []byte("hello\n"), chunkSize: 64, failAfter: -1},
+		fakeWaitClient: fakeWaitClient{responses: []*pb.WaitJobResponse{
+			{Id: "job-1", Finished: true, Status: "Failed", ExitCode: proto.Int32(3)},
+		}},
+	}
+
+	var stdout, stderr bytes.Buffer
+	code, err := runJob(context.Background(), client, &pb.StartJobRequest{Command: "make"}, &stdout, &stderr, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 3 {
+		t.Fatalf("expected the job's exit code 3, got %d", code)
+	}
+	if stdout.String() != "hello\n" {
+		t.Fatalf("expected only the job's output on stdout, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Job started with ID: job-1") || !strings.Contains(stderr.String(), "Job job-1: Failed (exit code 3)") {
+		t.Fatalf("expected the job ID and final status on stderr, got %q", stderr.String())
+	}
+	if len(client.stopped) != 0 {
+		t.Fatalf("expected no stop, got %v", client.stopped)
+	}
+}
+
+func TestRunJob_InterruptStopsJob(t *testing.T) {
+	client := &fakeRunClient{
+		fakeStreamClient: fakeStreamClient{output: []byte("hello\n"), chunkSize: 64, failAfter: 0, failures: 1, err: status.Error(codes.Canceled, "context canceled")},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var stdout, stderr bytes.Buffer
+	code, err := runJob(ctx, client, &pb.StartJobRequest{Command: "sleep"}, &stdout, &stderr, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != interruptedExitCode {
+		t.Fatalf("expected exit code %d, got %d", interruptedExitCode, code)
+	}
+	if len(client.stopped) != 1 || client.stopped[0] != "job-1" {
+		t.Fatalf("expected job-1 to be stopped, got %v", client.stopped)
+	}
+}
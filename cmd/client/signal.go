@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+var signalCmd = &cobra.Command{
+	Use:   "signal <job-id> <signal>",
+	Short: "Send a signal (e.g. HUP, USR1 or 10) to a running job",
+	Args:  cobra.ExactArgs(2),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID, sig := args[0], args[1]
+
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		_, err = client.SendSignal(cmd.Context(), &pb.SendSignalRequest{Id: jobID, Signal: sig})
+		if err != nil {
+			return fmt.Errorf("failed to signal job: %w", err)
+		}
+
+		fmt.Printf("Signal %s sent to job %s\n", sig, jobID)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(signalCmd)
+}
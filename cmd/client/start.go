@@ -2,9 +2,40 @@ package main
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var (
+	startNofile           uint64
+	startNice             int32
+	startEnv              []string
+	startDir              string
+	startPrivateTmp       bool
+	startWorkInTmp        bool
+	startKillOnDisconnect bool
+	startBinds            []string
+	startResources        pb.ResourceProfile
+	startTimeout          time.Duration
+	startMaxOutputBytes   uint64
+	startIdempotencyKey   string
+	startArgv0            string
+	startQueue            bool
+	startLease            bool
+	startLabels           []string
+	startStdin            bool
+	startUID              uint32
+	startGID              uint32
+	startGroups           []uint
+	startIsolate          []string
+	startKeepOutputBytes  uint64
+	startKeepOutputFor    time.Duration
 )
 
 var startCmd = &cobra.Command{
@@ -12,25 +43,189 @@ var startCmd = &cobra.Command{
 	Short: "Start a new job on the LPaaS worker",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		req, err := newStartRequest(cmd, args)
+		if err != nil {
+			return err
+		}
+
 		conn, client, err := NewLpaasClient()
 		if err != nil {
 			return err
 		}
 		defer conn.Close()
 
-		resp, err := client.StartJob(cmd.Context(), &pb.StartJobRequest{
-			Command: args[0],
-			Args:    args[1:],
-		})
+		resp, err := client.StartJob(cmd.Context(), req)
 		if err != nil {
 			return fmt.Errorf("failed to start job: %w", err)
 		}
 
 		fmt.Printf("Job started with ID: %s\n", resp.Id)
+		if resp.LeaseToken != "" {
+			fmt.Printf("Lease token: %s\n", resp.LeaseToken)
+		}
 		return nil
 	},
 }
 
+// newStartRequest returns the request starting args[0] with args[1:], as set
+// up by the start flags of cmd.
+func newStartRequest(cmd *cobra.Command, args []string) (*pb.StartJobRequest, error) {
+	binds, err := parseBindMounts(startBinds)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := parseLabels(startLabels)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := parseNamespaces(startIsolate)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pb.StartJobRequest{
+		Command:          args[0],
+		Args:             args[1:],
+		Argv0:            startArgv0,
+		Env:              startEnv,
+		WorkingDir:       startDir,
+		PrivateTmp:       startPrivateTmp || startWorkInTmp,
+		WorkInPrivateTmp: startWorkInTmp,
+		KillOnDisconnect: startKillOnDisconnect,
+		NofileLimit:      startNofile,
+		Nice:             startNice,
+		MaxOutputBytes:   startMaxOutputBytes,
+		BindMounts:       binds,
+		Resources:        &startResources,
+		IdempotencyKey:   startIdempotencyKey,
+		Queue:            startQueue,
+		Lease:            startLease,
+		Labels:           labels,
+		Stdin:            startStdin,
+		Namespaces:       namespaces,
+	}
+	if startTimeout > 0 {
+		req.Timeout = durationpb.New(startTimeout)
+	}
+	if cmd.Flags().Changed("uid") {
+		req.Uid = &startUID
+	}
+	if cmd.Flags().Changed("gid") {
+		req.Gid = &startGID
+	}
+	for _, g := range startGroups {
+		req.Groups = append(req.Groups, uint32(g))
+	}
+	if startKeepOutputBytes > 0 || startKeepOutputFor > 0 {
+		req.OutputRetention = &pb.OutputRetention{MaxBytes: startKeepOutputBytes}
+		if startKeepOutputFor > 0 {
+			req.OutputRetention.MaxAge = durationpb.New(startKeepOutputFor)
+		}
+	}
+	return req, nil
+}
+
+// parseBindMounts parses --bind values of the form SRC[:DST]. DST defaults to SRC.
+func parseBindMounts(values []string) ([]*pb.BindMount, error) {
+	var mounts []*pb.BindMount
+	for _, v := range values {
+		src, dst, ok := strings.Cut(v, ":")
+		if !ok {
+			dst = src
+		}
+		if src == "" || dst == "" {
+			return nil, fmt.Errorf("invalid --bind %q: expected SRC[:DST]", v)
+		}
+		mounts = append(mounts, &pb.BindMount{Source: src, Target: dst})
+	}
+	return mounts, nil
+}
+
+// parseNamespaces parses --isolate values naming the namespaces to run the job
+// in: pid, mount or network.
+func parseNamespaces(values []string) (*pb.Namespaces, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	ns := &pb.Namespaces{}
+	for _, v := range values {
+		switch v {
+		case "pid":
+			ns.Pid = true
+		case "mount":
+			ns.Mount = true
+		case "network":
+			ns.Network = true
+		default:
+			return nil, fmt.Errorf("invalid --isolate %q: expected pid, mount or network", v)
+		}
+	}
+	return ns, nil
+}
+
+// parseLabels parses --label values of the form KEY=VALUE.
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --label %q: expected KEY=VALUE", v)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// formatLabels renders labels as comma-separated KEY=VALUE pairs sorted by key.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// addStartFlags adds the flags setting up the job to start to cmd.
+func addStartFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringArrayVarP(&startLabels, "label", "l", nil, "Label KEY=VALUE tagging the job, e.g. pipeline=build (repeatable)")
+	flags.BoolVar(&startLease, "lease", false, "Print a lease token granting access to the job with any certificate (see --lease-token of stop, status and stream-logs)")
+	flags.Uint32Var(&startUID, "uid", 0, "User ID to run the job as, with --gid (requires a privileged worker)")
+	flags.Uint32Var(&startGID, "gid", 0, "Group ID to run the job as, with --uid")
+	flags.UintSliceVar(&startGroups, "groups", nil, "Comma-separated supplementary group IDs of the job, with --uid and --gid")
+	flags.BoolVar(&startStdin, "stdin", false, "Connect the job's stdin to a pipe fed with write-stdin, instead of /dev/null")
+	flags.BoolVar(&startQueue, "queue", false, "Queue the job until it can run if the worker's running jobs limits are reached")
+	flags.StringVar(&startArgv0, "argv0", "", "Program name passed to the command as argv[0] (defaults to the command)")
+	flags.StringVar(&startIdempotencyKey, "idempotency-key", "", "Key making retries safe: reusing it returns the job started with it")
+	flags.DurationVar(&startTimeout, "timeout", 0, "Kill the job if it runs longer than this (0 means no timeout)")
+	flags.Uint64Var(&startMaxOutputBytes, "max-output-bytes", 0, "Kill the job once it has written more than this many bytes of output in total (0 means no limit)")
+	flags.Uint64Var(&startResources.CpuPercent, "cpu", 0, "Hard CPU limit in percent of one CPU (0 uses the server default, or no limit with --cpu-weight)")
+	flags.Uint64Var(&startResources.CpuWeight, "cpu-weight", 0, "Share of contended CPU relative to other jobs, from 1 to 10000 (default 100); idle CPU stays usable")
+	flags.Uint64Var(&startResources.MemoryBytes, "memory", 0, "Memory limit in bytes (0 uses the server default)")
+	flags.Uint64Var(&startResources.IoBytesPerSec, "io-bps", 0, "Disk read and write limit in bytes per second (0 uses the server default)")
+	flags.Uint64Var(&startResources.IoReadBytesPerSec, "io-read-bps", 0, "Disk read limit in bytes per second, overriding --io-bps")
+	flags.Uint64Var(&startResources.IoWriteBytesPerSec, "io-write-bps", 0, "Disk write limit in bytes per second, overriding --io-bps")
+	flags.Uint64Var(&startResources.IoReadIops, "io-read-iops", 0, "Disk read operations per second (0 uses the server default, unlimited unless set)")
+	flags.Uint64Var(&startResources.IoWriteIops, "io-write-iops", 0, "Disk write operations per second (0 uses the server default, unlimited unless set)")
+	flags.StringSliceVar(&startResources.IoDevices, "io-device", nil, "Block device to apply the disk limits to, as major:minor (repeatable; default the disk backing /)")
+	flags.Uint64Var(&startResources.MaxPids, "max-pids", 0, "Maximum number of processes and threads (0 uses the server default)")
+	flags.StringSliceVar(&startIsolate, "isolate", nil, "Comma-separated namespaces to run the job in: pid, mount, network (requires a root worker)")
+	flags.StringArrayVar(&startBinds, "bind", nil, "Host path SRC[:DST] to expose read-only inside the job (repeatable, requires a root worker)")
+	flags.BoolVar(&startKillOnDisconnect, "kill-on-disconnect", false, "Stop the job once its last log streaming client disconnects")
+	flags.StringVar(&startDir, "dir", "", "Absolute path of the directory to run the job in")
+	flags.BoolVar(&startPrivateTmp, "private-tmp", false, "Give the job a private TMPDIR, removed along with the job")
+	flags.BoolVar(&startWorkInTmp, "work-in-tmp", false, "Run the job in its private TMPDIR (implies --private-tmp)")
+	flags.StringArrayVarP(&startEnv, "env", "e", nil, "Environment variable KEY=VALUE for the job (repeatable)")
+	flags.Uint64Var(&startNofile, "nofile", 0, "Maximum number of open files for the job (0 keeps the worker's limit)")
+	flags.Int32Var(&startNice, "nice", 0, "Scheduling niceness of the job, from -20 (highest priority) to 19 (lowest); negative values require an admin certificate (0 keeps the worker's niceness)")
+	flags.Uint64Var(&startKeepOutputBytes, "keep-output-bytes", 0, "Keep at most the last this many bytes of the job's output on the worker (0 keeps as much as the worker does)")
+	flags.DurationVar(&startKeepOutputFor, "keep-output-for", 0, "Discard the job's output on the worker once it is older than this, e.g. 10m (0 keeps output regardless of age)")
+}
+
 func init() {
+	addStartFlags(startCmd)
 	RootCmd.AddCommand(startCmd)
 }
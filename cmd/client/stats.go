@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+// renderStats writes a human readable breakdown of a job's resource usage to w.
+func renderStats(w io.Writer, resp *pb.StatsResponse) {
+	fmt.Fprintf(w, "Job %s:\n", resp.Id)
+	fmt.Fprintf(w, "  CPU: %s (user %s, system %s)\n",
+		resp.CpuUsage.AsDuration().Round(time.Millisecond),
+		resp.UserCpu.AsDuration().Round(time.Millisecond),
+		resp.SystemCpu.AsDuration().Round(time.Millisecond))
+	fmt.Fprintf(w, "  Memory: %s\n", formatBytes(resp.MemoryCurrentBytes))
+	if resp.MemoryPeakBytes > 0 {
+		fmt.Fprintf(w, "  PeakMemory: %s\n", formatBytes(resp.MemoryPeakBytes))
+	}
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats <job-id>",
+	Short: "Get the CPU and memory usage of a job",
+	Args:  cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		resp, err := client.GetStats(cmd.Context(), &pb.JobRequest{Id: jobID})
+		if err != nil {
+			return fmt.Errorf("failed to get stats: %w", err)
+		}
+
+		renderStats(os.Stdout, resp)
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(statsCmd)
+}
@@ -2,15 +2,230 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
 
 	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
 	"github.com/spf13/cobra"
 )
 
+// Exit reasons describe why a job reached a terminal state.
+const (
+	reasonExited   = "exited"
+	reasonSignaled = "signaled"
+	reasonOOM      = "oom"
+	reasonTimeout  = "timeout"
+	reasonStopped  = "stopped"
+	reasonOutput   = "output-limit"
+)
+
+// statusView holds the status fields rendered by the status command.
+// Zero-valued fields are omitted from the output.
+type statusView struct {
+	ID          string
+	Status      string
+	Command     string // command line of the job, shell-quoted
+	Path        string
+	Reason      string
+	ExitCode    *int32
+	Signal      string
+	StartedAt   time.Time
+	Duration    time.Duration
+	OutputBytes *uint64
+	PeakMemory  *uint64
+	Streams     uint32
+	Error       string // why the job failed
+	CleanupErr  string // why the worker failed to clean up after the job
+	Warnings    []string
+	Labels      map[string]string
+}
+
+// newStatusView builds a statusView from a GetStatus response.
+func newStatusView(resp *pb.StatusJobResponse) statusView {
+	v := statusView{
+		ID:          resp.Id,
+		Status:      resp.Status,
+		Command:     formatCommandLine(resp.Command, resp.Args, resp.ArgsRedacted),
+		Path:        resp.CommandPath,
+		ExitCode:    resp.ExitCode,
+		OutputBytes: resp.OutputBytes,
+		PeakMemory:  resp.PeakMemoryBytes,
+		Streams:     resp.ActiveStreams,
+		Signal:      resp.GetSignal(),
+		Warnings:    resp.LimitWarnings,
+		Labels:      resp.Labels,
+	}
+	v.Error, v.CleanupErr = jobErrors(resp.Error, resp.ExitError, resp.CleanupError)
+
+	if resp.StartedAt != nil {
+		v.StartedAt = resp.StartedAt.AsTime()
+		if resp.FinishedAt != nil {
+			v.Duration = resp.FinishedAt.AsTime().Sub(v.StartedAt)
+		} else {
+			v.Duration = time.Since(v.StartedAt)
+		}
+	}
+
+	switch resp.Status {
+	case "Exited", "Failed":
+		v.Reason = reasonExited
+		if v.Signal != "" {
+			v.Reason = reasonSignaled
+		}
+	case "Stopped":
+		v.Reason = reasonStopped
+	case "TimedOut":
+		v.Reason = reasonTimeout
+	case "OOMKilled":
+		v.Reason = reasonOOM
+	case "OutputLimitExceeded":
+		v.Reason = reasonOutput
+	}
+
+	return v
+}
+
+// renderStatus writes a human readable breakdown of a job status to w.
+func renderStatus(w io.Writer, v statusView) {
+	fmt.Fprintf(w, "Job %s:\n", v.ID)
+	fmt.Fprintf(w, "  Status: %s\n", v.Status)
+
+	if v.Command != "" {
+		fmt.Fprintf(w, "  Command: %s\n", v.Command)
+	}
+
+	if v.Path != "" {
+		fmt.Fprintf(w, "  Path: %s\n", v.Path)
+	}
+
+	if len(v.Labels) > 0 {
+		fmt.Fprintf(w, "  Labels: %s\n", formatLabels(v.Labels))
+	}
+
+	if v.Reason != "" {
+		fmt.Fprintf(w, "  Reason: %s\n", v.Reason)
+	}
+
+	if v.Signal != "" {
+		fmt.Fprintf(w, "  Signal: %s\n", v.Signal)
+	} else if v.ExitCode != nil {
+		fmt.Fprintf(w, "  ExitCode: %d\n", *v.ExitCode)
+	}
+
+	if !v.StartedAt.IsZero() {
+		fmt.Fprintf(w, "  Started: %s\n", v.StartedAt.Local().Format(time.RFC3339))
+	}
+
+	// Running jobs report how long they have been running so far.
+	if v.Duration > 0 && v.Status == "Running" {
+		fmt.Fprintf(w, "  Elapsed: %s\n", v.Duration.Round(time.Millisecond))
+	} else if v.Duration > 0 {
+		fmt.Fprintf(w, "  Duration: %s\n", v.Duration.Round(time.Millisecond))
+	}
+
+	if v.OutputBytes != nil && v.Status == "Running" {
+		fmt.Fprintf(w, "  Output: %s so far\n", formatBytes(*v.OutputBytes))
+	} else if v.OutputBytes != nil {
+		fmt.Fprintf(w, "  Output: %s\n", formatBytes(*v.OutputBytes))
+	}
+
+	if v.PeakMemory != nil {
+		fmt.Fprintf(w, "  PeakMemory: %s\n", formatBytes(*v.PeakMemory))
+	}
+
+	if v.Streams > 0 {
+		fmt.Fprintf(w, "  Streams: %d\n", v.Streams)
+	}
+
+	if v.Error != "" {
+		fmt.Fprintf(w, "  Error: %s\n", v.Error)
+	}
+	if v.CleanupErr != "" {
+		fmt.Fprintf(w, "  CleanupError: %s\n", v.CleanupErr)
+	}
+
+	for _, warning := range v.Warnings {
+		fmt.Fprintf(w, "  Warning: %s\n", warning)
+	}
+}
+
+// formatCommandLine renders command and args as a shell command line, quoting
+// words as needed, e.g. `bash -c 'echo hi'`. Redacted args are marked as such.
+func formatCommandLine(command string, args []string, redacted bool) string {
+	if command == "" {
+		return ""
+	}
+	words := []string{shellQuote(command)}
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	if redacted {
+		words = append(words, "[args redacted]")
+	}
+	return strings.Join(words, " ")
+}
+
+// shellQuote returns s quoted for a POSIX shell, or as is if no quoting is needed.
+func shellQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,+@%", r))
+	}) < 0
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// formatBytes renders a byte count using binary units, e.g. "12.3 MB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// jobErrors returns why a job failed and why cleaning up after it failed.
+// Servers that do not report them apart only send the combined error, which
+// is returned as the job's.
+func jobErrors(combined, exitErr, cleanupErr *string) (string, string) {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	if exitErr == nil && cleanupErr == nil {
+		return value(combined), ""
+	}
+	return value(exitErr), value(cleanupErr)
+}
+
+// statusExitCode returns the exit code of the status command for a job: 0
+// while it has not finished, else the exit code wait would return for it.
+func statusExitCode(resp *pb.StatusJobResponse) int {
+	switch resp.Status {
+	case "Pending", "Queued", "Running":
+		return 0
+	}
+	return jobExitCode(resp.Status, resp.ExitCode, resp.SignalNumber)
+}
+
+var statusLeaseToken string
+
 var statusCmd = &cobra.Command{
 	Use:   "status <job-id>",
 	Short: "Get the current status of a job",
-	Args:  cobra.ExactArgs(1),
+	Long: "Print the current status of a job. Once the job has finished, exit with its\n" +
+		"exit code, or 128 plus the signal number if a signal terminated it.",
+	Args: cobra.ExactArgs(1),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		jobID := args[0]
@@ -20,26 +235,28 @@ var statusCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		resp, err := client.GetStatus(cmd.Context(), &pb.JobRequest{Id: jobID})
+		resp, err := client.GetStatus(cmd.Context(), &pb.JobRequest{Id: jobID, LeaseToken: statusLeaseToken})
 		if err != nil {
 			return fmt.Errorf("failed to get status: %w", err)
 		}
 
-		fmt.Printf("Job %s:\n", resp.Id)
-		fmt.Printf("  Status: %s\n", resp.Status)
-
-		if resp.ExitCode != nil {
-			fmt.Printf("  ExitCode: %d\n", *resp.ExitCode)
+		if outputFmt == outputJSON {
+			err = writeJSON(os.Stdout, resp)
+		} else {
+			renderStatus(os.Stdout, newStatusView(resp))
 		}
-
-		if resp.Error != nil && *resp.Error != "" {
-			fmt.Printf("  Error: %s\n", *resp.Error)
+		if err != nil {
+			return err
 		}
 
+		if code := statusExitCode(resp); code != 0 {
+			return exitCodeError{code: code}
+		}
 		return nil
 	},
 }
 
 func init() {
+	statusCmd.Flags().StringVar(&statusLeaseToken, "lease-token", "", "Lease token of the job, to access a job started with a different certificate")
 	RootCmd.AddCommand(statusCmd)
 }
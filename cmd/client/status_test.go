@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRenderStatus_Reasons(t *testing.T) {
+	code := func(c int32) *int32 { return &c }
+	size := uint64(12_900_000)
+
+	tests := []struct {
+		name string
+		view statusView
+		want []string
+		not  []string
+	}{
+		{
+			name: "exited",
+			view: statusView{ID: "job-1", Status: "Exited", Reason: reasonExited, ExitCode: code(0), Duration: 1500 * time.Millisecond, OutputBytes: &size, PeakMemory: &size},
+			want: []string{"Job job-1:", "Status: Exited", "Reason: exited", "ExitCode: 0", "Duration: 1.5s", "Output: 12.3 MB", "PeakMemory: 12.3 MB"},
+			not:  []string{"Signal:", "Error:", "Warning:"},
+		},
+		{
+			name: "signaled",
+			view: statusView{ID: "job-2", Status: "Failed", Reason: reasonSignaled, ExitCode: code(-1), Signal: "SIGKILL"},
+			want: []string{"Reason: signaled", "Signal: SIGKILL"},
+			not:  []string{"ExitCode:"},
+		},
+		{
+			name: "oom",
+			view: statusView{ID: "job-3", Status: "OOMKilled", Reason: reasonOOM, Signal: "SIGKILL", Error: "memory limit exceeded"},
+			want: []string{"Status: OOMKilled", "Reason: oom", "Signal: SIGKILL", "Error: memory limit exceeded"},
+		},
+		{
+			name: "timeout",
+			view: statusView{ID: "job-4", Status: "TimedOut", Reason: reasonTimeout, ExitCode: code(-1), Duration: 10 * time.Second},
+			want: []string{"Status: TimedOut", "Reason: timeout", "ExitCode: -1", "Duration: 10s"},
+		},
+		{
+			name: "running",
+			view: statusView{ID: "job-5", Status: "Running", StartedAt: time.Now(), Duration: 2 * time.Second},
+			want: []string{"Status: Running", "Started:", "Elapsed: 2s"},
+			not:  []string{"Reason:", "ExitCode:", "Output:", "Duration:"},
+		},
+		{
+			name: "running output",
+			view: statusView{ID: "job-9", Status: "Running", OutputBytes: &size},
+			want: []string{"Output: 12.3 MB so far"},
+		},
+		{
+			name: "limit warnings",
+			view: statusView{ID: "job-6", Status: "Running", Warnings: []string{`memory.max: requested "1000000", kernel applied "999424"`}},
+			want: []string{`Warning: memory.max: requested "1000000", kernel applied "999424"`},
+		},
+		{
+			name: "labels",
+			view: statusView{ID: "job-7", Status: "Running", Labels: map[string]string{"pipeline": "build", "commit": "abc"}},
+			want: []string{"Labels: commit=abc,pipeline=build"},
+		},
+		{
+			name: "command",
+			view: statusView{ID: "job-8", Status: "Running", Command: "bash -c 'echo hi'", Path: "/usr/bin/bash"},
+			want: []string{"Command: bash -c 'echo hi'", "Path: /usr/bin/bash"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			renderStatus(&buf, tc.view)
+			out := buf.String()
+
+			for _, w := range tc.want {
+				if !strings.Contains(out, w) {
+					t.Fatalf("expected %q in output:\n%s", w, out)
+				}
+			}
+			for _, n := range tc.not {
+				if strings.Contains(out, n) {
+					t.Fatalf("did not expect %q in output:\n%s", n, out)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		0:          "0 B",
+		1023:       "1023 B",
+		1024:       "1.0 KB",
+		12_900_000: "12.3 MB",
+		3 << 30:    "3.0 GB",
+	}
+	for in, want := range tests {
+		if got := formatBytes(in); got != want {
+			t.Fatalf("formatBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatCommandLine(t *testing.T) {
+	tests := []struct {
+		command  string
+		args     []string
+		redacted bool
+		want     string
+	}{
+		{"sleep", []string{"10"}, false, "sleep 10"},
+		{"bash", []string{"-c", "echo 'hi there'"}, false, `bash -c 'echo '\''hi there'\'''`},
+		{"env", []string{"", "KEY=v,w"}, false, "env '' KEY=v,w"},
+		{"deploy", nil, true, "deploy [args redacted]"},
+		{"", nil, false, ""},
+	}
+	for _, tc := range tests {
+		if got := formatCommandLine(tc.command, tc.args, tc.redacted); got != tc.want {
+			t.Fatalf("formatCommandLine(%q, %q, %v) = %q, want %q", tc.command, tc.args, tc.redacted, got, tc.want)
+		}
+	}
+}
+
+func TestNewStatusView_Duration(t *testing.T) {
+	started := time.Now().Add(-time.Minute)
+
+	finished := newStatusView(&pb.StatusJobResponse{
+		Id:         "job-1",
+		Status:     "Exited",
+		StartedAt:  timestamppb.New(started),
+		FinishedAt: timestamppb.New(started.Add(3 * time.Second)),
+	})
+	if finished.Duration != 3*time.Second {
+		t.Fatalf("expected total duration 3s, got %s", finished.Duration)
+	}
+
+	running := newStatusView(&pb.StatusJobResponse{
+		Id:        "job-2",
+		Status:    "Running",
+		StartedAt: timestamppb.New(started),
+	})
+	if running.Duration < time.Minute {
+		t.Fatalf("expected elapsed time of at least 1m, got %s", running.Duration)
+	}
+}
+
+func TestNewStatusView_Reason(t *testing.T) {
+	for status, want := range map[string]string{
+		"Exited":              reasonExited,
+		"Failed":              reasonExited,
+		"Stopped":             reasonStopped,
+		"TimedOut":            reasonTimeout,
+		"OOMKilled":           reasonOOM,
+		"OutputLimitExceeded": reasonOutput,
+		"Running":             "",
+	} {
+		if got := newStatusView(&pb.StatusJobResponse{Status: status}).Reason; got != want {
+			t.Fatalf("status %s: expected reason %q, got %q", status, want, got)
+		}
+	}
+}
+
+func TestNewStatusView_Signaled(t *testing.T) {
+	sig := "SIGKILL"
+	v := newStatusView(&pb.StatusJobResponse{Status: "Failed", Signal: &sig})
+	if v.Reason != reasonSignaled || v.Signal != "SIGKILL" {
+		t.Fatalf("expected signaled reason with SIGKILL, got %q/%q", v.Reason, v.Signal)
+	}
+}
+
+func TestNewStatusView_Errors(t *testing.T) {
+	v := newStatusView(&pb.StatusJobResponse{
+		Status:       "Exited",
+		Error:        proto.String("cgroup stuck"),
+		CleanupError: proto.String("cgroup stuck"),
+	})
+	if v.Error != "" || v.CleanupErr != "cgroup stuck" {
+		t.Fatalf("expected only a cleanup error, got %q/%q", v.Error, v.CleanupErr)
+	}
+
+	var buf bytes.Buffer
+	renderStatus(&buf, v)
+	if out := buf.String(); !strings.Contains(out, "CleanupError: cgroup stuck") || strings.Contains(out, "  Error:") {
+		t.Fatalf("expected the cleanup error alone in output:\n%s", out)
+	}
+
+	// Servers that do not tell the errors apart only send the combined one.
+	v = newStatusView(&pb.StatusJobResponse{Status: "Failed", Error: proto.String("exec failed")})
+	if v.Error != "exec failed" || v.CleanupErr != "" {
+		t.Fatalf("expected the combined error as the job's, got %q/%q", v.Error, v.CleanupErr)
+	}
+}
+
+func TestWriteJSON_Status(t *testing.T) {
+	code, sig := int32(-1), "SIGKILL"
+	started := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var buf bytes.Buffer
+	err := writeJSON(&buf, &pb.StatusJobResponse{
+		Id:         "job-1",
+		Status:     "Failed",
+		ExitCode:   &code,
+		Signal:     &sig,
+		StartedAt:  timestamppb.New(started),
+		FinishedAt: timestamppb.New(started.Add(time.Second)),
+		Command:    "sleep",
+		Args:       []string{"10"},
+	})
+	if err != nil {
+		t.Fatalf("writeJSON error: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"id":            "job-1",
+		"status":        "Failed",
+		"exitCode":      float64(-1),
+		"signal":        "SIGKILL",
+		"startedAt":     "2024-01-02T03:04:05Z",
+		"finishedAt":    "2024-01-02T03:04:06Z",
+		"command":       "sleep",
+		"activeStreams": float64(0), // zero values are included too
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("expected %s = %v, got %v in %s", k, v, got[k], buf.String())
+		}
+	}
+}
+
+func TestRootCmd_InvalidOutput(t *testing.T) {
+	defer func(prev string) { outputFmt = prev }(outputFmt)
+
+	outputFmt = "yaml"
+	if err := RootCmd.PersistentPreRunE(RootCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid --output") {
+		t.Fatalf("expected invalid output error, got %v", err)
+	}
+	outputFmt = outputJSON
+	if err := RootCmd.PersistentPreRunE(RootCmd, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStatusExitCode(t *testing.T) {
+	tests := []struct {
+		resp *pb.StatusJobResponse
+		want int
+	}{
+		{&pb.StatusJobResponse{Status: "Running"}, 0},
+		{&pb.StatusJobResponse{Status: "Queued"}, 0},
+		{&pb.StatusJobResponse{Status: "Exited", ExitCode: proto.Int32(0)}, 0},
+		{&pb.StatusJobResponse{Status: "Failed", ExitCode: proto.Int32(1)}, 1},
+		{&pb.StatusJobResponse{Status: "Stopped", ExitCode: proto.Int32(-1), Signal: proto.String("SIGKILL"), SignalNumber: proto.Int32(9)}, 137},
+		// Servers that do not report the signal number.
+		{&pb.StatusJobResponse{Status: "Failed", ExitCode: proto.Int32(-1), Signal: proto.String("SIGKILL")}, 1},
+		{&pb.StatusJobResponse{Status: "Orphaned"}, 1},
+	}
+	for _, tt := range tests {
+		if got := statusExitCode(tt.resp); got != tt.want {
+			t.Fatalf("%v: expected exit code %d, got %d", tt.resp, tt.want, got)
+		}
+	}
+}
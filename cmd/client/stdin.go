@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// stdinChunkSize is how much input is sent per message.
+const stdinChunkSize = 32 * 1024
+
+// stdinClient is the part of the LPaaS client used to write to a job's stdin.
+type stdinClient interface {
+	WriteStdin(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[pb.StdinChunk, pb.WriteStdinResponse], error)
+}
+
+var writeStdinCmd = &cobra.Command{
+	Use:   "write-stdin <job-id>",
+	Short: "Send this command's stdin to a job started with --stdin",
+	Long: "Copy stdin to the stdin of a running job started with --stdin. Once stdin\n" +
+		"ends the job's stdin is closed, so the job reads EOF.",
+	Args: cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		written, err := writeStdin(cmd.Context(), client, args[0], os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Wrote %d bytes to job %s\n", written, args[0])
+		return nil
+	},
+}
+
+// writeStdin sends everything read from r to the stdin of jobID and closes the
+// job's stdin once r ends. It returns the number of bytes the job received.
+func writeStdin(ctx context.Context, client stdinClient, jobID string, r io.Reader) (uint64, error) {
+	stream, err := client.WriteStdin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write to job stdin: %w", err)
+	}
+
+	// The first message names the job, even if there is no input.
+	chunk := &pb.StdinChunk{Id: jobID}
+	buf := make([]byte, stdinChunkSize)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				// The server ended the stream; its error is returned by CloseAndRecv.
+				break
+			}
+			chunk = &pb.StdinChunk{}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return 0, fmt.Errorf("failed to read stdin: %w", readErr)
+		}
+	}
+	if chunk.Id != "" {
+		if err := stream.Send(chunk); err != nil && err != io.EOF {
+			return 0, fmt.Errorf("failed to write to job stdin: %w", err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return 0, fmt.Errorf("failed to write to job stdin: %w", err)
+	}
+	return resp.BytesWritten, nil
+}
+
+func init() {
+	RootCmd.AddCommand(writeStdinCmd)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// fakeStdinClient records the chunks sent on its WriteStdin stream.
+type fakeStdinClient struct {
+	grpc.ClientStream
+	chunks []*pb.StdinChunk
+}
+
+func (f *fakeStdinClient) WriteStdin(context.Context, ...grpc.CallOption) (grpc.ClientStreamingClient[pb.StdinChunk, pb.WriteStdinResponse], error) {
+	return f, nil
+}
+
+func (f *fakeStdinClient) Send(chunk *pb.StdinChunk) error {
+	f.chunks = append(f.chunks, &pb.StdinChunk{Id: chunk.Id, Data: bytes.Clone(chunk.Data)})
+	return nil
+}
+
+func (f *fakeStdinClient) CloseAndRecv() (*pb.WriteStdinResponse, error) {
+	var n int
+	for _, c := range f.chunks {
+		n += len(c.Data)
+	}
+	return &pb.WriteStdinResponse{BytesWritten: uint64(n)}, nil
+}
+
+func TestWriteStdin_SendsInputInChunks(t *testing.T) {
+	client := &fakeStdinClient{}
+	input := strings.Repeat("x", stdinChunkSize+10)
+
+	written, err := writeStdin(context.Background(), client, "job-1", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written != uint64(len(input)) {
+		t.Fatalf("expected %d bytes written, got %d", len(input), written)
+	}
+	if len(client.chunks) != 2 || client.chunks[0].Id != "job-1" || client.chunks[1].Id != "" {
+		t.Fatalf("expected 2 chunks, only the first naming the job, got %d", len(client.chunks))
+	}
+	if got := string(client.chunks[0].Data) + string(client.chunks[1].Data); got != input {
+		t.Fatalf("input not sent as read")
+	}
+}
+
+func TestWriteStdin_EmptyInputNamesJob(t *testing.T) {
+	client := &fakeStdinClient{}
+
+	if _, err := writeStdin(context.Background(), client, "job-1", strings.NewReader("")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.chunks) != 1 || client.chunks[0].Id != "job-1" || len(client.chunks[0].Data) != 0 {
+		t.Fatalf("expected a single empty chunk naming the job, got %v", client.chunks)
+	}
+}
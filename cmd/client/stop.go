@@ -2,9 +2,16 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var (
+	stopGrace      time.Duration
+	stopLeaseToken string
 )
 
 var stopCmd = &cobra.Command{
@@ -21,7 +28,12 @@ var stopCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		_, err = client.StopJob(cmd.Context(), &pb.JobRequest{Id: jobID})
+		req := &pb.StopJobRequest{Id: jobID, LeaseToken: stopLeaseToken}
+		if cmd.Flags().Changed("grace") {
+			req.GracePeriod = durationpb.New(stopGrace)
+		}
+
+		_, err = client.StopJob(cmd.Context(), req)
 		if err != nil {
 			return fmt.Errorf("failed to stop job: %w", err)
 		}
@@ -32,5 +44,7 @@ var stopCmd = &cobra.Command{
 }
 
 func init() {
+	stopCmd.Flags().DurationVar(&stopGrace, "grace", 0, "Time to wait after SIGTERM before killing the job (default: server setting)")
+	stopCmd.Flags().StringVar(&stopLeaseToken, "lease-token", "", "Lease token of the job, to stop a job started with a different certificate")
 	RootCmd.AddCommand(stopCmd)
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var waitTimeout time.Duration
+
+// waitClient is the part of the LPaaS client used to wait for a job.
+type waitClient interface {
+	WaitJob(ctx context.Context, in *pb.WaitJobRequest, opts ...grpc.CallOption) (*pb.WaitJobResponse, error)
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait <job-id>",
+	Short: "Wait for a job to finish and exit with its exit code",
+	Long: "Block until the job finishes, print its final status and exit with the job's\n" +
+		"exit code, or 128 plus the signal number if a signal terminated it.",
+	Args: cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		ctx := cmd.Context()
+		if waitTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, waitTimeout)
+			defer cancel()
+		}
+
+		resp, err := waitJob(ctx, client, args[0])
+		if err != nil {
+			return err
+		}
+
+		renderWaitResult(os.Stdout, resp)
+		if code := waitExitCode(resp); code != 0 {
+			return exitCodeError{code: code}
+		}
+		return nil
+	},
+}
+
+// waitJob waits until jobID finished, waiting again each time the server's
+// maximum wait passes, until ctx is done.
+func waitJob(ctx context.Context, client waitClient, jobID string) (*pb.WaitJobResponse, error) {
+	for {
+		req := &pb.WaitJobRequest{Id: jobID}
+		if deadline, ok := ctx.Deadline(); ok {
+			req.Timeout = durationpb.New(time.Until(deadline))
+		}
+
+		resp, err := client.WaitJob(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for job %s: %w", jobID, err)
+		}
+		if resp.Finished {
+			return resp, nil
+		}
+	}
+}
+
+// renderWaitResult writes the final status of a job to w.
+func renderWaitResult(w io.Writer, resp *pb.WaitJobResponse) {
+	fmt.Fprintf(w, "Job %s: %s", resp.Id, resp.Status)
+	switch {
+	case resp.Signal != nil:
+		fmt.Fprintf(w, " (signal %s)", resp.GetSignal())
+	case resp.ExitCode != nil:
+		fmt.Fprintf(w, " (exit code %d)", resp.GetExitCode())
+	}
+	fmt.Fprintln(w)
+	exitErr, cleanupErr := jobErrors(resp.Error, resp.ExitError, resp.CleanupError)
+	if exitErr != "" {
+		fmt.Fprintf(w, "  Error: %s\n", exitErr)
+	}
+	if cleanupErr != "" {
+		fmt.Fprintf(w, "  CleanupError: %s\n", cleanupErr)
+	}
+}
+
+// waitExitCode returns the exit code of the CLI for a finished job, as
+// jobExitCode does.
+func waitExitCode(resp *pb.WaitJobResponse) int {
+	return jobExitCode(resp.Status, resp.ExitCode, resp.SignalNumber)
+}
+
+// jobExitCode returns the exit code of the CLI for a job that finished with
+// status, following shell conventions: the job's own exit code, or 128 plus
+// the number of the signal that terminated it. Jobs that ended without either
+// exit with 1.
+func jobExitCode(status string, exitCode, signalNumber *int32) int {
+	switch {
+	case signalNumber != nil:
+		return 128 + int(*signalNumber)
+	case exitCode != nil && *exitCode > 0:
+		return int(*exitCode)
+	case status == "Exited":
+		return 0
+	default:
+		return 1
+	}
+}
+
+func init() {
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", 0, "Give up waiting after this long (0 waits until the job finishes)")
+	RootCmd.AddCommand(waitCmd)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeWaitClient returns its responses in order, one per WaitJob call.
+type fakeWaitClient struct {
+	responses []*pb.WaitJobResponse
+	calls     int
+}
+
+func (f *fakeWaitClient) WaitJob(context.Context, *pb.WaitJobRequest, ...grpc.CallOption) (*pb.WaitJobResponse, error) {
+	resp := f.responses[f.calls]
+	f.calls++
+	return resp, nil
+}
+
+func TestWaitJob_WaitsAgainUntilFinished(t *testing.T) {
+	client := &fakeWaitClient{responses: []*pb.WaitJobResponse{
+		{Id: "job-1", Status: "Running"},
+		{Id: "job-1", Status: "Running"},
+		{Id: "job-1", Finished: true, Status: "Failed", ExitCode: proto.Int32(3)},
+	}}
+
+	resp, err := waitJob(context.Background(), client, "job-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.calls != 3 || resp.Status != "Failed" {
+		t.Fatalf("expected the third response after 3 calls, got %v after %d", resp, client.calls)
+	}
+}
+
+func TestWaitExitCode(t *testing.T) {
+	tests := []struct {
+		resp *pb.WaitJobResponse
+		want int
+	}{
+		{&pb.WaitJobResponse{Status: "Exited", ExitCode: proto.Int32(0)}, 0},
+		{&pb.WaitJobResponse{Status: "Failed", ExitCode: proto.Int32(3)}, 3},
+		{&pb.WaitJobResponse{Status: "OOMKilled", ExitCode: proto.Int32(-1), Signal: proto.String("SIGKILL"), SignalNumber: proto.Int32(9)}, 137},
+		{&pb.WaitJobResponse{Status: "Stopped", ExitCode: proto.Int32(-1), Signal: proto.String("SIGTERM"), SignalNumber: proto.Int32(15)}, 143},
+		{&pb.WaitJobResponse{Status: "Failed", ExitCode: proto.Int32(-1), Error: proto.String("starting failed")}, 1},
+	}
+	for _, tt := range tests {
+		if got := waitExitCode(tt.resp); got != tt.want {
+			t.Fatalf("%v: expected exit code %d, got %d", tt.resp, tt.want, got)
+		}
+	}
+}
+
+func TestRenderWaitResult(t *testing.T) {
+	var buf bytes.Buffer
+	renderWaitResult(&buf, &pb.WaitJobResponse{
+		Id:           "job-1",
+		Finished:     true,
+		Status:       "OOMKilled",
+		ExitCode:     proto.Int32(-1),
+		Signal:       proto.String("SIGKILL"),
+		SignalNumber: proto.Int32(9),
+	})
+	if want := "Job job-1: OOMKilled (signal SIGKILL)\n"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	renderWaitResult(&buf, &pb.WaitJobResponse{Id: "job-2", Finished: true, Status: "Failed", ExitCode: proto.Int32(3)})
+	if want := "Job job-2: Failed (exit code 3)\n"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+	buf.Reset()
+	renderWaitResult(&buf, &pb.WaitJobResponse{
+		Id:           "job-3",
+		Finished:     true,
+		Status:       "Failed",
+		ExitCode:     proto.Int32(-1),
+		Error:        proto.String("exec failed\ncgroup stuck"),
+		ExitError:    proto.String("exec failed"),
+		CleanupError: proto.String("cgroup stuck"),
+	})
+	if want := "Job job-3: Failed (exit code -1)\n  Error: exec failed\n  CleanupError: cgroup stuck\n"; buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
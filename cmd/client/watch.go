@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchInterval time.Duration
+	watchTail     int
+)
+
+// spinnerFrames are cycled through while a watched job is running.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// terminalStatuses are the statuses after which a job no longer changes.
+var terminalStatuses = map[string]bool{
+	"Exited":              true,
+	"Failed":              true,
+	"Stopped":             true,
+	"TimedOut":            true,
+	"OOMKilled":           true,
+	"OutputLimitExceeded": true,
+	// Restored jobs whose process was lost with the previous worker.
+	"Orphaned": true,
+}
+
+// watchRenderer produces the live status line of the watch command. On a TTY
+// the line is redrawn in place with a spinner; otherwise a plain line is
+// emitted only when the status changes.
+type watchRenderer struct {
+	tty   bool
+	frame int
+	last  string
+}
+
+// render returns the text to write for the given status, possibly empty.
+func (r *watchRenderer) render(jobID, status string, elapsed time.Duration) string {
+	terminal := terminalStatuses[status]
+
+	if !r.tty {
+		if status == r.last {
+			return ""
+		}
+		r.last = status
+		return fmt.Sprintf("%s: %s\n", jobID, status)
+	}
+
+	// \r returns to the start of the line and \033[K clears it.
+	if terminal {
+		return fmt.Sprintf("\r\033[K%s: %s (%s)\n", jobID, status, elapsed.Round(time.Second))
+	}
+	frame := spinnerFrames[r.frame%len(spinnerFrames)]
+	r.frame++
+	return fmt.Sprintf("\r\033[K%s %s: %s (%s)", frame, jobID, status, elapsed.Round(time.Second))
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// lastLines returns at most n trailing lines of data.
+func lastLines(data []byte, n int) []byte {
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <job-id>",
+	Short: "Watch a job until it finishes, showing a live status line",
+	Args:  cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+		if watchInterval <= 0 {
+			return fmt.Errorf("invalid --interval %v: must be positive", watchInterval)
+		}
+
+		conn, client, err := NewLpaasClient()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		r := &watchRenderer{tty: isTerminal(os.Stdout)}
+		tick := time.NewTicker(watchInterval)
+		defer tick.Stop()
+
+		for {
+			resp, err := client.GetStatus(cmd.Context(), &pb.JobRequest{Id: jobID})
+			if err != nil {
+				return fmt.Errorf("failed to get status: %w", err)
+			}
+
+			var elapsed time.Duration
+			if resp.StartedAt != nil {
+				end := time.Now()
+				if resp.FinishedAt != nil {
+					end = resp.FinishedAt.AsTime()
+				}
+				elapsed = end.Sub(resp.StartedAt.AsTime())
+			}
+
+			fmt.Print(r.render(jobID, resp.Status, elapsed))
+
+			if terminalStatuses[resp.Status] {
+				break
+			}
+
+			select {
+			case <-cmd.Context().Done():
+				fmt.Println()
+				return cmd.Context().Err()
+			case <-tick.C:
+			}
+		}
+
+		if watchTail <= 0 {
+			return nil
+		}
+
+		stream, err := client.StreamOutput(cmd.Context(), &pb.StreamRequest{Id: jobID})
+		if err != nil {
+			return fmt.Errorf("stream start error: %w", err)
+		}
+
+		var out bytes.Buffer
+		for {
+			chunk, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("stream recv error: %w", err)
+			}
+			out.Write(chunk.Data)
+		}
+
+		_, err = os.Stdout.Write(lastLines(out.Bytes(), watchTail))
+		return err
+	},
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 500*time.Millisecond, "Status polling interval")
+	watchCmd.Flags().IntVar(&watchTail, "tail", 0, "Print the last N lines of output once the job finishes")
+	RootCmd.AddCommand(watchCmd)
+}
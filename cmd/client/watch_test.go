@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchRenderer_TTY(t *testing.T) {
+	r := &watchRenderer{tty: true}
+
+	first := r.render("job-1", "Running", time.Second)
+	second := r.render("job-1", "Running", 2*time.Second)
+	if !strings.HasPrefix(first, "\r\033[K| job-1: Running (1s)") {
+		t.Fatalf("unexpected first frame: %q", first)
+	}
+	if !strings.HasPrefix(second, "\r\033[K/ job-1: Running (2s)") {
+		t.Fatalf("spinner must advance, got %q", second)
+	}
+	if strings.HasSuffix(first, "\n") {
+		t.Fatalf("running line must be redrawn in place, got %q", first)
+	}
+
+	final := r.render("job-1", "Exited", 3*time.Second)
+	if final != "\r\033[Kjob-1: Exited (3s)\n" {
+		t.Fatalf("unexpected final line: %q", final)
+	}
+}
+
+func TestWatchRenderer_OrphanedIsFinal(t *testing.T) {
+	r := &watchRenderer{tty: true}
+
+	if got := r.render("job-1", "Orphaned", 0); got != "\r\033[Kjob-1: Orphaned (0s)\n" {
+		t.Fatalf("orphaned job must end the watch, got %q", got)
+	}
+}
+
+func TestWatchRenderer_Plain(t *testing.T) {
+	r := &watchRenderer{}
+
+	if got := r.render("job-1", "Running", time.Second); got != "job-1: Running\n" {
+		t.Fatalf("unexpected first line: %q", got)
+	}
+	if got := r.render("job-1", "Running", 2*time.Second); got != "" {
+		t.Fatalf("unchanged status must print nothing when piped, got %q", got)
+	}
+	if got := r.render("job-1", "Exited", 3*time.Second); got != "job-1: Exited\n" {
+		t.Fatalf("unexpected final line: %q", got)
+	}
+}
+
+func TestWatch_InvalidInterval(t *testing.T) {
+	defer func(d time.Duration) { watchInterval = d }(watchInterval)
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		watchInterval = d
+		if err := watchCmd.RunE(watchCmd, []string{"job-1"}); err == nil || !strings.Contains(err.Error(), "invalid --interval") {
+			t.Fatalf("expected invalid interval error for %v, got %v", d, err)
+		}
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	data := []byte("one\ntwo\nthree\n")
+
+	if got := string(lastLines(data, 2)); got != "two\nthree\n" {
+		t.Fatalf("unexpected tail: %q", got)
+	}
+	if got := string(lastLines(data, 10)); got != "one\ntwo\nthree\n" {
+		t.Fatalf("unexpected tail: %q", got)
+	}
+	if got := lastLines(nil, 3); got != nil {
+		t.Fatalf("expected nil for empty output, got %q", got)
+	}
+}
@@ -1,10 +1,15 @@
 package linuxjobs
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -15,26 +20,45 @@ import (
 
 var (
 	cgroupInitMu   sync.Mutex
-	cgroupInitDone bool
+	cgroupInitDone = make(map[string]bool) // keyed by cgroup root path
 )
 
 const (
+	defaultCgroupRoot = "/sys/fs/cgroup"
 	defaultCPUPercent = 50                     // 50% of one CPU
 	defaultMemBytes   = 1 * 1024 * 1024 * 1024 // 1 GB
 	defaultIOBps      = 10 * 1024 * 1024       // 10 MB/s
+	defaultMaxPIDs    = 512
+	minCPUWeight      = 1
+	maxCPUWeight      = 10000
 	cpuMaxFile        = "cpu.max"
+	cpuWeightFile     = "cpu.weight"
 	memoryMaxFile     = "memory.max"
 	ioMaxFile         = "io.max"
+	pidsMaxFile       = "pids.max"
 	cgroupKillFile    = "cgroup.kill"
+	cgroupProcsFile   = "cgroup.procs"
+	memoryPeakFile    = "memory.peak"
+	memoryCurrentFile = "memory.current"
+	cpuStatFile       = "cpu.stat"
+	memoryEventsFile  = "memory.events"
+	cgroupEventsFile  = "cgroup.events"
+
+	// defaultCgroupDeleteTimeout is how long deleting a cgroup waits for its
+	// killed processes to exit.
+	defaultCgroupDeleteTimeout = 10 * time.Second
+	// defaultCgroupDeletePoll is how often deleting a cgroup checks whether its
+	// processes have exited.
+	defaultCgroupDeletePoll = 50 * time.Millisecond
 )
 
-// ensureCgroupHierarchy ensures the cgroup hierarchy.
+// ensureCgroupHierarchy ensures the cgroup hierarchy under cgroupRootPath.
 // If already initialized, it's a no-op.
 func ensureCgroupHierarchy(lpaasCgroupRoot, cgroupRootPath string) error {
 	cgroupInitMu.Lock()
 	defer cgroupInitMu.Unlock()
 
-	if cgroupInitDone {
+	if cgroupInitDone[cgroupRootPath] {
 		return nil
 	}
 
@@ -48,28 +72,301 @@ func ensureCgroupHierarchy(lpaasCgroupRoot, cgroupRootPath string) error {
 		return fmt.Errorf("enable controllers on %q: %w", lpaasCgroupRoot, err)
 	}
 
-	cgroupInitDone = true
+	cgroupInitDone[cgroupRootPath] = true
 	return nil
 }
 
+// ensureOwnerCgroup ensures the cgroup at path nesting the job cgroups of an
+// owner exists, with controllers enabled for them. If already initialized,
+// it's a no-op.
+func ensureOwnerCgroup(path string) error {
+	cgroupInitMu.Lock()
+	defer cgroupInitMu.Unlock()
+
+	if cgroupInitDone[path] {
+		return nil
+	}
+
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return fmt.Errorf("create owner cgroup %q: %w", path, err)
+	}
+	if err := enableControllers(path); err != nil {
+		return fmt.Errorf("enable controllers on %q: %w", path, err)
+	}
+
+	cgroupInitDone[path] = true
+	return nil
+}
+
+// ownerCgroupName returns the name of the cgroup nesting the job cgroups of
+// owner. Owners may contain slashes, e.g. SPIFFE IDs, and dots would let them
+// name cgroup interface files such as cpu.max, so both are escaped.
+func ownerCgroupName(owner string) string {
+	return strings.ReplaceAll(url.PathEscape(owner), ".", "%2E")
+}
+
+// ValidateCgroupRoot checks that dir, the root passed to WithCgroupRoot, is a
+// directory on a cgroup v2 hierarchy. An empty dir checks /sys/fs/cgroup.
+func ValidateCgroupRoot(dir string) error {
+	if dir == "" {
+		dir = defaultCgroupRoot
+	}
+
+	var fs unix.Statfs_t
+	if err := unix.Statfs(dir, &fs); err != nil {
+		return fmt.Errorf("stat cgroup root %q: %w", dir, err)
+	}
+	if fs.Type != unix.CGROUP2_SUPER_MAGIC {
+		return fmt.Errorf("cgroup root %q is not on a cgroup v2 mount", dir)
+	}
+	return nil
+}
+
+// requiredControllers are the controllers the limits of jobs are applied with.
+var requiredControllers = []string{"cpu", "memory", "io", "pids"}
+
+// VerifyCgroupRoot checks that jobs can be confined under dir, the root passed
+// to WithCgroupRoot: that it is on a cgroup v2 hierarchy, that the cpu,
+// memory, io and pids controllers are available in it and that they can be
+// enabled for its children. The error lists every problem found, with what to
+// do about it. An empty dir checks /sys/fs/cgroup.
+func VerifyCgroupRoot(dir string) error {
+	if err := ValidateCgroupRoot(dir); err != nil {
+		return err
+	}
+	return checkCgroupDelegation(cmp.Or(dir, defaultCgroupRoot))
+}
+
+// checkCgroupDelegation checks that the required controllers are available in
+// the cgroup dir and can be enabled for its children.
+func checkCgroupDelegation(dir string) error {
+	var problems []string
+
+	available, err := readTrimmed(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("cannot list the available controllers: %v", err))
+	} else if missing := missingControllers(available); len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("controllers %s are not available; enable them in cgroup.subtree_control of the parent cgroup, or have them delegated, e.g. with Delegate=yes in the systemd unit",
+			strings.Join(missing, ", ")))
+	}
+
+	subtree := filepath.Join(dir, "cgroup.subtree_control")
+	if err := unix.Access(subtree, unix.W_OK); err != nil {
+		problems = append(problems, fmt.Sprintf("%s is not writable: %v; run the worker as root or as the user the cgroup was delegated to", subtree, err))
+	} else if err := checkNoInternalProcesses(dir, subtree); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("cgroup root %q cannot confine jobs:\n  - %s", dir, strings.Join(problems, "\n  - "))
+}
+
+// checkNoInternalProcesses checks that the controllers can be enabled for the
+// children of the cgroup dir. The kernel refuses to enable them in a non-root
+// cgroup with processes of its own, such as the worker itself.
+func checkNoInternalProcesses(dir, subtree string) error {
+	// Only non-root cgroups have a cgroup.type file.
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.type")); err != nil {
+		return nil
+	}
+	enabled, err := readTrimmed(subtree)
+	if err != nil {
+		return err
+	}
+	if len(missingControllers(enabled)) == 0 {
+		return nil
+	}
+	procs, err := readTrimmed(filepath.Join(dir, cgroupProcsFile))
+	if err != nil {
+		return err
+	}
+	if procs != "" {
+		return fmt.Errorf("controllers cannot be enabled for child cgroups while processes run in %s itself; move them, e.g. the worker, to a leaf cgroup such as %s",
+			dir, filepath.Join(dir, "worker"))
+	}
+	return nil
+}
+
+// missingControllers returns the required controllers not among controllers,
+// a space-separated list as in cgroup.controllers.
+func missingControllers(controllers string) []string {
+	fields := strings.Fields(controllers)
+	var missing []string
+	for _, c := range requiredControllers {
+		if !slices.Contains(fields, c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// OrphanPolicy selects what happens to job cgroups left behind by an earlier
+// worker, e.g. after an unclean shutdown.
+type OrphanPolicy int
+
+const (
+	// KeepOrphans leaves orphaned cgroups and any processes in them alone.
+	KeepOrphans OrphanPolicy = iota
+	// RemoveOrphans kills the processes in orphaned cgroups and removes them.
+	RemoveOrphans
+)
+
+// HandleOrphanedCgroups applies policy to the job cgroups found under
+// cgroupRoot, /sys/fs/cgroup if empty, including those nested in owner
+// cgroups, and returns their paths. Every job cgroup counts as orphaned, so
+// it must be called before any job is started under cgroupRoot. Removing
+// orphans also removes the owner cgroups left empty.
+func HandleOrphanedCgroups(cgroupRoot string, policy OrphanPolicy) ([]string, error) {
+	if cgroupRoot == "" {
+		cgroupRoot = defaultCgroupRoot
+	}
+	lpaasCgroupRoot := filepath.Join(cgroupRoot, "lpaas")
+
+	dirs, err := subdirs(lpaasCgroupRoot)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list job cgroups: %w", err)
+	}
+
+	var orphans []string
+	var errs []error
+	handle := func(path string) {
+		cg := &cgroupv2{cgroupRootPath: cgroupRoot, Path: path}
+		orphans = append(orphans, cg.Path)
+
+		if policy == RemoveOrphans {
+			if err := cg.delete(); err != nil {
+				errs = append(errs, fmt.Errorf("remove orphaned cgroup: %w", err))
+			}
+		}
+	}
+
+	for _, path := range dirs {
+		// Job cgroups have no children, so a cgroup with children, or one not
+		// named like a job, nests the job cgroups of an owner.
+		children, err := subdirs(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("list job cgroups: %w", err))
+			continue
+		}
+		if len(children) == 0 && strings.HasPrefix(filepath.Base(path), "job-") {
+			handle(path)
+			continue
+		}
+
+		failed := len(errs)
+		for _, child := range children {
+			handle(child)
+		}
+		if policy == RemoveOrphans && len(errs) == failed {
+			owner := &cgroupv2{cgroupRootPath: cgroupRoot, Path: path}
+			if err := owner.delete(); err != nil {
+				errs = append(errs, fmt.Errorf("remove owner cgroup: %w", err))
+			}
+		}
+	}
+	return orphans, errors.Join(errs...)
+}
+
+// subdirs returns the paths of the directories in dir, i.e. its child
+// cgroups; the rest are cgroup interface files.
+func subdirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		if e.IsDir() {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// errNoCgroup is returned by noCgroup for everything that needs a cgroup.
+var errNoCgroup = errors.New("job runs without a cgroup")
+
+// noCgroup is the cgroup of a job started in best-effort mode after its real
+// cgroup could not be set up. The job runs without resource limits and usage
+// accounting.
+type noCgroup struct{}
+
+func (noCgroup) delete() error                  { return nil }
+func (noCgroup) kill() error                    { return errNoCgroup }
+func (noCgroup) openFD() (int, error)           { return -1, errNoCgroup }
+func (noCgroup) procs() ([]int, error)          { return nil, errNoCgroup }
+func (noCgroup) memoryPeak() (uint64, error)    { return 0, errNoCgroup }
+func (noCgroup) memoryCurrent() (uint64, error) { return 0, errNoCgroup }
+func (noCgroup) stats() (Stats, error)          { return Stats{}, errNoCgroup }
+func (noCgroup) oomKills() (uint64, error)      { return 0, errNoCgroup }
+
 // cgroupv2 represents a single job’s cgroup.
 type cgroupv2 struct {
 	cgroupRootPath string // cgroup root path: /sys/fs/cgroup
-	Path           string // full path: /sys/fs/cgroup/lpaas/<jobID>
+	Path           string // full path: /sys/fs/cgroup/lpaas/[<owner>/]<jobID>
+	deletion       cgroupDeletion
+}
+
+// cgroupDeletion configures how long deleting a cgroup waits for its processes
+// to exit. Zero fields use the defaults.
+type cgroupDeletion struct {
+	timeout time.Duration
+	poll    time.Duration
 }
 
-// newCGroupV2 creates the directory for a job’s cgroup.
-func newCGroupV2(jobID string, cgroupRootPath string) (*cgroupv2, error) {
+// CgroupDeleteError is returned when a job's cgroup could not be deleted before
+// the delete timeout expired.
+type CgroupDeleteError struct {
+	Path    string
+	Timeout time.Duration
+
+	// Dying is set if the cgroup still had processes, i.e. they were killed
+	// but have not exited yet. Deleting the cgroup again later is likely to
+	// succeed. Otherwise the cgroup is stuck, e.g. on a process in
+	// uninterruptible sleep or a nested cgroup.
+	Dying bool
+
+	// Err is the last error removing the cgroup directory, if any.
+	Err error
+}
+
+func (e *CgroupDeleteError) Error() string {
+	if e.Dying {
+		return fmt.Sprintf("cgroup %q still has processes exiting after %v", e.Path, e.Timeout)
+	}
+	return fmt.Sprintf("cgroup %q is stuck after %v: %v", e.Path, e.Timeout, e.Err)
+}
+
+func (e *CgroupDeleteError) Unwrap() error { return e.Err }
+
+// newCGroupV2 creates the directory for a job’s cgroup. An empty
+// cgroupRootPath uses /sys/fs/cgroup. If owner is set, the job's cgroup is
+// nested in a cgroup of the owner's, which accounts the usage of all their
+// jobs.
+func newCGroupV2(jobID, cgroupRootPath, owner string) (*cgroupv2, error) {
 	if cgroupRootPath == "" {
-		cgroupRootPath = "/sys/fs/cgroup"
+		cgroupRootPath = defaultCgroupRoot
 	}
 	lpaasCgroupRoot := filepath.Join(cgroupRootPath, "lpaas")
-	path := filepath.Join(lpaasCgroupRoot, jobID)
 
 	if err := ensureCgroupHierarchy(lpaasCgroupRoot, cgroupRootPath); err != nil {
 		return nil, fmt.Errorf("failed to initialize cgroup: %w", err)
 	}
 
+	parent := lpaasCgroupRoot
+	if owner != "" {
+		parent = filepath.Join(lpaasCgroupRoot, ownerCgroupName(owner))
+		if err := ensureOwnerCgroup(parent); err != nil {
+			return nil, fmt.Errorf("failed to initialize cgroup: %w", err)
+		}
+	}
+	path := filepath.Join(parent, jobID)
+
 	if err := os.MkdirAll(path, 0o755); err != nil {
 		return nil, fmt.Errorf("create job cgroup %q: %w", path, err)
 	}
@@ -77,9 +374,13 @@ func newCGroupV2(jobID string, cgroupRootPath string) (*cgroupv2, error) {
 	return &cgroupv2{cgroupRootPath: cgroupRootPath, Path: path}, nil
 }
 
-// enableControllers activates cpu, memory, and io controllers for children under dir.
+// enableControllers activates cpu, memory, and io controllers for children
+// under dir, and the pids controller if the kernel provides it for dir.
 func enableControllers(dir string) error {
 	controllers := []string{"cpu", "memory", "io"}
+	if available, err := readTrimmed(filepath.Join(dir, "cgroup.controllers")); err == nil && slices.Contains(strings.Fields(available), "pids") {
+		controllers = append(controllers, "pids")
+	}
 	subtree := filepath.Join(dir, "cgroup.subtree_control")
 
 	for _, ctrl := range controllers {
@@ -93,38 +394,228 @@ func enableControllers(dir string) error {
 	return nil
 }
 
-// setLimits applies CPU, memory, and I/O throttling to this job.
-func (cg *cgroupv2) setLimits() error {
+// setLimits applies the CPU, memory, I/O and process limits of the profile to this
+// job. The kernel may round or ignore values it accepts, so the limits are read
+// back afterwards; it returns a warning for each one that differs from p.
+func (cg *cgroupv2) setLimits(p ResourceProfile) ([]string, error) {
 	cpuPath := filepath.Join(cg.Path, cpuMaxFile)
-	cpuLine := fmt.Sprintf("%d 100000", defaultCPUPercent*1000)
 
-	if err := os.WriteFile(cpuPath, []byte(cpuLine), 0o644); err != nil {
-		return fmt.Errorf("write cpu.max for %q: %w", cg.Path, err)
+	if err := os.WriteFile(cpuPath, []byte(cpuMaxLine(p)), 0o644); err != nil {
+		return nil, fmt.Errorf("write cpu.max for %q: %w", cg.Path, err)
+	}
+
+	if p.CPUWeight > 0 {
+		weightPath := filepath.Join(cg.Path, cpuWeightFile)
+		if err := os.WriteFile(weightPath, []byte(cpuWeightLine(p)), 0o644); err != nil {
+			return nil, fmt.Errorf("write cpu.weight for %q: %w", cg.Path, err)
+		}
 	}
 
 	memPath := filepath.Join(cg.Path, memoryMaxFile)
-	memLine := fmt.Sprintf("%d", defaultMemBytes)
 
-	if err := os.WriteFile(memPath, []byte(memLine), 0o644); err != nil {
-		return fmt.Errorf("write memory.max for %q: %w", cg.Path, err)
+	if err := os.WriteFile(memPath, []byte(memoryMaxLine(p)), 0o644); err != nil {
+		return nil, fmt.Errorf("write memory.max for %q: %w", cg.Path, err)
 	}
 
-	device, err := getRootBlockDevice()
+	devices, warnings, err := ioDevices(p, sysDevBlock)
 	if err != nil {
-		return fmt.Errorf("cannot determine root block device for io.max: %w", err)
+		return nil, err
+	}
+	if err := cg.writeIOMax(p, devices); err != nil {
+		return nil, err
 	}
 
-	ioPath := filepath.Join(cg.Path, ioMaxFile)
-	ioLine := fmt.Sprintf("%s rbps=%d wbps=%d\n", device, defaultIOBps, defaultIOBps)
+	// pids.max only exists if the pids controller is enabled, which
+	// enableControllers skips on kernels without it.
+	pidsPath := filepath.Join(cg.Path, pidsMaxFile)
+	if _, err := os.Stat(pidsPath); err != nil {
+		warnings = append(warnings, cg.checkLimits(p, devices)...)
+		return append(warnings, fmt.Sprintf("%s: pids controller unavailable, number of processes not limited", pidsMaxFile)), nil
+	}
+	if err := os.WriteFile(pidsPath, []byte(pidsMaxLine(p)), 0o644); err != nil {
+		return nil, fmt.Errorf("write pids.max for %q: %w", cg.Path, err)
+	}
+
+	return append(warnings, cg.checkLimits(p, devices)...), nil
+}
+
+// ioDevices returns the disks the I/O limits of p apply to, as major:minor:
+// the disks of p.IODevices, looked up in the sysfs directory sysDevBlock, or
+// the disk backing "/" if p names none. Devices that do not exist are
+// skipped with a warning rather than failing the job.
+func ioDevices(p ResourceProfile, sysDevBlock string) ([]string, []string, error) {
+	if len(p.IODevices) == 0 {
+		device, err := getRootBlockDevice()
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot determine root block device for io.max: %w", err)
+		}
+		return []string{device}, nil, nil
+	}
 
-	if err := os.WriteFile(ioPath, []byte(ioLine), 0o644); err != nil {
+	var devices, warnings []string
+	for _, dev := range p.IODevices {
+		major, minor, err := parseDevice(dev)
+		if err != nil {
+			return nil, nil, err
+		}
+		disk, err := diskDevice(sysDevBlock, major, minor)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: device %s skipped, I/O on it not limited: %v", ioMaxFile, dev, err))
+			continue
+		}
+		if !slices.Contains(devices, disk) {
+			devices = append(devices, disk)
+		}
+	}
+	return devices, warnings, nil
+}
+
+// parseDevice parses a block device given as major:minor, e.g. "8:0".
+func parseDevice(dev string) (uint32, uint32, error) {
+	majStr, minStr, ok := strings.Cut(dev, ":")
+	major, majErr := strconv.ParseUint(majStr, 10, 32)
+	minor, minErr := strconv.ParseUint(minStr, 10, 32)
+	if !ok || majErr != nil || minErr != nil {
+		return 0, 0, fmt.Errorf("%w: I/O device %q is not major:minor", ErrInvalidSpec, dev)
+	}
+	return uint32(major), uint32(minor), nil
+}
+
+// writeIOMax sets the I/O limits of p on each of devices. The kernel takes
+// one device per write to io.max, so each gets a write of its own.
+func (cg *cgroupv2) writeIOMax(p ResourceProfile, devices []string) error {
+	ioPath := filepath.Join(cg.Path, ioMaxFile)
+	f, err := os.OpenFile(ioPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
 		return fmt.Errorf("write io.max for %q: %w", cg.Path, err)
 	}
+	defer f.Close()
 
+	for _, device := range devices {
+		if _, err := f.WriteString(ioMaxLine(device, p)); err != nil {
+			return fmt.Errorf("write io.max for %q: %w", cg.Path, err)
+		}
+	}
 	return nil
 }
 
-// getRootBlockDevice returns major:minor of block device backing "/".
+// cpuMaxLine returns the cpu.max quota capping the job at p.CPUPercent of
+// one CPU per 100ms period, or no cap if p.CPUPercent is zero.
+func cpuMaxLine(p ResourceProfile) string {
+	if p.CPUPercent == 0 {
+		return "max 100000"
+	}
+	return fmt.Sprintf("%d 100000", p.CPUPercent*1000)
+}
+
+func cpuWeightLine(p ResourceProfile) string {
+	return fmt.Sprintf("%d", p.CPUWeight)
+}
+
+func memoryMaxLine(p ResourceProfile) string {
+	return fmt.Sprintf("%d", p.MemoryBytes)
+}
+
+// ioMaxLine returns the io.max line setting the I/O limits of p on device.
+// Zero limits are written as "max", i.e. unlimited.
+func ioMaxLine(device string, p ResourceProfile) string {
+	limits := p.ioLimits()
+	return fmt.Sprintf("%s rbps=%s wbps=%s riops=%s wiops=%s\n", device,
+		ioLimit(limits["rbps"]), ioLimit(limits["wbps"]), ioLimit(limits["riops"]), ioLimit(limits["wiops"]))
+}
+
+// ioLimit formats an io.max limit, 0 meaning unlimited.
+func ioLimit(v uint64) string {
+	if v == 0 {
+		return "max"
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+func pidsMaxLine(p ResourceProfile) string {
+	return fmt.Sprintf("%d", p.MaxPIDs)
+}
+
+// checkLimits reads back the limits of p, with the I/O limits set on devices,
+// and describes each one the kernel applied differently or that cannot be read.
+func (cg *cgroupv2) checkLimits(p ResourceProfile, devices []string) []string {
+	var warnings []string
+	check := func(file, requested string, applied func() (string, error)) {
+		got, err := applied()
+		switch {
+		case err != nil:
+			warnings = append(warnings, fmt.Sprintf("%s: cannot verify %q: %v", file, requested, err))
+		case got != requested:
+			warnings = append(warnings, fmt.Sprintf("%s: requested %q, kernel applied %q", file, requested, got))
+		}
+	}
+
+	check(cpuMaxFile, cpuMaxLine(p), func() (string, error) {
+		return readTrimmed(filepath.Join(cg.Path, cpuMaxFile))
+	})
+	if p.CPUWeight > 0 {
+		check(cpuWeightFile, cpuWeightLine(p), func() (string, error) {
+			return readTrimmed(filepath.Join(cg.Path, cpuWeightFile))
+		})
+	}
+	check(memoryMaxFile, memoryMaxLine(p), func() (string, error) {
+		return readTrimmed(filepath.Join(cg.Path, memoryMaxFile))
+	})
+	limits := p.ioLimits()
+	for _, device := range devices {
+		for _, key := range ioMaxKeys {
+			check(ioMaxFile, fmt.Sprintf("%s %s=%s", device, key, ioLimit(limits[key])), func() (string, error) {
+				limit, err := readIOMax(filepath.Join(cg.Path, ioMaxFile), device, key)
+				return device + " " + key + "=" + limit, err
+			})
+		}
+	}
+	// Without the pids controller there is no limit to check.
+	if _, err := os.Stat(filepath.Join(cg.Path, pidsMaxFile)); err == nil {
+		check(pidsMaxFile, pidsMaxLine(p), func() (string, error) {
+			return readTrimmed(filepath.Join(cg.Path, pidsMaxFile))
+		})
+	}
+	return warnings
+}
+
+// readTrimmed returns the contents of the file at path without surrounding whitespace.
+func readTrimmed(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %q: %w", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readIOMax returns the value of the limit key, e.g. "rbps", that io.max
+// holds for device. The kernel omits devices without limits and reports unset
+// limits as "max".
+func readIOMax(path, device, key string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %q: %w", path, err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != device {
+			continue
+		}
+		for _, kv := range fields[1:] {
+			if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+				return v, nil
+			}
+		}
+	}
+	return "max", nil
+}
+
+// sysDevBlock links each block device's major:minor to its sysfs directory.
+const sysDevBlock = "/sys/dev/block"
+
+// getRootBlockDevice returns major:minor of the disk backing "/". io.max only
+// accepts whole disks, so a partition is resolved to the disk containing it.
 func getRootBlockDevice() (string, error) {
 	cmd := exec.Command("findmnt", "-no", "SOURCE", "/")
 	out, err := cmd.Output()
@@ -137,27 +628,44 @@ func getRootBlockDevice() (string, error) {
 		return "", fmt.Errorf("empty device from findmnt")
 	}
 
-	base := dev
-	if strings.HasPrefix(dev, "/dev/") {
-		base = strings.TrimRightFunc(dev, func(r rune) bool {
-			return r >= '0' && r <= '9'
-		})
-	}
-
-	st, err := os.Stat(base)
+	st, err := os.Stat(dev)
 	if err != nil {
-		return "", fmt.Errorf("stat failed for %q: %w", base, err)
+		return "", fmt.Errorf("stat failed for %q: %w", dev, err)
 	}
 
 	stat, ok := st.Sys().(*syscall.Stat_t)
-	if !ok {
-		return "", fmt.Errorf("unexpected stat type for %q", base)
+	if !ok || st.Mode()&os.ModeDevice == 0 {
+		return "", fmt.Errorf("%q is not a block device", dev)
+	}
+
+	return diskDevice(sysDevBlock, unix.Major(stat.Rdev), unix.Minor(stat.Rdev))
+}
+
+// diskDevice returns major:minor of the disk for the block device major:minor,
+// looked up in the sysfs directory sysDevBlock. A partition's sysfs directory
+// has a "partition" file and lives in the directory of its disk, e.g.
+// .../block/nvme0n1/nvme0n1p1. Other devices, such as whole disks and
+// device-mapper targets, are returned as is.
+func diskDevice(sysDevBlock string, major, minor uint32) (string, error) {
+	majMin := fmt.Sprintf("%d:%d", major, minor)
+
+	dir, err := filepath.EvalSymlinks(filepath.Join(sysDevBlock, majMin))
+	if err != nil {
+		return "", fmt.Errorf("resolve sysfs entry of block device %s: %w", majMin, err)
 	}
 
-	major := unix.Major(stat.Rdev)
-	minor := unix.Minor(stat.Rdev)
+	if _, err := os.Stat(filepath.Join(dir, "partition")); err != nil {
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("check partition of block device %s: %w", majMin, err)
+		}
+		return majMin, nil
+	}
 
-	return fmt.Sprintf("%d:%d", major, minor), nil
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(dir), "dev"))
+	if err != nil {
+		return "", fmt.Errorf("read disk of partition %s: %w", majMin, err)
+	}
+	return strings.TrimSpace(string(data)), nil
 }
 
 // openFD opens the cgroup directory and returns its FD.
@@ -169,29 +677,179 @@ func (cg *cgroupv2) openFD() (int, error) {
 	return fd, nil
 }
 
-// delete removes this cgroup by writing "1" to cgroup.kill and polling until
-// the kernel deletes the directory. A missing cgroup.kill file is
-// treated as normal because the kernel may remove the cgroup immediately.
-func (cg *cgroupv2) delete() error {
-	killPath := filepath.Join(cg.Path, cgroupKillFile)
+// procs returns the PIDs of the processes that are members of this cgroup.
+func (cg *cgroupv2) procs() ([]int, error) {
+	data, err := os.ReadFile(filepath.Join(cg.Path, cgroupProcsFile))
+	if err != nil {
+		return nil, fmt.Errorf("read cgroup.procs for %q: %w", cg.Path, err)
+	}
+
+	var pids []int
+	for _, field := range strings.Fields(string(data)) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("parse cgroup.procs for %q: %w", cg.Path, err)
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// memoryPeak returns the peak memory usage in bytes recorded by the kernel.
+// memory.peak requires Linux 5.19 or later.
+func (cg *cgroupv2) memoryPeak() (uint64, error) {
+	return readUintFile(filepath.Join(cg.Path, memoryPeakFile))
+}
+
+// memoryCurrent returns the current memory usage in bytes.
+func (cg *cgroupv2) memoryCurrent() (uint64, error) {
+	return readUintFile(filepath.Join(cg.Path, memoryCurrentFile))
+}
+
+// Stats is a snapshot of the resources used by a job.
+type Stats struct {
+	// CPUUsage is the total CPU time consumed, the sum of UserCPU and SystemCPU.
+	CPUUsage  time.Duration
+	UserCPU   time.Duration
+	SystemCPU time.Duration
+
+	// MemoryCurrent is the memory in use in bytes.
+	MemoryCurrent uint64
+	// MemoryPeak is the highest memory usage in bytes. It is zero on kernels
+	// older than 5.19, which do not record it.
+	MemoryPeak uint64
+}
 
-	if err := os.WriteFile(killPath, []byte("1\n"), 0644); err != nil && !os.IsNotExist(err) {
+// stats reads the CPU and memory usage of this cgroup.
+func (cg *cgroupv2) stats() (Stats, error) {
+	var st Stats
+
+	path := filepath.Join(cg.Path, cpuStatFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Stats{}, fmt.Errorf("read %q: %w", path, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		usec, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return Stats{}, fmt.Errorf("parse %q: %w", path, err)
+		}
+		switch key {
+		case "usage_usec":
+			st.CPUUsage = time.Duration(usec) * time.Microsecond
+		case "user_usec":
+			st.UserCPU = time.Duration(usec) * time.Microsecond
+		case "system_usec":
+			st.SystemCPU = time.Duration(usec) * time.Microsecond
+		}
+	}
+
+	if st.MemoryCurrent, err = cg.memoryCurrent(); err != nil {
+		return Stats{}, err
+	}
+	// memory.peak is optional, see memoryPeak.
+	st.MemoryPeak, _ = cg.memoryPeak()
+
+	return st, nil
+}
+
+// oomKills returns how many processes in this cgroup the OOM killer has killed,
+// read from the oom_kill counter in memory.events.
+func (cg *cgroupv2) oomKills() (uint64, error) {
+	path := filepath.Join(cg.Path, memoryEventsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("read %q: %w", path, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok || key != "oom_kill" {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse %q: %w", path, err)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("no oom_kill counter in %q", path)
+}
+
+// readUintFile reads a cgroup interface file holding a single unsigned integer.
+func readUintFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("read %q: %w", path, err)
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %q: %w", path, err)
+	}
+	return v, nil
+}
+
+// kill sends SIGKILL to every process in this cgroup and its descendants by
+// writing "1" to cgroup.kill. Unlike signalling a process group, this also
+// reaches processes that left the group, such as daemons. It does not wait
+// for the processes to exit.
+func (cg *cgroupv2) kill() error {
+	if err := os.WriteFile(filepath.Join(cg.Path, cgroupKillFile), []byte("1\n"), 0644); err != nil {
 		return fmt.Errorf("write cgroup.kill: %w", err)
 	}
+	return nil
+}
+
+// delete removes this cgroup by killing its processes, waiting until
+// cgroup.events reports the cgroup unpopulated, i.e. all its processes have
+// exited, and removing the directory. A missing cgroup.kill file is treated
+// as normal because the kernel may remove the cgroup immediately. If the
+// cgroup cannot be removed in time, delete returns a *CgroupDeleteError.
+func (cg *cgroupv2) delete() error {
+	if err := cg.kill(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
 
-	timeout := time.After(1 * time.Second)
-	tick := time.NewTicker(50 * time.Millisecond)
+	timeout := cmp.Or(cg.deletion.timeout, defaultCgroupDeleteTimeout)
+	deadline := time.After(timeout)
+	tick := time.NewTicker(cmp.Or(cg.deletion.poll, defaultCgroupDeletePoll))
 	defer tick.Stop()
 
 	for {
-		select {
-		case <-timeout:
-			return fmt.Errorf("timeout deleting cgroup %q", cg.Path)
-		case <-tick.C:
-			err := os.RemoveAll(cg.Path)
-			if err == nil || os.IsNotExist(err) {
+		// Removing the directory fails while processes are still exiting,
+		// so it is only tried once they are gone.
+		populated, err := cg.populated()
+		var removeErr error
+		if err != nil || !populated {
+			removeErr = os.RemoveAll(cg.Path)
+			if removeErr == nil || os.IsNotExist(removeErr) {
 				return nil
 			}
 		}
+
+		select {
+		case <-deadline:
+			return &CgroupDeleteError{Path: cg.Path, Timeout: timeout, Dying: populated, Err: removeErr}
+		case <-tick.C:
+		}
+	}
+}
+
+// populated reports whether the cgroup or any of its descendants has live
+// processes, as reported by cgroup.events.
+func (cg *cgroupv2) populated() (bool, error) {
+	path := filepath.Join(cg.Path, cgroupEventsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("read %q: %w", path, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, " "); ok && key == "populated" {
+			return value == "1", nil
+		}
 	}
+	return false, fmt.Errorf("no populated field in %q", path)
 }
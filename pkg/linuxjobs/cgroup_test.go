@@ -1,16 +1,23 @@
 package linuxjobs
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/sys/unix"
 )
 
 func TestNewCGroupV2_CreatesDirectory(t *testing.T) {
 
-	cg, err := newCGroupV2("job1", t.TempDir())
+	cg, err := newCGroupV2("job1", t.TempDir(), "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -20,6 +27,199 @@ func TestNewCGroupV2_CreatesDirectory(t *testing.T) {
 	}
 }
 
+func TestNewCGroupV2_NestsInOwnerCgroup(t *testing.T) {
+	root := t.TempDir()
+
+	cg, err := newCGroupV2("job1", root, "spiffe://example.org/ns/a.b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ownerPath := filepath.Join(root, "lpaas", "spiffe:%2F%2Fexample%2Eorg%2Fns%2Fa%2Eb")
+	if cg.Path != filepath.Join(ownerPath, "job1") {
+		t.Fatalf("expected the job cgroup in the owner cgroup %s, got %s", ownerPath, cg.Path)
+	}
+	if _, err := os.Stat(cg.Path); err != nil {
+		t.Fatalf("expected directory created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ownerPath, "cgroup.subtree_control")); err != nil {
+		t.Fatalf("expected controllers enabled on the owner cgroup: %v", err)
+	}
+}
+
+func TestValidateCgroupRoot(t *testing.T) {
+	if err := ValidateCgroupRoot(t.TempDir()); err == nil {
+		t.Fatalf("expected error for a directory outside cgroupfs")
+	}
+	if err := ValidateCgroupRoot(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("expected error for a missing directory")
+	}
+
+	requireCgroupV2(t)
+	if err := ValidateCgroupRoot(""); err != nil {
+		t.Fatalf("unexpected error for the default root: %v", err)
+	}
+}
+
+func TestCheckCgroupDelegation(t *testing.T) {
+	// cgroupDir simulates a cgroup directory holding files.
+	cgroupDir := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatalf("setup failed: %v", err)
+			}
+		}
+		return dir
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string // substrings of the error, none if empty
+	}{
+		{
+			name:  "root with all controllers",
+			files: map[string]string{"cgroup.controllers": "cpuset cpu io memory pids\n", "cgroup.subtree_control": ""},
+		},
+		{
+			name:  "missing controllers",
+			files: map[string]string{"cgroup.controllers": "cpu memory\n", "cgroup.subtree_control": ""},
+			want:  []string{"controllers io, pids are not available", "Delegate=yes"},
+		},
+		{
+			name:  "no controllers file",
+			files: map[string]string{"cgroup.subtree_control": ""},
+			want:  []string{"cannot list the available controllers"},
+		},
+		{
+			name:  "no subtree_control file",
+			files: map[string]string{"cgroup.controllers": "cpu io memory pids\n"},
+			want:  []string{"cgroup.subtree_control is not writable"},
+		},
+		{
+			name: "delegated cgroup with processes",
+			files: map[string]string{
+				"cgroup.controllers":     "cpu io memory pids\n",
+				"cgroup.subtree_control": "cpu\n",
+				"cgroup.type":            "domain\n",
+				"cgroup.procs":           "1234\n",
+			},
+			want: []string{"while processes run in", "leaf cgroup"},
+		},
+		{
+			name: "delegated cgroup with controllers enabled",
+			files: map[string]string{
+				"cgroup.controllers":     "cpu io memory pids\n",
+				"cgroup.subtree_control": "cpu io memory pids\n",
+				"cgroup.type":            "domain\n",
+				"cgroup.procs":           "1234\n",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkCgroupDelegation(cgroupDir(t, tc.files))
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tc.want)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Fatalf("expected %q in error:\n%v", w, err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyCgroupRoot_NotCgroupV2(t *testing.T) {
+	if err := VerifyCgroupRoot(t.TempDir()); err == nil || !strings.Contains(err.Error(), "not on a cgroup v2 mount") {
+		t.Fatalf("expected an error for a directory outside cgroupfs, got %v", err)
+	}
+}
+
+func TestHandleOrphanedCgroups(t *testing.T) {
+	root := t.TempDir()
+	lpaasRoot := filepath.Join(root, "lpaas")
+	for _, dir := range []string{"job-a", "job-b"} {
+		if err := os.MkdirAll(filepath.Join(lpaasRoot, dir), 0o755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(lpaasRoot, "cgroup.subtree_control"), nil, 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	want := []string{filepath.Join(lpaasRoot, "job-a"), filepath.Join(lpaasRoot, "job-b")}
+
+	orphans, err := HandleOrphanedCgroups(root, KeepOrphans)
+	if err != nil || !slices.Equal(orphans, want) {
+		t.Fatalf("expected orphans %v, got %v (err=%v)", want, orphans, err)
+	}
+	for _, path := range want {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("orphan %s must be kept: %v", path, err)
+		}
+	}
+
+	orphans, err = HandleOrphanedCgroups(root, RemoveOrphans)
+	if err != nil || !slices.Equal(orphans, want) {
+		t.Fatalf("expected orphans %v, got %v (err=%v)", want, orphans, err)
+	}
+	for _, path := range want {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("orphan %s must be removed, got %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(lpaasRoot, "cgroup.subtree_control")); err != nil {
+		t.Fatalf("cgroup interface files must be kept: %v", err)
+	}
+}
+
+func TestHandleOrphanedCgroups_OwnerCgroups(t *testing.T) {
+	root := t.TempDir()
+	lpaasRoot := filepath.Join(root, "lpaas")
+	for _, dir := range []string{"alice/job-a", "job-b", "job-runner/job-c", "bob"} {
+		if err := os.MkdirAll(filepath.Join(lpaasRoot, dir), 0o755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	want := []string{
+		filepath.Join(lpaasRoot, "alice", "job-a"),
+		filepath.Join(lpaasRoot, "job-b"),
+		filepath.Join(lpaasRoot, "job-runner", "job-c"),
+	}
+
+	orphans, err := HandleOrphanedCgroups(root, KeepOrphans)
+	if err != nil || !slices.Equal(orphans, want) {
+		t.Fatalf("expected orphans %v, got %v (err=%v)", want, orphans, err)
+	}
+
+	orphans, err = HandleOrphanedCgroups(root, RemoveOrphans)
+	if err != nil || !slices.Equal(orphans, want) {
+		t.Fatalf("expected orphans %v, got %v (err=%v)", want, orphans, err)
+	}
+	for _, dir := range []string{"alice", "job-b", "job-runner", "bob"} {
+		if _, err := os.Stat(filepath.Join(lpaasRoot, dir)); !os.IsNotExist(err) {
+			t.Fatalf("cgroup %s must be removed, got %v", dir, err)
+		}
+	}
+}
+
+func TestHandleOrphanedCgroups_NoJobCgroups(t *testing.T) {
+	orphans, err := HandleOrphanedCgroups(t.TempDir(), RemoveOrphans)
+	if err != nil || len(orphans) != 0 {
+		t.Fatalf("expected no orphans, got %v (err=%v)", orphans, err)
+	}
+}
+
 func TestEnableControllers_HappyPath(t *testing.T) {
 	tmp := t.TempDir()
 
@@ -42,21 +242,42 @@ func TestEnableControllers_HappyPath(t *testing.T) {
 	}
 }
 
+func TestEnableControllers_Pids(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "cgroup.controllers"), []byte("cpuset cpu io memory pids\n"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	if err := enableControllers(tmp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The pids controller is enabled last, if available.
+	data, _ := os.ReadFile(filepath.Join(tmp, "cgroup.subtree_control"))
+	if string(data) != "+pids\n" {
+		t.Fatalf("unexpected subtree_control: %q", data)
+	}
+}
+
 func TestSetLimits_HappyPath(t *testing.T) {
-	cg, err := newCGroupV2("job1", t.TempDir())
+	cg, err := newCGroupV2("job1", t.TempDir(), "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	for _, f := range []string{cpuMaxFile, memoryMaxFile, ioMaxFile} {
+	for _, f := range []string{cpuMaxFile, memoryMaxFile, ioMaxFile, pidsMaxFile} {
 		if err := os.WriteFile(filepath.Join(cg.Path, f), nil, 0644); err != nil {
 			t.Fatalf("setup failed: %v", err)
 		}
 	}
 
-	if err := cg.setLimits(); err != nil {
+	warnings, err := cg.setLimits(defaultResourceProfile())
+	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if len(warnings) != 0 {
+		t.Fatalf("expected limits to read back as written, got %v", warnings)
+	}
 
 	if b, _ := os.ReadFile(filepath.Join(cg.Path, cpuMaxFile)); len(b) == 0 {
 		t.Fatalf("cpu.max not written")
@@ -67,10 +288,31 @@ func TestSetLimits_HappyPath(t *testing.T) {
 	if b, _ := os.ReadFile(filepath.Join(cg.Path, ioMaxFile)); len(b) == 0 {
 		t.Fatalf("io.max not written")
 	}
+	if b, _ := os.ReadFile(filepath.Join(cg.Path, pidsMaxFile)); string(b) != "512" {
+		t.Fatalf("expected pids.max 512, got %q", b)
+	}
+}
+
+func TestSetLimits_WithoutPidsController(t *testing.T) {
+	cg, err := newCGroupV2("job1", t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	warnings, err := cg.setLimits(defaultResourceProfile())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "pids.max: pids controller unavailable") {
+		t.Fatalf("expected a warning that processes are not limited, got %q", warnings)
+	}
+	if _, err := os.Stat(filepath.Join(cg.Path, pidsMaxFile)); !os.IsNotExist(err) {
+		t.Fatalf("pids.max must not be created without the controller, got %v", err)
+	}
 }
 
 func TestSetLimits_WritesFilesEvenIfMissing(t *testing.T) {
-	cg, err := newCGroupV2("job1", t.TempDir())
+	cg, err := newCGroupV2("job1", t.TempDir(), "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -84,7 +326,7 @@ func TestSetLimits_WritesFilesEvenIfMissing(t *testing.T) {
 	}
 
 	// Should succeed because WriteFile creates missing files
-	if err := cg.setLimits(); err != nil {
+	if _, err := cg.setLimits(defaultResourceProfile()); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -94,6 +336,221 @@ func TestSetLimits_WritesFilesEvenIfMissing(t *testing.T) {
 	}
 }
 
+func TestSetLimits_CPUModes(t *testing.T) {
+	tests := []struct {
+		name       string
+		p          ResourceProfile
+		wantMax    string
+		wantWeight string // empty if cpu.weight must not be written
+	}{
+		{"cap", ResourceProfile{CPUPercent: 150}, "150000 100000", ""},
+		{"weight", ResourceProfile{CPUWeight: 500}, "max 100000", "500"},
+		{"cap and weight", ResourceProfile{CPUPercent: 50, CPUWeight: 10000}, "50000 100000", "10000"},
+	}
+	for _, tt := range tests {
+		cg, err := newCGroupV2("job1", t.TempDir(), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		p := tt.p
+		p.MemoryBytes = defaultMemBytes
+
+		warnings, err := cg.setLimits(p)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if slices.ContainsFunc(warnings, func(w string) bool { return strings.HasPrefix(w, "cpu.") }) {
+			t.Fatalf("%s: expected the CPU limits to read back as written, got %q", tt.name, warnings)
+		}
+		if b, _ := os.ReadFile(filepath.Join(cg.Path, cpuMaxFile)); string(b) != tt.wantMax {
+			t.Fatalf("%s: expected cpu.max %q, got %q", tt.name, tt.wantMax, b)
+		}
+		b, err := os.ReadFile(filepath.Join(cg.Path, cpuWeightFile))
+		if tt.wantWeight == "" {
+			if !os.IsNotExist(err) {
+				t.Fatalf("%s: cpu.weight must not be written, got %q (err=%v)", tt.name, b, err)
+			}
+		} else if string(b) != tt.wantWeight {
+			t.Fatalf("%s: expected cpu.weight %q, got %q", tt.name, tt.wantWeight, b)
+		}
+	}
+}
+
+func TestCheckLimits_ReportsDiscrepancies(t *testing.T) {
+	cg := &cgroupv2{Path: t.TempDir()}
+	p := ResourceProfile{CPUPercent: 50, MemoryBytes: 1000000, IOBytesPerSec: 1048576}
+
+	// What the kernel reports after rounding memory down to whole pages and
+	// accepting only the read limit.
+	files := map[string]string{
+		cpuMaxFile:    "50000 100000\n",
+		memoryMaxFile: "999424\n",
+		ioMaxFile:     "8:0 rbps=1048576 wbps=max riops=max wiops=max\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(cg.Path, name), []byte(data), 0o644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	want := []string{
+		`memory.max: requested "1000000", kernel applied "999424"`,
+		`io.max: requested "8:0 wbps=1048576", kernel applied "8:0 wbps=max"`,
+	}
+	if got := cg.checkLimits(p, []string{"8:0"}); !slices.Equal(got, want) {
+		t.Fatalf("expected warnings %q, got %q", want, got)
+	}
+
+	// A device missing from io.max has no limits at all.
+	if got := cg.checkLimits(p, []string{"259:0"}); len(got) != 3 {
+		t.Fatalf("expected memory and both io warnings, got %q", got)
+	}
+}
+
+func TestIOMaxLine(t *testing.T) {
+	tests := []struct {
+		name string
+		p    ResourceProfile
+		want string
+	}{
+		{"shared bandwidth", ResourceProfile{IOBytesPerSec: 1048576}, "8:0 rbps=1048576 wbps=1048576 riops=max wiops=max\n"},
+		{"separate bandwidth", ResourceProfile{IOBytesPerSec: 1048576, IOWriteBytesPerSec: 4096}, "8:0 rbps=1048576 wbps=4096 riops=max wiops=max\n"},
+		{"iops", ResourceProfile{IOReadIOPS: 100, IOWriteIOPS: 50}, "8:0 rbps=max wbps=max riops=100 wiops=50\n"},
+	}
+	for _, tt := range tests {
+		if got := ioMaxLine("8:0", tt.p); got != tt.want {
+			t.Fatalf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWriteIOMax_OneLinePerDevice(t *testing.T) {
+	cg := &cgroupv2{Path: t.TempDir()}
+	p := ResourceProfile{IOReadBytesPerSec: 2048, IOWriteBytesPerSec: 1024, IOWriteIOPS: 10}
+
+	if err := cg.writeIOMax(p, []string{"8:0", "259:0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(cg.Path, ioMaxFile))
+	want := "8:0 rbps=2048 wbps=1024 riops=max wiops=10\n259:0 rbps=2048 wbps=1024 riops=max wiops=10\n"
+	if string(data) != want {
+		t.Fatalf("expected %q, got %q", want, data)
+	}
+	if got := cg.checkLimits(p, []string{"8:0", "259:0"}); len(got) != 2 {
+		// Only cpu.max and memory.max are missing.
+		t.Fatalf("expected the io.max limits to read back as written, got %q", got)
+	}
+}
+
+func TestIODevices_SkipsMissingDevices(t *testing.T) {
+	root := t.TempDir()
+	fakeSysBlock(t, root, "pci/block/nvme0n1", "259:0", false)
+	devBlock := fakeSysBlock(t, root, "pci/block/nvme0n1/nvme0n1p1", "259:1", true)
+
+	p := ResourceProfile{IODevices: []string{"259:1", "8:16", "259:0"}}
+	devices, warnings, err := ioDevices(p, devBlock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The partition resolves to its disk, which is only limited once.
+	if !slices.Equal(devices, []string{"259:0"}) {
+		t.Fatalf("expected only the existing disk, got %v", devices)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "device 8:16 skipped") {
+		t.Fatalf("expected a warning for the missing device, got %q", warnings)
+	}
+
+	if _, _, err := ioDevices(ResourceProfile{IODevices: []string{"sda"}}, devBlock); !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec for a malformed device, got %v", err)
+	}
+}
+
+// fakeSysBlock creates a sysfs-like block device directory at devices/path
+// with the given dev number, linked from dev/block/<dev>. It returns the
+// dev/block directory.
+func fakeSysBlock(t *testing.T, root, path, dev string, partition bool) string {
+	t.Helper()
+
+	dir := filepath.Join(root, "devices", path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dev"), []byte(dev+"\n"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if partition {
+		if err := os.WriteFile(filepath.Join(dir, "partition"), []byte("1\n"), 0o644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	devBlock := filepath.Join(root, "dev", "block")
+	if err := os.MkdirAll(devBlock, 0o755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.Symlink(filepath.Join("..", "..", "devices", path), filepath.Join(devBlock, dev)); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	return devBlock
+}
+
+func TestDiskDevice_ResolvesPartitionsToDisk(t *testing.T) {
+	tests := []struct {
+		name         string
+		disk, part   string // sysfs paths below devices/
+		diskDev      string
+		major, minor uint32
+	}{
+		{"sdaN", "pci0000:00/0000:00:10.0/block/sda", "pci0000:00/0000:00:10.0/block/sda/sda2", "8:0", 8, 2},
+		{"nvme0n1pN", "pci0000:00/0000:00:04.0/nvme/nvme0/nvme0n1", "pci0000:00/0000:00:04.0/nvme/nvme0/nvme0n1/nvme0n1p1", "259:0", 259, 1},
+		{"vdaN", "pci0000:00/0000:00:05.0/virtio1/block/vda", "pci0000:00/0000:00:05.0/virtio1/block/vda/vda15", "254:0", 254, 15},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		fakeSysBlock(t, root, tt.disk, tt.diskDev, false)
+		devBlock := fakeSysBlock(t, root, tt.part, fmt.Sprintf("%d:%d", tt.major, tt.minor), true)
+
+		got, err := diskDevice(devBlock, tt.major, tt.minor)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.diskDev {
+			t.Fatalf("%s: expected disk %s, got %s", tt.name, tt.diskDev, got)
+		}
+	}
+}
+
+func TestDiskDevice_KeepsWholeDevices(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		major, minor uint32
+	}{
+		{"dm-N", "virtual/block/dm-3", 253, 3},
+		{"nvme0n1", "pci0000:00/0000:00:04.0/nvme/nvme0/nvme0n1", 259, 0},
+	}
+
+	for _, tt := range tests {
+		dev := fmt.Sprintf("%d:%d", tt.major, tt.minor)
+		devBlock := fakeSysBlock(t, t.TempDir(), tt.path, dev, false)
+
+		got, err := diskDevice(devBlock, tt.major, tt.minor)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != dev {
+			t.Fatalf("%s: expected %s, got %s", tt.name, dev, got)
+		}
+	}
+}
+
+func TestDiskDevice_UnknownDevice(t *testing.T) {
+	if _, err := diskDevice(t.TempDir(), 8, 1); err == nil {
+		t.Fatalf("expected error for a device missing from sysfs")
+	}
+}
+
 func TestOpenFD_HappyPath(t *testing.T) {
 	tmp := t.TempDir()
 	cg := &cgroupv2{Path: tmp}
@@ -142,3 +599,215 @@ func TestDelete_IgnoresMissingCgroupKillFile(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestDelete_WaitsForPopulatedCgroup(t *testing.T) {
+	tmp := t.TempDir()
+	cg := &cgroupv2{Path: tmp, deletion: cgroupDeletion{timeout: 100 * time.Millisecond, poll: 10 * time.Millisecond}}
+
+	if err := os.WriteFile(filepath.Join(tmp, cgroupEventsFile), []byte("populated 1\nfrozen 0\n"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	err := cg.delete()
+	var deleteErr *CgroupDeleteError
+	if !errors.As(err, &deleteErr) || !deleteErr.Dying {
+		t.Fatalf("expected a CgroupDeleteError for a dying cgroup, got %v", err)
+	}
+	if _, err := os.Stat(tmp); err != nil {
+		t.Fatalf("expected populated cgroup kept, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmp, cgroupEventsFile), []byte("populated 0\nfrozen 0\n"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := cg.delete(); err != nil {
+		t.Fatalf("unexpected error once unpopulated: %v", err)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Fatalf("expected directory removed")
+	}
+}
+
+func TestProcs_ParsesPIDs(t *testing.T) {
+	tmp := t.TempDir()
+	cg := &cgroupv2{Path: tmp}
+
+	if err := os.WriteFile(filepath.Join(tmp, cgroupProcsFile), []byte("12\n345\n"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	pids, err := cg.procs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pids) != 2 || pids[0] != 12 || pids[1] != 345 {
+		t.Fatalf("unexpected pids: %v", pids)
+	}
+}
+
+func TestStartJob_ConfirmRunningFindsPIDInCgroup(t *testing.T) {
+	requireCgroupV2(t)
+
+	jm, err := NewJobManager()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	id, err := jm.StartJobWithSpec(context.Background(), JobSpec{Command: "sleep", Args: []string{"1"}, ConfirmRunning: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer jm.StopJob(id)
+
+	j := jm.jobs[id]
+	pids, err := j.cgroup.procs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(pids, j.cmd.Process.Pid) {
+		t.Fatalf("expected pid %d in cgroup.procs, got %v", j.cmd.Process.Pid, pids)
+	}
+}
+
+// requireCgroupV2 skips tests that need a real, writable cgroup v2 hierarchy.
+func requireCgroupV2(t *testing.T) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		t.Skip("requires cgroup v2 mounted at /sys/fs/cgroup")
+	}
+}
+
+func TestMemoryPeak_ReadsValue(t *testing.T) {
+	tmp := t.TempDir()
+	cg := &cgroupv2{Path: tmp}
+
+	if err := os.WriteFile(filepath.Join(tmp, memoryPeakFile), []byte("4096\n"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	peak, err := cg.memoryPeak()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if peak != 4096 {
+		t.Fatalf("expected 4096, got %d", peak)
+	}
+
+	if _, err := cg.memoryCurrent(); err == nil {
+		t.Fatalf("expected error for missing memory.current")
+	}
+}
+
+func TestPeakMemory_MemoryAllocatingJob(t *testing.T) {
+	requireCgroupV2(t)
+
+	jm, err := NewJobManager(WithPeakMemory())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// tail -n keeps its whole newline-free input in memory.
+	id, err := jm.StartJob(context.Background(), "bash", "-c", "head -c 64M /dev/zero | tail -n 1 > /dev/null")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-jm.jobs[id].done
+
+	peak, ok, err := jm.PeakMemory(id)
+	if err != nil || !ok {
+		t.Fatalf("expected tracked peak memory, got ok=%v err=%v", ok, err)
+	}
+	if peak < 1024*1024 || peak > defaultMemBytes {
+		t.Fatalf("implausible peak memory %d", peak)
+	}
+}
+
+func TestMaxPIDs_CapsForkingJob(t *testing.T) {
+	requireCgroupV2(t)
+
+	jm, err := NewJobManager()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// sh gives up, and exits, once a fork fails.
+	id, err := jm.StartJobWithSpec(context.Background(), JobSpec{
+		Command:   "sh",
+		Args:      []string{"-c", "for i in 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16; do sleep 30 & done; wait"},
+		Resources: ResourceProfile{MaxPIDs: 8},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	st, err := jm.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("expected the job to fail once it hit the limit: %v", err)
+	}
+	if st.Status != "Failed" {
+		t.Fatalf("expected Failed, got %+v", st)
+	}
+
+	r, err := jm.StreamJob(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	out, _ := io.ReadAll(r)
+	if !strings.Contains(string(out), "fork") {
+		t.Fatalf("expected a failed fork in the output, got %q", out)
+	}
+}
+
+func TestStats_ParsesCgroupFiles(t *testing.T) {
+	cg := &cgroupv2{Path: t.TempDir()}
+	files := map[string]string{
+		cpuStatFile:       "usage_usec 1500000\nuser_usec 1000000\nsystem_usec 500000\nnr_periods 0\n",
+		memoryCurrentFile: "4096\n",
+		memoryPeakFile:    "8192\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(cg.Path, name), []byte(data), 0644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	st, err := cg.stats()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Stats{
+		CPUUsage:      1500 * time.Millisecond,
+		UserCPU:       time.Second,
+		SystemCPU:     500 * time.Millisecond,
+		MemoryCurrent: 4096,
+		MemoryPeak:    8192,
+	}
+	if st != want {
+		t.Fatalf("expected %+v, got %+v", want, st)
+	}
+}
+
+func TestStats_MissingCgroup(t *testing.T) {
+	cg := &cgroupv2{Path: filepath.Join(t.TempDir(), "deleted")}
+	if _, err := cg.stats(); err == nil {
+		t.Fatalf("expected error for deleted cgroup")
+	}
+}
+
+func TestOOMKills_ParsesMemoryEvents(t *testing.T) {
+	cg := &cgroupv2{Path: t.TempDir()}
+	events := "low 0\nhigh 0\nmax 12\noom 2\noom_kill 1\noom_group_kill 0\n"
+	if err := os.WriteFile(filepath.Join(cg.Path, memoryEventsFile), []byte(events), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	n, err := cg.oomKills()
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 OOM kill, got %d (err=%v)", n, err)
+	}
+}
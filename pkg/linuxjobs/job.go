@@ -1,22 +1,123 @@
 package linuxjobs
 
 import (
-	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"os"
 	"os/exec"
 	"slices"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/rohitsakala/lpaas/pkg/metrics"
 	"golang.org/x/sys/unix"
 )
 
+// ErrJobNotRunning is returned for operations that require a running job.
+var ErrJobNotRunning = errors.New("job not running")
+
+// ErrJobRunning is returned for operations that require a finished job.
+var ErrJobRunning = errors.New("job still running")
+
+// ErrStreamIdle is returned by a job's output reader when the job produced no
+// output for the manager's stream idle timeout. The job keeps running.
+var ErrStreamIdle = errors.New("no job output within stream idle timeout")
+
+// ErrOutputClosed is returned once by a job's output reader when the job closed
+// the selected output streams but keeps running, e.g. after daemonizing. No
+// more output will arrive; reading again waits for the job to finish.
+var ErrOutputClosed = errors.New("job closed its output but is still running")
+
+// ErrStatsUnavailable is returned when the resource usage of a job cannot be
+// read, e.g. because it never started.
+var ErrStatsUnavailable = errors.New("job stats unavailable")
+
+// ErrOutputTruncated is returned when the start of a job's output was discarded
+// because it exceeded the output buffer cap.
+var ErrOutputTruncated = errors.New("job output truncated")
+
+// errCleanupPending is returned by remove while the cgroup of a job that just
+// finished is still being deleted.
+var errCleanupPending = errors.New("job cgroup still being deleted")
+
 type cgroup interface {
 	delete() error
+	kill() error
 	openFD() (int, error)
+	procs() ([]int, error)
+	memoryPeak() (uint64, error)
+	memoryCurrent() (uint64, error)
+	stats() (Stats, error)
+	oomKills() (uint64, error)
+}
+
+const (
+	// confirmTimeout bounds how long start waits for a job to show up in its cgroup.
+	confirmTimeout = 1 * time.Second
+	confirmPoll    = 10 * time.Millisecond
+	// memoryPollInterval is how often memory.current is sampled when memory.peak is unavailable.
+	memoryPollInterval = 100 * time.Millisecond
+	// tailScanChunk is how much output tailOffset reads at a time.
+	tailScanChunk = 4096
+	// outputClosedGrace is how long a job must keep running after closing an
+	// output stream for it to be reported closed. Exiting closes it as well.
+	outputClosedGrace = 100 * time.Millisecond
+	// strayOutputGrace is how long a job killed without a cgroup waits for the
+	// processes that escaped the kill to close its output pipes.
+	strayOutputGrace = 100 * time.Millisecond
+)
+
+// OutputStream selects or identifies a job's output stream.
+type OutputStream int
+
+const (
+	// StreamBoth selects stdout and stderr interleaved in the order they were written.
+	StreamBoth OutputStream = iota
+	// StreamStdout selects the job's standard output.
+	StreamStdout
+	// StreamStderr selects the job's standard error.
+	StreamStderr
+)
+
+func (o OutputStream) String() string {
+	switch o {
+	case StreamStdout:
+		return "stdout"
+	case StreamStderr:
+		return "stderr"
+	default:
+		return "both"
+	}
+}
+
+// StreamOptions selects which output of a job a reader returns. The zero value
+// follows both streams from the beginning.
+type StreamOptions struct {
+	// Stream selects the output stream to return.
+	Stream OutputStream
+	// TailLines starts the reader at the last TailLines lines of the selected
+	// stream instead of the beginning, if > 0.
+	TailLines int
+	// StartOffset starts the reader at this offset in the job's output, e.g.
+	// the offset following the last byte a reconnecting client received. An
+	// offset past the end waits for the output to get there; one before the
+	// oldest retained byte starts at that byte. Ignored if TailLines is set.
+	StartOffset int
+	// NoFollow ends the reader at the output retained when it was created,
+	// instead of waiting for more output until the job finishes.
+	NoFollow bool
+	// MaxLag bounds how many bytes a reader following the output may fall
+	// behind the newest output, if > 0. Readers never slow down the job, so a
+	// reader further behind, e.g. one feeding a slow client, skips forward to
+	// MaxLag bytes before the newest output and reports the bytes it skipped
+	// with Lagged, like a lagging consumer of a broadcast channel. Ignored by
+	// readers that do not follow, i.e. of finished jobs or with NoFollow.
+	MaxLag int
 }
 
 // status represents the lifecycle state of a job.
@@ -32,6 +133,18 @@ const (
 	exited
 	// failed is when the process has failed
 	failed
+	// timedOut is when the process was killed for running past its timeout
+	timedOut
+	// oomKilled is when the process failed after the OOM killer hit the job
+	oomKilled
+	// queued is when the job waits for a free slot to start running
+	queued
+	// orphaned is when a job restored from a JobStore had not finished when
+	// the worker stopped, so its outcome is unknown
+	orphaned
+	// outputLimitExceeded is when the process was killed for writing more
+	// output than its MaxOutputBytes
+	outputLimitExceeded
 )
 
 func (s status) String() string {
@@ -44,207 +157,1192 @@ func (s status) String() string {
 		return "Exited"
 	case failed:
 		return "Failed"
+	case timedOut:
+		return "TimedOut"
+	case oomKilled:
+		return "OOMKilled"
+	case queued:
+		return "Queued"
+	case orphaned:
+		return "Orphaned"
+	case outputLimitExceeded:
+		return "OutputLimitExceeded"
 	default:
 		return "Unknown"
 	}
 }
 
+// parseStatus returns the status whose String is s, or unknown.
+func parseStatus(s string) status {
+	for st := running; st <= outputLimitExceeded; st++ {
+		if st.String() == s {
+			return st
+		}
+	}
+	return unknown
+}
+
+// terminal reports whether a job in this status has finished.
+func (s status) terminal() bool {
+	return s == exited || s == failed || s == stopped || s == timedOut || s == oomKilled || s == orphaned || s == outputLimitExceeded
+}
+
 // job represents a single Linux process managed by the system.
 type job struct {
 	mu sync.Mutex
 
-	ID         string
-	command    string
-	args       []string
-	cmd        *exec.Cmd
-	cleanupErr error
+	ID             string
+	command        string
+	path           string // absolute path of command, resolved when the job was created
+	args           []string
+	argv0          string // overrides argv[0] when set
+	env            []string
+	workingDir     string
+	tempDir        string // private temp dir, removed with the job
+	workInTempDir  bool
+	nofileLimit    uint64
+	bindMounts     []BindMount
+	confirmRunning bool
+	timeout        time.Duration // kill the job after running this long, if > 0
+	maxOutput      uint64        // kill the job once it wrote more output than this, if > 0
+
+	// killOnDisconnect stops the job once its last streaming reader has been
+	// closed for disconnectGrace without a new one attaching.
+	killOnDisconnect bool
+	disconnectGrace  time.Duration
+	stopGrace        time.Duration
+	streamIdle       time.Duration // end streams without output for this long, if > 0
+	sink             OutputSink    // receives output and lifecycle events, if set
+	sinkKey          SinkKey
+	metrics          *metrics.Registry // records lifecycle transitions, if set
+	metricsOwner     string
+	totals           *jobTotals // accumulates the totals of the job's manager, if set
+	store            JobStore   // saves the job's record on status changes, if set
+	recordVersion    uint64     // number of the last change persisted
+	saveMu           sync.Mutex // orders saving the job's records
+	savedVersion     uint64     // number of the last change saved, guarded by saveMu
+	storeOwner       string
+	redactRecordArgs bool // leave args out of stored records
+	cmd              *exec.Cmd
+	groupKilled      chan struct{} // closed once only the job's process group could be killed
+	cleanupErr       error
+
+	openStdin     bool     // connect stdin to a pipe rather than /dev/null
+	stdin         *os.File // write end of the stdin pipe, nil once closed
+	stdinAttached bool     // a StdinWriter currently writes to stdin
 
 	status   status
-	exitErr  error // raw error returned by cmd.Wait()
-	exitCode int   // numeric exit code derived from exitErr
+	exitErr  error          // raw error returned by cmd.Wait()
+	exitCode int            // numeric exit code derived from exitErr
+	exitSig  syscall.Signal // signal that terminated the process, 0 if it exited
+
+	trackPeakMemory bool
+	peakMemory      uint64 // peak memory usage in bytes, if tracked
+	finalStats      *Stats // resource usage read just before the cgroup was deleted
+
+	createdAt  time.Time // when the job was created, before it was queued or started; never changed
+	startedAt  time.Time // set once the process started
+	finishedAt time.Time // set when done is closed
+
+	cancel        context.CancelFunc
+	stopRequested bool          // set once stop() is called
+	cleaning      chan struct{} // closed once the finished job's cgroup was deleted, or deleting it failed
+	outputLimited bool          // set once the job wrote more than maxOutput
+	done          chan struct{} // closed when job finishes
+
+	outBuf       outputBuffer
+	stdoutClosed bool                               // the job closed stdout while still running
+	stderrClosed bool                               // the job closed stderr while still running
+	segments     []segment                          // which stream produced each part of outBuf
+	readers      map[*streamingReader]chan struct{} // open log streamers
+	removed      bool                               // outBuf is closed once the last reader is closed
+	cgroup       cgroup
+
+	// limitWarnings describes limits the kernel applied differently than requested.
+	limitWarnings []string
 
-	cancel context.CancelFunc
-	done   chan struct{} // closed when job finishes
+	// spec is the spec the job was started from, with the resource limits in
+	// effect. It is never changed.
+	spec JobSpec
 
-	outBuf  *lockedBuffer
-	readers map[*streamingReader]chan struct{} // active log streamers
-	cgroup  cgroup
+	// onFinish, if set, is called once the job has finished running.
+	onFinish func()
+
+	// logger logs the lifecycle of the job, with the job ID on every line.
+	logger *slog.Logger
 }
 
-// newJob creates a new job instance with the given command and arguments.
-func newJob(id, cmd string, args ...string) (*job, error) {
-	cg, err := newCGroupV2(id, "")
+// newJob creates a new job instance from the given spec, with its cgroup under
+// cgroupRoot.
+func newJob(id string, spec JobSpec, cgroupRoot string) (*job, error) {
+	cg, warnings, err := newJobCgroup(id, spec, cgroupRoot, "", cgroupDeletion{})
 	if err != nil {
-		return nil, fmt.Errorf("create cgroup: %w", err)
+		return nil, err
 	}
 
-	if err := cg.setLimits(); err != nil {
-		return nil, fmt.Errorf("set limits: %w", err)
+	j := newJobInCgroup(id, spec, cg)
+	j.limitWarnings = warnings
+	return j, nil
+}
+
+// newJobCgroup creates the cgroup of job id under cgroupRoot, nested in the
+// cgroup of owner if set, with the limits of spec, deleted as configured by
+// deletion. It returns the limits the kernel applied differently than requested.
+func newJobCgroup(id string, spec JobSpec, cgroupRoot, owner string, deletion cgroupDeletion) (cgroup, []string, error) {
+	cg, err := newCGroupV2(id, cgroupRoot, owner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create cgroup: %w", err)
 	}
+	cg.deletion = deletion
 
+	warnings, err := cg.setLimits(spec.Resources.withDefaults(defaultResourceProfile()))
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("set limits: %w", err), cg.delete())
+	}
+	return cg, warnings, nil
+}
+
+// newJobInCgroup creates a new job instance from the given spec that runs in cg.
+func newJobInCgroup(id string, spec JobSpec, cg cgroup) *job {
 	return &job{
-		ID:      id,
-		command: cmd,
-		args:    args,
-		outBuf:  &lockedBuffer{b: new(bytes.Buffer)},
-		readers: make(map[*streamingReader]chan struct{}),
-		done:    make(chan struct{}),
-		cgroup:  cg,
-	}, nil
+		ID:               id,
+		command:          spec.Command,
+		args:             spec.Args,
+		argv0:            spec.Argv0,
+		env:              spec.Env,
+		workingDir:       spec.WorkingDir,
+		workInTempDir:    spec.WorkInPrivateTmp,
+		nofileLimit:      spec.NofileLimit,
+		bindMounts:       spec.BindMounts,
+		confirmRunning:   spec.ConfirmRunning,
+		timeout:          spec.Timeout,
+		maxOutput:        spec.MaxOutputBytes,
+		killOnDisconnect: spec.KillOnDisconnect,
+		openStdin:        spec.Stdin,
+		outBuf:           newLockedBuffer(nil),
+		readers:          make(map[*streamingReader]chan struct{}),
+		done:             make(chan struct{}),
+		createdAt:        time.Now(),
+		cgroup:           cg,
+		spec:             spec,
+		logger:           slog.Default().With("job", id),
+	}
 }
 
 // Start begins execution of the job using its own cancellable context.
 // It sets up cgroup association and output capturing.
 // It spawns a goroutine to monitor job completion and update status accordingly.
 func (j *job) start(ctx context.Context) error {
-	jobContext, cancel := context.WithCancel(ctx)
-	j.cancel = cancel
+	// The job outlives the request that started it, so its context keeps
+	// ctx's values but not its cancellation. ctx only aborts the start.
+	var jobContext context.Context
+	if j.timeout > 0 {
+		jobContext, j.cancel = context.WithTimeout(context.WithoutCancel(ctx), j.timeout)
+	} else {
+		jobContext, j.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	}
+	if err := ctx.Err(); err != nil {
+		return j.failStart(fmt.Errorf("start aborted: %w", err))
+	}
+
+	// Run the binary resolved when the job was created, if any, so the path
+	// reported for the job is the one that ran.
+	cmd := exec.CommandContext(jobContext, cmp.Or(j.path, j.command), j.args...)
+	cmd.Args[0] = cmp.Or(j.argv0, j.command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		// Own process group so stop() can signal the job and its children at once.
+		Setpgid: true,
+	}
 
 	fd, err := j.cgroup.openFD()
-	if err != nil {
-		return fmt.Errorf("open cgroup FD: %w", err)
+	switch {
+	case errors.Is(err, errNoCgroup):
+		// Best-effort mode: the job runs in the worker's cgroup, without limits.
+	case err != nil:
+		return j.failStart(fmt.Errorf("open cgroup FD: %w", err))
+	default:
+		defer unix.Close(fd)
+		cmd.SysProcAttr.CgroupFD = fd
+		cmd.SysProcAttr.UseCgroupFD = true
+	}
+	cmd.SysProcAttr.Cloneflags = j.spec.Namespaces.cloneflags()
+	if len(j.bindMounts) > 0 {
+		// The shim performs the bind mounts in the job's own mount namespace.
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNS
+	}
+	cmd.Dir = j.workingDir
+	if len(j.env) > 0 {
+		// os/exec keeps the last value of duplicate keys, so job entries win.
+		cmd.Env = append(os.Environ(), j.env...)
 	}
-	defer unix.Close(fd)
 
-	cmd := exec.CommandContext(jobContext, j.command, j.args...)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		CgroupFD:    fd,
-		UseCgroupFD: true,
+	shim := shimConfig{NofileLimit: j.nofileLimit, Nice: j.spec.Nice, BindMounts: j.bindMounts, Namespaces: j.spec.Namespaces}
+	if shim.needed() {
+		// The shim needs the worker's privileges for its setup, so it
+		// switches to the job's user itself.
+		shim.Credential = j.spec.Credential
+		if err := shim.wrap(cmd); err != nil {
+			return j.failStart(fmt.Errorf("prepare job shim: %w", err))
+		}
+	} else if j.spec.Credential != nil {
+		cmd.SysProcAttr.Credential = j.spec.Credential.sysCredential()
+	}
+
+	// The output is copied from pipes owned by the job rather than by os/exec,
+	// so a stream closed by a job that keeps running can be detected.
+	stdout, err := newOutputPipe()
+	if err != nil {
+		return j.failStart(err)
 	}
+	stderr, err := newOutputPipe()
+	if err != nil {
+		stdout.close()
+		return j.failStart(err)
+	}
+	cmd.Stdout = stdout.w
+	cmd.Stderr = stderr.w
 
-	writer := &notifyingWriter{job: j}
-	cmd.Stdout = writer
-	cmd.Stderr = writer
+	var stdinR *os.File
+	if j.openStdin {
+		r, w, err := os.Pipe()
+		if err != nil {
+			stdout.close()
+			stderr.close()
+			return j.failStart(fmt.Errorf("create stdin pipe: %w", err))
+		}
+		cmd.Stdin = r
+		stdinR = r
+		j.mu.Lock()
+		j.stdin = w
+		j.mu.Unlock()
+	}
 
 	j.cmd = cmd
+	j.groupKilled = make(chan struct{})
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("starting a linuxjob failed: %w", err)
+	err = cmd.Start()
+	if stdinR != nil {
+		// Only the job keeps the read end, so writes fail once it is gone.
+		stdinR.Close()
+	}
+	if err != nil {
+		stdout.close()
+		stderr.close()
+		return j.failStart(fmt.Errorf("starting a linuxjob failed: %w", err))
+	}
+
+	// Published before any output, which is copied below.
+	if j.sink != nil {
+		j.sink.PublishEvent(j.sinkKey, JobEvent{Type: JobStarted, Time: time.Now(), Status: running.String()})
+	}
+	if j.metrics != nil {
+		j.metrics.JobStarted(j.metricsOwner)
+	}
+	if j.totals != nil {
+		j.totals.started()
 	}
 
+	var copying sync.WaitGroup
+	copying.Add(2)
+	go j.copyOutput(stdout, StreamStdout, &copying)
+	go j.copyOutput(stderr, StreamStderr, &copying)
+
 	// This lock is not necessary here since no other goroutine can access j.status yet. But holding it for clarity.
 	j.mu.Lock()
 	j.status = running
+	j.startedAt = time.Now()
+	pending := j.persist()
 	j.mu.Unlock()
+	j.save(pending)
+	j.logger.Info("job started", "pid", cmd.Process.Pid, "command", j.command)
+
+	if j.trackPeakMemory {
+		if _, err := j.cgroup.memoryPeak(); err != nil {
+			go j.pollMemory()
+		}
+	}
 
 	go func() {
 		err := cmd.Wait()
+		j.waitOutput(&copying, stdout, stderr)
 
 		j.mu.Lock()
+		// The cgroup must still exist here, for memory.peak and memory.events.
+		// It is deleted below.
+		if j.trackPeakMemory {
+			if peak, err := j.cgroup.memoryPeak(); err == nil {
+				j.peakMemory = max(j.peakMemory, peak)
+			}
+		}
+
 		j.exitErr = err
 		j.exitCode = exitCodeFromErr(err)
-		// The only jobContext can err is when stop() function calls cancel()
-		if jobContext.Err() != nil {
+		j.exitSig = signalFromErr(err)
+		// jobContext errs when stop() calls cancel() or the job's timeout expires.
+		// A job that exits on its own during the stop grace period is stopped too.
+		if j.outputLimited {
+			j.status = outputLimitExceeded
+		} else if j.stopRequested {
+			j.status = stopped
+		} else if errors.Is(jobContext.Err(), context.DeadlineExceeded) {
+			j.status = timedOut
+		} else if jobContext.Err() != nil {
 			j.status = stopped
 		} else if err == nil {
 			j.status = exited
+		} else if j.oomKilled() {
+			j.status = oomKilled
 		} else {
 			j.status = failed
 		}
 
-		if err := j.cgroup.delete(); err != nil {
-			j.cleanupErr = err
+		if st, err := j.cgroup.stats(); err == nil {
+			j.finalStats = &st
 		}
 
+		// Unblocks writers of a job that stopped reading its stdin.
+		j.closeStdin()
+		j.finishedAt = time.Now()
+		// The cgroup is deleted once the lock is released, as waiting for its
+		// processes to exit may take up to the cgroup delete timeout.
+		cleaning := make(chan struct{})
+		j.cleaning = cleaning
+		pending := j.persist()
+		j.mu.Unlock()
+
+		// Saved before done is closed, so a job waited for is in the store.
+		j.save(pending)
+
+		j.mu.Lock()
+		// Counted before done is closed, so the totals include jobs waited for.
+		if j.totals != nil {
+			j.totals.finished(j.status, j.finalStats)
+		}
 		close(j.done)
 
+		finished := JobEvent{Type: JobFinished, Time: j.finishedAt, Status: j.status.String(), ExitCode: j.exitCode}
+		ran := j.finishedAt.Sub(j.startedAt)
 		j.mu.Unlock()
 
+		if err := j.cgroup.delete(); err != nil {
+			var deleteErr *CgroupDeleteError
+			dying := errors.As(err, &deleteErr) && deleteErr.Dying
+			j.logger.Warn("failed to delete job cgroup", "error", err, "dying", dying)
+
+			j.mu.Lock()
+			j.cleanupErr = err
+			pending := j.persist()
+			j.mu.Unlock()
+			j.save(pending)
+		}
+		close(cleaning)
+
+		j.logger.Info("job finished", "status", finished.Status, "exit_code", finished.ExitCode, "duration", ran)
+
+		if j.metrics != nil {
+			j.metrics.JobFinished(j.metricsOwner, finished.Status, ran)
+		}
+
+		// All output has been copied, so this is the last message for the job.
+		if j.sink != nil {
+			j.sink.PublishEvent(j.sinkKey, finished)
+		}
+		if j.onFinish != nil {
+			j.onFinish()
+		}
 	}()
 
+	if j.confirmRunning {
+		if err := j.confirmInCgroup(ctx, cmd.Process.Pid); err != nil {
+			j.cancel()
+			<-j.done
+			return fmt.Errorf("confirm job running: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// stop terminates a running job gracefully by sending a cancellation signal.
-func (j *job) stop() error {
+// outputPipe is a pipe a job writes one of its output streams to.
+type outputPipe struct {
+	r, w *os.File
+}
+
+func newOutputPipe() (outputPipe, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return outputPipe{}, fmt.Errorf("create output pipe: %w", err)
+	}
+	return outputPipe{r: r, w: w}, nil
+}
+
+func (p outputPipe) close() {
+	p.r.Close()
+	p.w.Close()
+}
+
+// waitOutput waits for copying, the copies of the job's output from pipes.
+// Like os/exec, it waits for the output of processes sharing the pipes too,
+// unless the job was killed without a cgroup: the processes that left its
+// process group survive that, so the pipes are closed after strayOutputGrace
+// rather than when those processes exit.
+func (j *job) waitOutput(copying *sync.WaitGroup, pipes ...outputPipe) {
+	copied := make(chan struct{})
+	go func() {
+		copying.Wait()
+		close(copied)
+	}()
+
+	select {
+	case <-copied:
+		return
+	case <-j.groupKilled:
+	}
+	select {
+	case <-copied:
+	case <-time.After(strayOutputGrace):
+		for _, p := range pipes {
+			p.r.Close()
+		}
+		<-copied
+	}
+}
+
+// copyOutput copies the job's output from p into the output buffer until the
+// job and any process it shared the pipe with closed it. If the job keeps
+// running afterwards, the stream is marked closed.
+func (j *job) copyOutput(p outputPipe, source OutputStream, copying *sync.WaitGroup) {
+	// Only the job may hold the write end, or the copy would never see EOF.
+	p.w.Close()
+	_, _ = io.Copy(&notifyingWriter{job: j, source: source}, p.r)
+	p.r.Close()
+	copying.Done()
+
+	select {
+	case <-j.done:
+		return
+	case <-time.After(outputClosedGrace):
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status != running {
+		return
+	}
+	if source == StreamStdout {
+		j.stdoutClosed = true
+	} else {
+		j.stderrClosed = true
+	}
+	j.notifyReaders()
+}
+
+// outputClosed reports whether a still running job closed the selected output
+// streams.
+func (j *job) outputClosed(sel OutputStream) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	switch sel {
+	case StreamStdout:
+		return j.stdoutClosed
+	case StreamStderr:
+		return j.stderrClosed
+	default:
+		return j.stdoutClosed && j.stderrClosed
+	}
+}
+
+// oomKilled reports whether the OOM killer killed any process of the job. The
+// cgroup must not have been deleted yet.
+func (j *job) oomKilled() bool {
+	// Each job has its own cgroup, so any kill counted is one of the job's.
+	n, err := j.cgroup.oomKills()
+	return err == nil && n > 0
+}
+
+// pollMemory samples memory.current until the job is done, recording the
+// highest value seen. It is used on kernels without memory.peak.
+func (j *job) pollMemory() {
+	tick := time.NewTicker(memoryPollInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-j.done:
+			return
+		case <-tick.C:
+			current, err := j.cgroup.memoryCurrent()
+			if err != nil {
+				continue
+			}
+			j.mu.Lock()
+			j.peakMemory = max(j.peakMemory, current)
+			j.mu.Unlock()
+		}
+	}
+}
+
+// failStart marks a job whose process never started as failed and releases its
+// resources, since no monitor goroutine exists to do so. It returns err, joined
+// with any cleanup error.
+func (j *job) failStart(err error) error {
+	j.cancel()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status = failed
+	j.exitErr = err
+	j.exitCode = -1
+
+	if cleanupErr := j.cgroup.delete(); cleanupErr != nil {
+		j.cleanupErr = cleanupErr
+		j.logger.Warn("failed to delete job cgroup", "error", cleanupErr)
+		err = errors.Join(err, fmt.Errorf("delete cgroup: %w", cleanupErr))
+	}
+	j.logger.Error("job failed to start", "error", err)
+
+	j.closeStdin()
+	j.finishedAt = time.Now()
+	close(j.done)
+
+	return err
+}
+
+// finishUnstarted ends a queued job that never started running, leaving it in
+// status st with err as its error.
+func (j *job) finishUnstarted(st status, err error) {
+	j.mu.Lock()
+	j.status = st
+	j.exitErr = err
+	j.exitCode = -1
+	j.finishedAt = time.Now()
+	pending := j.persist()
+	j.mu.Unlock()
+
+	j.save(pending)
+	close(j.done)
+}
+
+// confirmInCgroup waits until pid is listed in the job's cgroup.procs. A job
+// that already finished is considered confirmed since it did run, as is a job
+// without a cgroup. It gives up with ctx's error once ctx is done.
+func (j *job) confirmInCgroup(ctx context.Context, pid int) error {
+	timeout := time.After(confirmTimeout)
+	tick := time.NewTicker(confirmPoll)
+	defer tick.Stop()
+
+	for {
+		pids, err := j.cgroup.procs()
+		if errors.Is(err, errNoCgroup) || err == nil && slices.Contains(pids, pid) {
+			return nil
+		}
+
+		select {
+		case <-j.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("process %d not found in job cgroup", pid)
+		case <-tick.C:
+		}
+	}
+}
+
+// stop terminates a running job. It first sends SIGTERM to the job's process
+// group and waits up to grace for it to exit, then kills it by cancelling its
+// context and killing every process left in its cgroup. A grace <= 0 kills the
+// job immediately.
+func (j *job) stop(grace time.Duration) error {
 	j.mu.Lock()
 
 	if j.status != running {
 		j.mu.Unlock()
-		return fmt.Errorf("job %s not running", j.ID)
+		return fmt.Errorf("%w: %s", ErrJobNotRunning, j.ID)
 	}
+	j.stopRequested = true
 	j.mu.Unlock()
 
-	j.cancel()
+	if grace > 0 {
+		if err := unix.Kill(-j.cmd.Process.Pid, unix.SIGTERM); err == nil {
+			select {
+			case <-j.done:
+				return nil
+			case <-time.After(grace):
+			}
+		}
+	}
+
+	j.kill()
 
 	<-j.done
 
 	return nil
 }
 
-// statusSnapshot returns a  snapshot of job status.
-func (j *job) statusSnapshot() (status, int, error) {
+// kill kills the job without waiting for it to finish.
+func (j *job) kill() {
+	j.cancel()
+	// Killing the main process alone would leave behind any processes it
+	// started, and done is only closed once those holding the job's output
+	// pipes are gone as well.
+	j.killTree()
+}
+
+// killTree sends SIGKILL to all of the job's processes, including those that
+// left its process group. A job without a cgroup, or on a kernel without
+// cgroup.kill, only has its process group killed, and stops waiting for the
+// output of the processes that escaped it.
+func (j *job) killTree() {
+	err := j.cgroup.kill()
+	switch {
+	case err == nil:
+	case errors.Is(err, errNoCgroup), errors.Is(err, os.ErrNotExist):
+		_ = unix.Kill(-j.cmd.Process.Pid, unix.SIGKILL)
+		j.mu.Lock()
+		if j.groupKilled != nil {
+			select {
+			case <-j.groupKilled:
+			default:
+				close(j.groupKilled)
+			}
+		}
+		j.mu.Unlock()
+	default:
+		j.logger.Warn("failed to kill job cgroup", "error", err)
+	}
+}
+
+// signal delivers sig to the job's process.
+func (j *job) signal(sig syscall.Signal) error {
 	j.mu.Lock()
 	defer j.mu.Unlock()
-	return j.status, j.exitCode, errors.Join(j.exitErr, j.cleanupErr)
+
+	if j.status != running {
+		return fmt.Errorf("%w: %s", ErrJobNotRunning, j.ID)
+	}
+
+	if err := j.cmd.Process.Signal(sig); err != nil {
+		// The process may have exited before the monitor goroutine updated the status.
+		if errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("%w: %s", ErrJobNotRunning, j.ID)
+		}
+		return fmt.Errorf("signal job %s: %w", j.ID, err)
+	}
+	return nil
+}
+
+// jobState is a consistent snapshot of the lifecycle of a job.
+type jobState struct {
+	status     status
+	command    string
+	args       []string
+	exitCode   int
+	err        error // exit error joined with any cleanup error
+	exitErr    error
+	cleanupErr error
+	signal     syscall.Signal
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+	streams    int // readers streaming the job's output
+	outputLen  int // bytes of output ever written, including discarded output
+}
+
+// statusSnapshot returns a snapshot of the job's status, read under a single
+// lock acquisition so its fields are consistent with each other.
+func (j *job) statusSnapshot() jobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return jobState{
+		status:     j.status,
+		command:    j.command,
+		args:       j.args,
+		exitCode:   j.exitCode,
+		err:        errors.Join(j.exitErr, j.cleanupErr),
+		exitErr:    j.exitErr,
+		cleanupErr: j.cleanupErr,
+		signal:     j.exitSig,
+		createdAt:  j.createdAt,
+		startedAt:  j.startedAt,
+		finishedAt: j.finishedAt,
+		streams:    len(j.readers),
+		outputLen:  j.outBuf.len(),
+	}
+}
+
+// stats returns the resource usage of a running job, or the usage recorded when
+// a finished job's cgroup was deleted.
+func (j *job) stats() (Stats, error) {
+	// Holding the lock keeps the monitor goroutine from deleting the cgroup mid-read.
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	switch {
+	case j.status == running:
+		st, err := j.cgroup.stats()
+		if err != nil {
+			return Stats{}, fmt.Errorf("%w: %v", ErrStatsUnavailable, err)
+		}
+		return st, nil
+	case j.finalStats != nil:
+		return *j.finalStats, nil
+	default:
+		return Stats{}, fmt.Errorf("%w: no usage recorded for job %s (%s)", ErrStatsUnavailable, j.ID, j.status)
+	}
 }
 
-// Stream creates a new reader for consuming job output from the beginning.
-// If the job has already completed, it returns a reader over the complete output.
+// peakMemoryUsage returns the peak memory usage in bytes and whether it is tracked.
+func (j *job) peakMemoryUsage() (uint64, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.peakMemory, j.trackPeakMemory
+}
+
+// activeStreams returns the number of readers currently streaming the job's output.
+func (j *job) activeStreams() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.readers)
+}
+
+// stream creates a new reader for consuming job output from the beginning.
 func (j *job) stream() io.ReadCloser {
+	return j.streamOutput(StreamBoth)
+}
+
+// streamOutput creates a new reader for consuming the selected output stream
+// of the job from the beginning. If the job has already completed, the reader
+// returns EOF at the end of the output instead of waiting for more.
+func (j *job) streamOutput(sel OutputStream) *streamingReader {
+	return j.streamWith(context.Background(), StreamOptions{Stream: sel})
+}
+
+// streamWith creates a new reader for consuming the job output selected by
+// opts. Once ctx is done, a Read waiting for output returns ctx's error.
+func (j *job) streamWith(ctx context.Context, opts StreamOptions) *streamingReader {
+	r := &streamingReader{
+		ctx:        ctx,
+		job:        j,
+		sel:        opts.Stream,
+		offset:     0,
+		maxLag:     opts.MaxLag,
+		newData:    make(chan struct{}, 1),
+		lastOutput: time.Now(),
+	}
+
+	// The job completes by setting its terminal status and closing done in one
+	// critical section under j.mu, after all output has been written. Checking
+	// for completion and registering under the same lock means the reader
+	// either sees the final output without waiting, or is registered before
+	// completion and woken by done.
 	j.mu.Lock()
-	done := j.status == exited ||
-		j.status == failed ||
-		j.status == stopped
-	j.mu.Unlock()
+	defer j.mu.Unlock()
 
-	if done {
-		return io.NopCloser(bytes.NewReader(j.outBuf.bytes()))
+	r.noWait = j.finished()
+	if opts.TailLines > 0 {
+		r.offset = j.tailOffset(opts.Stream, opts.TailLines)
+	} else {
+		r.offset = max(opts.StartOffset, 0)
+	}
+	if opts.NoFollow {
+		r.noWait = true
+		r.snapshot = true
+		r.end = j.outBuf.len()
+	}
+	j.readers[r] = r.newData
+	return r
+}
+
+// tailOffset returns the offset at which the last lines lines of the selected
+// output stream start, or the oldest retained offset if fewer are retained. A
+// final line without a newline counts as a line. Callers must hold j.mu, which
+// keeps output from being written during the backward scan; output may still
+// expire under a time-bounded retention policy.
+func (j *job) tailOffset(sel OutputStream, lines int) int {
+	first := j.outBuf.start()
+	buf := make([]byte, tailScanChunk)
+	last := true // the next selected byte scanned is the last one
+
+	end := j.outBuf.len()
+	for i := len(j.segments); end > first; i-- {
+		// Output before the first segment was not attributed to a stream.
+		start, source := first, StreamBoth
+		if i > 0 {
+			start, source = max(j.segments[i-1].start, first), j.segments[i-1].source
+		}
+
+		if sel == StreamBoth || source == sel {
+			for pos := end; pos > start; {
+				from := max(start, pos-len(buf))
+				n, at, err := j.outBuf.readAt(buf[:pos-from], from)
+				if err != nil || at != from || n != pos-from {
+					// Output expired during the scan; fall back to the full
+					// history rather than lose output.
+					return j.outBuf.start()
+				}
+
+				for k := n - 1; k >= 0; k-- {
+					if buf[k] != '\n' {
+						last = false
+						continue
+					}
+					// A newline ending the output does not start another line.
+					if last {
+						last = false
+						continue
+					}
+					if lines--; lines == 0 {
+						return from + k + 1
+					}
+				}
+				pos = from
+			}
+		}
+		end = start
+	}
+	return first
+}
+
+// output returns a reader over the complete output of a finished job along
+// with the output size.
+func (j *job) output() (io.ReadCloser, int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.finished() {
+		return nil, 0, fmt.Errorf("%w: %s", ErrJobRunning, j.ID)
+	}
+	if start := j.outBuf.start(); start > 0 {
+		return nil, 0, fmt.Errorf("%w: first %d bytes of %s discarded", ErrOutputTruncated, start, j.ID)
 	}
 
 	r := &streamingReader{
+		ctx:     context.Background(),
 		job:     j,
-		offset:  0,
+		sel:     StreamBoth,
+		noWait:  true,
 		newData: make(chan struct{}, 1),
 	}
-	j.mu.Lock()
 	j.readers[r] = r.newData
+	return r, j.outBuf.len(), nil
+}
+
+// expired reports whether the job finished at least ttl before now and has no
+// open readers.
+func (j *job) expired(now time.Time, ttl time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.finished() &&
+		len(j.readers) == 0 &&
+		now.Sub(j.finishedAt) >= ttl
+}
+
+// finished reports whether the job reached a terminal state.
+// Callers must hold j.mu.
+func (j *job) finished() bool {
+	return j.status.terminal()
+}
+
+// releaseCgroup deletes the cgroup a finished job left behind, if any. It
+// returns ErrJobRunning if the job has not finished.
+func (j *job) releaseCgroup() error {
+	j.waitCleanup()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.finished() {
+		return fmt.Errorf("%w: %s", ErrJobRunning, j.ID)
+	}
+	return j.deleteLeftCgroup()
+}
+
+// waitCleanup waits until deleting the cgroup of a finished job is over. It
+// returns at once for a job that has not finished.
+func (j *job) waitCleanup() {
+	j.mu.Lock()
+	cleaning := j.cleaning
 	j.mu.Unlock()
-	return r
+
+	if cleaning != nil {
+		<-cleaning
+	}
+}
+
+// cleanupPending reports whether the cgroup of a finished job is still being
+// deleted. Callers must hold j.mu.
+func (j *job) cleanupPending() bool {
+	if j.cleaning == nil {
+		return false
+	}
+	select {
+	case <-j.cleaning:
+		return false
+	default:
+		return true
+	}
+}
+
+// deleteLeftCgroup deletes the cgroup of a finished job if deleting it failed
+// when the job finished. Callers must hold j.mu.
+func (j *job) deleteLeftCgroup() error {
+	if j.cleanupErr == nil {
+		return nil
+	}
+	if err := j.cgroup.delete(); err != nil {
+		j.logger.Warn("failed to delete job cgroup", "error", err)
+		return fmt.Errorf("delete cgroup: %w", err)
+	}
+	return nil
+}
+
+// remove releases the resources held by a finished job: its cgroup, if
+// deleting it failed when the job finished, and its output. Readers that are
+// still open keep the output until the last of them is closed. It returns
+// errCleanupPending while the cgroup is still being deleted; see waitCleanup.
+func (j *job) remove() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.finished() {
+		return fmt.Errorf("%w: %s", ErrJobRunning, j.ID)
+	}
+	if j.cleanupPending() {
+		return fmt.Errorf("%w: %s", errCleanupPending, j.ID)
+	}
+
+	if err := j.deleteLeftCgroup(); err != nil {
+		return err
+	}
+
+	if err := j.removeTempDir(); err != nil {
+		return err
+	}
+
+	j.removed = true
+	if len(j.readers) == 0 {
+		return j.outBuf.close()
+	}
+	return nil
+}
+
+// removeTempDir removes the private temporary directory of the job, if any.
+func (j *job) removeTempDir() error {
+	if j.tempDir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(j.tempDir); err != nil {
+		return fmt.Errorf("remove private temp dir: %w", err)
+	}
+	j.tempDir = ""
+	return nil
+}
+
+// segment marks the offset in the output buffer from which on the output was
+// produced by source, up to the start of the next segment.
+type segment struct {
+	start  int
+	source OutputStream
+}
+
+// recordSegment notes that output written at offset came from source. Segments
+// fully discarded from the output buffer are dropped. Callers must hold j.mu.
+func (j *job) recordSegment(offset int, source OutputStream) {
+	if n := len(j.segments); n > 0 && j.segments[n-1].source == source {
+		return
+	}
+	j.segments = append(j.segments, segment{start: offset, source: source})
+
+	first := j.outBuf.start()
+	for len(j.segments) > 1 && j.segments[1].start <= first {
+		j.segments = j.segments[1:]
+	}
+}
+
+// segmentAt returns the source of the output at offset and the offset at which
+// that source's segment ends.
+func (j *job) segmentAt(offset int) (OutputStream, int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// Index of the first segment starting after offset.
+	i, _ := slices.BinarySearchFunc(j.segments, offset, func(s segment, off int) int {
+		if s.start <= off {
+			return -1
+		}
+		return 1
+	})
+	if i == 0 {
+		return StreamBoth, j.outBuf.len()
+	}
+	end := j.outBuf.len()
+	if i < len(j.segments) {
+		end = j.segments[i].start
+	}
+	return j.segments[i-1].source, end
 }
 
 // notifyingWriter writes process output to the shared buffer
 // and notifies all active readers about new data.
 type notifyingWriter struct {
-	job *job
+	job    *job
+	source OutputStream // the stream this writer captures
 }
 
 // Write writes data to the job's output buffer and notifies readers about any new data.
+// The job lock is held while writing so the output and its segments stay consistent.
 func (w *notifyingWriter) Write(p []byte) (int, error) {
+	w.job.mu.Lock()
+	defer w.job.mu.Unlock()
+
+	offset := w.job.outBuf.len()
 	n, err := w.job.outBuf.write(p)
+	if n > 0 {
+		w.job.recordSegment(offset, w.source)
+		if w.job.sink != nil {
+			w.job.sink.PublishOutput(w.job.sinkKey, w.source, p[:n])
+		}
+	}
+	w.job.checkOutputLimit()
 
-	// Notify readers non-blockingly
-	w.job.mu.Lock()
-	for _, ch := range w.job.readers {
+	w.job.notifyReaders()
+	return n, err
+}
+
+// checkOutputLimit kills the job once its output exceeds maxOutput. Callers
+// must hold j.mu.
+func (j *job) checkOutputLimit() {
+	if j.maxOutput == 0 || j.outputLimited || uint64(j.outBuf.len()) <= j.maxOutput {
+		return
+	}
+	j.outputLimited = true
+	j.logger.Warn("job exceeded its output limit, killing it", "max_output_bytes", j.maxOutput)
+	// Not stop(), which waits for done: done is closed only once the output
+	// has been copied, and this runs while copying it. Killing the job also
+	// works before start marked it running.
+	go j.kill()
+}
+
+// notifyReaders wakes all readers waiting for output without blocking.
+// Callers must hold j.mu.
+func (j *job) notifyReaders() {
+	for _, ch := range j.readers {
 		select {
 		case ch <- struct{}{}:
 		default:
 		}
 	}
-	w.job.mu.Unlock()
-
-	return n, err
 }
 
 // streamingReader allows each client to independently consume job output.
 type streamingReader struct {
-	job     *job
-	offset  int
+	ctx    context.Context // ends waiting for output once done
+	job    *job
+	sel    OutputStream // streams to return, others are skipped
+	source OutputStream // stream of the data returned by the last Read
+	offset int
+
+	dataOffset int  // offset of the data returned by the last Read
+	skipped    bool // output before the last Read's data was discarded unread
+	maxLag     int  // how far a following reader may fall behind, 0 for no bound
+	lagged     int  // bytes skipped before the last Read's data to stay within maxLag
+
+	noWait  bool // return EOF at the end of the output instead of waiting
 	newData chan struct{}
+
+	snapshot bool // return EOF at offset end, ignoring later output
+	end      int
+
+	closedReported bool // ErrOutputClosed was returned
+
+	lastOutput time.Time // when Read last returned data, for the idle timeout
 }
 
 // Read reads data from the job's output buffer, blocking until new data is available or the job is done.
+// If older output was discarded by the buffer cap, the reader skips forward to the oldest retained byte.
+// If the job produces no output for the stream idle timeout, Read returns ErrStreamIdle.
+// If the job closes the selected streams but keeps running, Read returns ErrOutputClosed
+// once and then waits for the job to finish.
+// If the reader's context is done while waiting, Read returns the context's error.
+// Each Read returns data from a single output stream, reported by Source, and
+// never more than is available: a short Read does not mean the output ended,
+// only io.EOF does. A Read with an empty p returns at once.
 // Read must be closed when no longer needed.
 func (r *streamingReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	var idle <-chan time.Time // fires once the stream idle timeout passed without output
+
+	skipped := false // output was discarded before this reader got to it
+	lagged := 0      // bytes skipped as the reader fell more than maxLag behind
+
 	for {
 		total := r.job.outBuf.len()
+		if r.snapshot {
+			total = min(total, r.end)
+		}
+
+		if r.maxLag > 0 && !r.noWait && total-r.offset > r.maxLag {
+			lagged += total - r.maxLag - r.offset
+			r.offset = total - r.maxLag
+		}
 
 		if r.offset < total {
-			n, err := r.job.outBuf.readAt(p, r.offset)
-			r.offset += n
+			offset := max(r.offset, r.job.outBuf.start())
+			skipped = skipped || offset > r.offset
+			if offset >= total {
+				// Output written since total was read pushed out all of it.
+				r.offset = offset
+				continue
+			}
+			source, end := r.job.segmentAt(offset)
+			end = min(end, total)
+			if r.sel != StreamBoth && source != r.sel {
+				r.offset = end
+				continue
+			}
+
+			n, from, err := r.job.outBuf.readAt(p[:min(len(p), end-offset)], offset)
+			if from != offset {
+				// Output was discarded concurrently; the segment may no longer apply.
+				r.offset = from
+				skipped = true
+				continue
+			}
+			r.offset = from + n
+			r.source = source
+			r.dataOffset = from
+			r.skipped = skipped
+			r.lagged = lagged
+			r.lastOutput = time.Now()
 			return n, err
 		}
 
+		if r.noWait {
+			return 0, io.EOF
+		}
+
+		if !r.closedReported && r.job.outputClosed(r.sel) {
+			r.closedReported = true
+			return 0, ErrOutputClosed
+		}
+
+		if idle == nil && r.job.streamIdle > 0 {
+			timer := time.NewTimer(time.Until(r.lastOutput.Add(r.job.streamIdle)))
+			defer timer.Stop()
+			idle = timer.C
+		}
+
 		select {
 		case <-r.job.done:
 			total = r.job.outBuf.len()
@@ -253,62 +1351,73 @@ func (r *streamingReader) Read(p []byte) (int, error) {
 			}
 		case <-r.newData:
 			continue
+		case <-idle:
+			return 0, fmt.Errorf("%w: %s", ErrStreamIdle, r.job.streamIdle)
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
 		}
 	}
 }
 
-// Close unregisters the reader from the job and releases associated resources.
-func (r *streamingReader) Close() error {
-	r.job.mu.Lock()
-	delete(r.job.readers, r)
-	r.job.mu.Unlock()
-
-	close(r.newData)
-
-	return nil
+// Source returns the output stream that produced the data of the last Read.
+func (r *streamingReader) Source() OutputStream {
+	return r.source
 }
 
-// lockedBuffer is a threadsafe buffer used for storing process output.
-type lockedBuffer struct {
-	mu sync.RWMutex
-	b  *bytes.Buffer
-	n  int
+// Offset returns the offset in the job's output of the data of the last Read.
+func (r *streamingReader) Offset() int {
+	return r.dataOffset
 }
 
-func (l *lockedBuffer) write(p []byte) (int, error) {
-	l.mu.Lock()
-	n, err := l.b.Write(p)
-	l.n += n
-	l.mu.Unlock()
-	return n, err
+// Skipped reports whether output preceding the data of the last Read was
+// discarded by the output cap before the reader got to it.
+func (r *streamingReader) Skipped() bool {
+	return r.skipped
 }
 
-func (l *lockedBuffer) len() int {
-	l.mu.RLock()
-	n := l.n
-	l.mu.RUnlock()
-	return n
+// Lagged returns the number of bytes of output skipped before the data of
+// the last Read as the reader fell more than its MaxLag behind.
+func (r *streamingReader) Lagged() int {
+	return r.lagged
 }
 
-func (l *lockedBuffer) bytes() []byte {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return slices.Clone(l.b.Bytes())
-}
+// Close unregisters the reader from the job and releases associated resources.
+// Closing a reader more than once has no effect.
+func (r *streamingReader) Close() error {
+	r.job.mu.Lock()
+	if _, ok := r.job.readers[r]; !ok {
+		r.job.mu.Unlock()
+		return nil
+	}
+	delete(r.job.readers, r)
+	last := len(r.job.readers) == 0
+	var err error
+	if last && r.job.removed {
+		err = r.job.outBuf.close()
+	}
+	r.job.mu.Unlock()
 
-func (l *lockedBuffer) readAt(p []byte, offset int) (int, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+	// newData is not closed: writers may still hold it, and it only carries
+	// wake-ups, so it is garbage collected along with the reader.
 
-	if offset >= l.n {
-		return 0, io.EOF
+	if !r.noWait && last && r.job.killOnDisconnect {
+		time.AfterFunc(r.job.disconnectGrace, r.job.stopIfDisconnected)
 	}
 
-	buf := l.b.Bytes()
+	return err
+}
 
-	n := copy(p, buf[offset:])
+// stopIfDisconnected stops the job if it is still running with no readers
+// attached, i.e. no client reconnected during the grace period.
+func (j *job) stopIfDisconnected() {
+	j.mu.Lock()
+	idle := j.status == running && len(j.readers) == 0
+	j.mu.Unlock()
 
-	return n, nil
+	if idle {
+		j.logger.Info("stopping job after its last output stream disconnected")
+		_ = j.stop(j.stopGrace)
+	}
 }
 
 // exitCodeFromErr extracts the process exit code from exec errors.
@@ -322,3 +1431,16 @@ func exitCodeFromErr(err error) int {
 	}
 	return -1
 }
+
+// signalFromErr extracts the signal that terminated the process from exec
+// errors, or returns 0 if the process was not killed by a signal.
+func signalFromErr(err error) syscall.Signal {
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		return 0
+	}
+	if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal()
+	}
+	return 0
+}
@@ -2,21 +2,31 @@ package linuxjobs
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
+	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // newTestJob is a small helper to avoid repeating boilerplate.
 func newTestJob() *job {
-	j, _ := newJob("job-1", "echo", "hi")
+	j, _ := newJob("job-1", JobSpec{Command: "echo", Args: []string{"hi"}}, "")
 	return j
 }
 
 type fakeCGroup struct {
 	deleteCalled bool
+	killCalled   bool
 	deleteErr    error
+	stat         Stats
+	statErr      error
+	ooms         uint64
 }
 
 func (f *fakeCGroup) delete() error {
@@ -24,10 +34,35 @@ func (f *fakeCGroup) delete() error {
 	return f.deleteErr
 }
 
+func (f *fakeCGroup) kill() error {
+	f.killCalled = true
+	return nil
+}
+
 func (f *fakeCGroup) openFD() (int, error) {
 	return 0, nil
 }
 
+func (f *fakeCGroup) procs() ([]int, error) {
+	return nil, nil
+}
+
+func (f *fakeCGroup) memoryPeak() (uint64, error) {
+	return 0, nil
+}
+
+func (f *fakeCGroup) memoryCurrent() (uint64, error) {
+	return 0, nil
+}
+
+func (f *fakeCGroup) stats() (Stats, error) {
+	return f.stat, f.statErr
+}
+
+func (f *fakeCGroup) oomKills() (uint64, error) {
+	return f.ooms, nil
+}
+
 func TestNewJob_InitialState(t *testing.T) {
 	j := newTestJob()
 
@@ -55,9 +90,11 @@ func TestNewJob_InitialState(t *testing.T) {
 }
 
 func TestJobStop_HappyPath(t *testing.T) {
+	cg := &fakeCGroup{}
 	j := &job{
 		status: running,
 		done:   make(chan struct{}),
+		cgroup: cg,
 	}
 
 	// set a fake cancel that just closes done after being called
@@ -65,7 +102,7 @@ func TestJobStop_HappyPath(t *testing.T) {
 		close(j.done)
 	}
 
-	err := j.stop()
+	err := j.stop(0)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -73,6 +110,9 @@ func TestJobStop_HappyPath(t *testing.T) {
 	if j.status != running {
 		t.Fatalf("stop() should NOT modify status; got %v", j.status)
 	}
+	if !cg.killCalled {
+		t.Fatalf("stop() must kill the job's cgroup")
+	}
 }
 
 func TestStatusSnapshot_ReturnsCopy(t *testing.T) {
@@ -82,14 +122,14 @@ func TestStatusSnapshot_ReturnsCopy(t *testing.T) {
 	j.exitCode = 42
 	j.exitErr = errors.New("boom")
 
-	s, code, err := j.statusSnapshot()
-	if s != exited {
-		t.Fatalf("expected status exited, got %v", s)
+	state := j.statusSnapshot()
+	if state.status != exited {
+		t.Fatalf("expected status exited, got %v", state.status)
 	}
-	if code != 42 {
-		t.Fatalf("expected exitCode 42, got %d", code)
+	if state.exitCode != 42 {
+		t.Fatalf("expected exitCode 42, got %d", state.exitCode)
 	}
-	if err == nil || err.Error() != "boom" {
+	if err := state.err; err == nil || err.Error() != "boom" {
 		t.Fatalf("unexpected exitErr: %v", err)
 	}
 }
@@ -135,6 +175,22 @@ func TestExitCodeFromErr_ExitError(t *testing.T) {
 	}
 }
 
+func TestSignalFromErr(t *testing.T) {
+	err := exec.Command("sh", "-c", "kill -9 $$").Run()
+	if sig := signalFromErr(err); sig != syscall.SIGKILL {
+		t.Fatalf("expected SIGKILL, got %v (err=%v)", sig, err)
+	}
+
+	err = exec.Command("sh", "-c", "exit 7").Run()
+	if sig := signalFromErr(err); sig != 0 {
+		t.Fatalf("expected no signal for a normal exit, got %v", sig)
+	}
+
+	if sig := signalFromErr(nil); sig != 0 {
+		t.Fatalf("expected no signal for nil error, got %v", sig)
+	}
+}
+
 func TestStreamingReader_ReadsAllDataAndEOF(t *testing.T) {
 	j := newTestJob()
 	j.outBuf = &lockedBuffer{
@@ -145,6 +201,7 @@ func TestStreamingReader_ReadsAllDataAndEOF(t *testing.T) {
 	close(j.done) // simulate finished job
 
 	r := &streamingReader{
+		ctx:     context.Background(),
 		job:     j,
 		offset:  0,
 		newData: make(chan struct{}, 1),
@@ -175,6 +232,7 @@ func TestStreamingReader_PartialReads(t *testing.T) {
 	close(j.done)
 
 	r := &streamingReader{
+		ctx:     context.Background(),
 		job:     j,
 		offset:  0,
 		newData: make(chan struct{}, 1),
@@ -198,6 +256,137 @@ func TestStreamingReader_PartialReads(t *testing.T) {
 	}
 }
 
+func TestStreamingReader_ReadBoundaries(t *testing.T) {
+	j := newTestJob()
+	j.done = make(chan struct{})
+	w := &notifyingWriter{job: j, source: StreamStdout}
+	r := j.streamOutput(StreamBoth)
+	defer r.Close()
+
+	read := func(size int) (string, error) {
+		buf := make([]byte, size)
+		n, err := r.Read(buf)
+		return string(buf[:n]), err
+	}
+
+	if n, err := r.Read(nil); n != 0 || err != nil {
+		t.Fatalf("empty p: expected 0 bytes without waiting, got n=%d err=%v", n, err)
+	}
+
+	// p larger than the output returns what is there.
+	w.Write([]byte("abc"))
+	if got, err := read(10); got != "abc" || err != nil {
+		t.Fatalf("large p: expected 'abc', got %q (err=%v)", got, err)
+	}
+
+	// p smaller than the output returns the rest on the next Read.
+	w.Write([]byte("defgh"))
+	if got, err := read(3); got != "def" || err != nil {
+		t.Fatalf("small p: expected 'def', got %q (err=%v)", got, err)
+	}
+	if got, err := read(3); got != "gh" || err != nil {
+		t.Fatalf("small p: expected 'gh', got %q (err=%v)", got, err)
+	}
+
+	// At the end of the output of a running job, Read waits for more.
+	type result struct {
+		data string
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		data, err := read(10)
+		done <- result{data, err}
+	}()
+	select {
+	case res := <-done:
+		t.Fatalf("expected Read to wait at the end of the output, got %q (err=%v)", res.data, res.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	w.Write([]byte("ij"))
+	if res := <-done; res.data != "ij" || res.err != nil {
+		t.Fatalf("expected only the new 'ij', got %q (err=%v)", res.data, res.err)
+	}
+
+	// At the end of the output of a finished job, Read returns EOF.
+	close(j.done)
+	if got, err := read(10); got != "" || err != io.EOF {
+		t.Fatalf("expected EOF, got %q (err=%v)", got, err)
+	}
+	if r.offset != len("abcdefghij") {
+		t.Fatalf("expected reader offset %d, got %d", len("abcdefghij"), r.offset)
+	}
+}
+
+// hookBuffer calls onLen once after the next len and onReadAt once before the
+// next readAt of the wrapped buffer, to change it at those points.
+type hookBuffer struct {
+	outputBuffer
+	onLen, onReadAt func()
+}
+
+func (h *hookBuffer) len() int {
+	n := h.outputBuffer.len()
+	if f := h.onLen; f != nil {
+		h.onLen = nil
+		f()
+	}
+	return n
+}
+
+func (h *hookBuffer) readAt(p []byte, offset int) (int, int, error) {
+	if f := h.onReadAt; f != nil {
+		h.onReadAt = nil
+		f()
+	}
+	return h.outputBuffer.readAt(p, offset)
+}
+
+func TestStreamingReader_OutputDiscardedPastSeenEnd(t *testing.T) {
+	lb := newLockedBuffer(SizeRetention(4))
+	lb.write([]byte("abcd"))
+
+	// Once Read has seen 4 bytes of output, 8 more push all of them out.
+	j := newTestJob()
+	j.outBuf = &hookBuffer{outputBuffer: lb, onLen: func() { lb.write([]byte("efghijkl")) }}
+	j.done = make(chan struct{})
+	close(j.done)
+
+	r := &streamingReader{
+		ctx:     context.Background(),
+		job:     j,
+		newData: make(chan struct{}, 1),
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "ijkl" {
+		t.Fatalf("expected only retained 'ijkl', got %q", data)
+	}
+	if !r.Skipped() {
+		t.Fatalf("expected the reader to report the discarded output")
+	}
+}
+
+func TestTailOffset_OutputExpiredDuringScan(t *testing.T) {
+	lb := newLockedBuffer(nil)
+	lb.write([]byte("a\nb\nc\n"))
+
+	j := newTestJob()
+	j.outBuf = &hookBuffer{outputBuffer: lb, onReadAt: func() {
+		// As if a time-bounded retention policy expired "a\nb".
+		lb.mu.Lock()
+		lb.b.Next(3)
+		lb.mu.Unlock()
+	}}
+
+	if got := j.tailOffset(StreamBoth, 2); got != 3 {
+		t.Fatalf("expected the oldest retained offset 3, got %d", got)
+	}
+}
+
 func TestStreamingReader_CloseRemovesReader(t *testing.T) {
 	j := newTestJob()
 	j.outBuf = &lockedBuffer{
@@ -221,6 +410,49 @@ func TestStreamingReader_CloseRemovesReader(t *testing.T) {
 	}
 }
 
+func TestStreamingReader_ConcurrentWritesAndCloses(t *testing.T) {
+	j := newTestJob()
+	j.outBuf = &lockedBuffer{b: new(bytes.Buffer)}
+	j.done = make(chan struct{})
+	w := &notifyingWriter{job: j}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := w.Write([]byte("x")); err != nil {
+					t.Errorf("write error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	for range 1000 {
+		r := j.stream().(*streamingReader)
+		if err := r.Close(); err != nil {
+			t.Fatalf("Close returned error: %v", err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatalf("second Close returned error: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if n := j.activeStreams(); n != 0 {
+		t.Fatalf("expected no readers after Close, got %d", n)
+	}
+}
+
 func TestNotifyingWriter_WritesAndNotifies(t *testing.T) {
 	j := newTestJob()
 	j.outBuf = &lockedBuffer{
@@ -282,3 +514,610 @@ func TestStream_ReturnsStaticReaderForCompletedJob(t *testing.T) {
 		t.Fatalf("expected 'final', got %q", buf[:n])
 	}
 }
+
+func TestLockedBuffer_DiscardsOldestBeyondMax(t *testing.T) {
+	lb := newLockedBuffer(SizeRetention(5))
+
+	if _, err := lb.write([]byte("hello")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := lb.write([]byte("world")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+
+	if got := lb.len(); got != 10 {
+		t.Fatalf("expected total length 10, got %d", got)
+	}
+	if got := lb.start(); got != 5 {
+		t.Fatalf("expected oldest retained offset 5, got %d", got)
+	}
+	if got := lb.bytes(); string(got) != "world" {
+		t.Fatalf("expected retained 'world', got %q", got)
+	}
+}
+
+func TestStreamingReader_SkipsTrimmedOutput(t *testing.T) {
+	j := newTestJob()
+	j.outBuf = newLockedBuffer(SizeRetention(4))
+	j.outBuf.write([]byte("abcdefgh"))
+	j.done = make(chan struct{})
+	close(j.done)
+
+	r := &streamingReader{
+		ctx:     context.Background(),
+		job:     j,
+		offset:  0,
+		newData: make(chan struct{}, 1),
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "efgh" {
+		t.Fatalf("expected only retained 'efgh', got %q", data)
+	}
+	if r.offset != 8 {
+		t.Fatalf("expected reader offset 8, got %d", r.offset)
+	}
+}
+
+func TestJobStart_FailureRemovesCgroup(t *testing.T) {
+	cg, err := newCGroupV2("job-start-fail", t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	j := newTestJob()
+	j.command = "lpaas-no-such-command"
+	j.cgroup = cg
+
+	if err := j.start(context.Background()); err == nil {
+		t.Fatalf("expected start to fail for a nonexistent command")
+	}
+
+	if _, err := os.Stat(cg.Path); !os.IsNotExist(err) {
+		t.Fatalf("expected cgroup %q to be removed, got %v", cg.Path, err)
+	}
+
+	select {
+	case <-j.done:
+	default:
+		t.Fatalf("done must be closed after a failed start")
+	}
+
+	if s := j.statusSnapshot().status; s != failed {
+		t.Fatalf("expected failed status, got %v", s)
+	}
+}
+
+// newDisconnectTestJob returns a running kill-on-disconnect job whose cancel
+// closes done, so stopping it does not need a real process.
+func TestJobStart_Argv0(t *testing.T) {
+	spec := JobSpec{Command: "cat", Args: []string{"/proc/self/cmdline"}, Argv0: "my-cat"}
+	j := newJobInCgroup("job-1", spec, noCgroup{})
+
+	if err := j.start(context.Background()); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	<-j.done
+
+	want := "my-cat\x00/proc/self/cmdline\x00"
+	if got := string(j.outBuf.bytes()); got != want {
+		t.Fatalf("expected cmdline %q, got %q", want, got)
+	}
+}
+
+// stuckCgroup is a job cgroup whose deletion blocks until release is closed
+// and then fails.
+type stuckCgroup struct {
+	noCgroup
+	release chan struct{}
+}
+
+func (c stuckCgroup) delete() error {
+	<-c.release
+	return errors.New("cgroup still populated")
+}
+
+func TestJobFinish_DoesNotWaitForCgroupDeletion(t *testing.T) {
+	cg := stuckCgroup{release: make(chan struct{})}
+	j := newJobInCgroup("job-1", JobSpec{Command: "true"}, cg)
+
+	if err := j.start(context.Background()); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	select {
+	case <-j.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job not done while its cgroup is being deleted")
+	}
+
+	// The job lock is not held while the cgroup is being deleted.
+	if st := j.statusSnapshot(); st.status != exited || st.cleanupErr != nil {
+		t.Fatalf("expected exited without a cleanup error yet, got %v/%v", st.status, st.cleanupErr)
+	}
+	if err := j.remove(); !errors.Is(err, errCleanupPending) {
+		t.Fatalf("expected errCleanupPending, got %v", err)
+	}
+
+	close(cg.release)
+	j.waitCleanup()
+	if st := j.statusSnapshot(); st.cleanupErr == nil {
+		t.Fatalf("expected the cleanup error recorded once deletion failed")
+	}
+}
+
+func TestStreamOutput_ReportsOutputClosedByRunningJob(t *testing.T) {
+	spec := JobSpec{Command: "bash", Args: []string{"-c", "echo daemonizing; exec >&- 2>&-; sleep 5"}}
+	j := newJobInCgroup("job-1", spec, noCgroup{})
+	j.outBuf = newLockedBuffer(nil)
+
+	if err := j.start(context.Background()); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	defer j.stop(0)
+
+	r := j.streamOutput(StreamBoth)
+	defer r.Close()
+
+	buf := make([]byte, 64)
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != "daemonizing\n" {
+		t.Fatalf("expected %q, got %q (err=%v)", "daemonizing\n", buf[:n], err)
+	}
+
+	if _, err := r.Read(buf); !errors.Is(err, ErrOutputClosed) {
+		t.Fatalf("expected ErrOutputClosed, got %v", err)
+	}
+	if st := j.statusSnapshot().status; st != running {
+		t.Fatalf("expected job to keep running, got %v", st)
+	}
+
+	// The stream stays open until the job finishes.
+	time.AfterFunc(100*time.Millisecond, func() { j.stop(0) })
+	if _, err := r.Read(buf); err != io.EOF {
+		t.Fatalf("expected EOF once the job finished, got %v", err)
+	}
+}
+
+func TestStreamOutput_ExitingJobDoesNotReportOutputClosed(t *testing.T) {
+	j := newJobInCgroup("job-1", JobSpec{Command: "echo", Args: []string{"hi"}}, noCgroup{})
+	j.outBuf = newLockedBuffer(nil)
+
+	if err := j.start(context.Background()); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	r := j.streamOutput(StreamBoth)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "hi\n" {
+		t.Fatalf("expected %q, got %q (err=%v)", "hi\n", data, err)
+	}
+}
+
+func newDisconnectTestJob() *job {
+	j := newTestJob()
+	j.status = running
+	j.killOnDisconnect = true
+	j.disconnectGrace = 50 * time.Millisecond
+	j.cancel = func() { close(j.done) }
+	return j
+}
+
+func TestKillOnDisconnect_StopsAfterGrace(t *testing.T) {
+	j := newDisconnectTestJob()
+
+	r := j.stream()
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	select {
+	case <-j.done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("job must be stopped after its last stream disconnects")
+	}
+}
+
+func TestKillOnDisconnect_ReconnectWithinGrace(t *testing.T) {
+	j := newDisconnectTestJob()
+	j.disconnectGrace = 200 * time.Millisecond
+
+	first := j.stream()
+	first.Close()
+
+	second := j.stream()
+	defer second.Close()
+
+	select {
+	case <-j.done:
+		t.Fatalf("job must keep running while a client is attached")
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
+func TestStreamOutput_SelectsStream(t *testing.T) {
+	j := newTestJob()
+	j.outBuf = newLockedBuffer(nil)
+	stdout := &notifyingWriter{job: j, source: StreamStdout}
+	stderr := &notifyingWriter{job: j, source: StreamStderr}
+	stdout.Write([]byte("out1 "))
+	stderr.Write([]byte("err1 "))
+	stdout.Write([]byte("out2 "))
+	stdout.Write([]byte("out3 "))
+	stderr.Write([]byte("err2 "))
+	j.status = exited
+
+	tests := []struct {
+		sel  OutputStream
+		want string
+	}{
+		{StreamBoth, "out1 err1 out2 out3 err2 "},
+		{StreamStdout, "out1 out2 out3 "},
+		{StreamStderr, "err1 err2 "},
+	}
+	for _, tt := range tests {
+		r := j.streamOutput(tt.sel)
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.sel, err)
+		}
+		if string(data) != tt.want {
+			t.Fatalf("%v: expected %q, got %q", tt.sel, tt.want, data)
+		}
+	}
+}
+
+func TestStreamOutput_ReportsSourcePerRead(t *testing.T) {
+	j := newTestJob()
+	j.outBuf = newLockedBuffer(nil)
+	(&notifyingWriter{job: j, source: StreamStdout}).Write([]byte("out"))
+	(&notifyingWriter{job: j, source: StreamStderr}).Write([]byte("err"))
+	j.status = exited
+
+	r := j.streamOutput(StreamBoth)
+	defer r.Close()
+
+	buf := make([]byte, 16)
+	for _, want := range []struct {
+		data   string
+		source OutputStream
+	}{{"out", StreamStdout}, {"err", StreamStderr}} {
+		n, err := r.Read(buf)
+		if err != nil || string(buf[:n]) != want.data || r.Source() != want.source {
+			t.Fatalf("expected %q from %v, got %q from %v (err=%v)", want.data, want.source, buf[:n], r.Source(), err)
+		}
+	}
+}
+
+func TestStreamOutput_DropsTrimmedSegments(t *testing.T) {
+	j := newTestJob()
+	j.outBuf = newLockedBuffer(SizeRetention(8))
+	stdout := &notifyingWriter{job: j, source: StreamStdout}
+	stderr := &notifyingWriter{job: j, source: StreamStderr}
+	stdout.Write([]byte("aaaa"))
+	stderr.Write([]byte("bbbb"))
+	stdout.Write([]byte("cccc"))
+	j.status = exited
+
+	if len(j.segments) != 2 {
+		t.Fatalf("expected trimmed stdout segment to be dropped, got %v", j.segments)
+	}
+
+	r := j.streamOutput(StreamStderr)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "bbbb" {
+		t.Fatalf("expected %q, got %q (err=%v)", "bbbb", data, err)
+	}
+}
+
+func TestStreamOutputTail(t *testing.T) {
+	long := strings.Repeat("x", 2*tailScanChunk+10)
+
+	tests := []struct {
+		name   string
+		writes []string // alternating stdout and stderr, starting with stdout
+		sel    OutputStream
+		lines  int
+		want   string
+	}{
+		{"zero is full history", []string{"a\nb\nc\n"}, StreamBoth, 0, "a\nb\nc\n"},
+		{"last lines", []string{"a\nb\nc\n"}, StreamBoth, 2, "b\nc\n"},
+		{"more than available", []string{"a\nb\nc\n"}, StreamBoth, 10, "a\nb\nc\n"},
+		{"unterminated last line", []string{"a\nb\nc"}, StreamBoth, 1, "c"},
+		{"lines longer than a scan chunk", []string{"a\n" + long + "\nend\n"}, StreamBoth, 2, long + "\nend\n"},
+		{"across streams", []string{"o1\n", "e1\n", "o2\n"}, StreamBoth, 2, "e1\no2\n"},
+		{"stdout only", []string{"o1\no2\n", "e1\ne2\n", "o3\n"}, StreamStdout, 2, "o2\no3\n"},
+		{"stderr only", []string{"o1\n", "e1\ne2\n", "o2\n"}, StreamStderr, 1, "e2\n"},
+	}
+
+	for _, tt := range tests {
+		j := newTestJob()
+		j.outBuf = newLockedBuffer(nil)
+		writers := []*notifyingWriter{{job: j, source: StreamStdout}, {job: j, source: StreamStderr}}
+		for i, w := range tt.writes {
+			writers[i%2].Write([]byte(w))
+		}
+		j.status = exited
+
+		r := j.streamWith(context.Background(), StreamOptions{Stream: tt.sel, TailLines: tt.lines})
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if string(data) != tt.want {
+			t.Fatalf("%s: expected %q, got %q", tt.name, tt.want, data)
+		}
+	}
+}
+
+func TestStreamOutputTail_FollowsNewOutput(t *testing.T) {
+	j := newTestJob()
+	j.status = running
+	j.outBuf = newLockedBuffer(nil)
+	stdout := &notifyingWriter{job: j, source: StreamStdout}
+	stdout.Write([]byte("old\nrecent\n"))
+
+	r := j.streamWith(context.Background(), StreamOptions{TailLines: 1})
+	defer r.Close()
+
+	buf := make([]byte, 64)
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != "recent\n" {
+		t.Fatalf("expected %q, got %q (err=%v)", "recent\n", buf[:n], err)
+	}
+
+	stdout.Write([]byte("new\n"))
+	n, err = r.Read(buf)
+	if err != nil || string(buf[:n]) != "new\n" {
+		t.Fatalf("expected %q, got %q (err=%v)", "new\n", buf[:n], err)
+	}
+}
+
+func TestStreamWith_StartOffset(t *testing.T) {
+	j := newTestJob()
+	j.status = running
+	j.outBuf = newLockedBuffer(SizeRetention(8))
+	stdout := &notifyingWriter{job: j, source: StreamStdout}
+	stdout.Write([]byte("0123456789"))
+
+	buf := make([]byte, 64)
+
+	// Within the retained output: resume exactly where asked.
+	r := j.streamWith(context.Background(), StreamOptions{StartOffset: 6})
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != "6789" || r.Offset() != 6 || r.Skipped() {
+		t.Fatalf("expected %q at 6, got %q at %d (skipped=%v, err=%v)", "6789", buf[:n], r.Offset(), r.Skipped(), err)
+	}
+	r.Close()
+
+	// Before the retained output: start at the oldest retained byte and report the gap.
+	r = j.streamWith(context.Background(), StreamOptions{StartOffset: 1})
+	n, err = r.Read(buf)
+	if err != nil || string(buf[:n]) != "23456789" || r.Offset() != 2 || !r.Skipped() {
+		t.Fatalf("expected %q at 2, got %q at %d (skipped=%v, err=%v)", "23456789", buf[:n], r.Offset(), r.Skipped(), err)
+	}
+	r.Close()
+
+	// Past the end: wait for the output to get there.
+	r = j.streamWith(context.Background(), StreamOptions{StartOffset: 12})
+	defer r.Close()
+	stdout.Write([]byte("abcd"))
+	n, err = r.Read(buf)
+	if err != nil || string(buf[:n]) != "cd" || r.Offset() != 12 || r.Skipped() {
+		t.Fatalf("expected %q at 12, got %q at %d (skipped=%v, err=%v)", "cd", buf[:n], r.Offset(), r.Skipped(), err)
+	}
+}
+
+func TestStreamWith_MaxLagSkipsAhead(t *testing.T) {
+	j := newTestJob()
+	j.status = running
+	j.outBuf = newLockedBuffer(nil)
+	stdout := &notifyingWriter{job: j, source: StreamStdout}
+	stdout.Write([]byte("0123456789"))
+
+	buf := make([]byte, 64)
+
+	// A reader more than MaxLag behind skips to MaxLag before the newest output.
+	r := j.streamWith(context.Background(), StreamOptions{MaxLag: 4})
+	defer r.Close()
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != "6789" || r.Offset() != 6 || r.Lagged() != 6 || r.Skipped() {
+		t.Fatalf("expected %q at 6 after skipping 6 bytes, got %q at %d (lagged=%d, err=%v)", "6789", buf[:n], r.Offset(), r.Lagged(), err)
+	}
+
+	// A reader keeping up gets all the output.
+	stdout.Write([]byte("ab"))
+	n, err = r.Read(buf)
+	if err != nil || string(buf[:n]) != "ab" || r.Lagged() != 0 {
+		t.Fatalf("expected %q without lag, got %q (lagged=%d, err=%v)", "ab", buf[:n], r.Lagged(), err)
+	}
+
+	// Readers that do not follow the output never lag.
+	snap := j.streamWith(context.Background(), StreamOptions{MaxLag: 4, NoFollow: true})
+	defer snap.Close()
+	n, err = snap.Read(buf)
+	if err != nil || string(buf[:n]) != "0123456789ab" || snap.Lagged() != 0 {
+		t.Fatalf("expected the whole output, got %q (lagged=%d, err=%v)", buf[:n], snap.Lagged(), err)
+	}
+}
+
+func TestStreamWith_NoFollowReturnsSnapshot(t *testing.T) {
+	j := newTestJob()
+	j.status = running
+	j.outBuf = newLockedBuffer(nil)
+	stdout := &notifyingWriter{job: j, source: StreamStdout}
+	stdout.Write([]byte("so far\n"))
+
+	r := j.streamWith(context.Background(), StreamOptions{NoFollow: true})
+	defer r.Close()
+
+	// Output written after the snapshot, even to the same segment, is not returned.
+	stdout.Write([]byte("later\n"))
+
+	done := make(chan struct{})
+	var data []byte
+	var err error
+	go func() {
+		data, err = io.ReadAll(r)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("snapshot reader blocked on a running job")
+	}
+	if err != nil || string(data) != "so far\n" {
+		t.Fatalf("expected %q, got %q (err=%v)", "so far\n", data, err)
+	}
+}
+
+func TestStreamWith_ContextCancelEndsRead(t *testing.T) {
+	j := newTestJob()
+	j.status = running
+	j.outBuf = newLockedBuffer(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := j.streamWith(ctx, StreamOptions{})
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := r.Read(make([]byte, 16)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if n := j.activeStreams(); n != 0 {
+		t.Fatalf("expected reader to be unregistered, got %d readers", n)
+	}
+}
+
+func TestStreamOutput_EndsIdleStream(t *testing.T) {
+	j := newTestJob()
+	j.status = running
+	j.outBuf = newLockedBuffer(nil)
+	j.streamIdle = 100 * time.Millisecond
+	stdout := &notifyingWriter{job: j, source: StreamStdout}
+
+	r := j.streamOutput(StreamBoth)
+	defer r.Close()
+
+	// Output keeps the stream alive past the idle timeout.
+	buf := make([]byte, 16)
+	for i := 0; i < 3; i++ {
+		time.AfterFunc(60*time.Millisecond, func() { stdout.Write([]byte("tick")) })
+		if n, err := r.Read(buf); err != nil || string(buf[:n]) != "tick" {
+			t.Fatalf("expected %q, got %q (err=%v)", "tick", buf[:n], err)
+		}
+	}
+
+	start := time.Now()
+	_, err := r.Read(buf)
+	if !errors.Is(err, ErrStreamIdle) {
+		t.Fatalf("expected ErrStreamIdle, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < j.streamIdle {
+		t.Fatalf("stream ended after %v, before the idle timeout", elapsed)
+	}
+
+	select {
+	case <-j.done:
+		t.Fatalf("idle stream must not end the job")
+	default:
+	}
+}
+
+func TestStream_RaceWithCompletion(t *testing.T) {
+	const want = "line 1\nline 2\nline 3\n"
+
+	for i := 0; i < 200; i++ {
+		j := newTestJob()
+		j.status = running
+
+		// Readers attach before, during and after completion.
+		results := make(chan string, 8)
+		for k := 0; k < cap(results); k++ {
+			go func() {
+				r := j.stream()
+				defer r.Close()
+				data, _ := io.ReadAll(r)
+				results <- string(data)
+			}()
+		}
+
+		// Mirror the monitor goroutine: write all output, then complete under j.mu.
+		w := &notifyingWriter{job: j, source: StreamStdout}
+		for _, line := range []string{"line 1\n", "line 2\n", "line 3\n"} {
+			w.Write([]byte(line))
+		}
+		j.mu.Lock()
+		j.status = exited
+		close(j.done)
+		j.mu.Unlock()
+
+		for k := 0; k < cap(results); k++ {
+			select {
+			case got := <-results:
+				if got != want {
+					t.Fatalf("iteration %d: reader missed output, got %q", i, got)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("iteration %d: reader hung after job completion", i)
+			}
+		}
+	}
+}
+
+func TestJobStats_FinishedJobReportsLastKnownValues(t *testing.T) {
+	j := newTestJob()
+	cg := &fakeCGroup{stat: Stats{CPUUsage: time.Second, MemoryCurrent: 1024}}
+	j.cgroup = cg
+	j.status = running
+
+	st, err := j.stats()
+	if err != nil || st.CPUUsage != time.Second {
+		t.Fatalf("expected live stats, got %+v (err=%v)", st, err)
+	}
+
+	// Once finished, the cgroup is gone and only the recorded values remain.
+	final := Stats{CPUUsage: 2 * time.Second}
+	j.finalStats = &final
+	j.status = exited
+	cg.statErr = errors.New("cgroup deleted")
+
+	st, err = j.stats()
+	if err != nil || st != final {
+		t.Fatalf("expected final stats %+v, got %+v (err=%v)", final, st, err)
+	}
+
+	j.finalStats = nil
+	if _, err := j.stats(); !errors.Is(err, ErrStatsUnavailable) {
+		t.Fatalf("expected ErrStatsUnavailable, got %v", err)
+	}
+}
+
+func TestJobStart_CancelledContext(t *testing.T) {
+	j := newTestJob()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := j.start(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-j.done:
+	default:
+		t.Fatalf("expected aborted job to be done")
+	}
+	if st := j.statusSnapshot(); st.status != failed || !st.startedAt.IsZero() {
+		t.Fatalf("expected job failed without starting, got %+v", st)
+	}
+}
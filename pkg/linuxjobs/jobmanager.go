@@ -1,90 +1,1035 @@
 package linuxjobs
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/rohitsakala/lpaas/pkg/metrics"
 )
 
+// ErrTooManyJobs is returned when starting a job would exceed the manager's
+// limit on concurrently running jobs.
+var ErrTooManyJobs = errors.New("too many running jobs")
+
+// ErrJobNotFound is returned when an operation names a job the manager does
+// not know about, including one removed concurrently with the call.
+var ErrJobNotFound = errors.New("job not found")
+
 // newJobID returns a unique job identifier.
 func newJobID() string {
 	return fmt.Sprintf("job-%s", uuid.NewString())
 }
 
+const (
+	// defaultMaxOutputBytes is the default cap on output retained per job.
+	defaultMaxOutputBytes = 64 * 1024 * 1024 // 64 MB
+	// defaultDisconnectGracePeriod is how long a kill-on-disconnect job waits for a client to reconnect.
+	defaultDisconnectGracePeriod = 5 * time.Second
+	// defaultStopGracePeriod is how long a job may take to exit after SIGTERM before it is killed.
+	defaultStopGracePeriod = 10 * time.Second
+	// maxReapInterval bounds how often the reaper scans for expired jobs.
+	maxReapInterval = time.Minute
+	// probeOutputBytes is how much of a failed probe job's output is reported.
+	probeOutputBytes = 512
+	// shutdownRetryInterval is how often Shutdown retries stopping a job still being started.
+	shutdownRetryInterval = 10 * time.Millisecond
+)
+
 // JobManager manages the lifecycle of all jobs. It is safe for concurrent use.
 type JobManager struct {
 	jobs map[string]*job
 	mu   sync.Mutex
+
+	retention        RetentionPolicy
+	spillOutput      bool
+	outputDir        string
+	stopGrace        time.Duration
+	disconnectGrace  time.Duration
+	streamIdle       time.Duration
+	peakMemory       bool
+	resources        ResourceProfile
+	cgroupRoot       string
+	cgroupOwner      string // nests job cgroups in a cgroup of this owner, if set
+	cgroupDeletion   cgroupDeletion
+	bestEffortLimits bool // start jobs without limits if their cgroup cannot be set up
+	maxRunning       int  // 0 means unlimited
+	starting         int  // jobs being started, counted against maxRunning
+	sink             OutputSink
+	sinkOwner        string
+	tempRoot         string // parent of private job temp dirs, os.TempDir() if empty
+	metrics          *metrics.Registry
+	metricsOwner     string
+	totals           jobTotals
+	slots            *Slots // limits running jobs across managers, if set
+	logger           *slog.Logger
+	policy           CommandPolicy
+	store            JobStore // persists job records, if set
+	storeOwner       string
+	redactRecordArgs bool   // leave args out of stored records
+	queue            []*job // jobs waiting for a slot, oldest first
+	shuttingDown     bool   // set by Shutdown to keep queued jobs from starting
+
+	jobTTL     time.Duration
+	onRemove   func(jobID string) // called once a job was removed, if set
+	stopReaper chan struct{}      // closed by Close to halt the reaper
+	reaperDone chan struct{}      // closed once the reaper has exited
+	closeOnce  sync.Once
+}
+
+// Option configures a JobManager.
+type Option func(*JobManager)
+
+// WithMaxOutputBytes caps the output retained in memory for each job. Once the
+// cap is exceeded the oldest output is discarded. A value <= 0 disables the cap.
+// It is a shorthand for WithOutputRetention(SizeRetention(n)).
+func WithMaxOutputBytes(n int) Option {
+	return WithOutputRetention(SizeRetention(n))
+}
+
+// WithOutputRetention sets the policy deciding how much output is retained in
+// memory for each job, replacing the default cap of 64 MB. Jobs may tighten it
+// with their own JobSpec.OutputRetention.
+func WithOutputRetention(p RetentionPolicy) Option {
+	return func(jm *JobManager) {
+		jm.retention = p
+	}
+}
+
+// WithDiskOutput stores each job's output in a temporary file under dir instead
+// of in memory, so memory stays flat for jobs producing large amounts of output.
+// An empty dir uses the default temporary directory. Output retention policies,
+// such as the cap set by WithMaxOutputBytes, do not apply to output stored on
+// disk.
+func WithDiskOutput(dir string) Option {
+	return func(jm *JobManager) {
+		jm.spillOutput = true
+		jm.outputDir = dir
+	}
+}
+
+// WithStopGracePeriod sets how long StopJob waits for a job to exit after
+// SIGTERM before killing it. A value <= 0 kills jobs immediately.
+func WithStopGracePeriod(d time.Duration) Option {
+	return func(jm *JobManager) {
+		jm.stopGrace = d
+	}
+}
+
+// WithDisconnectGracePeriod sets how long a job started with KillOnDisconnect
+// keeps running after its last streaming client disconnects, giving clients a
+// chance to reconnect.
+func WithDisconnectGracePeriod(d time.Duration) Option {
+	return func(jm *JobManager) {
+		jm.disconnectGrace = d
+	}
+}
+
+// WithStreamIdleTimeout ends output streams of running jobs that produced no
+// output for d, so silent jobs do not hold streaming connections forever.
+// Readers then fail with ErrStreamIdle; clients may reconnect. A value <= 0,
+// the default, keeps streams open until the job finishes.
+func WithStreamIdleTimeout(d time.Duration) Option {
+	return func(jm *JobManager) {
+		jm.streamIdle = d
+	}
+}
+
+// WithBestEffortLimits starts jobs without resource limits when their cgroup
+// cannot be created or configured, e.g. on hosts without cgroup v2 or without
+// delegation, instead of failing to start them. The failure is logged. Such
+// jobs report no resource usage. Production deployments should leave this off.
+func WithBestEffortLimits() Option {
+	return func(jm *JobManager) {
+		jm.bestEffortLimits = true
+	}
+}
+
+// WithPeakMemory records the peak memory usage of each job, read from
+// memory.peak or, on older kernels, by polling memory.current while it runs.
+func WithPeakMemory() Option {
+	return func(jm *JobManager) {
+		jm.peakMemory = true
+	}
+}
+
+// WithDefaultResources sets the resource profile applied to jobs that do not
+// set their own limits. Zero fields keep the built-in defaults.
+func WithDefaultResources(p ResourceProfile) Option {
+	return func(jm *JobManager) {
+		jm.resources = p.withDefaults(defaultResourceProfile())
+	}
+}
+
+// WithCgroupRoot creates job cgroups under dir instead of /sys/fs/cgroup, e.g.
+// a sub-cgroup such as /sys/fs/cgroup/lpaas.slice delegated to the worker by
+// systemd or a container runtime. Use ValidateCgroupRoot to check dir first.
+func WithCgroupRoot(dir string) Option {
+	return func(jm *JobManager) {
+		jm.cgroupRoot = dir
+	}
+}
+
+// WithOwnerCgroup nests the cgroups of jobs in a cgroup of owner,
+// lpaas/<owner>/<jobID> under the cgroup root, whose usage files account for
+// all of the owner's jobs, including removed ones. Characters of owner that
+// cannot appear in a cgroup name, such as '/', are escaped.
+func WithOwnerCgroup(owner string) Option {
+	return func(jm *JobManager) {
+		jm.cgroupOwner = owner
+	}
+}
+
+// WithCgroupDeleteTimeout sets how long deleting the cgroup of a finished job
+// waits for its killed processes to exit, checking every poll. Processes
+// killed while blocked on slow I/O may take several seconds to exit. A cgroup
+// not deleted in time is retried when the job is removed. Values <= 0 keep the
+// defaults of 10s and 50ms.
+func WithCgroupDeleteTimeout(timeout, poll time.Duration) Option {
+	return func(jm *JobManager) {
+		jm.cgroupDeletion = cgroupDeletion{timeout: max(timeout, 0), poll: max(poll, 0)}
+	}
+}
+
+// WithMaxRunningJobs limits the number of jobs running at the same time.
+// Finished jobs do not count against the limit. A value <= 0, the default,
+// disables the limit.
+func WithMaxRunningJobs(n int) Option {
+	return func(jm *JobManager) {
+		jm.maxRunning = n
+	}
+}
+
+// WithJobTTL removes finished jobs, along with their output, once ttl has passed
+// since they finished. Jobs whose output is still being streamed are kept until
+// their readers are closed. A value <= 0, the default, keeps jobs until they
+// are removed with RemoveJob.
+func WithJobTTL(ttl time.Duration) Option {
+	return func(jm *JobManager) {
+		jm.jobTTL = ttl
+	}
+}
+
+// WithOnRemove calls fn with the ID of every job removed, by RemoveJob or once
+// its TTL expired, so state kept about the job elsewhere can be dropped. fn is
+// called without the manager locked.
+func WithOnRemove(fn func(jobID string)) Option {
+	return func(jm *JobManager) {
+		jm.onRemove = fn
+	}
+}
+
+// WithOutputSink publishes the output and lifecycle events of every job to
+// sink. owner is passed to the sink along with the job ID, so the jobs of
+// different managers can be told apart.
+func WithOutputSink(sink OutputSink, owner string) Option {
+	return func(jm *JobManager) {
+		jm.sink = sink
+		jm.sinkOwner = owner
+	}
 }
 
-// NewJobManager creates a JobManager with the map to hold jobs.
-func NewJobManager() (*JobManager, error) {
-	return &JobManager{
-		jobs: make(map[string]*job),
-	}, nil
+// WithTempRoot creates the private temporary directories of jobs started
+// with JobSpec.PrivateTmp under dir instead of the default temp directory.
+// NewJobManager fails unless dir is an existing, writable directory.
+func WithTempRoot(dir string) Option {
+	return func(jm *JobManager) {
+		jm.tempRoot = dir
+	}
+}
+
+// WithMetrics records the lifecycle transitions of every job in reg, labelled
+// with owner.
+func WithMetrics(reg *metrics.Registry, owner string) Option {
+	return func(jm *JobManager) {
+		jm.metrics = reg
+		jm.metricsOwner = owner
+	}
+}
+
+// WithLogger logs the lifecycle of every job to logger, with owner and the job
+// ID on every line. Without it, jobs are logged to slog.Default().
+func WithLogger(logger *slog.Logger, owner string) Option {
+	return func(jm *JobManager) {
+		jm.logger = logger.With("owner", owner)
+	}
+}
+
+// NewJobManager creates a JobManager with the map to hold jobs. If a job TTL is
+// configured, it starts a reaper that runs until Close is called.
+func NewJobManager(opts ...Option) (*JobManager, error) {
+	jm := &JobManager{
+		jobs:            make(map[string]*job),
+		retention:       SizeRetention(defaultMaxOutputBytes),
+		stopGrace:       defaultStopGracePeriod,
+		disconnectGrace: defaultDisconnectGracePeriod,
+		resources:       defaultResourceProfile(),
+		logger:          slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(jm)
+	}
+
+	if err := jm.policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if jm.tempRoot != "" {
+		if err := ValidateTempRoot(jm.tempRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	if jm.slots != nil {
+		jm.slots.watch(jm)
+	}
+
+	if jm.jobTTL > 0 {
+		jm.stopReaper = make(chan struct{})
+		jm.reaperDone = make(chan struct{})
+		go jm.reap(min(jm.jobTTL, maxReapInterval))
+	}
+	return jm, nil
+}
+
+// Close stops the reaper and waits for it to exit. Jobs are left running.
+// It is safe to call Close more than once.
+func (jm *JobManager) Close() {
+	jm.closeOnce.Do(func() {
+		if jm.slots != nil {
+			jm.slots.unwatch(jm)
+		}
+		if jm.stopReaper == nil {
+			return
+		}
+		close(jm.stopReaper)
+		<-jm.reaperDone
+	})
+}
+
+// Shutdown stops all jobs of the manager, so that the worker can exit without
+// orphaning their processes and cgroups, then closes the manager. Queued jobs
+// are stopped before they start. Running jobs get SIGTERM and are killed
+// after the manager's stop grace period, shortened to end by ctx's deadline.
+// Shutdown waits for all jobs to finish and returns ctx's error if it is done
+// first.
+func (jm *JobManager) Shutdown(ctx context.Context) error {
+	jm.mu.Lock()
+	jm.shuttingDown = true
+	queued := jm.queue
+	jm.queue = nil
+	jobs := slices.Collect(maps.Values(jm.jobs))
+	jm.mu.Unlock()
+
+	for _, job := range queued {
+		job.finishUnstarted(stopped, nil)
+		job.logger.Info("queued job stopped on shutdown")
+	}
+
+	grace := jm.stopGrace
+	if deadline, ok := ctx.Deadline(); ok {
+		grace = min(grace, time.Until(deadline))
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Go(func() { jm.shutdownJob(ctx, job, grace) })
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("shutdown: %w", ctx.Err())
+	}
+	jm.Close()
+	return nil
+}
+
+// shutdownJob stops job with the given grace and waits for it to finish or
+// for ctx to be done. A job taken off the queue just before the shutdown may
+// not be running yet, so stopping it is retried until it is.
+func (jm *JobManager) shutdownJob(ctx context.Context, job *job, grace time.Duration) {
+	for {
+		select {
+		case <-job.done:
+			return
+		default:
+		}
+
+		err := job.stop(grace)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, ErrJobNotRunning) {
+			job.logger.Error("failed to stop job on shutdown", "error", err)
+			return
+		}
+
+		select {
+		case <-job.done:
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(shutdownRetryInterval):
+		}
+	}
+}
+
+// reap removes expired jobs every interval until the manager is closed.
+func (jm *JobManager) reap(interval time.Duration) {
+	defer close(jm.reaperDone)
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-jm.stopReaper:
+			return
+		case now := <-tick.C:
+			jm.removeExpired(now)
+		}
+	}
+}
+
+// removeExpired removes the jobs that finished more than the job TTL before now
+// and are not being streamed. Like RemoveJob, it locks the manager before the job.
+func (jm *JobManager) removeExpired(now time.Time) {
+	var removed []*job
+
+	jm.mu.Lock()
+	for id, job := range jm.jobs {
+		if !job.expired(now, jm.jobTTL) {
+			continue
+		}
+		// A failed removal, e.g. of a stuck cgroup or one still being
+		// deleted, is retried on the next scan.
+		if err := job.remove(); err == nil {
+			delete(jm.jobs, id)
+			removed = append(removed, job)
+		}
+	}
+	jm.mu.Unlock()
+
+	for _, job := range removed {
+		job.deleteRecord()
+		if jm.onRemove != nil {
+			jm.onRemove(job.ID)
+		}
+	}
 }
 
 // StartJob creates a job and starts running it.
-func (jm *JobManager) StartJob(command string, args ...string) (string, error) {
+func (jm *JobManager) StartJob(ctx context.Context, command string, args ...string) (string, error) {
+	return jm.StartJobWithSpec(ctx, JobSpec{Command: command, Args: args})
+}
+
+// StartJobWithSpec validates the spec, creates a job from it and starts running it.
+// Validation failures wrap ErrInvalidSpec. It returns ErrCommandNotAllowed if
+// the command policy rejects the command and ErrTooManyJobs if the limit on
+// running jobs has been reached.
+//
+// ctx only bounds starting the job: if it is done before the start completes,
+// the start is aborted with ctx's error, a process already created is killed
+// and no job is registered. Once started, the job is detached from ctx and
+// keeps running after ctx is cancelled, until it exits, is stopped or times out.
+func (jm *JobManager) StartJobWithSpec(ctx context.Context, spec JobSpec) (string, error) {
+	job, _, err := jm.startJob(ctx, spec, false)
+	if err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// StartAndStream starts a job like StartJobWithSpec and returns a reader for
+// its stdout and stderr. The reader is attached before the job starts, so it
+// sees all output from the first byte on, however quickly the job writes or
+// exits. The reader must be closed by the caller when no longer needed.
+func (jm *JobManager) StartAndStream(ctx context.Context, spec JobSpec) (string, OutputReader, error) {
+	job, r, err := jm.startJob(ctx, spec, true)
+	if err != nil {
+		return "", nil, err
+	}
+	return job.ID, r, nil
+}
+
+// startJob creates and starts a job from spec and registers it. If stream is
+// set, a reader over both output streams is attached before the job starts.
+func (jm *JobManager) startJob(ctx context.Context, spec JobSpec, stream bool) (*job, *streamingReader, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("start job: %w", err)
+	}
+
+	path, err := jm.checkSpec(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := jm.reserveSlot(); err != nil {
+		return nil, nil, err
+	}
+	defer jm.releaseSlot()
+
+	release, err := jm.acquireSharedSlot()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spec.Resources = spec.Resources.withDefaults(jm.resources)
 	jobID := newJobID()
 
-	job, err := newJob(jobID, command, args...)
+	out, err := jm.newOutputBuffer(spec)
 	if err != nil {
-		return "", fmt.Errorf("create job: %w", err)
+		release()
+		return nil, nil, fmt.Errorf("create output buffer: %w", err)
 	}
 
-	if err := job.start(context.Background()); err != nil {
-		return "", fmt.Errorf("failed to start job %s: %w", jobID, err)
+	cg, warnings, err := jm.jobCgroup(jobID, spec)
+	if err != nil {
+		release()
+		out.close()
+		return nil, nil, fmt.Errorf("create job: %w", err)
+	}
+	job := newJobInCgroup(jobID, spec, cg)
+	job.path = path
+	job.limitWarnings = warnings
+	jm.configureJob(job, out)
+	job.onFinish = jm.finishHook(release)
+
+	if spec.PrivateTmp {
+		if err := jm.createTempDir(job); err != nil {
+			release()
+			out.close()
+			return nil, nil, errors.Join(err, job.cgroup.delete())
+		}
+	}
+
+	var r *streamingReader
+	if stream {
+		r = job.streamOutput(StreamBoth)
+	}
+
+	if err := job.start(ctx); err != nil {
+		release()
+		if r != nil {
+			r.Close()
+		}
+		out.close()
+		_ = job.removeTempDir()
+		return nil, nil, fmt.Errorf("failed to start job %s: %w", jobID, err)
 	}
 
 	jm.mu.Lock()
 	jm.jobs[jobID] = job
 	jm.mu.Unlock()
 
-	return job.ID, nil
+	return job, r, nil
+}
+
+// checkSpec validates spec and checks its command against the command policy,
+// before any resources are created for the job. It returns the absolute path
+// of the command.
+func (jm *JobManager) checkSpec(spec JobSpec) (string, error) {
+	if err := spec.validate(); err != nil {
+		return "", err
+	}
+	// A missing binary fails the start rather than the job after it started.
+	path, err := spec.resolveCommand()
+	if err != nil {
+		return "", err
+	}
+	return path, jm.policy.check(spec.Command, path)
+}
+
+// jobCgroup creates the cgroup of job id. In best-effort mode, a job whose
+// cgroup cannot be set up runs without one, with a limit warning saying so.
+func (jm *JobManager) jobCgroup(id string, spec JobSpec) (cgroup, []string, error) {
+	cg, warnings, err := newJobCgroup(id, spec, jm.cgroupRoot, jm.cgroupOwner, jm.cgroupDeletion)
+	if err != nil && jm.bestEffortLimits {
+		jm.logger.Warn("job runs without resource limits", "job", id, "error", err)
+		return noCgroup{}, []string{fmt.Sprintf("no resource limits applied: %v", err)}, nil
+	}
+	return cg, warnings, err
+}
+
+// configureJob applies the manager's settings to a new job writing its output
+// to out.
+func (jm *JobManager) configureJob(job *job, out outputBuffer) {
+	job.outBuf = out
+	job.trackPeakMemory = jm.peakMemory
+	job.disconnectGrace = jm.disconnectGrace
+	job.stopGrace = jm.stopGrace
+	job.streamIdle = jm.streamIdle
+	job.sink = jm.sink
+	job.sinkKey = SinkKey{Owner: jm.sinkOwner, JobID: job.ID}
+	job.metrics = jm.metrics
+	job.metricsOwner = jm.metricsOwner
+	job.totals = &jm.totals
+	job.store = jm.store
+	job.storeOwner = jm.storeOwner
+	job.redactRecordArgs = jm.redactRecordArgs
+	job.logger = jm.logger.With("job", job.ID)
+}
+
+// finishHook returns the onFinish hook of a job holding a shared slot, which
+// release frees. Queued jobs are started in the slot the job leaves.
+func (jm *JobManager) finishHook(release func()) func() {
+	return func() {
+		release()
+		jm.schedule()
+	}
+}
+
+// createTempDir creates the private temporary directory of job and points
+// TMPDIR at it. Entries in the job's own environment keep precedence.
+func (jm *JobManager) createTempDir(job *job) error {
+	root := jm.tempRoot
+	if root == "" {
+		root = os.TempDir()
+	}
+
+	dir := filepath.Join(root, "lpaas-"+job.ID)
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return fmt.Errorf("create private temp dir: %w", err)
+	}
+	if c := job.spec.Credential; c != nil {
+		// The job owns the directory even if it runs as another user.
+		if err := os.Chown(dir, int(c.UID), int(c.GID)); err != nil {
+			return errors.Join(fmt.Errorf("chown private temp dir: %w", err), os.Remove(dir))
+		}
+	}
+
+	job.tempDir = dir
+	job.env = append([]string{"TMPDIR=" + dir}, job.env...)
+	if job.workInTempDir {
+		job.workingDir = dir
+	}
+	return nil
+}
+
+// newOutputBuffer returns the buffer a job started from spec stores its
+// output in. The job's retention policy applies on top of the manager's.
+func (jm *JobManager) newOutputBuffer(spec JobSpec) (outputBuffer, error) {
+	if jm.spillOutput {
+		return newFileBuffer(jm.outputDir)
+	}
+	policy := jm.retention
+	if spec.OutputRetention != nil {
+		policy = CombinedRetention(jm.retention, spec.OutputRetention)
+	}
+	return newLockedBuffer(policy), nil
+}
+
+// Verify checks that the manager's cgroup root can confine jobs, as
+// VerifyCgroupRoot does, without starting a job like Probe.
+func (jm *JobManager) Verify() error {
+	return VerifyCgroupRoot(jm.cgroupRoot)
+}
+
+// Probe runs spec as a job under the manager's full confinement and waits for
+// it to finish. It returns an error describing what went wrong unless the job
+// exits successfully, verifying the worker can create cgroups, apply limits and
+// run processes. The probe job is removed once it finished.
+func (jm *JobManager) Probe(ctx context.Context, spec JobSpec) error {
+	spec.ConfirmRunning = true
+
+	jobID, err := jm.StartJobWithSpec(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("start probe job: %w", err)
+	}
+
+	jm.mu.Lock()
+	job := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		_ = job.stop(0)
+		_ = jm.RemoveJob(jobID)
+		return fmt.Errorf("probe job %s did not finish: %w", spec.Command, ctx.Err())
+	}
+	defer jm.RemoveJob(jobID)
+
+	state := job.statusSnapshot()
+	if state.status != exited {
+		output := lastBytes(job.outBuf.bytes(), probeOutputBytes)
+		return fmt.Errorf("probe job %s %s with exit code %d: %v; output: %q",
+			spec.Command, strings.ToLower(state.status.String()), state.exitCode, state.err, output)
+	}
+	if state.err != nil {
+		return fmt.Errorf("probe job %s: %w", spec.Command, state.err)
+	}
+	return nil
+}
+
+// lastBytes returns at most the last n bytes of b.
+func lastBytes(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[len(b)-n:]
+	}
+	return b
+}
+
+// reserveSlot counts a job being started against the running jobs limit, so
+// concurrent starts cannot exceed it. It must be paired with releaseSlot.
+func (jm *JobManager) reserveSlot() error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	if jm.maxRunning > 0 && jm.runningJobs()+jm.starting >= jm.maxRunning {
+		return fmt.Errorf("%w: limit is %d", ErrTooManyJobs, jm.maxRunning)
+	}
+	jm.starting++
+	return nil
 }
 
-// StopJob calls the stop function of the job with the given ID.
+// releaseSlot ends a reservation made by reserveSlot.
+func (jm *JobManager) releaseSlot() {
+	jm.mu.Lock()
+	jm.starting--
+	jm.mu.Unlock()
+}
+
+// runningJobs returns the number of running jobs. Callers must hold jm.mu.
+func (jm *JobManager) runningJobs() int {
+	n := 0
+	for _, job := range jm.jobs {
+		if job.statusSnapshot().status == running {
+			n++
+		}
+	}
+	return n
+}
+
+// StopJob stops the job with the given ID using the manager's grace period.
 func (jm *JobManager) StopJob(jobID string) error {
+	return jm.StopJobWithGrace(jobID, jm.stopGrace)
+}
+
+// StopJobWithGrace sends SIGTERM to the job with the given ID and kills it if
+// it has not exited after grace. A grace <= 0 kills the job immediately.
+func (jm *JobManager) StopJobWithGrace(jobID string, grace time.Duration) error {
 	jm.mu.Lock()
 	job, ok := jm.jobs[jobID]
 	jm.mu.Unlock()
 	if !ok {
-		return fmt.Errorf("job %s not found", jobID)
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
 	}
 
-	if err := job.stop(); err != nil {
+	if jm.dequeue(job) {
+		job.finishUnstarted(stopped, nil)
+		job.logger.Info("queued job stopped before it started")
+		return nil
+	}
+
+	job.logger.Info("stopping job", "grace", grace)
+
+	if err := job.stop(grace); err != nil {
 		return fmt.Errorf("stop job: %w", err)
 	}
 
 	return nil
 }
 
+// Signal delivers sig to the job with the given ID. It fails with
+// ErrSignalNotAllowed for signals outside the allowed set and with
+// ErrJobNotRunning if the job is not running.
+func (jm *JobManager) Signal(jobID string, sig syscall.Signal) error {
+	if !signalAllowed(sig) {
+		return fmt.Errorf("%w: %d", ErrSignalNotAllowed, sig)
+	}
+
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	return job.signal(sig)
+}
+
+// JobStatus describes the lifecycle of a job at one point in time. Its fields
+// are consistent with each other, e.g. a job reported as Exited always has an
+// exit code and a finish time.
+type JobStatus struct {
+	Status        string         // e.g. "Running" or "Exited"
+	Command       string         // command the job runs, as given in its spec
+	Args          []string       // arguments of the command
+	ExitCode      *int32         // nil until the job finished
+	Err           error          // exit error of the job, joined with any cleanup error
+	ExitErr       error          // why the job failed, e.g. it could not be started
+	CleanupErr    error          // why cleaning up after the job failed, e.g. deleting its cgroup
+	Signal        syscall.Signal // signal that terminated the job, 0 if none
+	CreatedAt     time.Time      // when the job was started or queued
+	StartedAt     time.Time      // zero until the job started running
+	FinishedAt    time.Time      // zero until the job finished
+	ActiveStreams int            // clients currently streaming the job's output
+	OutputBytes   int            // bytes of output written so far, including discarded output
+}
+
+// JobStatus returns the status of the job, with all fields read at once.
+func (jm *JobManager) JobStatus(jobID string) (JobStatus, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	if !ok {
+		return JobStatus{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	state := job.statusSnapshot()
+	st := JobStatus{
+		Status:        state.status.String(),
+		Command:       state.command,
+		Args:          slices.Clone(state.args),
+		Err:           state.err,
+		ExitErr:       state.exitErr,
+		CleanupErr:    state.cleanupErr,
+		Signal:        state.signal,
+		CreatedAt:     state.createdAt,
+		StartedAt:     state.startedAt,
+		FinishedAt:    state.finishedAt,
+		ActiveStreams: state.streams,
+		OutputBytes:   state.outputLen,
+	}
+	if state.status.terminal() {
+		code := int32(state.exitCode)
+		st.ExitCode = &code
+	}
+	return st, nil
+}
+
+// Wait blocks until the job finished and returns its final status. It returns
+// ctx's error if ctx is done before the job finished.
+func (jm *JobManager) Wait(ctx context.Context, jobID string) (JobStatus, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	if !ok {
+		return JobStatus{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		// A job that finished meanwhile is reported all the same.
+		select {
+		case <-job.done:
+		default:
+			return JobStatus{}, ctx.Err()
+		}
+	}
+	return jm.JobStatus(jobID)
+}
+
 // Status returns the job's status, exit code (if any), and exit error (exit error will contain the cleanup error if any).
 func (jm *JobManager) Status(jobID string) (string, *int32, error) {
+	st, err := jm.JobStatus(jobID)
+	if err != nil {
+		return "", nil, err
+	}
+	return st.Status, st.ExitCode, st.Err
+}
+
+// Times returns when the job started and finished running. finishedAt is zero
+// while the job is still running.
+func (jm *JobManager) Times(jobID string) (startedAt, finishedAt time.Time, err error) {
+	st, err := jm.JobStatus(jobID)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return st.StartedAt, st.FinishedAt, nil
+}
+
+// PeakMemory returns the peak memory usage of the job in bytes. ok is false if
+// the manager does not track peak memory.
+func (jm *JobManager) PeakMemory(jobID string) (peak uint64, ok bool, err error) {
+	jm.mu.Lock()
+	job, found := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	if !found {
+		return 0, false, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	peak, ok = job.peakMemoryUsage()
+	return peak, ok, nil
+}
+
+// Stats returns the resource usage of a running job, or the last usage recorded
+// for a finished one. It returns ErrStatsUnavailable if no usage can be read.
+func (jm *JobManager) Stats(jobID string) (Stats, error) {
 	jm.mu.Lock()
 	job, ok := jm.jobs[jobID]
 	jm.mu.Unlock()
 
 	if !ok {
-		return "", nil, fmt.Errorf("job %s not found", jobID)
+		return Stats{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
 	}
+	return job.stats()
+}
+
+// ExitSignal returns the signal that terminated the job, e.g. SIGKILL sent by
+// the OOM killer. ok is false if the job is running or exited on its own.
+func (jm *JobManager) ExitSignal(jobID string) (sig syscall.Signal, ok bool, err error) {
+	st, err := jm.JobStatus(jobID)
+	if err != nil {
+		return 0, false, err
+	}
+	return st.Signal, st.Signal != 0, nil
+}
 
-	statusVal, code, jobErr := job.statusSnapshot()
+// ActiveStreams returns the number of clients currently streaming the output of the job.
+func (jm *JobManager) ActiveStreams(jobID string) (int, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
 
-	var exitCode *int32
-	if statusVal == exited || statusVal == failed || statusVal == stopped {
-		v := int32(code)
-		exitCode = &v
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
 	}
+	return job.activeStreams(), nil
+}
 
-	return statusVal.String(), exitCode, jobErr
+// LimitWarnings describes the resource limits of the job that the kernel
+// applied differently than requested, or could not be applied at all.
+func (jm *JobManager) LimitWarnings(jobID string) ([]string, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	// Set before the job is registered and never changed.
+	return slices.Clone(job.limitWarnings), nil
+}
+
+// Spec returns the spec the job was started from, with the resource limits
+// that were in effect, including the manager's defaults. Starting a job from
+// it reproduces the job's invocation.
+func (jm *JobManager) Spec(jobID string) (JobSpec, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	if !ok {
+		return JobSpec{}, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	spec := job.spec
+	spec.Args = slices.Clone(spec.Args)
+	spec.Env = slices.Clone(spec.Env)
+	spec.BindMounts = slices.Clone(spec.BindMounts)
+	spec.Labels = maps.Clone(spec.Labels)
+	if c := spec.Credential; c != nil {
+		spec.Credential = &Credential{UID: c.UID, GID: c.GID, Groups: slices.Clone(c.Groups)}
+	}
+	return spec, nil
 }
 
-// JobExists returns true if a job with the given ID exists.
+// RestartJob starts a new job with the spec of the finished job jobID, i.e.
+// the same command, arguments, environment, limits and labels, and returns the
+// new job's ID. The finished job and its output are kept. It returns
+// ErrJobRunning if the job has not finished. The new job gets its own cgroup,
+// so one the finished job left behind is deleted first.
+func (jm *JobManager) RestartJob(ctx context.Context, jobID string) (string, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	if err := job.releaseCgroup(); err != nil {
+		return "", err
+	}
+
+	spec, err := jm.Spec(jobID)
+	if err != nil {
+		return "", err
+	}
+	id, err := jm.StartJobWithSpec(ctx, spec)
+	if err != nil {
+		return "", err
+	}
+	job.logger.Info("job restarted", "new_job", id)
+	return id, nil
+}
+
+// CommandPath returns the absolute path of the binary the job runs, resolved
+// when the job was started.
+func (jm *JobManager) CommandPath(jobID string) (string, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	return job.path, nil
+}
+
+// OutputBytes returns the number of output bytes the manager's jobs hold in
+// memory. Output stored on disk is not counted.
+func (jm *JobManager) OutputBytes() int {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	n := 0
+	for _, job := range jm.jobs {
+		n += job.outBuf.memBytes()
+	}
+	return n
+}
+
+// Totals returns the totals of all jobs the manager has run, including jobs
+// that have since been removed.
+func (jm *JobManager) Totals() JobTotals {
+	return jm.totals.snapshot()
+}
+
+// JobIDs returns the IDs of all jobs of the manager in the order they were
+// created, oldest first, with jobs created at the same time ordered by ID.
+// The order of the remaining jobs is kept as jobs are added and removed.
+func (jm *JobManager) JobIDs() []string {
+	jm.mu.Lock()
+	jobs := slices.Collect(maps.Values(jm.jobs))
+	jm.mu.Unlock()
+
+	slices.SortFunc(jobs, func(a, b *job) int {
+		return cmp.Or(a.createdAt.Compare(b.createdAt), strings.Compare(a.ID, b.ID))
+	})
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	return ids
+}
+
+// JobExists returns true if a job with the given ID exists. The job may be
+// removed right after; operations on it report ErrJobNotFound themselves, so
+// callers need not check first.
 func (jm *JobManager) JobExists(jobID string) bool {
 	jm.mu.Lock()
 	defer jm.mu.Unlock()
@@ -99,7 +1044,83 @@ func (jm *JobManager) StreamJob(jobID string) (io.ReadCloser, error) {
 	job, ok := jm.jobs[jobID]
 	jm.mu.Unlock()
 	if !ok {
-		return nil, fmt.Errorf("job %s not found", jobID)
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
 	}
 	return job.stream(), nil
 }
+
+// RemoveJob forgets a finished job and releases its cgroup and output. It
+// returns ErrJobRunning if the job has not finished. Readers still streaming
+// the job's output can finish reading it.
+func (jm *JobManager) RemoveJob(jobID string) error {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	// Deleting the cgroup of a job that just finished may take up to the
+	// cgroup delete timeout, so it is waited for without the manager lock.
+	job.waitCleanup()
+
+	jm.mu.Lock()
+	if jm.jobs[jobID] != job {
+		jm.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	if err := job.remove(); err != nil {
+		jm.mu.Unlock()
+		return fmt.Errorf("remove job: %w", err)
+	}
+	delete(jm.jobs, jobID)
+	jm.mu.Unlock()
+
+	job.deleteRecord()
+	if jm.onRemove != nil {
+		jm.onRemove(jobID)
+	}
+	return nil
+}
+
+// ReadOutput returns a reader over the complete output of a finished job along
+// with the output size in bytes. It returns ErrJobRunning if the job has not
+// finished and ErrOutputTruncated if part of the output was discarded.
+// The reader must be closed by the caller when no longer needed.
+func (jm *JobManager) ReadOutput(jobID string) (io.ReadCloser, int, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	return job.output()
+}
+
+// OutputReader streams job output and describes the data returned by the
+// last Read: which stream produced it, where it is in the job's output, and
+// whether output before it was discarded unread or skipped as the reader
+// lagged behind.
+type OutputReader interface {
+	io.ReadCloser
+	Source() OutputStream
+	Offset() int
+	Skipped() bool
+	Lagged() int
+}
+
+// StreamJobOutput returns a reader for the output of a job selected by opts.
+// By default it starts from the oldest retained output and follows new output
+// until the job finishes. With StreamBoth, stdout and stderr are returned in
+// the order they were written and no single Read mixes them.
+// Once ctx is done, for instance because the client went away, a Read waiting
+// for output returns ctx's error.
+// The reader must be closed by the caller when no longer needed.
+func (jm *JobManager) StreamJobOutput(ctx context.Context, jobID string, opts StreamOptions) (OutputReader, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	return job.streamWith(ctx, opts), nil
+}
@@ -1,7 +1,24 @@
 package linuxjobs
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/rohitsakala/lpaas/pkg/metrics"
 )
 
 func TestNewJobManager(t *testing.T) {
@@ -37,16 +54,16 @@ func TestJob_NotFound(t *testing.T) {
 func TestStatus_NotFound(t *testing.T) {
 	jm := &JobManager{jobs: make(map[string]*job)}
 	_, _, err := jm.Status("missing")
-	if err == nil {
-		t.Fatalf("expected error for missing job")
+	if !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound, got %v", err)
 	}
 }
 
 func TestStopJob_NotFound(t *testing.T) {
 	jm := &JobManager{jobs: make(map[string]*job)}
 	err := jm.StopJob("missing")
-	if err == nil {
-		t.Fatalf("expected error for missing job")
+	if !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound, got %v", err)
 	}
 }
 
@@ -98,3 +115,1224 @@ func TestStreamJob_ReturnsReader(t *testing.T) {
 	}
 	_ = r.Close()
 }
+
+func TestNewJobManager_MaxOutputBytes(t *testing.T) {
+	jm, err := NewJobManager()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if jm.retention != SizeRetention(defaultMaxOutputBytes) {
+		t.Fatalf("expected default cap %d, got %v", defaultMaxOutputBytes, jm.retention)
+	}
+
+	jm, err = NewJobManager(WithMaxOutputBytes(1024))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if jm.retention != SizeRetention(1024) {
+		t.Fatalf("expected cap 1024, got %v", jm.retention)
+	}
+}
+
+func TestTimes_ReturnsValues(t *testing.T) {
+	j := newTestJob()
+	started := time.Now().Add(-time.Minute)
+	finished := started.Add(30 * time.Second)
+	j.startedAt = started
+	j.finishedAt = finished
+
+	jm := &JobManager{jobs: map[string]*job{
+		"job-1": j,
+	}}
+
+	gotStarted, gotFinished, err := jm.Times("job-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !gotStarted.Equal(started) || !gotFinished.Equal(finished) {
+		t.Fatalf("unexpected times: started=%v finished=%v", gotStarted, gotFinished)
+	}
+}
+
+func TestTimes_NotFound(t *testing.T) {
+	jm := &JobManager{jobs: make(map[string]*job)}
+	if _, _, err := jm.Times("missing"); err == nil {
+		t.Fatalf("expected error for missing job")
+	}
+}
+
+func TestRemoveJob_RunningJobIsKept(t *testing.T) {
+	jm := &JobManager{jobs: make(map[string]*job)}
+	j := newTestJob()
+	j.status = running
+	jm.jobs["job-1"] = j
+
+	if err := jm.RemoveJob("job-1"); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("expected ErrJobRunning, got %v", err)
+	}
+	if !jm.JobExists("job-1") {
+		t.Fatalf("running job must not be removed")
+	}
+}
+
+func TestRemoveJob_ReleasesResources(t *testing.T) {
+	fb, err := newFileBuffer(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cg := &fakeCGroup{}
+
+	jm := &JobManager{jobs: make(map[string]*job)}
+	j := newTestJob()
+	j.outBuf = fb
+	j.cgroup = cg
+	j.cleanupErr = errors.New("timeout deleting cgroup")
+	j.status = exited
+	jm.jobs["job-1"] = j
+
+	if err := jm.RemoveJob("job-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if jm.JobExists("job-1") {
+		t.Fatalf("expected job to be removed")
+	}
+	if !cg.deleteCalled {
+		t.Fatalf("expected lingering cgroup to be deleted")
+	}
+	if _, err := os.Stat(fb.f.Name()); !os.IsNotExist(err) {
+		t.Fatalf("expected output file removed, got %v", err)
+	}
+}
+
+func TestRemoveJob_ConcurrentOperations(t *testing.T) {
+	jm := &JobManager{jobs: make(map[string]*job)}
+	const jobs = 50
+	for i := range jobs {
+		fb, err := newFileBuffer(t.TempDir())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		j := newTestJob()
+		j.outBuf = fb
+		j.status = exited
+		jm.jobs[fmt.Sprintf("job-%d", i)] = j
+	}
+
+	// Every operation racing the removal of its job either sees the job or
+	// fails with ErrJobNotFound, never with some other error.
+	check := func(op string, err error, allowed ...error) {
+		if err == nil || errors.Is(err, ErrJobNotFound) {
+			return
+		}
+		for _, target := range allowed {
+			if errors.Is(err, target) {
+				return
+			}
+		}
+		t.Errorf("%s: unexpected error: %v", op, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range jobs {
+		id := fmt.Sprintf("job-%d", i)
+		wg.Go(func() { check("RemoveJob", jm.RemoveJob(id)) })
+		wg.Go(func() { check("StopJob", jm.StopJob(id), ErrJobNotRunning) })
+		wg.Go(func() {
+			_, err := jm.JobStatus(id)
+			check("JobStatus", err)
+		})
+		wg.Go(func() {
+			r, err := jm.StreamJobOutput(context.Background(), id, StreamOptions{NoFollow: true})
+			if err == nil {
+				r.Close()
+			}
+			check("StreamJobOutput", err)
+		})
+		wg.Go(func() {
+			r, _, err := jm.ReadOutput(id)
+			if err == nil {
+				r.Close()
+			}
+			check("ReadOutput", err)
+		})
+	}
+	wg.Wait()
+
+	if ids := jm.JobIDs(); len(ids) != 0 {
+		t.Fatalf("expected all jobs removed, got %v", ids)
+	}
+}
+
+func TestRestartJob_RunningOrLingeringCgroup(t *testing.T) {
+	jm := &JobManager{jobs: make(map[string]*job)}
+	j := newTestJob()
+	j.status = running
+	jm.jobs["job-1"] = j
+
+	if _, err := jm.RestartJob(context.Background(), "job-1"); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("expected ErrJobRunning, got %v", err)
+	}
+
+	// A finished job whose cgroup still cannot be deleted is not restarted.
+	cg := &fakeCGroup{deleteErr: errors.New("still populated")}
+	j.cgroup = cg
+	j.cleanupErr = errors.New("timeout deleting cgroup")
+	j.status = exited
+	if _, err := jm.RestartJob(context.Background(), "job-1"); err == nil || !cg.deleteCalled {
+		t.Fatalf("expected the lingering cgroup deletion to fail the restart, got %v", err)
+	}
+	if len(jm.JobIDs()) != 1 {
+		t.Fatalf("expected no new job, got %v", jm.JobIDs())
+	}
+}
+
+func TestRestartJob_StartsNewJobWithSameSpec(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, err := jm.StartJobWithSpec(context.Background(), JobSpec{
+		Command: "bash",
+		Args:    []string{"-c", "echo $GREETING"},
+		Env:     []string{"GREETING=hello"},
+		Labels:  map[string]string{"team": "infra"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jm.Wait(context.Background(), first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := jm.RestartJob(context.Background(), first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second == first {
+		t.Fatalf("expected a new job ID, got %s", second)
+	}
+	if st, err := jm.Wait(context.Background(), second); err != nil || st.Status != "Exited" {
+		t.Fatalf("expected the new job to exit, got %+v (err=%v)", st, err)
+	}
+
+	r, _, err := jm.ReadOutput(second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	if out, _ := io.ReadAll(r); string(out) != "hello\n" {
+		t.Fatalf("expected the output of the same command, got %q", out)
+	}
+	if spec, _ := jm.Spec(second); spec.Labels["team"] != "infra" {
+		t.Fatalf("expected the labels to be kept, got %v", spec.Labels)
+	}
+	if !jm.JobExists(first) {
+		t.Fatalf("expected the finished job to be kept")
+	}
+}
+
+func TestRemoveJob_OpenReaderFinishesReading(t *testing.T) {
+	fb, err := newFileBuffer(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jm := &JobManager{jobs: make(map[string]*job)}
+	j := newTestJob()
+	j.outBuf = fb
+	(&notifyingWriter{job: j}).Write([]byte("output"))
+	j.status = exited
+	jm.jobs["job-1"] = j
+
+	r, err := jm.StreamJob("job-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := jm.RemoveJob("job-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "output" {
+		t.Fatalf("expected %q, got %q (err=%v)", "output", data, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+	if _, err := os.Stat(fb.f.Name()); !os.IsNotExist(err) {
+		t.Fatalf("expected output file removed after last reader closed, got %v", err)
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	now := time.Now()
+	jm := &JobManager{jobs: make(map[string]*job), jobTTL: time.Hour}
+
+	newFinished := func(finishedAt time.Time) *job {
+		j := newTestJob()
+		j.status = exited
+		j.finishedAt = finishedAt
+		return j
+	}
+	jm.jobs["expired"] = newFinished(now.Add(-2 * time.Hour))
+	jm.jobs["recent"] = newFinished(now.Add(-time.Minute))
+	jm.jobs["streamed"] = newFinished(now.Add(-2 * time.Hour))
+	jm.jobs["running"] = newTestJob()
+	jm.jobs["running"].status = running
+
+	r, err := jm.StreamJob("streamed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jm.removeExpired(now)
+	for id, want := range map[string]bool{"expired": false, "recent": true, "streamed": true, "running": true} {
+		if got := jm.JobExists(id); got != want {
+			t.Fatalf("job %s: expected exists=%v, got %v", id, want, got)
+		}
+	}
+
+	r.Close()
+	jm.removeExpired(now)
+	if jm.JobExists("streamed") {
+		t.Fatalf("expected streamed job to be removed once its reader closed")
+	}
+}
+
+func TestOnRemove_CalledForRemovedJobs(t *testing.T) {
+	var removed []string
+	jm := &JobManager{jobs: make(map[string]*job), jobTTL: time.Hour}
+	WithOnRemove(func(id string) { removed = append(removed, id) })(jm)
+
+	for _, id := range []string{"removed", "expired", "running"} {
+		j := newTestJob()
+		j.ID = id
+		j.status = exited
+		j.finishedAt = time.Now().Add(-2 * time.Hour)
+		jm.jobs[id] = j
+	}
+	jm.jobs["running"].status = running
+
+	if err := jm.RemoveJob("removed"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := jm.RemoveJob("running"); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("expected ErrJobRunning, got %v", err)
+	}
+	jm.removeExpired(time.Now())
+
+	if !slices.Equal(removed, []string{"removed", "expired"}) {
+		t.Fatalf("expected removed and expired jobs reported, got %v", removed)
+	}
+}
+
+func TestJobTTL_ReaperRemovesJobsUntilClosed(t *testing.T) {
+	jm, err := NewJobManager(WithJobTTL(20 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	j := newTestJob()
+	j.status = exited
+	j.finishedAt = time.Now()
+	jm.mu.Lock()
+	jm.jobs["job-1"] = j
+	jm.mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for jm.JobExists("job-1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected reaper to remove the expired job")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	jm.Close()
+	jm.Close()
+	select {
+	case <-jm.reaperDone:
+	default:
+		t.Fatalf("expected reaper to have exited")
+	}
+}
+
+func TestStartJob_MaxRunningJobs(t *testing.T) {
+	jm := &JobManager{jobs: make(map[string]*job), maxRunning: 1}
+
+	finished := newTestJob()
+	finished.status = exited
+	jm.jobs["finished"] = finished
+
+	if err := jm.reserveSlot(); err != nil {
+		t.Fatalf("finished jobs must not count against the limit: %v", err)
+	}
+	if err := jm.reserveSlot(); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("job being started must count against the limit, got %v", err)
+	}
+	jm.releaseSlot()
+
+	active := newTestJob()
+	active.status = running
+	jm.jobs["running"] = active
+
+	if _, err := jm.StartJob(context.Background(), "true"); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("expected ErrTooManyJobs, got %v", err)
+	}
+}
+
+func TestActiveStreams(t *testing.T) {
+	jm := &JobManager{jobs: make(map[string]*job)}
+	j := newTestJob()
+	j.status = running
+	jm.jobs["job-1"] = j
+
+	first, _ := jm.StreamJob("job-1")
+	second, _ := jm.StreamJob("job-1")
+	if n, err := jm.ActiveStreams("job-1"); err != nil || n != 2 {
+		t.Fatalf("expected 2 active streams, got %d (err=%v)", n, err)
+	}
+	if st, err := jm.JobStatus("job-1"); err != nil || st.ActiveStreams != 2 {
+		t.Fatalf("expected 2 active streams in the status, got %+v (err=%v)", st, err)
+	}
+
+	first.Close()
+	second.Close()
+	if n, err := jm.ActiveStreams("job-1"); err != nil || n != 0 {
+		t.Fatalf("expected 0 active streams, got %d (err=%v)", n, err)
+	}
+
+	if _, err := jm.ActiveStreams("missing"); err == nil {
+		t.Fatalf("expected error for missing job")
+	}
+}
+
+func TestProbe_CgroupFailure(t *testing.T) {
+	// A cgroup root below a regular file cannot be created.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = jm.Probe(context.Background(), JobSpec{Command: "true"})
+	if err == nil || !strings.Contains(err.Error(), "create cgroup") {
+		t.Fatalf("expected cgroup creation error, got %v", err)
+	}
+}
+
+func TestBestEffortLimits_RunsJobWithoutCgroup(t *testing.T) {
+	// A cgroup root below a regular file cannot be created.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	root := filepath.Join(file, "cgroup")
+
+	strict, err := NewJobManager(WithCgroupRoot(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := strict.StartJob(context.Background(), "true"); err == nil {
+		t.Fatalf("expected start to fail without best-effort limits")
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(root), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobID, r, err := jm.StartAndStream(context.Background(), JobSpec{Command: "echo", Args: []string{"hi"}, ConfirmRunning: true})
+	if err != nil {
+		t.Fatalf("expected job to start without limits, got %v", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "hi\n" {
+		t.Fatalf("expected %q, got %q (err=%v)", "hi\n", data, err)
+	}
+
+	status, code, err := jm.Status(jobID)
+	if err != nil || status != "Exited" || code == nil || *code != 0 {
+		t.Fatalf("expected Exited with code 0, got %s %v (err=%v)", status, code, err)
+	}
+	if _, err := jm.Stats(jobID); !errors.Is(err, ErrStatsUnavailable) {
+		t.Fatalf("expected ErrStatsUnavailable, got %v", err)
+	}
+	if warnings, err := jm.LimitWarnings(jobID); err != nil || len(warnings) != 1 {
+		t.Fatalf("expected a warning about the missing limits, got %q (err=%v)", warnings, err)
+	}
+}
+
+func TestOutputSink_ReceivesCompleteOutput(t *testing.T) {
+	// A cgroup root below a regular file lets the job run without a cgroup.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	sink := NewMemorySink()
+	jm, err := NewJobManager(
+		WithCgroupRoot(filepath.Join(file, "cgroup")),
+		WithBestEffortLimits(),
+		WithMaxOutputBytes(16),
+		WithOutputSink(sink, "rohit"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// More output than the job retains.
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "seq 1 20; exit 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := SinkKey{Owner: "rohit", JobID: jobID}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.Events(key)) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var want strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&want, "%d\n", i)
+	}
+	if got := string(sink.Output(key)); got != want.String() {
+		t.Fatalf("expected the complete output %q, got %q", want.String(), got)
+	}
+
+	events := sink.Events(key)
+	if len(events) != 2 || events[0].Type != JobStarted || events[1].Type != JobFinished {
+		t.Fatalf("expected started and finished events, got %+v", events)
+	}
+	if events[1].Status != "Failed" || events[1].ExitCode != 3 {
+		t.Fatalf("expected the job to finish Failed with code 3, got %+v", events[1])
+	}
+}
+
+func TestPrivateTmp_RemovedWithJob(t *testing.T) {
+	// A cgroup root below a regular file lets the job run without a cgroup.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	if _, err := NewJobManager(WithTempRoot(filepath.Join(file, "tmp"))); err == nil {
+		t.Fatalf("expected an invalid temp root to be rejected")
+	}
+
+	root := t.TempDir()
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithTempRoot(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobID, r, err := jm.StartAndStream(context.Background(), JobSpec{
+		Command:          "bash",
+		Args:             []string{"-c", `echo "$TMPDIR"; pwd; touch "$TMPDIR/scratch"`},
+		PrivateTmp:       true,
+		WorkInPrivateTmp: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir := filepath.Join(root, "lpaas-"+jobID)
+	if want := dir + "\n" + dir + "\n"; string(data) != want {
+		t.Fatalf("expected TMPDIR and working dir %q, got %q", dir, data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scratch")); err != nil {
+		t.Fatalf("expected the temp dir to be kept until the job is removed: %v", err)
+	}
+
+	if err := jm.RemoveJob(jobID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected the temp dir to be removed, got %v", err)
+	}
+}
+
+func TestCredential_RunsAsUserWithOwnTmp(t *testing.T) {
+	if !canSetIDs() {
+		t.Skip("requires CAP_SETUID and CAP_SETGID")
+	}
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	// The job must be able to reach its temp dir as another user.
+	root := t.TempDir()
+	for _, dir := range []string{filepath.Dir(root), root} {
+		if err := os.Chmod(dir, 0o755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithTempRoot(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, r, err := jm.StartAndStream(context.Background(), JobSpec{
+		Command:          "bash",
+		Args:             []string{"-c", `id -u; touch "$TMPDIR/scratch" && echo ok`},
+		PrivateTmp:       true,
+		WorkInPrivateTmp: true,
+		Credential:       &Credential{UID: 65534, GID: 65534},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "65534\nok\n" {
+		t.Fatalf("expected the job to run as 65534 and write its temp dir, got %q", data)
+	}
+}
+
+func TestMetrics_RecordsLifecycle(t *testing.T) {
+	// A cgroup root below a regular file lets the job run without a cgroup.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	reg := metrics.NewRegistry()
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithMetrics(reg, "rohit"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, r, err := jm.StartAndStream(context.Background(), JobSpec{Command: "false"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.ReadAll(r)
+	r.Close()
+
+	want := `lpaas_jobs_finished_total{owner="rohit",status="Failed"} 1`
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var b strings.Builder
+		reg.WriteTo(&b)
+		if strings.Contains(b.String(), want) && strings.Contains(b.String(), `lpaas_jobs_running{owner="rohit"} 0`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %q and no running jobs in:\n%s", want, b.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueueJob_StartsQueuedJobsInOrder(t *testing.T) {
+	// A regular file as cgroup root makes cgroup creation fail, so the jobs run
+	// without limits in best-effort mode.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithMaxRunningJobs(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, err := jm.QueueJob(context.Background(), JobSpec{Command: "sleep", Args: []string{"10"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := jm.QueueJob(context.Background(), JobSpec{Command: "echo", Args: []string{"second"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	third, err := jm.QueueJob(context.Background(), JobSpec{Command: "echo", Args: []string{"third"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for id, want := range map[string]string{first: "Running", second: "Queued", third: "Queued"} {
+		if status, code, err := jm.Status(id); err != nil || status != want || code != nil {
+			t.Fatalf("expected job %s to be %s, got %s %v (err=%v)", id, want, status, code, err)
+		}
+	}
+
+	// A queued job can be streamed before it starts.
+	r, err := jm.StreamJob(second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	// A queued job stopped while waiting never runs.
+	if err := jm.StopJob(third); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, _, _ := jm.Status(third); status != "Stopped" {
+		t.Fatalf("expected the third job to be Stopped, got %s", status)
+	}
+
+	if err := jm.StopJobWithGrace(first, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "second\n" {
+		t.Fatalf("expected %q, got %q (err=%v)", "second\n", data, err)
+	}
+	if status, code, err := jm.Status(second); err != nil || status != "Exited" || code == nil || *code != 0 {
+		t.Fatalf("expected the second job to exit with code 0, got %s %v (err=%v)", status, code, err)
+	}
+	if out, _, err := jm.ReadOutput(third); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if data, _ := io.ReadAll(out); len(data) != 0 {
+		t.Fatalf("expected no output from the third job, got %q", data)
+	}
+}
+
+func TestQueueJob_SharedSlots(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	slots := NewSlots(1)
+	opts := []Option{WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithSharedSlots(slots)}
+	a, err := NewJobManager(opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := NewJobManager(opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	running, err := a.StartJob(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := b.StartJob(context.Background(), "true"); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("expected ErrTooManyJobs, got %v", err)
+	}
+
+	queued, err := b.QueueJob(context.Background(), JobSpec{Command: "true"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, _, _ := b.Status(queued); status != "Queued" {
+		t.Fatalf("expected the job to be Queued, got %s", status)
+	}
+
+	// The job of b starts in the slot the job of a leaves.
+	if err := a.StopJobWithGrace(running, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := b.StreamJob(queued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	_, _ = io.Copy(io.Discard, r)
+
+	if status, code, err := b.Status(queued); err != nil || status != "Exited" || code == nil || *code != 0 {
+		t.Fatalf("expected the job to exit with code 0, got %s %v (err=%v)", status, code, err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLogger_LogsJobLifecycle(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	var buf syncBuffer
+	jm, err := NewJobManager(
+		WithCgroupRoot(filepath.Join(file, "cgroup")),
+		WithBestEffortLimits(),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil)), "rohit"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "exit 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(buf.String(), "job finished") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if entry["owner"] != "rohit" || entry["job"] != jobID {
+			t.Fatalf("expected owner and job ID on every line, got %q", line)
+		}
+		msgs = append(msgs, entry["msg"].(string))
+		if entry["msg"] == "job finished" && (entry["status"] != "Failed" || entry["exit_code"] != float64(3)) {
+			t.Fatalf("expected the job to finish as Failed with exit code 3, got %q", line)
+		}
+	}
+
+	want := []string{"job runs without resource limits", "job started", "job finished"}
+	if !slices.Equal(msgs, want) {
+		t.Fatalf("expected %q, got %q", want, msgs)
+	}
+}
+
+func TestStartJob_CommandNotFound(t *testing.T) {
+	root := t.TempDir()
+	jm, err := NewJobManager(WithCgroupRoot(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Fails synchronously, before a cgroup is created for the job.
+	if _, err := jm.StartJob(context.Background(), "lpaas-no-such-command"); !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec, got %v", err)
+	}
+	if entries, err := os.ReadDir(root); err != nil || len(entries) != 0 {
+		t.Fatalf("expected no cgroups to be created, got %v (err=%v)", entries, err)
+	}
+	if ids := jm.JobIDs(); len(ids) != 0 {
+		t.Fatalf("expected no jobs, got %v", ids)
+	}
+}
+
+func TestStartJob_ReportsCommandPath(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := exec.LookPath("true")
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	jobID, err := jm.StartJob(context.Background(), "true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path, err := jm.CommandPath(jobID); err != nil || path != want {
+		t.Fatalf("expected %s, got %q (err=%v)", want, path, err)
+	}
+}
+
+func TestStartJob_CancelledContext(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithMaxRunningJobs(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := jm.StartJob(ctx, "sleep", "10"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if ids := jm.JobIDs(); len(ids) != 0 {
+		t.Fatalf("expected no job registered, got %v", ids)
+	}
+
+	// The aborted start does not hold on to the running jobs slot.
+	id, err := jm.StartJob(context.Background(), "true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jm.Wait(context.Background(), id); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStartJob_OutlivesContext(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer jm.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id, err := jm.StartJobWithSpec(ctx, JobSpec{Command: "sleep", Args: []string{"10"}, ConfirmRunning: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cancel()
+	defer jm.StopJobWithGrace(id, 0)
+
+	time.Sleep(100 * time.Millisecond)
+	if st, err := jm.JobStatus(id); err != nil || st.Status != running.String() {
+		t.Fatalf("expected job still running after its context was cancelled, got %+v (err=%v)", st, err)
+	}
+}
+
+func TestJobStatus_ReportsConsistentFields(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobID, err := jm.StartJob(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	st, err := jm.JobStatus(jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.Status != "Running" || st.ExitCode != nil || st.Signal != 0 || st.StartedAt.IsZero() || !st.FinishedAt.IsZero() {
+		t.Fatalf("unexpected status of a running job: %+v", st)
+	}
+	if st.Command != "sleep" || !slices.Equal(st.Args, []string{"10"}) {
+		t.Fatalf("expected the command and args of the job, got %q %q", st.Command, st.Args)
+	}
+
+	if err := jm.StopJobWithGrace(jobID, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	st, err = jm.JobStatus(jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.Status != "Stopped" || st.ExitCode == nil || st.Signal != syscall.SIGKILL || st.FinishedAt.Before(st.StartedAt) {
+		t.Fatalf("unexpected status of a stopped job: %+v", st)
+	}
+
+	if _, err := jm.JobStatus("no-such-id"); err == nil {
+		t.Fatalf("expected an error for an unknown job")
+	}
+}
+
+func TestJobStatus_SeparatesExitAndCleanupErrors(t *testing.T) {
+	j := newTestJob()
+	j.status = failed
+	j.exitErr = errors.New("exec failed")
+	j.cleanupErr = errors.New("timeout deleting cgroup")
+	jm := &JobManager{jobs: map[string]*job{"job-1": j}}
+
+	st, err := jm.JobStatus("job-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.ExitErr != j.exitErr || st.CleanupErr != j.cleanupErr {
+		t.Fatalf("expected the exit and cleanup errors apart, got %v/%v", st.ExitErr, st.CleanupErr)
+	}
+	if !errors.Is(st.Err, j.exitErr) || !errors.Is(st.Err, j.cleanupErr) {
+		t.Fatalf("expected the combined error to hold both, got %v", st.Err)
+	}
+
+	rec := j.record()
+	if rec.Error != "exec failed" || rec.CleanupErr != "timeout deleting cgroup" {
+		t.Fatalf("expected the errors recorded apart, got %q/%q", rec.Error, rec.CleanupErr)
+	}
+}
+
+func TestJobStatus_OutputBytesCountsDiscardedOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithMaxOutputBytes(1024))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobID, err := jm.StartJob(context.Background(), "head", "-c", "10000", "/dev/zero")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jm.Wait(context.Background(), jobID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	st, err := jm.JobStatus(jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.OutputBytes != 10000 {
+		t.Fatalf("expected 10000 output bytes despite the 1024 byte cap, got %d", st.OutputBytes)
+	}
+}
+
+func TestMaxOutputBytes_KillsRunawayJob(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithMaxOutputBytes(1024))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// yes prints forever, as fast as the output is copied.
+	jobID, err := jm.StartJobWithSpec(context.Background(), JobSpec{Command: "yes", MaxOutputBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	st, err := jm.Wait(ctx, jobID)
+	if err != nil {
+		t.Fatalf("job was not killed: %v", err)
+	}
+
+	if st.Status != "OutputLimitExceeded" {
+		t.Fatalf("expected status OutputLimitExceeded, got %s", st.Status)
+	}
+	if st.OutputBytes <= 1<<20 {
+		t.Fatalf("expected more than %d output bytes, got %d", 1<<20, st.OutputBytes)
+	}
+	if st.Signal != syscall.SIGKILL {
+		t.Fatalf("expected the job to be killed, got signal %v", st.Signal)
+	}
+}
+
+func TestWait_ReturnsFinalStatus(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "sleep 0.1; exit 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st, err := jm.Wait(context.Background(), jobID)
+	if err != nil || st.Status != "Failed" || st.ExitCode == nil || *st.ExitCode != 3 {
+		t.Fatalf("expected Failed with exit code 3, got %+v (err=%v)", st, err)
+	}
+
+	running, err := jm.StartJob(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer jm.StopJobWithGrace(running, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := jm.Wait(ctx, running); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestTotals_AccumulatesFinishedJobs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Jobs finishing at the same time are all counted.
+	const n = 20
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			command := "true"
+			if i%2 == 0 {
+				command = "false"
+			}
+			id, err := jm.StartJob(context.Background(), command)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			jm.Wait(context.Background(), id)
+			if err := jm.RemoveJob(id); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	running, err := jm.StartJob(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := JobTotals{Started: n + 1, Running: 1, Failed: n / 2}
+	if got := jm.Totals(); got != want {
+		t.Fatalf("expected %+v including removed jobs, got %+v", want, got)
+	}
+
+	// Stopped jobs are not counted as failed.
+	jm.StopJobWithGrace(running, 0)
+	jm.Wait(context.Background(), running)
+	want = JobTotals{Started: n + 1, Failed: n / 2}
+	if got := jm.Totals(); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestJobTotals_AddsCPUUsage(t *testing.T) {
+	var totals jobTotals
+	totals.started()
+	totals.started()
+	totals.started()
+	totals.finished(exited, &Stats{CPUUsage: time.Second})
+	totals.finished(oomKilled, &Stats{CPUUsage: 500 * time.Millisecond})
+
+	want := JobTotals{Started: 3, Running: 1, Failed: 1, CPUUsage: 1500 * time.Millisecond}
+	if got := totals.snapshot(); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestJobIDs_CreationOrder(t *testing.T) {
+	jm, err := NewJobManager()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t0 := time.Now()
+	for id, created := range map[string]time.Time{
+		"job-c": t0,
+		"job-a": t0.Add(time.Second),
+		"job-b": t0.Add(time.Second),
+		"job-0": t0.Add(2 * time.Second),
+	} {
+		j := newTestJob()
+		j.ID = id
+		j.createdAt = created
+		jm.jobs[id] = j
+	}
+
+	want := []string{"job-c", "job-a", "job-b", "job-0"}
+	if got := jm.JobIDs(); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, oldest first and ties by ID, got %v", want, got)
+	}
+}
+
+func TestShutdown_StopsRunningAndQueuedJobs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithMaxRunningJobs(1), WithStopGracePeriod(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	running, err := jm.StartJob(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	queued, err := jm.QueueJob(context.Background(), JobSpec{Command: "true"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// sleep exits on SIGTERM, well before the grace period.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := jm.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, id := range []string{running, queued} {
+		if status, _, _ := jm.Status(id); status != "Stopped" {
+			t.Fatalf("expected job %s to be Stopped, got %s", id, status)
+		}
+	}
+}
+
+func TestShutdown_KillsJobsByDeadline(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithStopGracePeriod(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	id, err := jm.StartJob(context.Background(), "bash", "-c", "trap '' TERM; sleep 10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_ = jm.Shutdown(ctx)
+	if _, err := jm.Wait(context.Background(), id); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the job killed by the deadline, took %s", elapsed)
+	}
+}
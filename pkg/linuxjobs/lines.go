@@ -0,0 +1,128 @@
+package linuxjobs
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"unicode/utf8"
+)
+
+// Line is a line of job output returned by a LineReader.
+type Line struct {
+	// Data is the line, ending in a newline unless Partial is set.
+	Data []byte
+	// Source is the output stream that produced the line.
+	Source OutputStream
+	// Offset is the offset of the line in the job's output.
+	Offset int
+	// Partial is set if the line has no newline yet: it is longer than the
+	// reader's maximum, is followed by output of the other stream or by a
+	// gap, or the output ended before the line was complete. The rest of the
+	// line, if any, follows in the next Line.
+	Partial bool
+	// Skipped is set if output before the line was discarded unread.
+	Skipped bool
+	// Lagged is the number of bytes of output skipped before the line as the
+	// reader fell too far behind; see StreamOptions.MaxLag.
+	Lagged int
+}
+
+// LineReader reads the output of an OutputReader one line at a time, so that
+// clients get complete lines and multibyte UTF-8 characters are never split.
+// Every Line is a contiguous part of the job's output, so a reader resumed at
+// Offset plus the length of Data continues right after it.
+type LineReader struct {
+	r      OutputReader
+	maxLen int
+	chunk  []byte
+
+	line Line   // line being read, its data contiguous in the output
+	in   []byte // data read from r, not yet added to line
+	inAt Line   // source, offset, skipped flag and lag of in
+	err  error  // error of the last Read, returned once in and line are drained
+}
+
+// NewLineReader returns a LineReader over r splitting lines longer than
+// maxLen bytes.
+func NewLineReader(r OutputReader, maxLen int) *LineReader {
+	return &LineReader{r: r, maxLen: max(maxLen, utf8.UTFMax), chunk: make([]byte, 4096)}
+}
+
+// ReadLine returns the next line. A partial line ending the available output
+// is only returned once the output ends or the reader fails, e.g. with
+// ErrOutputClosed or ErrStreamIdle; errors are returned after it. After
+// ErrOutputClosed, reading again waits for the job to finish like the
+// underlying reader.
+func (l *LineReader) ReadLine() (Line, error) {
+	for {
+		if i := bytes.IndexByte(l.line.Data, '\n'); i >= 0 {
+			return l.take(i+1, false), nil
+		}
+		if len(l.line.Data) >= l.maxLen {
+			return l.take(runeBoundary(l.line.Data, l.maxLen), true), nil
+		}
+
+		if len(l.in) > 0 {
+			if len(l.line.Data) == 0 {
+				l.line = Line{Source: l.inAt.Source, Offset: l.inAt.Offset, Skipped: l.inAt.Skipped, Lagged: l.inAt.Lagged}
+			} else if l.inAt.Source != l.line.Source || l.inAt.Offset != l.line.Offset+len(l.line.Data) {
+				return l.take(len(l.line.Data), true), nil
+			}
+
+			// Add at most up to the next newline, so line holds a single line
+			// of at most maxLen bytes.
+			n := min(len(l.in), l.maxLen-len(l.line.Data))
+			if i := bytes.IndexByte(l.in[:n], '\n'); i >= 0 {
+				n = i + 1
+			}
+			l.line.Data = append(l.line.Data, l.in[:n]...)
+			l.in = l.in[n:]
+			l.inAt.Offset += n
+			l.inAt.Skipped = false
+			l.inAt.Lagged = 0
+			continue
+		}
+
+		if l.err != nil {
+			if len(l.line.Data) > 0 {
+				return l.take(len(l.line.Data), true), nil
+			}
+			err := l.err
+			if errors.Is(err, ErrOutputClosed) {
+				l.err = nil
+			}
+			return Line{}, err
+		}
+
+		n, err := l.r.Read(l.chunk)
+		l.in = l.chunk[:n]
+		l.inAt = Line{Source: l.r.Source(), Offset: l.r.Offset(), Skipped: l.r.Skipped(), Lagged: l.r.Lagged()}
+		l.err = err
+	}
+}
+
+// take returns the first n bytes of the line being read, marked partial if
+// set, and keeps the rest as the start of the next line.
+func (l *LineReader) take(n int, partial bool) Line {
+	line := l.line
+	line.Data = slices.Clone(l.line.Data[:n])
+	line.Partial = partial
+
+	rest := l.line.Data[n:]
+	l.line = Line{Source: line.Source, Offset: line.Offset + n, Data: append(l.line.Data[:0], rest...)}
+	return line
+}
+
+// runeBoundary returns the largest n <= max at which data can be split without
+// splitting a UTF-8 character.
+func runeBoundary(data []byte, max int) int {
+	for start := max - 1; start >= 0 && start >= max-utf8.UTFMax; start-- {
+		if utf8.RuneStart(data[start]) {
+			if utf8.FullRune(data[start:max]) {
+				return max
+			}
+			return start
+		}
+	}
+	return max
+}
@@ -0,0 +1,118 @@
+package linuxjobs
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// fakeRead is the result of a Read of a fakeOutputReader.
+type fakeRead struct {
+	data    string
+	source  OutputStream
+	offset  int
+	skipped bool
+	lagged  int
+	err     error
+}
+
+// fakeOutputReader returns its reads in order, then io.EOF.
+type fakeOutputReader struct {
+	reads []fakeRead
+	last  fakeRead
+}
+
+func (f *fakeOutputReader) Read(p []byte) (int, error) {
+	if len(f.reads) == 0 {
+		return 0, io.EOF
+	}
+	f.last, f.reads = f.reads[0], f.reads[1:]
+	return copy(p, f.last.data), f.last.err
+}
+
+func (f *fakeOutputReader) Source() OutputStream { return f.last.source }
+func (f *fakeOutputReader) Offset() int          { return f.last.offset }
+func (f *fakeOutputReader) Skipped() bool        { return f.last.skipped }
+func (f *fakeOutputReader) Lagged() int          { return f.last.lagged }
+func (f *fakeOutputReader) Close() error         { return nil }
+
+// readLines reads lines from l until an error.
+func readLines(l *LineReader) ([]Line, error) {
+	var lines []Line
+	for {
+		line, err := l.ReadLine()
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestLineReader_SplitsAndJoinsLines(t *testing.T) {
+	r := &fakeOutputReader{reads: []fakeRead{
+		{data: "one\ntw", source: StreamStdout, offset: 0},
+		{data: "o\nthr", source: StreamStdout, offset: 6},
+		{data: "warn\n", source: StreamStderr, offset: 11},
+		{data: "ee", source: StreamStdout, offset: 16},
+	}}
+
+	lines, err := readLines(NewLineReader(r, 1024))
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	want := []Line{
+		{Data: []byte("one\n"), Source: StreamStdout, Offset: 0},
+		{Data: []byte("two\n"), Source: StreamStdout, Offset: 4},
+		// Output of the other stream cuts the line short.
+		{Data: []byte("thr"), Source: StreamStdout, Offset: 8, Partial: true},
+		{Data: []byte("warn\n"), Source: StreamStderr, Offset: 11},
+		// The output ends before the last line is complete.
+		{Data: []byte("ee"), Source: StreamStdout, Offset: 16, Partial: true},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i := range want {
+		got := lines[i]
+		if string(got.Data) != string(want[i].Data) || got.Source != want[i].Source || got.Offset != want[i].Offset || got.Partial != want[i].Partial {
+			t.Fatalf("line %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+}
+
+func TestLineReader_SplitsLongLinesOnCharacterBoundaries(t *testing.T) {
+	// "é" is 2 bytes, so a split after 5 bytes would cut the third one.
+	r := &fakeOutputReader{reads: []fakeRead{{data: "ééééé\n", source: StreamStdout}}}
+
+	lines, _ := readLines(NewLineReader(r, 5))
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %+v", lines)
+	}
+	if string(lines[0].Data) != "éé" || !lines[0].Partial || lines[1].Offset != 4 {
+		t.Fatalf("expected the long line split after 2 characters, got %+v", lines)
+	}
+	if string(lines[2].Data) != "é\n" || lines[2].Partial {
+		t.Fatalf("expected the line to end complete, got %+v", lines[2])
+	}
+}
+
+func TestLineReader_FlushesPartialLineBeforeError(t *testing.T) {
+	r := &fakeOutputReader{reads: []fakeRead{
+		{data: "prompt> ", source: StreamStdout, err: ErrOutputClosed},
+		{data: "bye\n", source: StreamStdout, offset: 100, skipped: true, lagged: 50},
+	}}
+	l := NewLineReader(r, 1024)
+
+	line, err := l.ReadLine()
+	if err != nil || string(line.Data) != "prompt> " || !line.Partial {
+		t.Fatalf("expected the partial line first, got %+v, %v", line, err)
+	}
+	if _, err := l.ReadLine(); !errors.Is(err, ErrOutputClosed) {
+		t.Fatalf("expected ErrOutputClosed, got %v", err)
+	}
+	// Reading continues after ErrOutputClosed.
+	line, err = l.ReadLine()
+	if err != nil || string(line.Data) != "bye\n" || !line.Skipped || line.Lagged != 50 || line.Offset != 100 {
+		t.Fatalf("expected the next line after the gap, got %+v, %v", line, err)
+	}
+}
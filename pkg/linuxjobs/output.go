@@ -0,0 +1,218 @@
+package linuxjobs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// outputBuffer stores a job's output. Offsets are absolute positions in the
+// output stream, so they stay valid even if an implementation discards old data.
+type outputBuffer interface {
+	write(p []byte) (int, error)
+	// readAt copies data starting at offset into p. If offset points before the
+	// oldest retained byte, reading starts from the oldest retained byte instead.
+	// It returns the number of bytes copied and the offset they start at.
+	readAt(p []byte, offset int) (int, int, error)
+	// len returns the total number of bytes ever written.
+	len() int
+	// start returns the offset of the oldest retained byte.
+	start() int
+	bytes() []byte
+	// memBytes returns the number of bytes held in memory.
+	memBytes() int
+	// close releases the resources held by the buffer.
+	close() error
+}
+
+// lockedBuffer is a threadsafe buffer used for storing process output.
+// Offsets are absolute positions in the output stream. When a retention
+// policy is set, the buffer discards the oldest output as the policy decides.
+type lockedBuffer struct {
+	mu     sync.RWMutex
+	b      *bytes.Buffer
+	n      int             // total bytes ever written
+	policy RetentionPolicy // retains all output if nil
+	log    writeLog        // write times of the retained output, if policy is set
+}
+
+// newLockedBuffer returns a buffer retaining the output policy retains, or
+// all output if policy is nil.
+func newLockedBuffer(policy RetentionPolicy) *lockedBuffer {
+	return &lockedBuffer{b: new(bytes.Buffer), policy: policy}
+}
+
+func (l *lockedBuffer) write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n, err := l.b.Write(p)
+	l.n += n
+
+	if l.policy != nil && n > 0 {
+		now := time.Now()
+		l.log.add(l.n, now)
+		l.discard(now)
+	}
+	return n, err
+}
+
+// discard drops the output the retention policy no longer retains at now.
+// The caller must hold l.mu for writing.
+func (l *lockedBuffer) discard(now time.Time) {
+	if l.policy == nil {
+		return
+	}
+	out := retainedOutput{start: l.n - l.b.Len(), end: l.n, log: &l.log}
+	if n := min(l.policy.Discard(out, now), l.b.Len()); n > 0 {
+		l.b.Next(n)
+		l.log.trim(l.n - l.b.Len())
+	}
+}
+
+// expire applies the retention policy before the retained output is read,
+// for output that aged out since it was last written.
+func (l *lockedBuffer) expire() {
+	if l.policy == nil {
+		return
+	}
+	l.mu.Lock()
+	l.discard(time.Now())
+	l.mu.Unlock()
+}
+
+func (l *lockedBuffer) len() int {
+	l.mu.RLock()
+	n := l.n
+	l.mu.RUnlock()
+	return n
+}
+
+func (l *lockedBuffer) start() int {
+	l.expire()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.n - l.b.Len()
+}
+
+func (l *lockedBuffer) bytes() []byte {
+	l.expire()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return slices.Clone(l.b.Bytes())
+}
+
+func (l *lockedBuffer) memBytes() int {
+	l.expire()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.b.Len()
+}
+
+func (l *lockedBuffer) readAt(p []byte, offset int) (int, int, error) {
+	l.expire()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	start := l.n - l.b.Len()
+	offset = max(offset, start)
+
+	if offset >= l.n {
+		return 0, offset, io.EOF
+	}
+
+	buf := l.b.Bytes()
+
+	n := copy(p, buf[offset-start:])
+
+	return n, offset, nil
+}
+
+func (l *lockedBuffer) close() error {
+	return nil
+}
+
+// fileBuffer is a threadsafe output buffer backed by a temporary file, so
+// memory usage stays flat regardless of output volume.
+type fileBuffer struct {
+	mu sync.RWMutex
+	f  *os.File
+	n  int
+}
+
+// newFileBuffer creates the backing file in dir, or the default temp dir if empty.
+func newFileBuffer(dir string) (*fileBuffer, error) {
+	f, err := os.CreateTemp(dir, "lpaas-output-*")
+	if err != nil {
+		return nil, fmt.Errorf("create output file: %w", err)
+	}
+	return &fileBuffer{f: f}, nil
+}
+
+func (fb *fileBuffer) write(p []byte) (int, error) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	n, err := fb.f.Write(p)
+	fb.n += n
+	return n, err
+}
+
+func (fb *fileBuffer) len() int {
+	fb.mu.RLock()
+	defer fb.mu.RUnlock()
+	return fb.n
+}
+
+func (fb *fileBuffer) start() int {
+	return 0
+}
+
+func (fb *fileBuffer) bytes() []byte {
+	fb.mu.RLock()
+	defer fb.mu.RUnlock()
+
+	b := make([]byte, fb.n)
+	n, _ := fb.f.ReadAt(b, 0)
+	return b[:n]
+}
+
+func (fb *fileBuffer) memBytes() int {
+	return 0
+}
+
+func (fb *fileBuffer) readAt(p []byte, offset int) (int, int, error) {
+	fb.mu.RLock()
+	defer fb.mu.RUnlock()
+
+	offset = max(offset, 0)
+	if offset >= fb.n {
+		return 0, offset, io.EOF
+	}
+
+	// Only read up to the bytes fully written so far.
+	p = p[:min(len(p), fb.n-offset)]
+	n, err := fb.f.ReadAt(p, int64(offset))
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, offset, err
+}
+
+// close closes and removes the backing file.
+func (fb *fileBuffer) close() error {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	if err := fb.f.Close(); err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+	if err := os.Remove(fb.f.Name()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package linuxjobs
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestFileBuffer_WriteAndReadAt(t *testing.T) {
+	fb, err := newFileBuffer(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer fb.close()
+
+	if _, err := fb.write([]byte("hello ")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if _, err := fb.write([]byte("world")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if fb.len() != 11 {
+		t.Fatalf("expected length 11, got %d", fb.len())
+	}
+
+	buf := make([]byte, 5)
+	n, from, err := fb.readAt(buf, 6)
+	if err != nil || from != 6 || string(buf[:n]) != "world" {
+		t.Fatalf("unexpected readAt: n=%d from=%d err=%v data=%q", n, from, err, buf[:n])
+	}
+
+	if _, _, err := fb.readAt(buf, 11); err != io.EOF {
+		t.Fatalf("expected EOF at end, got %v", err)
+	}
+}
+
+func TestFileBuffer_CloseRemovesFile(t *testing.T) {
+	fb, err := newFileBuffer(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	name := fb.f.Name()
+
+	if err := fb.close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Fatalf("expected output file removed, got %v", err)
+	}
+}
+
+func TestStream_CompletedJobReadsFullFile(t *testing.T) {
+	fb, err := newFileBuffer(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer fb.close()
+
+	j := newTestJob()
+	j.outBuf = fb
+	w := &notifyingWriter{job: j}
+	w.Write([]byte("first\n"))
+	w.Write([]byte("second\n"))
+	j.status = exited
+
+	rc := j.stream()
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Fatalf("unexpected output: %q", data)
+	}
+}
@@ -0,0 +1,76 @@
+package linuxjobs
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrCommandNotAllowed is returned when the command of a job is not allowed by
+// the manager's command policy.
+var ErrCommandNotAllowed = errors.New("command not allowed")
+
+// CommandPolicy restricts the binaries jobs may run. Entries are either
+// absolute paths or binary basenames such as "bash", matched against the
+// command as resolved with exec.LookPath, so "bash" and "/bin/bash" are
+// treated alike. Absolute paths match through symlinks.
+//
+// A command must match an Allow entry, unless Allow is empty, and must not
+// match any Deny entry. The zero CommandPolicy allows all commands.
+type CommandPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// WithCommandPolicy rejects jobs whose command p does not allow with
+// ErrCommandNotAllowed. NewJobManager fails if p is invalid.
+func WithCommandPolicy(p CommandPolicy) Option {
+	return func(jm *JobManager) {
+		jm.policy = p
+	}
+}
+
+// Validate checks that every entry of the policy is an absolute path or a
+// basename.
+func (p CommandPolicy) Validate() error {
+	for _, entry := range slices.Concat(p.Allow, p.Deny) {
+		if entry == "" || (!filepath.IsAbs(entry) && strings.Contains(entry, "/")) {
+			return fmt.Errorf("command policy entry %q is neither an absolute path nor a basename", entry)
+		}
+	}
+	return nil
+}
+
+// check returns ErrCommandNotAllowed unless the policy allows command, which
+// resolves to the absolute path.
+func (p CommandPolicy) check(command, path string) error {
+	if len(p.Allow) == 0 && len(p.Deny) == 0 {
+		return nil
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		real = path
+	}
+
+	matches := func(entry string) bool {
+		if !filepath.IsAbs(entry) {
+			return entry == filepath.Base(path) || entry == filepath.Base(real)
+		}
+		if entry == path {
+			return true
+		}
+		realEntry, err := filepath.EvalSymlinks(entry)
+		return err == nil && realEntry == real
+	}
+
+	if len(p.Allow) > 0 && !slices.ContainsFunc(p.Allow, matches) {
+		return fmt.Errorf("%w: %s is not in the allowlist", ErrCommandNotAllowed, command)
+	}
+	if slices.ContainsFunc(p.Deny, matches) {
+		return fmt.Errorf("%w: %s is in the denylist", ErrCommandNotAllowed, command)
+	}
+	return nil
+}
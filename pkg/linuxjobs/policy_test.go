@@ -0,0 +1,88 @@
+package linuxjobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandPolicy_Check(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "tool")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.Symlink(tool, filepath.Join(dir, "alias")); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		name    string
+		policy  CommandPolicy
+		command string
+		allowed bool
+	}{
+		{"empty policy", CommandPolicy{}, "tool", true},
+		{"allowed basename", CommandPolicy{Allow: []string{"tool"}}, "tool", true},
+		{"allowed basename by path", CommandPolicy{Allow: []string{"tool"}}, tool, true},
+		{"allowed path by basename", CommandPolicy{Allow: []string{tool}}, "tool", true},
+		{"allowed path through symlink", CommandPolicy{Allow: []string{tool}}, "alias", true},
+		{"not in allowlist", CommandPolicy{Allow: []string{"tool"}}, "other", false},
+		{"denied basename", CommandPolicy{Deny: []string{"tool"}}, tool, false},
+		{"denied through symlink", CommandPolicy{Deny: []string{"tool"}}, "alias", false},
+		{"not in denylist", CommandPolicy{Deny: []string{"tool"}}, "other", true},
+		{"deny wins", CommandPolicy{Allow: []string{"tool"}, Deny: []string{tool}}, "tool", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := JobSpec{Command: tt.command}.resolveCommand()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err = tt.policy.check(tt.command, path)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected %s to be allowed, got %v", tt.command, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrCommandNotAllowed) {
+				t.Fatalf("expected ErrCommandNotAllowed for %s, got %v", tt.command, err)
+			}
+		})
+	}
+}
+
+func TestCommandPolicy_Validate(t *testing.T) {
+	if err := (CommandPolicy{Allow: []string{"bash", "/bin/sh"}, Deny: []string{"rm"}}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, entry := range []string{"", "bin/bash", "./tool"} {
+		if err := (CommandPolicy{Deny: []string{entry}}).Validate(); err == nil {
+			t.Fatalf("expected entry %q to be rejected", entry)
+		}
+	}
+}
+
+func TestStartJob_CommandNotAllowed(t *testing.T) {
+	jm, err := NewJobManager(WithCommandPolicy(CommandPolicy{Allow: []string{"true"}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jm.StartJob(context.Background(), "false"); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Fatalf("expected ErrCommandNotAllowed, got %v", err)
+	}
+	if _, err := jm.QueueJob(context.Background(), JobSpec{Command: "false"}); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Fatalf("expected ErrCommandNotAllowed, got %v", err)
+	}
+	if len(jm.JobIDs()) != 0 {
+		t.Fatalf("expected no jobs, got %v", jm.JobIDs())
+	}
+
+	if _, err := NewJobManager(WithCommandPolicy(CommandPolicy{Allow: []string{"bin/true"}})); err == nil {
+		t.Fatalf("expected an invalid policy to be rejected")
+	}
+}
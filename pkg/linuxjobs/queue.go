@@ -0,0 +1,209 @@
+package linuxjobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Slots limits the number of jobs running at the same time across all the
+// managers sharing it, e.g. all the owners of a worker. It is safe for
+// concurrent use.
+type Slots struct {
+	mu       sync.Mutex
+	max      int
+	used     int
+	managers map[*JobManager]struct{} // scheduled when a slot is freed
+}
+
+// NewSlots returns Slots allowing n running jobs. A value <= 0 disables the
+// limit.
+func NewSlots(n int) *Slots {
+	return &Slots{
+		max:      n,
+		managers: make(map[*JobManager]struct{}),
+	}
+}
+
+// WithSharedSlots counts the jobs of the manager against slots, in addition
+// to the manager's own WithMaxRunningJobs limit.
+func WithSharedSlots(slots *Slots) Option {
+	return func(jm *JobManager) {
+		jm.slots = slots
+	}
+}
+
+// tryAcquire takes a slot, reporting whether one was free.
+func (s *Slots) tryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.max > 0 && s.used >= s.max {
+		return false
+	}
+	s.used++
+	return true
+}
+
+// release frees a slot taken by tryAcquire and lets the managers sharing the
+// slots start a queued job in it.
+func (s *Slots) release() {
+	s.mu.Lock()
+	s.used--
+	managers := slices.Collect(maps.Keys(s.managers))
+	s.mu.Unlock()
+
+	// Called without the lock, as schedule acquires slots.
+	for _, jm := range managers {
+		jm.schedule()
+	}
+}
+
+func (s *Slots) watch(jm *JobManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.managers[jm] = struct{}{}
+}
+
+func (s *Slots) unwatch(jm *JobManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.managers, jm)
+}
+
+// acquireSharedSlot takes a slot of the manager's shared slots, if any. It
+// returns ErrTooManyJobs if none is free. The returned function frees the
+// slot; it may be called more than once.
+func (jm *JobManager) acquireSharedSlot() (func(), error) {
+	if jm.slots == nil {
+		return func() {}, nil
+	}
+	if !jm.slots.tryAcquire() {
+		return nil, fmt.Errorf("%w: worker limit is %d", ErrTooManyJobs, jm.slots.max)
+	}
+	return sync.OnceFunc(jm.slots.release), nil
+}
+
+// QueueJob validates the spec and starts a job from it like StartJobWithSpec
+// if the running jobs limits allow. Otherwise the job is queued with status
+// Queued and started once a running job finishes, in the order jobs were
+// queued. Its output can be streamed and it can be stopped while it waits.
+// A queued job does not depend on ctx, which only bounds starting the job
+// right away.
+func (jm *JobManager) QueueJob(ctx context.Context, spec JobSpec) (string, error) {
+	path, err := jm.checkSpec(spec)
+	if err != nil {
+		return "", err
+	}
+
+	jm.mu.Lock()
+	waiting := len(jm.queue) > 0
+	jm.mu.Unlock()
+
+	// Jobs do not overtake those already waiting.
+	if !waiting {
+		id, err := jm.StartJobWithSpec(ctx, spec)
+		if !errors.Is(err, ErrTooManyJobs) {
+			return id, err
+		}
+	}
+
+	spec.Resources = spec.Resources.withDefaults(jm.resources)
+	out, err := jm.newOutputBuffer(spec)
+	if err != nil {
+		return "", fmt.Errorf("create output buffer: %w", err)
+	}
+
+	// The cgroup is created once the job leaves the queue.
+	job := newJobInCgroup(newJobID(), spec, noCgroup{})
+	job.path = path
+	job.status = queued
+	jm.configureJob(job, out)
+	job.mu.Lock()
+	pending := job.persist()
+	job.mu.Unlock()
+	job.save(pending)
+
+	jm.mu.Lock()
+	jm.jobs[job.ID] = job
+	jm.queue = append(jm.queue, job)
+	jm.mu.Unlock()
+	job.logger.Info("job queued")
+
+	// A job may have finished since the limits were checked.
+	jm.schedule()
+
+	return job.ID, nil
+}
+
+// schedule starts queued jobs for as long as the running jobs limits allow.
+func (jm *JobManager) schedule() {
+	for {
+		jm.mu.Lock()
+		if len(jm.queue) == 0 || jm.shuttingDown || (jm.maxRunning > 0 && jm.runningJobs()+jm.starting >= jm.maxRunning) {
+			jm.mu.Unlock()
+			return
+		}
+		release, err := jm.acquireSharedSlot()
+		if err != nil {
+			jm.mu.Unlock()
+			return
+		}
+		job := jm.queue[0]
+		jm.queue = jm.queue[1:]
+		jm.starting++
+		jm.mu.Unlock()
+
+		jm.launch(job, release)
+		jm.releaseSlot()
+	}
+}
+
+// launch starts a job taken off the queue in the shared slot release frees.
+// A job that cannot be started ends as Failed.
+func (jm *JobManager) launch(job *job, release func()) {
+	cg, warnings, err := jm.jobCgroup(job.ID, job.spec)
+	if err != nil {
+		release()
+		job.logger.Error("job failed to start", "error", err)
+		job.finishUnstarted(failed, fmt.Errorf("create job: %w", err))
+		return
+	}
+	job.mu.Lock()
+	job.cgroup = cg
+	job.limitWarnings = warnings
+	job.mu.Unlock()
+	job.onFinish = jm.finishHook(release)
+
+	if job.spec.PrivateTmp {
+		if err := jm.createTempDir(job); err != nil {
+			release()
+			err = errors.Join(err, cg.delete())
+			job.logger.Error("job failed to start", "error", err)
+			job.finishUnstarted(failed, err)
+			return
+		}
+	}
+
+	// start logs its failures.
+	if err := job.start(context.Background()); err != nil {
+		release()
+		_ = job.removeTempDir()
+	}
+}
+
+// dequeue removes job from the queue, reporting whether it was waiting there.
+func (jm *JobManager) dequeue(job *job) bool {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	i := slices.Index(jm.queue, job)
+	if i < 0 {
+		return false
+	}
+	jm.queue = slices.Delete(jm.queue, i, i+1)
+	return true
+}
@@ -0,0 +1,145 @@
+package linuxjobs
+
+import "time"
+
+// retentionResolution is how precisely the write times of retained output are
+// tracked. Writes within this span of each other share the time of the last,
+// so time-bounded retention may keep output up to this much longer.
+const retentionResolution = time.Second
+
+// RetentionPolicy decides how much of a job's output is retained in memory.
+// Output is discarded oldest first; streams that had not read it yet report
+// the gap. The policy is consulted after every write and whenever the
+// retained output is read, so time-bounded policies take effect even while a
+// job is silent.
+type RetentionPolicy interface {
+	// Discard returns how many of the oldest bytes of out to discard at now.
+	Discard(out RetainedOutput, now time.Time) int
+}
+
+// RetainedOutput describes the output a job retains, for a RetentionPolicy
+// to decide what to discard.
+type RetainedOutput interface {
+	// Len returns the number of bytes retained.
+	Len() int
+	// WrittenBefore returns the number of the oldest retained bytes that were
+	// written before t.
+	WrittenBefore(t time.Time) int
+}
+
+// UnlimitedRetention returns a policy retaining all output.
+func UnlimitedRetention() RetentionPolicy {
+	return unlimitedRetention{}
+}
+
+type unlimitedRetention struct{}
+
+func (unlimitedRetention) Discard(RetainedOutput, time.Time) int {
+	return 0
+}
+
+// SizeRetention returns a policy retaining at most the last maxBytes of
+// output. A value <= 0 retains all output.
+func SizeRetention(maxBytes int) RetentionPolicy {
+	if maxBytes <= 0 {
+		return unlimitedRetention{}
+	}
+	return sizeRetention(maxBytes)
+}
+
+type sizeRetention int
+
+func (s sizeRetention) Discard(out RetainedOutput, _ time.Time) int {
+	return max(out.Len()-int(s), 0)
+}
+
+// TimeRetention returns a policy retaining only the output written within
+// the last d. A value <= 0 retains all output.
+func TimeRetention(d time.Duration) RetentionPolicy {
+	if d <= 0 {
+		return unlimitedRetention{}
+	}
+	return timeRetention(d)
+}
+
+type timeRetention time.Duration
+
+func (d timeRetention) Discard(out RetainedOutput, now time.Time) int {
+	return out.WrittenBefore(now.Add(-time.Duration(d)))
+}
+
+// CombinedRetention returns a policy retaining only the output every one of
+// policies retains, e.g. at most 10 MB written within the last hour. Nil
+// policies are ignored.
+func CombinedRetention(policies ...RetentionPolicy) RetentionPolicy {
+	var c combinedRetention
+	for _, p := range policies {
+		if p != nil {
+			c = append(c, p)
+		}
+	}
+	return c
+}
+
+type combinedRetention []RetentionPolicy
+
+func (c combinedRetention) Discard(out RetainedOutput, now time.Time) int {
+	n := 0
+	for _, p := range c {
+		n = max(n, p.Discard(out, now))
+	}
+	return n
+}
+
+// outputChunk records that the output up to end was last written to at
+// written, in writes that began at opened.
+type outputChunk struct {
+	end             int
+	opened, written time.Time
+}
+
+// writeLog tracks when the retained output of a buffer was written.
+type writeLog struct {
+	chunks []outputChunk // oldest first
+}
+
+// add records that the output up to end was written at now.
+func (w *writeLog) add(end int, now time.Time) {
+	if n := len(w.chunks); n > 0 && now.Sub(w.chunks[n-1].opened) < retentionResolution {
+		w.chunks[n-1].end = end
+		w.chunks[n-1].written = now
+		return
+	}
+	w.chunks = append(w.chunks, outputChunk{end: end, opened: now, written: now})
+}
+
+// trim forgets the chunks of output before start, which was discarded.
+func (w *writeLog) trim(start int) {
+	i := 0
+	for i < len(w.chunks) && w.chunks[i].end <= start {
+		i++
+	}
+	w.chunks = w.chunks[i:]
+}
+
+// retainedOutput is the RetainedOutput of a buffer retaining the output
+// from start to end.
+type retainedOutput struct {
+	start, end int
+	log        *writeLog
+}
+
+func (r retainedOutput) Len() int {
+	return r.end - r.start
+}
+
+func (r retainedOutput) WrittenBefore(t time.Time) int {
+	n := 0
+	for _, c := range r.log.chunks {
+		if !c.written.Before(t) {
+			break
+		}
+		n = c.end - r.start
+	}
+	return max(n, 0)
+}
@@ -0,0 +1,118 @@
+package linuxjobs
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicies_Discard(t *testing.T) {
+	t0 := time.Now()
+	var log writeLog
+	log.add(10, t0)
+	log.add(30, t0.Add(time.Minute))
+	log.add(60, t0.Add(2*time.Minute))
+	out := retainedOutput{start: 5, end: 60, log: &log}
+	now := t0.Add(2*time.Minute + time.Second)
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   int
+	}{
+		{"unlimited", UnlimitedRetention(), 0},
+		{"size within cap", SizeRetention(100), 0},
+		{"size beyond cap", SizeRetention(20), 35},
+		{"size disabled", SizeRetention(0), 0},
+		{"time keeps recent writes", TimeRetention(time.Hour), 0},
+		{"time drops old writes", TimeRetention(90 * time.Second), 5},
+		{"time drops all but newest", TimeRetention(30 * time.Second), 25},
+		{"time disabled", TimeRetention(0), 0},
+		{"combined takes the most", CombinedRetention(SizeRetention(50), TimeRetention(90*time.Second), nil), 5},
+		{"combined empty", CombinedRetention(), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Discard(out, now); got != tt.want {
+				t.Fatalf("expected %d bytes discarded, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteLog_CoalescesWritesWithinResolution(t *testing.T) {
+	t0 := time.Now()
+	var log writeLog
+	log.add(1, t0)
+	log.add(2, t0.Add(retentionResolution/2))
+	log.add(3, t0.Add(retentionResolution))
+
+	if len(log.chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %+v", log.chunks)
+	}
+	if c := log.chunks[0]; c.end != 2 || !c.written.Equal(t0.Add(retentionResolution/2)) {
+		t.Fatalf("expected first chunk to end at 2 with the later write time, got %+v", c)
+	}
+
+	log.trim(2)
+	if len(log.chunks) != 1 || log.chunks[0].end != 3 {
+		t.Fatalf("expected only the last chunk kept, got %+v", log.chunks)
+	}
+}
+
+func TestLockedBuffer_TimeRetentionExpiresSilentOutput(t *testing.T) {
+	lb := newLockedBuffer(TimeRetention(50 * time.Millisecond))
+	if _, err := lb.write([]byte("old")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if got := lb.bytes(); string(got) != "old" {
+		t.Fatalf("expected 'old' retained, got %q", got)
+	}
+
+	// Nothing is written meanwhile; the output expires when it is read.
+	time.Sleep(100 * time.Millisecond)
+	if got := lb.start(); got != 3 {
+		t.Fatalf("expected oldest retained offset 3, got %d", got)
+	}
+	if got := lb.memBytes(); got != 0 {
+		t.Fatalf("expected no output retained, got %d bytes", got)
+	}
+
+	if _, err := lb.write([]byte("new")); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if got := lb.bytes(); string(got) != "new" {
+		t.Fatalf("expected 'new' retained, got %q", got)
+	}
+}
+
+func TestStreamingReader_ReportsOutputExpiredByRetention(t *testing.T) {
+	j := newTestJob()
+	j.outBuf = newLockedBuffer(TimeRetention(retentionResolution / 2))
+	j.outBuf.write([]byte("expired"))
+	j.done = make(chan struct{})
+
+	r := &streamingReader{
+		ctx:     context.Background(),
+		job:     j,
+		offset:  0,
+		newData: make(chan struct{}, 1),
+	}
+
+	// Written after the resolution, so not tracked along with the old output.
+	time.Sleep(retentionResolution + 100*time.Millisecond)
+	j.outBuf.write([]byte("kept"))
+	close(j.done)
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "kept" {
+		t.Fatalf("expected only 'kept', got %q", data)
+	}
+	if !r.Skipped() {
+		t.Fatalf("expected the reader to report the discarded output")
+	}
+}
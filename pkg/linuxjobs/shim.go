@@ -0,0 +1,201 @@
+package linuxjobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Some job setup, such as rlimits, niceness or bind mounts, must happen in the child between fork and
+// exec, which os/exec offers no hook for. For those jobs the worker re-executes
+// itself as a small shim that applies the setup and then execs the real command
+// in place, so the PID and cgroup membership are preserved.
+const (
+	shimArg0 = "lpaas-init"
+	shimEnv  = "LPAAS_SHIM_CONFIG"
+	shimPath = "/proc/self/exe"
+)
+
+// shimConfig is passed from the worker to the shim through the environment.
+// The command's args are passed as the shim's own args instead, as the kernel
+// limits each environment string to 128 KiB.
+type shimConfig struct {
+	Path        string      `json:"path"`
+	NofileLimit uint64      `json:"nofileLimit,omitempty"`
+	Nice        int         `json:"nice,omitempty"`
+	BindMounts  []BindMount `json:"bindMounts,omitempty"`
+	Namespaces  Namespaces  `json:"namespaces,omitzero"`
+	// Credential is switched to after the setup, which may need the
+	// worker's privileges, right before the exec.
+	Credential *Credential `json:"credential,omitempty"`
+}
+
+// needed reports whether the config requires any pre-exec setup.
+func (c shimConfig) needed() bool {
+	return c.NofileLimit > 0 || c.Nice != 0 || len(c.BindMounts) > 0 || c.Namespaces.any()
+}
+
+// wrap rewrites cmd to start the shim, which later execs the original command.
+// The shim's args are shimArg0, "--" and the args of the command, including
+// its argv[0].
+func (c shimConfig) wrap(cmd *exec.Cmd) error {
+	c.Path = cmd.Path
+
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("encode shim config: %w", err)
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+
+	cmd.Path = shimPath
+	cmd.Args = append([]string{shimArg0, "--"}, cmd.Args...)
+	cmd.Env = append(env, shimEnv+"="+string(raw))
+	return nil
+}
+
+// Init must be called first thing in main by every binary that runs jobs
+// through linuxjobs. When the process was started as a job's shim, Init applies
+// the job's pre-exec setup and execs the job's command, never returning.
+// Otherwise it returns immediately.
+func Init() {
+	if len(os.Args) == 0 || os.Args[0] != shimArg0 {
+		return
+	}
+
+	if err := runShim(); err != nil {
+		fmt.Fprintf(os.Stderr, "lpaas-init: %v\n", err)
+		os.Exit(127)
+	}
+}
+
+// runShim applies the setup described by the shim config and execs the command.
+func runShim() error {
+	var cfg shimConfig
+	if err := json.Unmarshal([]byte(os.Getenv(shimEnv)), &cfg); err != nil {
+		return fmt.Errorf("decode shim config: %w", err)
+	}
+	os.Unsetenv(shimEnv)
+	if len(os.Args) < 3 || os.Args[1] != "--" {
+		return fmt.Errorf("missing command args")
+	}
+	args := os.Args[2:]
+
+	if cfg.Nice != 0 {
+		// The niceness is a property of the calling thread, which must be
+		// the one that execs the command for it to apply.
+		runtime.LockOSThread()
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, cfg.Nice); err != nil {
+			return fmt.Errorf("set nice %d: %w", cfg.Nice, err)
+		}
+	}
+
+	if cfg.NofileLimit > 0 {
+		rl := syscall.Rlimit{Cur: cfg.NofileLimit, Max: cfg.NofileLimit}
+		if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+			return fmt.Errorf("set nofile limit: %w", err)
+		}
+	}
+
+	if len(cfg.BindMounts) > 0 || cfg.Namespaces.PID || cfg.Namespaces.Mount {
+		// Keep the job's mounts from propagating back to the host's namespace.
+		if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+			return fmt.Errorf("make mounts private: %w", err)
+		}
+	}
+
+	if len(cfg.BindMounts) > 0 {
+		if err := bindMounts(cfg.BindMounts); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Namespaces.PID {
+		// The inherited /proc shows the host's processes.
+		if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+			return fmt.Errorf("mount /proc: %w", err)
+		}
+	}
+
+	if cfg.Namespaces.Network {
+		if err := loopbackUp(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Credential != nil {
+		if err := setCredential(cfg.Credential); err != nil {
+			return err
+		}
+	}
+
+	if err := syscall.Exec(cfg.Path, args, os.Environ()); err != nil {
+		return fmt.Errorf("exec %s: %w", cfg.Path, err)
+	}
+	return nil
+}
+
+// bindMounts performs read-only bind mounts in the shim's own mount namespace,
+// whose mounts must be private.
+func bindMounts(mounts []BindMount) error {
+	for _, m := range mounts {
+		if err := unix.Mount(m.Source, m.Target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("bind mount %s at %s: %w", m.Source, m.Target, err)
+		}
+		// A bind mount only becomes read-only when remounted.
+		if err := unix.Mount("", m.Target, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY, ""); err != nil {
+			return fmt.Errorf("make %s read-only: %w", m.Target, err)
+		}
+	}
+	return nil
+}
+
+// loopbackUp brings up the loopback interface of a new network namespace,
+// which starts out down.
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("bring up loopback: %w", err)
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return fmt.Errorf("bring up loopback: %w", err)
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return fmt.Errorf("bring up loopback: %w", err)
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	if err := unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr); err != nil {
+		return fmt.Errorf("bring up loopback: %w", err)
+	}
+	return nil
+}
+
+// setCredential switches the shim to the user and groups of c. The groups go
+// first, while the shim still has the privileges to change them.
+func setCredential(c *Credential) error {
+	groups := make([]int, len(c.Groups))
+	for i, g := range c.Groups {
+		groups[i] = int(g)
+	}
+	if err := syscall.Setgroups(groups); err != nil {
+		return fmt.Errorf("set supplementary groups: %w", err)
+	}
+	if err := syscall.Setgid(int(c.GID)); err != nil {
+		return fmt.Errorf("set gid %d: %w", c.GID, err)
+	}
+	if err := syscall.Setuid(int(c.UID)); err != nil {
+		return fmt.Errorf("set uid %d: %w", c.UID, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package linuxjobs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrSignalNotAllowed is returned for signals clients may not deliver to jobs.
+var ErrSignalNotAllowed = errors.New("signal not allowed")
+
+// allowedSignals are the signals clients may deliver to a job, keyed by name.
+var allowedSignals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGCONT": syscall.SIGCONT,
+	"SIGSTOP": syscall.SIGSTOP,
+}
+
+// ParseSignal parses a signal given by name ("SIGHUP" or "HUP", case
+// insensitive) or number ("1"). Signals outside the allowed set wrap
+// ErrSignalNotAllowed.
+func ParseSignal(s string) (syscall.Signal, error) {
+	if num, err := strconv.Atoi(s); err == nil {
+		sig := syscall.Signal(num)
+		if !signalAllowed(sig) {
+			return 0, fmt.Errorf("%w: %d", ErrSignalNotAllowed, num)
+		}
+		return sig, nil
+	}
+
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, ok := allowedSignals[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrSignalNotAllowed, s)
+	}
+	return sig, nil
+}
+
+// signalAllowed reports whether sig may be delivered to a job.
+func signalAllowed(sig syscall.Signal) bool {
+	for _, allowed := range allowedSignals {
+		if sig == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package linuxjobs
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want syscall.Signal
+	}{
+		{"SIGHUP", syscall.SIGHUP},
+		{"hup", syscall.SIGHUP},
+		{"USR1", syscall.SIGUSR1},
+		{"15", syscall.SIGTERM},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseSignal(tc.in)
+		if err != nil {
+			t.Fatalf("ParseSignal(%q): unexpected error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("ParseSignal(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseSignal_RejectsDisallowed(t *testing.T) {
+	for _, in := range []string{"SIGSEGV", "11", "NOPE", ""} {
+		if _, err := ParseSignal(in); !errors.Is(err, ErrSignalNotAllowed) {
+			t.Fatalf("ParseSignal(%q): expected ErrSignalNotAllowed, got %v", in, err)
+		}
+	}
+}
+
+func TestJobSignal_NotRunning(t *testing.T) {
+	j := newTestJob()
+	j.status = exited
+
+	if err := j.signal(syscall.SIGHUP); !errors.Is(err, ErrJobNotRunning) {
+		t.Fatalf("expected ErrJobNotRunning, got %v", err)
+	}
+}
+
+func TestSignal_RejectsDisallowed(t *testing.T) {
+	jm := &JobManager{jobs: make(map[string]*job)}
+	if err := jm.Signal("job-1", syscall.SIGSEGV); !errors.Is(err, ErrSignalNotAllowed) {
+		t.Fatalf("expected ErrSignalNotAllowed, got %v", err)
+	}
+}
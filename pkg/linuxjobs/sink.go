@@ -0,0 +1,105 @@
+package linuxjobs
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// SinkKey identifies the job a message published to an OutputSink belongs to,
+// e.g. to derive a broker topic or message key from.
+type SinkKey struct {
+	Owner string // set by WithOutputSink, e.g. the client the jobs belong to
+	JobID string
+}
+
+// JobEventType is the kind of lifecycle event published to an OutputSink.
+type JobEventType int
+
+const (
+	JobStarted JobEventType = iota
+	JobFinished
+)
+
+func (t JobEventType) String() string {
+	switch t {
+	case JobStarted:
+		return "started"
+	case JobFinished:
+		return "finished"
+	default:
+		return "unknown"
+	}
+}
+
+// JobEvent is a lifecycle event of a job.
+type JobEvent struct {
+	Type     JobEventType
+	Time     time.Time
+	Status   string // status of the job after the event, e.g. "Running" or "Exited"
+	ExitCode int    // set for JobFinished
+}
+
+// OutputSink receives the output and lifecycle events of jobs as they happen,
+// e.g. to publish them to a message broker such as Kafka or NATS.
+//
+// For each job, JobStarted is published first, followed by its output in the
+// order it was written and finally JobFinished. Methods are called
+// synchronously from the job's output handling and must not block; sinks
+// talking to remote systems should buffer and publish asynchronously.
+// Implementations must be safe for concurrent use.
+type OutputSink interface {
+	// PublishOutput publishes output written by a job to source. data must not
+	// be retained after the call returns.
+	PublishOutput(key SinkKey, source OutputStream, data []byte)
+	// PublishEvent publishes a lifecycle event of a job.
+	PublishEvent(key SinkKey, ev JobEvent)
+}
+
+// NoopSink discards everything published to it.
+type NoopSink struct{}
+
+func (NoopSink) PublishOutput(SinkKey, OutputStream, []byte) {}
+
+func (NoopSink) PublishEvent(SinkKey, JobEvent) {}
+
+// MemorySink keeps everything published to it in memory, for tests.
+type MemorySink struct {
+	mu     sync.Mutex
+	output map[SinkKey][]byte
+	events map[SinkKey][]JobEvent
+}
+
+// NewMemorySink returns an empty MemorySink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{
+		output: make(map[SinkKey][]byte),
+		events: make(map[SinkKey][]JobEvent),
+	}
+}
+
+func (s *MemorySink) PublishOutput(key SinkKey, _ OutputStream, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output[key] = append(s.output[key], data...)
+}
+
+func (s *MemorySink) PublishEvent(key SinkKey, ev JobEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[key] = append(s.events[key], ev)
+}
+
+// Output returns the output published for key, stdout and stderr combined.
+func (s *MemorySink) Output(key SinkKey) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.output[key])
+}
+
+// Events returns the lifecycle events published for key.
+func (s *MemorySink) Events(key SinkKey) []JobEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.events[key])
+}
@@ -0,0 +1,498 @@
+package linuxjobs
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrInvalidSpec is returned when a JobSpec fails validation.
+var ErrInvalidSpec = errors.New("invalid job spec")
+
+// ErrNotPermitted is returned when a JobSpec needs privileges the worker lacks,
+// such as running the job as another user.
+var ErrNotPermitted = errors.New("worker lacks the privileges for job spec")
+
+const (
+	maxLabels      = 64 // labels per job
+	maxLabelLength = 63 // bytes per label key or value
+	minNice        = -20
+	maxNice        = 19
+)
+
+// JobSpec describes the command a job runs and how it is run.
+type JobSpec struct {
+	Command string
+	Args    []string
+
+	// Argv0 is passed to the command as argv[0] instead of Command, e.g. to
+	// select a busybox applet or give the process a recognizable name.
+	// Command is still the binary that is executed.
+	Argv0 string
+
+	// Env holds KEY=VALUE entries added to the environment inherited from the
+	// worker. They take precedence over the worker's variables, and when a key
+	// appears more than once the last entry wins.
+	Env []string
+
+	// WorkingDir is the absolute path of an existing directory the job runs in.
+	// Empty runs the job in the worker's working directory.
+	WorkingDir string
+
+	// PrivateTmp creates a temporary directory for the job alone, under the
+	// manager's temp root, and points TMPDIR at it unless Env sets TMPDIR.
+	// The directory is removed along with the job.
+	PrivateTmp bool
+
+	// WorkInPrivateTmp runs the job in its private temporary directory.
+	// It requires PrivateTmp and excludes WorkingDir.
+	WorkInPrivateTmp bool
+
+	// Credential runs the job as another user and group, e.g. an unprivileged
+	// one. Nil runs the job as the worker's user. It requires the worker to
+	// have CAP_SETUID and CAP_SETGID.
+	Credential *Credential
+
+	// NofileLimit caps the number of open files (RLIMIT_NOFILE) of the job.
+	// Zero keeps the limit inherited from the worker.
+	NofileLimit uint64
+
+	// Nice sets the scheduling niceness of the job, from -20, the highest
+	// priority, to 19, the lowest. Zero keeps the niceness inherited from the
+	// worker. Negative values require the worker to have CAP_SYS_NICE.
+	Nice int
+
+	// ConfirmRunning makes StartJob wait until the process is verified to be
+	// live in its cgroup before returning, trading latency for a stronger guarantee.
+	ConfirmRunning bool
+
+	// KillOnDisconnect stops the job when its last streaming client disconnects
+	// and none reconnects within the manager's disconnect grace period.
+	KillOnDisconnect bool
+
+	// Stdin connects the job's standard input to a pipe fed with
+	// JobManager.OpenStdin, instead of /dev/null. The job reads EOF once the
+	// writer is closed or the job finishes.
+	Stdin bool
+
+	// Timeout kills the job once it has run this long, leaving it TimedOut.
+	// Zero lets the job run until it exits or is stopped.
+	Timeout time.Duration
+
+	// MaxOutputBytes kills the job once it has written more than this many
+	// bytes of output in total, leaving it OutputLimitExceeded. Unlike output
+	// retention, which discards old output, it stops jobs stuck printing in a
+	// loop. Zero leaves the output unlimited.
+	MaxOutputBytes uint64
+
+	// BindMounts are exposed read-only inside the job. A job with bind mounts
+	// runs in its own mount namespace, which requires the worker to run as root.
+	BindMounts []BindMount
+
+	// Namespaces isolates the job in Linux namespaces of its own, so it
+	// cannot see or signal processes of other jobs or the host.
+	Namespaces Namespaces
+
+	// Resources sets the cgroup limits of the job. Zero fields fall back to the
+	// manager's default resource profile.
+	Resources ResourceProfile
+
+	// Labels tag the job for clients, e.g. pipeline=build, and can be used to
+	// select jobs. They do not affect how the job runs.
+	Labels map[string]string
+
+	// OutputRetention discards the job's output the policy does not retain,
+	// in addition to what the manager's policy discards. Nil applies the
+	// manager's policy alone.
+	OutputRetention RetentionPolicy
+}
+
+// ResourceProfile describes the cgroup limits applied to a job.
+type ResourceProfile struct {
+	// CPUPercent caps the CPU time the job may use, in percent of one CPU,
+	// with cpu.max. Zero leaves the CPU time uncapped if CPUWeight is set.
+	CPUPercent uint64
+	// CPUWeight shares the CPU among contending jobs in proportion to their
+	// weights with cpu.weight, from 1 to 10000; the kernel's default is 100.
+	// Unlike a cap, it leaves idle CPU to jobs that can use it. Zero keeps
+	// the default weight.
+	CPUWeight uint64
+	// MemoryBytes caps the job's memory usage.
+	MemoryBytes uint64
+	// IOBytesPerSec caps both read and write bandwidth on the I/O devices,
+	// unless IOReadBytesPerSec or IOWriteBytesPerSec set their own.
+	IOBytesPerSec uint64
+	// IOReadBytesPerSec and IOWriteBytesPerSec cap the read and write
+	// bandwidth on the I/O devices, if set.
+	IOReadBytesPerSec  uint64
+	IOWriteBytesPerSec uint64
+	// IOReadIOPS and IOWriteIOPS cap the read and write operations per second
+	// on the I/O devices. Zero leaves them unlimited.
+	IOReadIOPS  uint64
+	IOWriteIOPS uint64
+	// IODevices are the block devices the I/O limits apply to, as
+	// major:minor, e.g. "8:0". A partition stands for its disk. Devices that
+	// do not exist on the host are skipped. Empty limits the disk backing "/".
+	IODevices []string
+	// MaxPIDs caps the number of processes and threads of the job, so a fork
+	// bomb cannot exhaust the host's PIDs. It is not enforced on kernels
+	// without the pids controller.
+	MaxPIDs uint64
+}
+
+// defaultResourceProfile returns the limits used when neither the job nor the
+// manager sets them.
+func defaultResourceProfile() ResourceProfile {
+	return ResourceProfile{
+		CPUPercent:    defaultCPUPercent,
+		MemoryBytes:   defaultMemBytes,
+		IOBytesPerSec: defaultIOBps,
+		MaxPIDs:       defaultMaxPIDs,
+	}
+}
+
+// withDefaults returns p with its zero fields taken from defaults.
+func (p ResourceProfile) withDefaults(defaults ResourceProfile) ResourceProfile {
+	// The CPU cap and weight are taken from defaults together, so a job
+	// setting only a weight is not capped by the default cap.
+	if p.CPUPercent == 0 && p.CPUWeight == 0 {
+		p.CPUPercent = defaults.CPUPercent
+		p.CPUWeight = defaults.CPUWeight
+	}
+	if p.MemoryBytes == 0 {
+		p.MemoryBytes = defaults.MemoryBytes
+	}
+	if p.IOBytesPerSec == 0 {
+		p.IOBytesPerSec = defaults.IOBytesPerSec
+	}
+	if p.IOReadBytesPerSec == 0 {
+		p.IOReadBytesPerSec = defaults.IOReadBytesPerSec
+	}
+	if p.IOWriteBytesPerSec == 0 {
+		p.IOWriteBytesPerSec = defaults.IOWriteBytesPerSec
+	}
+	if p.IOReadIOPS == 0 {
+		p.IOReadIOPS = defaults.IOReadIOPS
+	}
+	if p.IOWriteIOPS == 0 {
+		p.IOWriteIOPS = defaults.IOWriteIOPS
+	}
+	if len(p.IODevices) == 0 {
+		p.IODevices = defaults.IODevices
+	}
+	if p.MaxPIDs == 0 {
+		p.MaxPIDs = defaults.MaxPIDs
+	}
+	return p
+}
+
+// ioMaxKeys are the io.max limits set on each I/O device, in the order the
+// kernel lists them.
+var ioMaxKeys = []string{"rbps", "wbps", "riops", "wiops"}
+
+// ioLimits returns the io.max limits of p by key, 0 meaning unlimited.
+func (p ResourceProfile) ioLimits() map[string]uint64 {
+	return map[string]uint64{
+		"rbps":  cmp.Or(p.IOReadBytesPerSec, p.IOBytesPerSec),
+		"wbps":  cmp.Or(p.IOWriteBytesPerSec, p.IOBytesPerSec),
+		"riops": p.IOReadIOPS,
+		"wiops": p.IOWriteIOPS,
+	}
+}
+
+// Namespaces selects the Linux namespaces a job gets of its own. Creating
+// any of them requires the worker to run as root.
+type Namespaces struct {
+	// PID runs the job as PID 1 of its own PID namespace, with /proc mounted
+	// for it, which implies Mount. Like any PID 1, the job ignores signals it
+	// has no handler for, so stopping it may need the kill after the grace
+	// period. Its remaining processes are killed when it exits.
+	PID bool `json:"pid,omitempty"`
+	// Mount gives the job its own mount namespace. Its mounts do not
+	// propagate to the host.
+	Mount bool `json:"mount,omitempty"`
+	// Network gives the job its own network namespace with only a loopback
+	// interface, so it can reach neither the host's network nor other jobs.
+	Network bool `json:"network,omitempty"`
+}
+
+// any reports whether any namespace is selected.
+func (n Namespaces) any() bool {
+	return n.PID || n.Mount || n.Network
+}
+
+// cloneflags returns the clone flags creating the namespaces of n.
+func (n Namespaces) cloneflags() uintptr {
+	var flags uintptr
+	if n.PID {
+		flags |= syscall.CLONE_NEWPID | syscall.CLONE_NEWNS
+	}
+	if n.Mount {
+		flags |= syscall.CLONE_NEWNS
+	}
+	if n.Network {
+		flags |= syscall.CLONE_NEWNET
+	}
+	return flags
+}
+
+// Credential is the user and groups a job runs as.
+type Credential struct {
+	UID uint32
+	GID uint32
+	// Groups are the supplementary groups of the job. Empty drops those of
+	// the worker.
+	Groups []uint32
+}
+
+// sysCredential returns c for syscall.SysProcAttr.
+func (c *Credential) sysCredential() *syscall.Credential {
+	return &syscall.Credential{Uid: c.UID, Gid: c.GID, Groups: c.Groups}
+}
+
+// BindMount makes the host path Source visible read-only at Target inside a
+// job's mount namespace. Both must be absolute paths to existing files or
+// directories of the same kind.
+type BindMount struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// validate checks the spec before any resources are created for the job. The
+// command is checked by resolveCommand.
+func (s JobSpec) validate() error {
+	if s.Command == "" {
+		return fmt.Errorf("%w: empty command", ErrInvalidSpec)
+	}
+
+	// exec passes strings NUL-terminated, so one with a NUL would be cut short.
+	if strings.ContainsRune(s.Command, 0) || strings.ContainsRune(s.Argv0, 0) {
+		return fmt.Errorf("%w: command contains a NUL byte", ErrInvalidSpec)
+	}
+	for i, arg := range s.Args {
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("%w: arg %d contains a NUL byte", ErrInvalidSpec, i)
+		}
+	}
+
+	// A job started as the shim, with a config of its own, would run its
+	// pre-exec setup unchecked.
+	if s.Argv0 == shimArg0 {
+		return fmt.Errorf("%w: argv0 %q is reserved", ErrInvalidSpec, shimArg0)
+	}
+
+	for _, kv := range s.Env {
+		key, _, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("%w: malformed env entry %q, expected KEY=VALUE", ErrInvalidSpec, kv)
+		}
+		if strings.ContainsRune(kv, 0) {
+			return fmt.Errorf("%w: env entry %s contains a NUL byte", ErrInvalidSpec, key)
+		}
+		if key == shimEnv {
+			return fmt.Errorf("%w: env entry %s is reserved", ErrInvalidSpec, key)
+		}
+	}
+
+	if s.WorkingDir != "" {
+		if !filepath.IsAbs(s.WorkingDir) {
+			return fmt.Errorf("%w: working dir %q is not an absolute path", ErrInvalidSpec, s.WorkingDir)
+		}
+		fi, err := os.Stat(s.WorkingDir)
+		if err != nil {
+			return fmt.Errorf("%w: working dir: %v", ErrInvalidSpec, err)
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("%w: working dir %q is not a directory", ErrInvalidSpec, s.WorkingDir)
+		}
+	}
+
+	if s.WorkInPrivateTmp {
+		if !s.PrivateTmp {
+			return fmt.Errorf("%w: running in the private temp dir requires a private temp dir", ErrInvalidSpec)
+		}
+		if s.WorkingDir != "" {
+			return fmt.Errorf("%w: working dir and running in the private temp dir are mutually exclusive", ErrInvalidSpec)
+		}
+	}
+
+	if s.Timeout < 0 {
+		return fmt.Errorf("%w: negative timeout %v", ErrInvalidSpec, s.Timeout)
+	}
+
+	if s.NofileLimit > 0 {
+		var rl unix.Rlimit
+		if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rl); err != nil {
+			return fmt.Errorf("get nofile limit: %w", err)
+		}
+		if s.NofileLimit > rl.Max {
+			return fmt.Errorf("%w: nofile limit %d exceeds host hard limit %d", ErrInvalidSpec, s.NofileLimit, rl.Max)
+		}
+	}
+
+	if s.Nice < minNice || s.Nice > maxNice {
+		return fmt.Errorf("%w: nice %d is outside [%d, %d]", ErrInvalidSpec, s.Nice, minNice, maxNice)
+	}
+	if s.Nice < 0 && !hasCapabilities(unix.CAP_SYS_NICE) {
+		return fmt.Errorf("%w: negative nice %d requires CAP_SYS_NICE", ErrNotPermitted, s.Nice)
+	}
+
+	if s.Credential != nil && !canSetIDs() {
+		return fmt.Errorf("%w: running as uid %d requires CAP_SETUID and CAP_SETGID", ErrNotPermitted, s.Credential.UID)
+	}
+
+	if s.Namespaces.any() && os.Geteuid() != 0 {
+		return fmt.Errorf("%w: namespaces require the worker to run as root", ErrNotPermitted)
+	}
+
+	if len(s.BindMounts) > 0 && os.Geteuid() != 0 {
+		return fmt.Errorf("%w: bind mounts require the worker to run as root", ErrNotPermitted)
+	}
+	for _, m := range s.BindMounts {
+		if err := m.validate(); err != nil {
+			return err
+		}
+	}
+
+	if w := s.Resources.CPUWeight; w != 0 && (w < minCPUWeight || w > maxCPUWeight) {
+		return fmt.Errorf("%w: CPU weight %d is outside %d-%d", ErrInvalidSpec, w, minCPUWeight, maxCPUWeight)
+	}
+
+	for _, dev := range s.Resources.IODevices {
+		if _, _, err := parseDevice(dev); err != nil {
+			return err
+		}
+	}
+
+	if err := validateLabels(s.Labels); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateLabels checks that there are at most maxLabels labels, whose keys
+// and values are short strings of letters, digits and ".-_/", and keys start
+// with a letter or digit.
+func validateLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return fmt.Errorf("%w: %d labels exceed the limit of %d", ErrInvalidSpec, len(labels), maxLabels)
+	}
+	valid := func(s string) bool {
+		return len(s) <= maxLabelLength && !strings.ContainsFunc(s, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(".-_/", r))
+		})
+	}
+	for key, value := range labels {
+		if key == "" || strings.ContainsRune(".-_/", rune(key[0])) || !valid(key) {
+			return fmt.Errorf("%w: invalid label key %q", ErrInvalidSpec, key)
+		}
+		if !valid(value) {
+			return fmt.Errorf("%w: invalid value %q of label %q", ErrInvalidSpec, value, key)
+		}
+	}
+	return nil
+}
+
+// MatchLabels reports whether labels has every key of selector with the same
+// value. An empty selector matches all labels.
+func MatchLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// canSetIDs reports whether the worker may change the user and groups of the
+// processes it starts.
+func canSetIDs() bool {
+	return hasCapabilities(unix.CAP_SETUID, unix.CAP_SETGID)
+}
+
+// hasCapabilities reports whether the worker has all of caps in its
+// effective set.
+func hasCapabilities(caps ...uint) bool {
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&hdr, &data[0]); err != nil {
+		return false
+	}
+	for _, c := range caps {
+		if data[c/32].Effective&(1<<(c%32)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveCommand returns the absolute path of the binary the job runs. Like
+// os/exec, commands without a slash are looked up in the worker's PATH, while
+// relative paths are relative to the job's working directory.
+func (s JobSpec) resolveCommand() (string, error) {
+	command := s.Command
+	if strings.Contains(command, "/") && !filepath.IsAbs(command) {
+		if s.WorkInPrivateTmp {
+			return "", fmt.Errorf("%w: relative command %s cannot exist in the new private temp dir", ErrInvalidSpec, command)
+		}
+		if s.WorkingDir != "" {
+			command = filepath.Join(s.WorkingDir, command)
+		}
+	}
+
+	path, err := exec.LookPath(command)
+	if err == nil {
+		path, err = filepath.Abs(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: command: %v", ErrInvalidSpec, err)
+	}
+	return path, nil
+}
+
+// ValidateTempRoot checks that dir can hold the private temporary directories
+// of jobs, i.e. that it is an absolute path to a writable directory.
+func ValidateTempRoot(dir string) error {
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("temp root %q is not an absolute path", dir)
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("temp root: %w", err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("temp root %q is not a directory", dir)
+	}
+	if err := unix.Access(dir, unix.W_OK|unix.X_OK); err != nil {
+		return fmt.Errorf("temp root %q is not writable: %w", dir, err)
+	}
+	return nil
+}
+
+// validate checks that both ends of the bind mount exist and are compatible.
+func (m BindMount) validate() error {
+	if !filepath.IsAbs(m.Source) || !filepath.IsAbs(m.Target) {
+		return fmt.Errorf("%w: bind mount %s:%s must use absolute paths", ErrInvalidSpec, m.Source, m.Target)
+	}
+	src, err := os.Stat(m.Source)
+	if err != nil {
+		return fmt.Errorf("%w: bind mount source: %v", ErrInvalidSpec, err)
+	}
+	dst, err := os.Stat(m.Target)
+	if err != nil {
+		return fmt.Errorf("%w: bind mount target: %v", ErrInvalidSpec, err)
+	}
+	if src.IsDir() != dst.IsDir() {
+		return fmt.Errorf("%w: bind mount %s:%s mixes a file and a directory", ErrInvalidSpec, m.Source, m.Target)
+	}
+	return nil
+}
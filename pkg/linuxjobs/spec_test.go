@@ -0,0 +1,299 @@
+package linuxjobs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestValidate_EmptyCommand(t *testing.T) {
+	err := JobSpec{}.validate()
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestValidate_NofileLimitAboveHardLimit(t *testing.T) {
+	err := JobSpec{Command: "true", NofileLimit: math.MaxUint64 - 1}.validate()
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestValidate_NofileLimitWithinHardLimit(t *testing.T) {
+	if err := (JobSpec{Command: "true", NofileLimit: 64}).validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_Env(t *testing.T) {
+	valid := JobSpec{Command: "env", Env: []string{"FOO=bar", "EMPTY=", "FOO=baz"}}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, kv := range []string{"FOO", "=bar", ""} {
+		err := JobSpec{Command: "env", Env: []string{kv}}.validate()
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("env %q: expected ErrInvalidSpec, got %v", kv, err)
+		}
+	}
+}
+
+func TestValidate_NULBytes(t *testing.T) {
+	for name, spec := range map[string]JobSpec{
+		"command": {Command: "ech\x00o"},
+		"argv0":   {Command: "echo", Argv0: "my\x00echo"},
+		"arg":     {Command: "echo", Args: []string{"ok", "cut\x00short"}},
+		"env":     {Command: "env", Env: []string{"FOO=ba\x00r"}},
+	} {
+		err := spec.validate()
+		if !errors.Is(err, ErrInvalidSpec) || !strings.Contains(err.Error(), "NUL") {
+			t.Fatalf("%s: expected ErrInvalidSpec for the NUL byte, got %v", name, err)
+		}
+	}
+}
+
+func TestValidate_Labels(t *testing.T) {
+	valid := JobSpec{Command: "true", Labels: map[string]string{"pipeline": "build", "example.com/commit": "abc-123", "empty": ""}}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tooMany := make(map[string]string)
+	for i := range maxLabels + 1 {
+		tooMany[fmt.Sprintf("l%d", i)] = "x"
+	}
+	for _, labels := range []map[string]string{
+		{"": "x"},
+		{"-leading": "x"},
+		{"with space": "x"},
+		{"key": "new\nline"},
+		{strings.Repeat("k", maxLabelLength+1): "x"},
+		{"key": strings.Repeat("v", maxLabelLength+1)},
+		tooMany,
+	} {
+		err := JobSpec{Command: "true", Labels: labels}.validate()
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("labels %q: expected ErrInvalidSpec, got %v", labels, err)
+		}
+	}
+}
+
+func TestMatchLabels(t *testing.T) {
+	labels := map[string]string{"pipeline": "build", "commit": "abc"}
+	tests := []struct {
+		selector map[string]string
+		want     bool
+	}{
+		{nil, true},
+		{map[string]string{"pipeline": "build"}, true},
+		{map[string]string{"pipeline": "build", "commit": "abc"}, true},
+		{map[string]string{"pipeline": "deploy"}, false},
+		{map[string]string{"pipeline": "build", "stage": ""}, false},
+	}
+	for _, tt := range tests {
+		if got := MatchLabels(labels, tt.selector); got != tt.want {
+			t.Fatalf("MatchLabels(%v): expected %v, got %v", tt.selector, tt.want, got)
+		}
+	}
+}
+
+func TestValidate_WorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := (JobSpec{Command: "pwd", WorkingDir: dir}).validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	for _, wd := range []string{"relative/dir", filepath.Join(dir, "missing"), file} {
+		err := JobSpec{Command: "pwd", WorkingDir: wd}.validate()
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("working dir %q: expected ErrInvalidSpec, got %v", wd, err)
+		}
+	}
+}
+
+func TestValidate_BindMounts(t *testing.T) {
+	if os.Geteuid() != 0 {
+		err := JobSpec{Command: "ls", BindMounts: []BindMount{{Source: "/tmp", Target: "/tmp"}}}.validate()
+		if !errors.Is(err, ErrNotPermitted) {
+			t.Fatalf("expected ErrNotPermitted without root, got %v", err)
+		}
+		t.Skip("remaining checks require root")
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	valid := JobSpec{Command: "ls", BindMounts: []BindMount{{Source: dir, Target: dir}, {Source: file, Target: file}}}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, m := range []BindMount{
+		{Source: "relative", Target: dir},
+		{Source: dir, Target: "relative"},
+		{Source: filepath.Join(dir, "missing"), Target: dir},
+		{Source: dir, Target: filepath.Join(dir, "missing")},
+		{Source: dir, Target: file},
+	} {
+		err := JobSpec{Command: "ls", BindMounts: []BindMount{m}}.validate()
+		if !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("bind mount %+v: expected ErrInvalidSpec, got %v", m, err)
+		}
+	}
+}
+
+func TestResourceProfile_WithDefaults(t *testing.T) {
+	defaults := ResourceProfile{CPUPercent: 50, MemoryBytes: 1 << 30, IOBytesPerSec: 1 << 20, IOWriteIOPS: 100, IODevices: []string{"8:0"}, MaxPIDs: 512}
+
+	got := ResourceProfile{CPUPercent: 200, MaxPIDs: 64, IOReadIOPS: 1000}.withDefaults(defaults)
+	want := ResourceProfile{CPUPercent: 200, MemoryBytes: 1 << 30, IOBytesPerSec: 1 << 20, IOReadIOPS: 1000, IOWriteIOPS: 100, IODevices: []string{"8:0"}, MaxPIDs: 64}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestResourceProfile_WithDefaultsCPU(t *testing.T) {
+	defaults := ResourceProfile{CPUPercent: 50, CPUWeight: 200}
+
+	// A job setting only a weight is not capped by the default cap.
+	if got := (ResourceProfile{CPUWeight: 1000}).withDefaults(defaults); got.CPUPercent != 0 || got.CPUWeight != 1000 {
+		t.Fatalf("expected an uncapped weight of 1000, got %+v", got)
+	}
+	if got := (ResourceProfile{CPUPercent: 100}).withDefaults(defaults); got.CPUPercent != 100 || got.CPUWeight != 0 {
+		t.Fatalf("expected a cap of 100%% and the default weight, got %+v", got)
+	}
+	if got := (ResourceProfile{}).withDefaults(defaults); got.CPUPercent != 50 || got.CPUWeight != 200 {
+		t.Fatalf("expected the default cap and weight, got %+v", got)
+	}
+}
+
+func TestValidate_CPUWeight(t *testing.T) {
+	for _, w := range []uint64{1, 100, 10000} {
+		if err := (JobSpec{Command: "true", Resources: ResourceProfile{CPUWeight: w}}).validate(); err != nil {
+			t.Fatalf("weight %d: unexpected error: %v", w, err)
+		}
+	}
+	if err := (JobSpec{Command: "true", Resources: ResourceProfile{CPUWeight: 10001}}).validate(); !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestValidate_NegativeTimeout(t *testing.T) {
+	err := JobSpec{Command: "sleep", Timeout: -time.Second}.validate()
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestValidate_Argv0(t *testing.T) {
+	if err := (JobSpec{Command: "true", Argv0: "my-true"}).validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := (&JobManager{}).checkSpec(JobSpec{Command: "no-such-binary", Argv0: "tool"})
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec for a missing binary, got %v", err)
+	}
+}
+
+func TestValidate_ShimReserved(t *testing.T) {
+	for name, spec := range map[string]JobSpec{
+		"argv0": {Command: "/proc/self/exe", Argv0: shimArg0},
+		"env":   {Command: "/proc/self/exe", Env: []string{shimEnv + `={"path":"/bin/sh"}`}},
+	} {
+		if err := spec.validate(); !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("%s: expected ErrInvalidSpec, got %v", name, err)
+		}
+	}
+}
+
+func TestValidate_WorkInPrivateTmp(t *testing.T) {
+	if err := (JobSpec{Command: "pwd", PrivateTmp: true, WorkInPrivateTmp: true}).validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, spec := range []JobSpec{
+		{Command: "pwd", WorkInPrivateTmp: true},
+		{Command: "pwd", PrivateTmp: true, WorkInPrivateTmp: true, WorkingDir: t.TempDir()},
+	} {
+		if err := spec.validate(); !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("%+v: expected ErrInvalidSpec, got %v", spec, err)
+		}
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "tool")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	for _, spec := range []JobSpec{
+		{Command: "tool"},
+		{Command: tool},
+		{Command: "./tool", WorkingDir: dir},
+	} {
+		if path, err := spec.resolveCommand(); err != nil || path != tool {
+			t.Fatalf("%+v: expected %s, got %q (err=%v)", spec, tool, path, err)
+		}
+	}
+
+	for _, spec := range []JobSpec{
+		{Command: "lpaas-no-such-command"},
+		{Command: filepath.Join(dir, "missing")},
+		{Command: "./tool", PrivateTmp: true, WorkInPrivateTmp: true},
+	} {
+		if _, err := spec.resolveCommand(); !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("%+v: expected ErrInvalidSpec, got %v", spec, err)
+		}
+	}
+}
+
+func TestValidate_Nice(t *testing.T) {
+	for _, nice := range []int{-21, 20} {
+		if err := (JobSpec{Command: "true", Nice: nice}).validate(); !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("nice %d: expected ErrInvalidSpec, got %v", nice, err)
+		}
+	}
+	if err := (JobSpec{Command: "true", Nice: 19}).validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := JobSpec{Command: "true", Nice: -20}.validate()
+	if hasCapabilities(unix.CAP_SYS_NICE) && err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasCapabilities(unix.CAP_SYS_NICE) && !errors.Is(err, ErrNotPermitted) {
+		t.Fatalf("expected ErrNotPermitted without CAP_SYS_NICE, got %v", err)
+	}
+}
+
+func TestValidate_Credential(t *testing.T) {
+	err := JobSpec{Command: "true", Credential: &Credential{UID: 65534, GID: 65534}}.validate()
+	if canSetIDs() && err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !canSetIDs() && !errors.Is(err, ErrNotPermitted) {
+		t.Fatalf("expected ErrNotPermitted without CAP_SETUID, got %v", err)
+	}
+}
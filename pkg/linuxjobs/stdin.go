@@ -0,0 +1,143 @@
+package linuxjobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrStdinUnavailable is returned when opening the stdin of a job that was not
+// started with JobSpec.Stdin, is not running yet, has finished, has had its
+// stdin closed, or already has a writer.
+var ErrStdinUnavailable = errors.New("job stdin unavailable")
+
+// ErrStdinClosed is returned by a StdinWriter once the job's stdin was closed,
+// e.g. because the job finished or was stopped.
+var ErrStdinClosed = errors.New("job stdin closed")
+
+// StdinWriter writes to the standard input of a job. A job has at most one
+// writer at a time.
+type StdinWriter struct {
+	job  *job
+	f    *os.File
+	ctx  context.Context
+	stop func() bool // stops interrupting writes when ctx is done
+
+	mu   sync.Mutex
+	done bool // Close or Release was called; ctx no longer interrupts writes
+}
+
+// OpenStdin returns a writer to the standard input of a running job started
+// with JobSpec.Stdin. Closing the writer closes the job's stdin, so the job
+// reads EOF; releasing it leaves stdin open for the next writer.
+//
+// Writes block while the job does not read. They fail with ErrStdinClosed once
+// the job finishes or is stopped, and with ctx's error once ctx is done, so a
+// blocked write never outlives the job or the caller.
+func (jm *JobManager) OpenStdin(ctx context.Context, jobID string) (*StdinWriter, error) {
+	jm.mu.Lock()
+	job, ok := jm.jobs[jobID]
+	jm.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	switch {
+	case !job.openStdin:
+		return nil, fmt.Errorf("%w: job %s was not started with stdin", ErrStdinUnavailable, jobID)
+	case job.status != running:
+		return nil, fmt.Errorf("%w: job %s is %s", ErrStdinUnavailable, jobID, job.status)
+	case job.stdin == nil:
+		return nil, fmt.Errorf("%w: stdin of job %s is closed", ErrStdinUnavailable, jobID)
+	case job.stdinAttached:
+		return nil, fmt.Errorf("%w: stdin of job %s already has a writer", ErrStdinUnavailable, jobID)
+	}
+	job.stdinAttached = true
+
+	w := &StdinWriter{job: job, f: job.stdin, ctx: ctx}
+	w.stop = context.AfterFunc(ctx, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.done {
+			// Interrupts a write blocked on a job that does not read.
+			w.f.SetWriteDeadline(time.Now())
+		}
+	})
+	return w, nil
+}
+
+// Write writes p to the job's stdin.
+func (w *StdinWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	switch {
+	case err == nil:
+	case errors.Is(err, os.ErrDeadlineExceeded) && w.ctx.Err() != nil:
+		err = w.ctx.Err()
+	case errors.Is(err, os.ErrClosed), errors.Is(err, syscall.EPIPE):
+		err = ErrStdinClosed
+	}
+	return n, err
+}
+
+// Close closes the job's stdin, so the job reads EOF once it consumed what was
+// written. Closing stdin that the job already closed is not an error.
+func (w *StdinWriter) Close() error {
+	if !w.detach() {
+		return nil
+	}
+
+	j := w.job
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stdinAttached = false
+	if j.stdin != w.f {
+		return nil
+	}
+	return j.closeStdin()
+}
+
+// Release detaches the writer and leaves the job's stdin open, e.g. for a
+// client that reconnects to continue writing.
+func (w *StdinWriter) Release() {
+	if !w.detach() {
+		return
+	}
+	// Undoes the deadline set when ctx was done, for the next writer.
+	w.f.SetWriteDeadline(time.Time{})
+
+	j := w.job
+	j.mu.Lock()
+	j.stdinAttached = false
+	j.mu.Unlock()
+}
+
+// detach stops interrupting writes on ctx and reports whether this is the
+// first call to Close or Release.
+func (w *StdinWriter) detach() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return false
+	}
+	w.done = true
+	w.stop()
+	return true
+}
+
+// closeStdin closes the write end of the job's stdin pipe, if still open.
+// Callers must hold j.mu.
+func (j *job) closeStdin() error {
+	if j.stdin == nil {
+		return nil
+	}
+	err := j.stdin.Close()
+	j.stdin = nil
+	return err
+}
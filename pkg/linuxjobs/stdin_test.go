@@ -0,0 +1,159 @@
+package linuxjobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newStdinTestManager(t *testing.T) *JobManager {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return jm
+}
+
+func TestOpenStdin_WritesUntilClosed(t *testing.T) {
+	jm := newStdinTestManager(t)
+
+	id, err := jm.StartJobWithSpec(context.Background(), JobSpec{Command: "cat", Stdin: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w, err := jm.OpenStdin(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jm.OpenStdin(context.Background(), id); !errors.Is(err, ErrStdinUnavailable) {
+		t.Fatalf("expected ErrStdinUnavailable for a second writer, got %v", err)
+	}
+
+	if _, err := io.WriteString(w, "hello "); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Release()
+	// The stdin is still open after a release, for the next writer.
+	w, err = jm.OpenStdin(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := io.WriteString(w, "stdin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := jm.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("expected cat to exit on EOF: %v", err)
+	}
+	if st.Status != "Exited" {
+		t.Fatalf("expected Exited, got %+v", st)
+	}
+	r, err := jm.StreamJob(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	out, _ := io.ReadAll(r)
+	if string(out) != "hello stdin" {
+		t.Fatalf("expected the written input echoed, got %q", out)
+	}
+}
+
+func TestOpenStdin_WithoutStdin(t *testing.T) {
+	jm := newStdinTestManager(t)
+
+	id, err := jm.StartJob(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer jm.StopJobWithGrace(id, 0)
+
+	if _, err := jm.OpenStdin(context.Background(), id); !errors.Is(err, ErrStdinUnavailable) {
+		t.Fatalf("expected ErrStdinUnavailable, got %v", err)
+	}
+}
+
+func TestOpenStdin_StopUnblocksWriter(t *testing.T) {
+	jm := newStdinTestManager(t)
+
+	// The job never reads, so writes block once the pipe is full.
+	id, err := jm.StartJobWithSpec(context.Background(), JobSpec{Command: "sleep", Args: []string{"10"}, Stdin: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w, err := jm.OpenStdin(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := w.Write(bytes.Repeat([]byte("x"), 1<<20))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		t.Fatalf("expected the write to block, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := jm.StopJobWithGrace(id, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case err := <-written:
+		if !errors.Is(err, ErrStdinClosed) {
+			t.Fatalf("expected ErrStdinClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("write still blocked after the job was stopped")
+	}
+}
+
+func TestOpenStdin_ContextUnblocksWriter(t *testing.T) {
+	jm := newStdinTestManager(t)
+
+	id, err := jm.StartJobWithSpec(context.Background(), JobSpec{Command: "sleep", Args: []string{"10"}, Stdin: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer jm.StopJobWithGrace(id, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w, err := jm.OpenStdin(ctx, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 1<<20)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to interrupt the write, got %v", err)
+	}
+	w.Release()
+
+	// The stdin stays open and usable by the next writer.
+	w, err = jm.OpenStdin(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+package linuxjobs
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// JobRecord is the metadata of a job kept in a JobStore, so the job can still
+// be inspected after the worker restarts. Output is not part of it.
+type JobRecord struct {
+	ID          string            `json:"id"`
+	Owner       string            `json:"owner"` // set by WithJobStore
+	Command     string            `json:"command"`
+	Args        []string          `json:"args,omitempty"` // unset with WithRedactedRecordArgs
+	Labels      map[string]string `json:"labels,omitempty"`
+	CommandPath string            `json:"command_path,omitempty"`
+	Status      string            `json:"status"`
+	ExitCode    int               `json:"exit_code"`
+	Signal      int               `json:"signal,omitempty"`
+	Error       string            `json:"error,omitempty"` // exit error; combined with the cleanup error in older records
+	CleanupErr  string            `json:"cleanup_error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at,omitzero"`
+	StartedAt   time.Time         `json:"started_at,omitzero"`
+	FinishedAt  time.Time         `json:"finished_at,omitzero"`
+}
+
+// JobStore persists the records of jobs across worker restarts. A job is saved
+// whenever its status changes and deleted once the job is removed.
+// Implementations must be safe for concurrent use.
+type JobStore interface {
+	// SaveJob creates or replaces the record with the ID of rec.
+	SaveJob(rec JobRecord) error
+	// DeleteJob deletes the record of job id, if any.
+	DeleteJob(id string) error
+	// LoadJobs returns all records.
+	LoadJobs() ([]JobRecord, error)
+}
+
+// WithJobStore saves the record of every job to store, with owner, so it can
+// be restored with RestoreJobs after a restart.
+func WithJobStore(store JobStore, owner string) Option {
+	return func(jm *JobManager) {
+		jm.store = store
+		jm.storeOwner = owner
+	}
+}
+
+// WithRedactedRecordArgs leaves the arguments of jobs out of the records saved
+// with WithJobStore, for jobs that get secrets as arguments. Jobs restored from
+// such records have no arguments.
+func WithRedactedRecordArgs() Option {
+	return func(jm *JobManager) {
+		jm.redactRecordArgs = true
+	}
+}
+
+// FileStore is a JobStore keeping all records in a single JSON file, which is
+// rewritten on every change. It suits workers with up to a few thousand jobs.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]JobRecord
+}
+
+// NewFileStore returns a FileStore backed by the file at path, loading the
+// records already in it. A missing file is created on the first change.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, records: make(map[string]JobRecord)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read job store: %w", err)
+	}
+
+	var records []JobRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parse job store %q: %w", path, err)
+	}
+	for _, rec := range records {
+		s.records[rec.ID] = rec
+	}
+	return s, nil
+}
+
+func (s *FileStore) SaveJob(rec JobRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[rec.ID] = rec
+	return s.flush()
+}
+
+func (s *FileStore) DeleteJob(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[id]; !ok {
+		return nil
+	}
+	delete(s.records, id)
+	return s.flush()
+}
+
+func (s *FileStore) LoadJobs() ([]JobRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []JobRecord
+	for _, id := range slices.Sorted(maps.Keys(s.records)) {
+		records = append(records, s.records[id])
+	}
+	return records, nil
+}
+
+// flush writes all records to the file, replacing it atomically so a crash
+// leaves either the old or the new records. Callers must hold s.mu.
+func (s *FileStore) flush() error {
+	records := make([]JobRecord, 0, len(s.records))
+	for _, id := range slices.Sorted(maps.Keys(s.records)) {
+		records = append(records, s.records[id])
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("encode job store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("write job store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write job store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write job store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write job store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write job store: %w", err)
+	}
+	return nil
+}
+
+// record returns the record of the job for its manager's store.
+// Callers must hold j.mu.
+func (j *job) record() JobRecord {
+	rec := JobRecord{
+		ID:          j.ID,
+		Owner:       j.storeOwner,
+		Command:     j.command,
+		Labels:      j.spec.Labels,
+		CommandPath: j.path,
+		Status:      j.status.String(),
+		ExitCode:    j.exitCode,
+		Signal:      int(j.exitSig),
+		CreatedAt:   j.createdAt,
+		StartedAt:   j.startedAt,
+		FinishedAt:  j.finishedAt,
+	}
+	if !j.redactRecordArgs {
+		rec.Args = j.args
+	}
+	if j.exitErr != nil {
+		rec.Error = j.exitErr.Error()
+	}
+	if j.cleanupErr != nil {
+		rec.CleanupErr = j.cleanupErr.Error()
+	}
+	return rec
+}
+
+// pendingRecord is a job record to be saved, numbered in the order of the
+// changes of the job.
+type pendingRecord struct {
+	rec     JobRecord
+	version uint64 // 0 if the job has no store
+}
+
+// persist returns the record of a change of the job, to be saved with save
+// once j.mu is released, so writing the store does not hold up the job.
+// Callers must hold j.mu.
+func (j *job) persist() pendingRecord {
+	if j.store == nil {
+		return pendingRecord{}
+	}
+	j.recordVersion++
+	return pendingRecord{rec: j.record(), version: j.recordVersion}
+}
+
+// save saves p to the job's store, unless the record of a later change was
+// saved already: records saved concurrently reach the store in the order of
+// the changes they record. A job that cannot be saved keeps running; the
+// failure is logged.
+func (j *job) save(p pendingRecord) {
+	if p.version == 0 {
+		return
+	}
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	if p.version <= j.savedVersion {
+		return
+	}
+	j.savedVersion = p.version
+	if err := j.store.SaveJob(p.rec); err != nil {
+		j.logger.Warn("failed to save job record", "error", err)
+	}
+}
+
+// deleteRecord deletes the record of a removed job from its store. Records of
+// earlier changes still being saved are dropped.
+func (j *job) deleteRecord() {
+	if j.store == nil {
+		return
+	}
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	j.savedVersion = math.MaxUint64
+	if err := j.store.DeleteJob(j.ID); err != nil {
+		j.logger.Warn("failed to delete job record", "error", err)
+	}
+}
+
+// RestoreJobs adds finished jobs from records, e.g. loaded from the manager's
+// store after a restart, so they can be inspected and removed like the jobs
+// the manager ran itself. Their output is empty. Jobs that had not finished
+// when the records were saved have status Orphaned: the manager lost track of
+// their processes. Records of jobs the manager already has are skipped.
+func (jm *JobManager) RestoreJobs(records []JobRecord) error {
+	var errs []error
+	for _, rec := range records {
+		spec := JobSpec{Command: rec.Command, Args: rec.Args, Labels: rec.Labels}
+		out, err := jm.newOutputBuffer(spec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("restore job %s: create output buffer: %w", rec.ID, err))
+			continue
+		}
+
+		job := newJobInCgroup(rec.ID, spec, noCgroup{})
+		job.path = rec.CommandPath
+		jm.configureJob(job, out)
+
+		job.status = parseStatus(rec.Status)
+		job.exitCode = rec.ExitCode
+		job.exitSig = syscall.Signal(rec.Signal)
+		if rec.Error != "" {
+			job.exitErr = errors.New(rec.Error)
+		}
+		if rec.CleanupErr != "" {
+			job.cleanupErr = errors.New(rec.CleanupErr)
+		}
+		// Records saved without a creation time are ordered by their start time.
+		if created := cmp.Or(rec.CreatedAt, rec.StartedAt); !created.IsZero() {
+			job.createdAt = created
+		}
+		job.startedAt = rec.StartedAt
+		job.finishedAt = rec.FinishedAt
+		orphan := !job.status.terminal()
+		if orphan {
+			job.status = orphaned
+			job.exitCode = -1
+			job.finishedAt = time.Now()
+		}
+		close(job.done)
+
+		jm.mu.Lock()
+		_, exists := jm.jobs[rec.ID]
+		if !exists {
+			jm.jobs[rec.ID] = job
+		}
+		jm.mu.Unlock()
+
+		if exists {
+			out.close()
+			continue
+		}
+		if orphan {
+			job.logger.Warn("job was still running when its record was saved", "status", rec.Status)
+			job.mu.Lock()
+			pending := job.persist()
+			job.mu.Unlock()
+			job.save(pending)
+		}
+	}
+	return errors.Join(errs...)
+}
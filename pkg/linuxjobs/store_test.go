@@ -0,0 +1,168 @@
+package linuxjobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore_SavesAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	finished := time.Now().UTC().Truncate(time.Second)
+	for _, rec := range []JobRecord{
+		{ID: "job-a", Owner: "rohit", Command: "true", Status: "Exited", FinishedAt: finished},
+		{ID: "job-b", Owner: "rohit", Command: "false", Status: "Failed", ExitCode: 1},
+		{ID: "job-c", Owner: "alice", Command: "sleep", Args: []string{"1"}, Status: "Running"},
+	} {
+		if err := store.SaveJob(rec); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := store.DeleteJob("job-b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, err := reopened.LoadJobs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 || records[0].ID != "job-a" || records[1].ID != "job-c" {
+		t.Fatalf("expected job-a and job-c, got %+v", records)
+	}
+	if !records[0].FinishedAt.Equal(finished) || records[1].Owner != "alice" || records[1].Args[0] != "1" {
+		t.Fatalf("records not restored as saved: %+v", records)
+	}
+}
+
+func TestNewFileStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := NewFileStore(path); err == nil {
+		t.Fatalf("expected error for a corrupt store")
+	}
+}
+
+func TestJobSave_KeepsTheOrderOfChanges(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j := newTestJob()
+	j.store = store
+
+	j.status = running
+	started := j.persist()
+	j.status = exited
+	finished := j.persist()
+
+	// Saved outside the job lock, the records may be saved out of order.
+	j.save(finished)
+	j.save(started)
+	if records, _ := store.LoadJobs(); len(records) != 1 || records[0].Status != "Exited" {
+		t.Fatalf("expected the latest record kept, got %+v", records)
+	}
+
+	j.deleteRecord()
+	j.save(finished)
+	if records, _ := store.LoadJobs(); len(records) != 0 {
+		t.Fatalf("expected no record saved after the job was removed, got %+v", records)
+	}
+}
+
+func TestJobRecord_RedactedArgs(t *testing.T) {
+	j := newTestJob()
+	j.args = []string{"--token", "secret"}
+	if rec := j.record(); len(rec.Args) != 2 {
+		t.Fatalf("expected the args recorded, got %+v", rec)
+	}
+
+	j.redactRecordArgs = true
+	if rec := j.record(); rec.Args != nil {
+		t.Fatalf("expected no args recorded, got %q", rec.Args)
+	}
+}
+
+func TestJobStore_SavesJobsAndRestoresThem(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jm, err := NewJobManager(WithCgroupRoot(filepath.Join(file, "cgroup")), WithBestEffortLimits(), WithJobStore(store, "rohit"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exitedID, err := jm.StartJob(context.Background(), "bash", "-c", "exit 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jm.Wait(context.Background(), exitedID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runningID, err := jm.StartJob(context.Background(), "sleep", "10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer jm.StopJobWithGrace(runningID, 0)
+
+	// A new manager, as after a restart, restores both jobs from the store.
+	records, err := store.LoadJobs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restarted, err := NewJobManager(WithJobStore(store, "rohit"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := restarted.RestoreJobs(records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := restarted.JobIDs(); len(ids) != 2 || ids[0] != exitedID || ids[1] != runningID {
+		t.Fatalf("expected the jobs restored in creation order, got %v", ids)
+	}
+
+	st, err := restarted.JobStatus(exitedID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.Status != "Failed" || st.ExitCode == nil || *st.ExitCode != 3 || st.StartedAt.IsZero() {
+		t.Fatalf("unexpected status of a restored job: %+v", st)
+	}
+	if path, _ := restarted.CommandPath(exitedID); !filepath.IsAbs(path) {
+		t.Fatalf("expected the command path restored, got %q", path)
+	}
+
+	st, err = restarted.JobStatus(runningID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.Status != "Orphaned" || st.FinishedAt.IsZero() {
+		t.Fatalf("expected a running job restored as orphaned, got %+v", st)
+	}
+
+	// Removing a restored job deletes its record.
+	if err := restarted.RemoveJob(exitedID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, _ = store.LoadJobs()
+	if len(records) != 1 || records[0].ID != runningID || records[0].Status != "Orphaned" {
+		t.Fatalf("expected only the orphaned record left, got %+v", records)
+	}
+}
@@ -0,0 +1,59 @@
+package linuxjobs
+
+import (
+	"sync"
+	"time"
+)
+
+// JobTotals aggregates the jobs a JobManager has run over its lifetime,
+// including jobs that have since been removed.
+type JobTotals struct {
+	// Started counts the jobs whose process started.
+	Started uint64
+	// Running counts the jobs still running.
+	Running uint64
+	// Failed counts the finished jobs that failed, timed out or were killed
+	// for running out of memory. Stopped jobs are not counted.
+	Failed uint64
+	// CPUUsage is the CPU time of all finished jobs, as read from their
+	// cgroups when they exited. Jobs without a cgroup add nothing.
+	CPUUsage time.Duration
+}
+
+// jobTotals accumulates the JobTotals of a manager as its jobs start and
+// finish. Each update happens under one lock, so a snapshot is consistent
+// however many jobs finish at the same time.
+type jobTotals struct {
+	mu     sync.Mutex
+	totals JobTotals
+}
+
+// started counts a job whose process started.
+func (t *jobTotals) started() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totals.Started++
+	t.totals.Running++
+}
+
+// finished counts a started job that finished in status st with the final
+// resource usage final, nil if none was recorded.
+func (t *jobTotals) finished(st status, final *Stats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totals.Running--
+	switch st {
+	case failed, timedOut, oomKilled, outputLimitExceeded:
+		t.totals.Failed++
+	}
+	if final != nil {
+		t.totals.CPUUsage += final.CPUUsage
+	}
+}
+
+// snapshot returns the current totals.
+func (t *jobTotals) snapshot() JobTotals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.totals
+}
@@ -0,0 +1,159 @@
+// Package metrics collects worker metrics about jobs and exposes them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the job duration histogram.
+var durationBuckets = []float64{0.1, 1, 10, 60, 300, 1800, 3600}
+
+// finishedKey labels the jobs that finished with a status.
+type finishedKey struct {
+	owner  string
+	status string
+}
+
+// histogram counts the observed job durations per bucket.
+type histogram struct {
+	counts []uint64 // per bucket in durationBuckets, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Registry holds the job metrics of a worker. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.Mutex
+	started   map[string]uint64 // by owner
+	finished  map[finishedKey]uint64
+	running   map[string]int64 // by owner
+	durations map[string]*histogram
+
+	// outputBytes reports the output held in memory at scrape time.
+	outputBytes func() int
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		started:   make(map[string]uint64),
+		finished:  make(map[finishedKey]uint64),
+		running:   make(map[string]int64),
+		durations: make(map[string]*histogram),
+	}
+}
+
+// JobStarted records that a job of owner started running.
+func (r *Registry) JobStarted(owner string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.started[owner]++
+	r.running[owner]++
+}
+
+// JobFinished records that a job of owner finished with status after running for d.
+func (r *Registry) JobFinished(owner, status string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.finished[finishedKey{owner: owner, status: status}]++
+	r.running[owner]--
+
+	h, ok := r.durations[status]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		r.durations[status] = h
+	}
+	secs := d.Seconds()
+	if i, _ := slices.BinarySearch(durationBuckets, secs); i < len(durationBuckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += secs
+}
+
+// SetOutputBytesFunc sets the function reporting the job output held in
+// memory, called on every scrape.
+func (r *Registry) SetOutputBytesFunc(f func() int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outputBytes = f
+}
+
+// WriteTo writes all metrics to w in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	outputBytes := r.outputBytes
+	var b strings.Builder
+
+	writeHeader(&b, "lpaas_jobs_running", "gauge", "Number of running jobs.")
+	for _, owner := range slices.Sorted(maps.Keys(r.running)) {
+		fmt.Fprintf(&b, "lpaas_jobs_running{owner=%s} %d\n", quote(owner), r.running[owner])
+	}
+
+	writeHeader(&b, "lpaas_jobs_started_total", "counter", "Number of jobs started.")
+	for _, owner := range slices.Sorted(maps.Keys(r.started)) {
+		fmt.Fprintf(&b, "lpaas_jobs_started_total{owner=%s} %d\n", quote(owner), r.started[owner])
+	}
+
+	writeHeader(&b, "lpaas_jobs_finished_total", "counter", "Number of jobs finished, by final status.")
+	finished := make([]finishedKey, 0, len(r.finished))
+	for k := range r.finished {
+		finished = append(finished, k)
+	}
+	slices.SortFunc(finished, func(a, b finishedKey) int {
+		return strings.Compare(a.owner+"\x00"+a.status, b.owner+"\x00"+b.status)
+	})
+	for _, k := range finished {
+		fmt.Fprintf(&b, "lpaas_jobs_finished_total{owner=%s,status=%s} %d\n", quote(k.owner), quote(k.status), r.finished[k])
+	}
+
+	writeHeader(&b, "lpaas_job_duration_seconds", "histogram", "Run time of finished jobs, by final status.")
+	for _, status := range slices.Sorted(maps.Keys(r.durations)) {
+		h := r.durations[status]
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "lpaas_job_duration_seconds_bucket{status=%s,le=\"%g\"} %d\n", quote(status), le, cumulative)
+		}
+		fmt.Fprintf(&b, "lpaas_job_duration_seconds_bucket{status=%s,le=\"+Inf\"} %d\n", quote(status), h.count)
+		fmt.Fprintf(&b, "lpaas_job_duration_seconds_sum{status=%s} %g\n", quote(status), h.sum)
+		fmt.Fprintf(&b, "lpaas_job_duration_seconds_count{status=%s} %d\n", quote(status), h.count)
+	}
+	r.mu.Unlock()
+
+	// Called without the lock, as it may take locks of its own.
+	if outputBytes != nil {
+		writeHeader(&b, "lpaas_output_buffer_bytes", "gauge", "Job output held in memory, in bytes.")
+		fmt.Fprintf(&b, "lpaas_output_buffer_bytes %d\n", outputBytes())
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler returns an HTTP handler serving the metrics, e.g. on /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+func writeHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// quote returns v as a label value, escaped as the exposition format requires.
+func quote(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
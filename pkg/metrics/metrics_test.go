@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+	r.JobStarted("rohit")
+	r.JobStarted("rohit")
+	r.JobStarted(`ali"ce`)
+	r.JobFinished("rohit", "Exited", 2*time.Second)
+	r.SetOutputBytesFunc(func() int { return 42 })
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		`lpaas_jobs_running{owner="ali\"ce"} 1`,
+		`lpaas_jobs_running{owner="rohit"} 1`,
+		`lpaas_jobs_started_total{owner="rohit"} 2`,
+		`lpaas_jobs_finished_total{owner="rohit",status="Exited"} 1`,
+		`lpaas_job_duration_seconds_bucket{status="Exited",le="1"} 0`,
+		`lpaas_job_duration_seconds_bucket{status="Exited",le="10"} 1`,
+		`lpaas_job_duration_seconds_bucket{status="Exited",le="+Inf"} 1`,
+		`lpaas_job_duration_seconds_sum{status="Exited"} 2`,
+		`lpaas_output_buffer_bytes 42`,
+		`# TYPE lpaas_job_duration_seconds histogram`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Fatalf("expected line %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	lpaasv1alpha1 "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/rohitsakala/lpaas/pkg/linuxjobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bounds on the audit stream. Each subscriber holds at most
+// auditBufferChunks chunks of auditBufferBytes in total; output published
+// while its buffer is full is dropped for that subscriber only.
+const (
+	maxAuditStreams   = 4
+	auditBufferChunks = 256
+	auditBufferBytes  = 4 << 20
+)
+
+// errTooManyAuditStreams is returned when maxAuditStreams clients are already
+// following the audit stream.
+var errTooManyAuditStreams = errors.New("too many audit streams")
+
+// auditHub fans the output of all owners' jobs in to the clients of
+// StreamAllOutput. It is installed as the output sink of every JobManager and
+// passes everything on to next, the sink configured with WithOutputSink, if
+// any.
+type auditHub struct {
+	next linuxjobs.OutputSink
+
+	mu   sync.Mutex
+	subs map[*auditSub]struct{}
+}
+
+// auditSub is a client following the audit stream.
+type auditSub struct {
+	chunks chan *lpaasv1alpha1.AuditChunk
+
+	// Guarded by the hub's mu.
+	buffered int    // bytes of the chunks in chunks
+	dropped  uint64 // bytes dropped since the last chunk queued
+}
+
+func newAuditHub() *auditHub {
+	return &auditHub{subs: make(map[*auditSub]struct{})}
+}
+
+// subscribe adds a client to the hub, which is sent the output published from
+// now on until it unsubscribes.
+func (h *auditHub) subscribe() (*auditSub, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subs) >= maxAuditStreams {
+		return nil, errTooManyAuditStreams
+	}
+	sub := &auditSub{chunks: make(chan *lpaasv1alpha1.AuditChunk, auditBufferChunks)}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+func (h *auditHub) unsubscribe(sub *auditSub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, sub)
+}
+
+// received releases the buffer space of a chunk taken from sub.chunks.
+func (h *auditHub) received(sub *auditSub, chunk *lpaasv1alpha1.AuditChunk) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub.buffered -= len(chunk.Data)
+}
+
+// PublishOutput queues data for every subscriber with room for it. It never
+// blocks, as it is called while the job's output is being handled.
+func (h *auditHub) PublishOutput(key linuxjobs.SinkKey, source linuxjobs.OutputStream, data []byte) {
+	if h.next != nil {
+		h.next.PublishOutput(key, source, data)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subs) == 0 {
+		return
+	}
+	// Chunks are only read once queued, so subscribers share the copy.
+	data = slices.Clone(data)
+	for sub := range h.subs {
+		if sub.buffered+len(data) > auditBufferBytes {
+			sub.dropped += uint64(len(data))
+			continue
+		}
+		chunk := &lpaasv1alpha1.AuditChunk{
+			Owner:        key.Owner,
+			Id:           key.JobID,
+			Source:       lpaasv1alpha1.OutputStream(source),
+			Data:         data,
+			DroppedBytes: sub.dropped,
+		}
+		select {
+		case sub.chunks <- chunk:
+			sub.buffered += len(data)
+			sub.dropped = 0
+		default:
+			sub.dropped += uint64(len(data))
+		}
+	}
+}
+
+func (h *auditHub) PublishEvent(key linuxjobs.SinkKey, ev linuxjobs.JobEvent) {
+	if h.next != nil {
+		h.next.PublishEvent(key, ev)
+	}
+}
+
+// StreamAllOutput streams the output all running jobs of all owners write
+// from now on to an admin, for auditing. Clients that fall behind have output
+// dropped rather than slowing down jobs, reported in the next chunk sent.
+func (s *Server) StreamAllOutput(req *lpaasv1alpha1.StreamAllOutputRequest, stream lpaasv1alpha1.Lpaas_StreamAllOutputServer) error {
+	owner, err := extractOwnerFromTLS(stream.Context(), s.identity)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+	if !isAdmin(stream.Context()) {
+		return status.Errorf(codes.PermissionDenied, "the audit stream requires the %s OU", adminOU)
+	}
+	if s.audit == nil {
+		return status.Errorf(codes.FailedPrecondition, "the audit stream is disabled on this server")
+	}
+
+	sub, err := s.audit.subscribe()
+	if err != nil {
+		return status.Errorf(codes.ResourceExhausted, "%v, at most %d clients may follow it", err, maxAuditStreams)
+	}
+	defer s.audit.unsubscribe(sub)
+
+	// Following everyone's output is logged, so its use can be reviewed.
+	s.logger.Info("audit stream started", "owner", owner)
+	defer s.logger.Info("audit stream ended", "owner", owner)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case chunk := <-sub.chunks:
+			s.audit.received(sub, chunk)
+			if err := stream.Send(chunk); err != nil {
+				return status.Errorf(codes.Unavailable, "failed to send audit chunk: %v", err)
+			}
+		}
+	}
+}
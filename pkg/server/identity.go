@@ -0,0 +1,45 @@
+package server
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// IdentitySource is the field of a client certificate that names its owner.
+type IdentitySource string
+
+const (
+	// IdentityCN names the owner by the certificate's Common Name.
+	IdentityCN IdentitySource = "cn"
+	// IdentityURISAN names the owner by the certificate's first URI SAN, in
+	// full, e.g. the SPIFFE ID spiffe://example.org/ci/runner.
+	IdentityURISAN IdentitySource = "uri-san"
+	// IdentityDNSSAN names the owner by the certificate's first DNS SAN.
+	IdentityDNSSAN IdentitySource = "dns-san"
+	// IdentityEmailSAN names the owner by the certificate's first email SAN.
+	IdentityEmailSAN IdentitySource = "email-san"
+)
+
+// ParseIdentitySource parses the name of an IdentitySource, e.g. from a flag.
+func ParseIdentitySource(name string) (IdentitySource, error) {
+	switch src := IdentitySource(name); src {
+	case IdentityCN, IdentityURISAN, IdentityDNSSAN, IdentityEmailSAN:
+		return src, nil
+	}
+	return "", fmt.Errorf("unknown identity source %q: must be %s, %s, %s or %s", name, IdentityCN, IdentityURISAN, IdentityDNSSAN, IdentityEmailSAN)
+}
+
+// owner returns the owner cert names by src. Certificates without the field
+// fall back to their CN, so certificates issued before a PKI moved identities
+// to SANs keep their owner.
+func (src IdentitySource) owner(cert *x509.Certificate) string {
+	switch {
+	case src == IdentityURISAN && len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case src == IdentityDNSSAN && len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case src == IdentityEmailSAN && len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/rohitsakala/lpaas/pkg/linuxjobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// lease grants whoever holds its token access to a job, whatever the CN of
+// their certificate.
+type lease struct {
+	owner string // CN of the client that started the job
+	token string
+}
+
+// newLeaseToken returns a random, unguessable lease token.
+func newLeaseToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate lease token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// leaseToken returns the lease token of job id started by owner, issuing one
+// if the job has none yet.
+func (s *Server) leaseToken(owner, id string) (string, error) {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+
+	if l, ok := s.leases[id]; ok {
+		return l.token, nil
+	}
+
+	token, err := newLeaseToken()
+	if err != nil {
+		return "", err
+	}
+
+	s.leases[id] = lease{owner: owner, token: token}
+	return token, nil
+}
+
+// managerForLease returns the JobManager holding job id if token is the job's
+// lease token. Jobs without a lease or with a different one are not found,
+// just like jobs of other owners.
+func (s *Server) managerForLease(id, token string) (*linuxjobs.JobManager, error) {
+	s.leaseMu.Lock()
+	l, ok := s.leases[id]
+	s.leaseMu.Unlock()
+
+	if !ok || subtle.ConstantTimeCompare([]byte(l.token), []byte(token)) != 1 {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", id)
+	}
+
+	mgr, ok := s.managerForOwner(l.owner)
+	if !ok {
+		s.leaseMu.Lock()
+		delete(s.leases, id)
+		s.leaseMu.Unlock()
+		return nil, status.Errorf(codes.NotFound, "job %s not found", id)
+	}
+	return mgr, nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	lpaasv1alpha1 "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+)
+
+// maxListPageSize bounds the page size of ListJobs; larger sizes are reduced
+// to it.
+const maxListPageSize = 1000
+
+// errInvalidPageToken is returned for page tokens the server did not issue,
+// or issued for another sort.
+var errInvalidPageToken = errors.New("invalid page token")
+
+// listKey is the position of a job in the orders of ListJobs.
+type listKey struct {
+	Sort    lpaasv1alpha1.ListJobsSort `json:"s"`
+	Owner   string                     `json:"o"`
+	Status  string                     `json:"st,omitempty"`
+	Created time.Time                  `json:"c"`
+	ID      string                     `json:"i"`
+}
+
+// compare orders a before b by their sort, which both must share. Job IDs
+// are unique, so only keys of the same job compare equal.
+func (a listKey) compare(b listKey) int {
+	created := cmp.Or(a.Created.Compare(b.Created), strings.Compare(a.ID, b.ID))
+	switch a.Sort {
+	case lpaasv1alpha1.ListJobsSort_LIST_JOBS_SORT_CREATED:
+		return created
+	case lpaasv1alpha1.ListJobsSort_LIST_JOBS_SORT_STATUS:
+		return cmp.Or(strings.Compare(a.Status, b.Status), created)
+	default:
+		return cmp.Or(strings.Compare(a.Owner, b.Owner), created)
+	}
+}
+
+// encodePageToken returns the opaque page token listing the jobs after k.
+func encodePageToken(k listKey) string {
+	data, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageToken returns the key of the last job listed before token, which
+// must have been issued for sort.
+func decodePageToken(token string, sort lpaasv1alpha1.ListJobsSort) (listKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return listKey{}, errInvalidPageToken
+	}
+	var k listKey
+	if err := json.Unmarshal(data, &k); err != nil || k.ID == "" {
+		return listKey{}, errInvalidPageToken
+	}
+	if k.Sort != sort {
+		return listKey{}, errInvalidPageToken
+	}
+	return k, nil
+}
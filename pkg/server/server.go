@@ -2,34 +2,111 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	lpaasv1alpha1 "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
 	"github.com/rohitsakala/lpaas/pkg/linuxjobs"
+	"github.com/rohitsakala/lpaas/pkg/metrics"
+	"golang.org/x/sys/unix"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	// Lets clients ask for gzip compressed responses, e.g. to stream bulk
+	// logs over slow links.
+	_ "google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// defaultMaxWait bounds how long a WaitJob call blocks unless configured
+// otherwise with WithMaxWait.
+const defaultMaxWait = 5 * time.Minute
+
+// Default limits on the arguments of a StartJob request, unless configured
+// otherwise with WithMaxArgs. They are well below the kernel's limit on the
+// arguments and environment of exec, typically 2 MiB.
+const (
+	defaultMaxArgs      = 4096
+	defaultMaxArgsBytes = 256 << 10
+)
+
+// maxArgBytes is the kernel's limit on each argument of exec, MAX_ARG_STRLEN,
+// including the argument's NUL byte. Unlike the limits above, it applies
+// however the server is configured.
+const maxArgBytes = 128 << 10
+
+// maxStreamLine is the longest line sent in one chunk by StreamOutput in line
+// mode; longer lines are split.
+const maxStreamLine = 64 * 1024
+
+// Bounds and default of the size of the chunks StreamOutput sends raw output
+// in. Larger chunks cut the per-message overhead of streaming bulk output.
+const (
+	MinStreamChunkSize     = 1024
+	MaxStreamChunkSize     = 1024 * 1024
+	defaultStreamChunkSize = 4096
+)
+
+// adminOU is the certificate Organizational Unit of clients allowed to
+// inspect the jobs of all owners.
+const adminOU = "admin"
+
 // extractOwnerFromTLS returns the client's identity from the mTLS certificate
-// by reading the Common Name (CN) of the first peer certificate.
-func extractOwnerFromTLS(ctx context.Context) (string, error) {
+// by reading the field src of the first peer certificate, its Common Name (CN)
+// by default.
+func extractOwnerFromTLS(ctx context.Context, src IdentitySource) (string, error) {
+	cert, err := peerCertificate(ctx)
+	if err != nil {
+		return "", err
+	}
+	return src.owner(cert), nil
+}
+
+// isAdmin reports whether the client's mTLS certificate carries the admin
+// Organizational Unit (OU).
+func isAdmin(ctx context.Context) bool {
+	cert, err := peerCertificate(ctx)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(cert.Subject.OrganizationalUnit, adminOU)
+}
+
+// peerCertificate returns the client's mTLS certificate.
+func peerCertificate(ctx context.Context) (*x509.Certificate, error) {
 	p, ok := peer.FromContext(ctx)
 	if !ok {
-		return "", fmt.Errorf("no peer info in context")
+		return nil, fmt.Errorf("no peer info in context")
 	}
 	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
 	if !ok {
-		return "", fmt.Errorf("no TLS info available")
+		return nil, fmt.Errorf("no TLS info available")
 	}
 	state := tlsInfo.State
 	if len(state.PeerCertificates) == 0 {
-		return "", fmt.Errorf("no peer certificate found")
+		return nil, fmt.Errorf("no peer certificate found")
+	}
+
+	// The handshake checked the validity period, but a connection may outlive
+	// the certificate.
+	cert := state.PeerCertificates[0]
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("client certificate is only valid from %s to %s", cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
 	}
-	return state.PeerCertificates[0].Subject.CommonName, nil
+	return cert, nil
 }
 
 // Server implements the Lpaas gRPC service and manages a JobManager per owner.
@@ -37,15 +114,334 @@ type Server struct {
 	lpaasv1alpha1.UnimplementedLpaasServer
 	mu       sync.RWMutex
 	managers map[string]*linuxjobs.JobManager
+
+	managerOpts []linuxjobs.Option
+
+	// ownerResources holds the default resource profile of each owner that
+	// has one; other owners get the profile set in managerOpts, if any.
+	ownerResources map[string]linuxjobs.ResourceProfile
+
+	// ownerCgroups nests the cgroups of each owner's jobs in a cgroup of the
+	// owner's.
+	ownerCgroups bool
+
+	// sink receives the output and lifecycle events of all owners' jobs, if set.
+	sink linuxjobs.OutputSink
+
+	// audit fans the output of all owners' jobs in to StreamAllOutput, if
+	// enabled with WithAuditStream.
+	audit *auditHub
+
+	// metrics records the lifecycle transitions of all owners' jobs, if set.
+	metrics *metrics.Registry
+
+	logger *slog.Logger
+
+	// store persists the records of all owners' jobs across restarts, if set.
+	store linuxjobs.JobStore
+
+	// maxWait bounds how long a WaitJob call blocks.
+	maxWait time.Duration
+
+	// redactArgs leaves job arguments out of GetStatus, ListJobs and the store.
+	redactArgs bool
+
+	// maxArgs and maxArgsBytes limit the number and total size of the
+	// arguments of a started job; <= 0 means unlimited.
+	maxArgs, maxArgsBytes int
+
+	// identity is the client certificate field naming the owner of a call.
+	identity IdentitySource
+
+	// streamChunkSize is the size of the chunks StreamOutput sends raw output
+	// in, unless the request asks for another.
+	streamChunkSize int
+
+	// startedByKey maps idempotency keys to the job started with them, and
+	// keyByJob maps the job back, to forget the key once the job is removed.
+	// Keys are scoped per owner, so different owners may use the same key.
+	// keyLocks serializes the StartJob calls carrying the same key. All three
+	// are guarded by keyMu.
+	startedByKey map[idempotencyKey]string
+	keyByJob     map[string]idempotencyKey
+	keyLocks     map[idempotencyKey]*keyLock
+	keyMu        sync.Mutex
+
+	// leases maps job IDs to the lease issued for them, if any.
+	leases  map[string]lease
+	leaseMu sync.Mutex
+}
+
+// idempotencyKey identifies a StartJob idempotency key of one owner.
+type idempotencyKey struct {
+	owner string // certificate CN
+	key   string
+}
+
+// keyLock is held while a StartJob call with its key runs. It is dropped once
+// no call waits for it.
+type keyLock struct {
+	mu    sync.Mutex
+	users int // guarded by Server.keyMu
+}
+
+// lockKey serializes the StartJob calls carrying key, while calls with other
+// keys run concurrently. It returns the function unlocking key.
+func (s *Server) lockKey(key idempotencyKey) (unlock func()) {
+	s.keyMu.Lock()
+	l, ok := s.keyLocks[key]
+	if !ok {
+		l = &keyLock{}
+		s.keyLocks[key] = l
+	}
+	l.users++
+	s.keyMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		s.keyMu.Lock()
+		defer s.keyMu.Unlock()
+		if l.users--; l.users == 0 {
+			delete(s.keyLocks, key)
+		}
+	}
+}
+
+// forgetJob drops what the server keeps about a removed job: the idempotency
+// key it was started with and its lease.
+func (s *Server) forgetJob(id string) {
+	s.keyMu.Lock()
+	if key, ok := s.keyByJob[id]; ok {
+		delete(s.keyByJob, id)
+		if s.startedByKey[key] == id {
+			delete(s.startedByKey, key)
+		}
+	}
+	s.keyMu.Unlock()
+
+	s.leaseMu.Lock()
+	delete(s.leases, id)
+	s.leaseMu.Unlock()
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithManagerOptions sets the options each owner's JobManager is created with.
+func WithManagerOptions(opts ...linuxjobs.Option) Option {
+	return func(s *Server) {
+		s.managerOpts = append(s.managerOpts, opts...)
+	}
+}
+
+// WithDefaultResources sets the resource profile applied to jobs that do not set
+// their own limits, for owners without a profile of their own.
+func WithDefaultResources(p linuxjobs.ResourceProfile) Option {
+	return WithManagerOptions(linuxjobs.WithDefaultResources(p))
+}
+
+// WithOwnerResources sets the default resource profile of the owner with the
+// given certificate CN, taking precedence over WithDefaultResources.
+func WithOwnerResources(owner string, p linuxjobs.ResourceProfile) Option {
+	return func(s *Server) {
+		s.ownerResources[owner] = p
+	}
+}
+
+// WithOwnerCgroups nests the cgroups of each owner's jobs in a cgroup named
+// after the owner, lpaas/<owner>/<jobID> under the cgroup root, so the usage
+// of all jobs of an owner can be read from the owner's cgroup.
+func WithOwnerCgroups() Option {
+	return func(s *Server) {
+		s.ownerCgroups = true
+	}
+}
+
+// WithOutputSink publishes the output and lifecycle events of all jobs to sink,
+// keyed by the owner's certificate CN and the job ID.
+func WithOutputSink(sink linuxjobs.OutputSink) Option {
+	return func(s *Server) {
+		s.sink = sink
+	}
+}
+
+// WithAuditStream enables StreamAllOutput, letting admins follow the output
+// of all owners' jobs as it is written. Jobs may write secrets and personal
+// data to their output, so enable it only where such auditing is permitted.
+func WithAuditStream() Option {
+	return func(s *Server) {
+		s.audit = newAuditHub()
+	}
+}
+
+// WithMetrics records job metrics labelled with the owner's certificate CN in
+// reg, including the output all jobs hold in memory.
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(s *Server) {
+		s.metrics = reg
+	}
+}
+
+// WithLogger logs the lifecycle of all jobs to logger, with the owner's
+// certificate CN and the job ID on every line. Without it, the server logs to
+// slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithJobStore saves the records of all jobs, with the owner's certificate CN,
+// to store. NewServer restores the jobs found in store, so their status can
+// still be queried and listed after the worker restarts.
+func WithJobStore(store linuxjobs.JobStore) Option {
+	return func(s *Server) {
+		s.store = store
+	}
+}
+
+// WithMaxWait bounds how long a WaitJob call blocks before returning the
+// current status of a job that has not finished. A value <= 0 lets calls wait
+// until the job finished or the client gives up.
+func WithMaxWait(d time.Duration) Option {
+	return func(s *Server) {
+		s.maxWait = d
+	}
+}
+
+// WithMaxArgs limits StartJob requests to at most count arguments of at most
+// bytes in total. Larger requests fail with InvalidArgument before a job is
+// started. A value <= 0 lifts the respective limit.
+func WithMaxArgs(count, bytes int) Option {
+	return func(s *Server) {
+		s.maxArgs, s.maxArgsBytes = count, bytes
+	}
+}
+
+// WithRedactedJobArgs leaves the arguments of jobs out of GetStatus and
+// ListJobs responses, and out of the records saved with WithJobStore, for
+// workers whose jobs get secrets as arguments.
+func WithRedactedJobArgs() Option {
+	return func(s *Server) {
+		s.redactArgs = true
+	}
+}
+
+// WithIdentitySource names the owner of each call by the field src of the
+// client certificate instead of its CN, e.g. by a SPIFFE ID in a URI SAN.
+// Certificates without the field are still named by their CN.
+func WithIdentitySource(src IdentitySource) Option {
+	return func(s *Server) {
+		s.identity = src
+	}
+}
+
+// WithStreamChunkSize sets the size of the chunks StreamOutput sends raw
+// output in to n bytes, clamped to MinStreamChunkSize and MaxStreamChunkSize.
+// Requests may ask for another size within the same bounds.
+func WithStreamChunkSize(n int) Option {
+	return func(s *Server) {
+		s.streamChunkSize = min(max(n, MinStreamChunkSize), MaxStreamChunkSize)
+	}
 }
 
 // NewServer creates a new Server instance with an empty manager map.
-func NewServer() *Server {
-	return &Server{
-		managers: make(map[string]*linuxjobs.JobManager),
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		managers:        make(map[string]*linuxjobs.JobManager),
+		ownerResources:  make(map[string]linuxjobs.ResourceProfile),
+		startedByKey:    make(map[idempotencyKey]string),
+		keyByJob:        make(map[string]idempotencyKey),
+		keyLocks:        make(map[idempotencyKey]*keyLock),
+		leases:          make(map[string]lease),
+		logger:          slog.Default(),
+		maxWait:         defaultMaxWait,
+		maxArgs:         defaultMaxArgs,
+		maxArgsBytes:    defaultMaxArgsBytes,
+		identity:        IdentityCN,
+		streamChunkSize: defaultStreamChunkSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.audit != nil {
+		s.audit.next, s.sink = s.sink, s.audit
+	}
+	if s.metrics != nil {
+		s.metrics.SetOutputBytesFunc(s.outputBytes)
+	}
+	if s.store != nil {
+		s.restoreJobs()
+	}
+	return s
+}
+
+// restoreJobs restores the jobs in the server's store into the managers of
+// their owners. Jobs that cannot be restored are logged and skipped.
+func (s *Server) restoreJobs() {
+	records, err := s.store.LoadJobs()
+	if err != nil {
+		s.logger.Error("failed to load job records", "error", err)
+		return
+	}
+
+	byOwner := make(map[string][]linuxjobs.JobRecord)
+	for _, rec := range records {
+		byOwner[rec.Owner] = append(byOwner[rec.Owner], rec)
+	}
+	for owner, recs := range byOwner {
+		mgr, err := s.getOrCreateManager(owner)
+		if err == nil {
+			err = mgr.RestoreJobs(recs)
+		}
+		if err != nil {
+			s.logger.Error("failed to restore jobs", "owner", owner, "error", err)
+			continue
+		}
+		s.logger.Info("restored jobs", "owner", owner, "jobs", len(recs))
 	}
 }
 
+// outputBytes returns the output the jobs of all owners hold in memory.
+func (s *Server) outputBytes() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, mgr := range s.managers {
+		n += mgr.OutputBytes()
+	}
+	return n
+}
+
+// Shutdown stops the jobs of all owners, as JobManager.Shutdown does, so that
+// the worker can exit without orphaning them. Call it once the gRPC server
+// stopped serving, as jobs started meanwhile may be left running.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.RLock()
+	managers := maps.Clone(s.managers)
+	s.mu.RUnlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for owner, mgr := range managers {
+		wg.Go(func() {
+			if err := mgr.Shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("owner %s: %w", owner, err))
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 // getOrCreateManager returns the JobManager for the given owner, creating one
 // if it does not already exist.
 func (s *Server) getOrCreateManager(owner string) (*linuxjobs.JobManager, error) {
@@ -56,12 +452,33 @@ func (s *Server) getOrCreateManager(owner string) (*linuxjobs.JobManager, error)
 		return mgr, nil
 	}
 
-	mgr, err := linuxjobs.NewJobManager()
+	opts := append(slices.Clip(s.managerOpts), linuxjobs.WithLogger(s.logger, owner), linuxjobs.WithOnRemove(s.forgetJob))
+	if p, ok := s.ownerResources[owner]; ok {
+		opts = append(slices.Clip(opts), linuxjobs.WithDefaultResources(p))
+	}
+	if s.ownerCgroups {
+		opts = append(slices.Clip(opts), linuxjobs.WithOwnerCgroup(owner))
+	}
+	if s.sink != nil {
+		opts = append(slices.Clip(opts), linuxjobs.WithOutputSink(s.sink, owner))
+	}
+	if s.metrics != nil {
+		opts = append(slices.Clip(opts), linuxjobs.WithMetrics(s.metrics, owner))
+	}
+	if s.store != nil {
+		opts = append(slices.Clip(opts), linuxjobs.WithJobStore(s.store, owner))
+		if s.redactArgs {
+			opts = append(opts, linuxjobs.WithRedactedRecordArgs())
+		}
+	}
+
+	mgr, err := linuxjobs.NewJobManager(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create JobManager for owner %s: %v", owner, err)
 	}
 
 	s.managers[owner] = mgr
+	s.logger.Info("created job manager", "owner", owner)
 	return mgr, nil
 }
 
@@ -73,85 +490,731 @@ func (s *Server) managerForOwner(owner string) (*linuxjobs.JobManager, bool) {
 	return mgr, ok
 }
 
+// managerForJob returns the JobManager to look up job id in for a request by
+// owner. Admins may access the jobs of every owner. For other clients, the jobs
+// of other owners are not found, just like jobs that do not exist. Whether the
+// job exists is left to the JobManager method called next, which reports
+// linuxjobs.ErrJobNotFound atomically with the operation.
+func (s *Server) managerForJob(ctx context.Context, owner, id string) (*linuxjobs.JobManager, error) {
+	mgr, ok := s.managerForOwner(owner)
+	if isAdmin(ctx) && (!ok || !mgr.JobExists(id)) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		for _, m := range s.managers {
+			if m.JobExists(id) {
+				return m, nil
+			}
+		}
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "job %s not found", id)
+		}
+	}
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
+	}
+	return mgr, nil
+}
+
 // StartJob starts a new job for the authenticated owner.
 func (s *Server) StartJob(ctx context.Context, req *lpaasv1alpha1.StartJobRequest) (*lpaasv1alpha1.StartJobResponse, error) {
-	owner, err := extractOwnerFromTLS(ctx)
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
 	}
 
+	if err := s.checkArgs(req.Args); err != nil {
+		return nil, err
+	}
+	if req.Nice < 0 && !isAdmin(ctx) {
+		return nil, status.Errorf(codes.PermissionDenied, "negative nice requires the %s OU", adminOU)
+	}
+
 	mgr, err := s.getOrCreateManager(owner)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get or create job manager: %v", err)
 	}
 
-	id, err := mgr.StartJob(req.Command, req.Args...)
+	// A retried request returns the job started by the first one, as long as
+	// that job has not been removed.
+	if req.IdempotencyKey != "" {
+		key := idempotencyKey{owner: owner, key: req.IdempotencyKey}
+		defer s.lockKey(key)()
+
+		s.keyMu.Lock()
+		id, ok := s.startedByKey[key]
+		s.keyMu.Unlock()
+		if ok && mgr.JobExists(id) {
+			return s.startJobResponse(owner, id, req.Lease)
+		}
+	}
+
+	cred, err := credentialFromRequest(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid job: %v", err)
+	}
+
+	start := mgr.StartJobWithSpec
+	if req.Queue {
+		start = mgr.QueueJob
+	}
+	id, err := start(ctx, linuxjobs.JobSpec{
+		Command:          req.Command,
+		Args:             req.Args,
+		Argv0:            req.Argv0,
+		Env:              req.Env,
+		WorkingDir:       req.WorkingDir,
+		PrivateTmp:       req.PrivateTmp,
+		WorkInPrivateTmp: req.WorkInPrivateTmp,
+		NofileLimit:      req.NofileLimit,
+		Nice:             int(req.Nice),
+		ConfirmRunning:   req.ConfirmRunning,
+		KillOnDisconnect: req.KillOnDisconnect,
+		BindMounts:       bindMountsFromRequest(req.BindMounts),
+		OutputRetention:  outputRetentionFromRequest(req.OutputRetention),
+		Timeout:          req.GetTimeout().AsDuration(),
+		MaxOutputBytes:   req.MaxOutputBytes,
+		Labels:           req.Labels,
+		Stdin:            req.Stdin,
+		Credential:       cred,
+		Namespaces: linuxjobs.Namespaces{
+			PID:     req.GetNamespaces().GetPid(),
+			Mount:   req.GetNamespaces().GetMount(),
+			Network: req.GetNamespaces().GetNetwork(),
+		},
+		Resources: linuxjobs.ResourceProfile{
+			CPUPercent:         req.GetResources().GetCpuPercent(),
+			CPUWeight:          req.GetResources().GetCpuWeight(),
+			MemoryBytes:        req.GetResources().GetMemoryBytes(),
+			IOBytesPerSec:      req.GetResources().GetIoBytesPerSec(),
+			IOReadBytesPerSec:  req.GetResources().GetIoReadBytesPerSec(),
+			IOWriteBytesPerSec: req.GetResources().GetIoWriteBytesPerSec(),
+			IOReadIOPS:         req.GetResources().GetIoReadIops(),
+			IOWriteIOPS:        req.GetResources().GetIoWriteIops(),
+			IODevices:          req.GetResources().GetIoDevices(),
+			MaxPIDs:            req.GetResources().GetMaxPids(),
+		},
+	})
+	if errors.Is(err, linuxjobs.ErrInvalidSpec) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid job: %v", err)
+	}
+	if errors.Is(err, linuxjobs.ErrCommandNotAllowed) || errors.Is(err, linuxjobs.ErrNotPermitted) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot start job: %v", err)
+	}
+	if errors.Is(err, linuxjobs.ErrTooManyJobs) {
+		return nil, status.Errorf(codes.ResourceExhausted, "cannot start job: %v", err)
+	}
+	if err != nil && ctx.Err() != nil {
+		// The client gave up before the job started; none was left running.
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to start job: %v", err)
 	}
 
-	return &lpaasv1alpha1.StartJobResponse{Id: id}, nil
+	if req.IdempotencyKey != "" {
+		key := idempotencyKey{owner: owner, key: req.IdempotencyKey}
+		s.keyMu.Lock()
+		if old, ok := s.startedByKey[key]; ok {
+			delete(s.keyByJob, old)
+		}
+		s.startedByKey[key] = id
+		s.keyByJob[id] = key
+		s.keyMu.Unlock()
+	}
+
+	return s.startJobResponse(owner, id, req.Lease)
 }
 
-// StopJob stops a running job owned by the authenticated client.
-func (s *Server) StopJob(ctx context.Context, req *lpaasv1alpha1.JobRequest) (*lpaasv1alpha1.StopJobResponse, error) {
-	owner, err := extractOwnerFromTLS(ctx)
+// RestartJob starts a new job with the spec of a finished job of the
+// authenticated owner.
+func (s *Server) RestartJob(ctx context.Context, req *lpaasv1alpha1.RestartJobRequest) (*lpaasv1alpha1.StartJobResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
 	}
 
 	mgr, ok := s.managerForOwner(owner)
 	if !ok {
-		return nil, status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
+
+	// The job may have been started by an admin certificate with the same
+	// owner, but the caller's certificate decides.
+	if spec, err := mgr.Spec(req.Id); err == nil && spec.Nice < 0 && !isAdmin(ctx) {
+		return nil, status.Errorf(codes.PermissionDenied, "negative nice requires the %s OU", adminOU)
 	}
 
-	if !mgr.JobExists(req.Id) {
+	id, err := mgr.RestartJob(ctx, req.Id)
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
 		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
 	}
+	if errors.Is(err, linuxjobs.ErrJobRunning) {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %s is still running", req.Id)
+	}
+	if errors.Is(err, linuxjobs.ErrCommandNotAllowed) || errors.Is(err, linuxjobs.ErrNotPermitted) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot restart job: %v", err)
+	}
+	if errors.Is(err, linuxjobs.ErrTooManyJobs) {
+		return nil, status.Errorf(codes.ResourceExhausted, "cannot restart job: %v", err)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to restart job %s: %v", req.Id, err)
+	}
+
+	return s.startJobResponse(owner, id, req.Lease)
+}
+
+// checkArgs returns an InvalidArgument error if args exceed the limits set
+// with WithMaxArgs, or one of them exceeds maxArgBytes. Each argument counts
+// with the NUL byte exec appends to it.
+func (s *Server) checkArgs(args []string) error {
+	if s.maxArgs > 0 && len(args) > s.maxArgs {
+		return status.Errorf(codes.InvalidArgument, "invalid job: %d args exceed the limit of %d", len(args), s.maxArgs)
+	}
+	for i, arg := range args {
+		if len(arg)+1 > maxArgBytes {
+			return status.Errorf(codes.InvalidArgument, "invalid job: arg %d of %d bytes exceeds the limit of %d bytes per arg", i, len(arg)+1, maxArgBytes)
+		}
+	}
+	if s.maxArgsBytes > 0 {
+		size := 0
+		for _, arg := range args {
+			size += len(arg) + 1
+		}
+		if size > s.maxArgsBytes {
+			return status.Errorf(codes.InvalidArgument, "invalid job: args of %d bytes exceed the limit of %d bytes", size, s.maxArgsBytes)
+		}
+	}
+	return nil
+}
 
-	if err := mgr.StopJob(req.Id); err != nil {
+// startJobResponse returns the response for job id started by owner, with the
+// job's lease token if withLease is set.
+func (s *Server) startJobResponse(owner, id string, withLease bool) (*lpaasv1alpha1.StartJobResponse, error) {
+	resp := &lpaasv1alpha1.StartJobResponse{Id: id}
+	if withLease {
+		token, err := s.leaseToken(owner, id)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "job %s started but its lease could not be issued: %v", id, err)
+		}
+		resp.LeaseToken = token
+	}
+	return resp, nil
+}
+
+// secretEnvMarkers are substrings of environment keys, in upper case, whose
+// values may be secrets and are not returned in job invocations.
+var secretEnvMarkers = []string{"SECRET", "PASSWORD", "PASSWD", "TOKEN", "KEY", "CREDENTIAL", "AUTH"}
+
+// invocationFromSpec converts the spec of a job to its API invocation, leaving
+// out environment entries that may hold secrets.
+func invocationFromSpec(spec linuxjobs.JobSpec) *lpaasv1alpha1.JobInvocation {
+	inv := &lpaasv1alpha1.JobInvocation{
+		Command:          spec.Command,
+		Args:             spec.Args,
+		Argv0:            spec.Argv0,
+		WorkingDir:       spec.WorkingDir,
+		NofileLimit:      spec.NofileLimit,
+		Nice:             int32(spec.Nice),
+		MaxOutputBytes:   spec.MaxOutputBytes,
+		ConfirmRunning:   spec.ConfirmRunning,
+		KillOnDisconnect: spec.KillOnDisconnect,
+		PrivateTmp:       spec.PrivateTmp,
+		WorkInPrivateTmp: spec.WorkInPrivateTmp,
+		Resources: &lpaasv1alpha1.ResourceProfile{
+			CpuPercent:         spec.Resources.CPUPercent,
+			CpuWeight:          spec.Resources.CPUWeight,
+			MemoryBytes:        spec.Resources.MemoryBytes,
+			IoBytesPerSec:      spec.Resources.IOBytesPerSec,
+			IoReadBytesPerSec:  spec.Resources.IOReadBytesPerSec,
+			IoWriteBytesPerSec: spec.Resources.IOWriteBytesPerSec,
+			IoReadIops:         spec.Resources.IOReadIOPS,
+			IoWriteIops:        spec.Resources.IOWriteIOPS,
+			IoDevices:          spec.Resources.IODevices,
+			MaxPids:            spec.Resources.MaxPIDs,
+		},
+	}
+	if spec.Timeout > 0 {
+		inv.Timeout = durationpb.New(spec.Timeout)
+	}
+	if c := spec.Credential; c != nil {
+		inv.Uid, inv.Gid, inv.Groups = &c.UID, &c.GID, c.Groups
+	}
+	if ns := spec.Namespaces; ns != (linuxjobs.Namespaces{}) {
+		inv.Namespaces = &lpaasv1alpha1.Namespaces{Pid: ns.PID, Mount: ns.Mount, Network: ns.Network}
+	}
+	for _, m := range spec.BindMounts {
+		inv.BindMounts = append(inv.BindMounts, &lpaasv1alpha1.BindMount{Source: m.Source, Target: m.Target})
+	}
+
+	for _, kv := range spec.Env {
+		key, _, _ := strings.Cut(kv, "=")
+		upper := strings.ToUpper(key)
+		if slices.ContainsFunc(secretEnvMarkers, func(m string) bool { return strings.Contains(upper, m) }) {
+			inv.RedactedEnvKeys = append(inv.RedactedEnvKeys, key)
+			continue
+		}
+		inv.Env = append(inv.Env, kv)
+	}
+	return inv
+}
+
+// errorMessage returns the message of err, nil if err is nil.
+func errorMessage(err error) *string {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	return &msg
+}
+
+// credentialFromRequest returns the user and groups req runs its job as, nil
+// for the worker's.
+func credentialFromRequest(req *lpaasv1alpha1.StartJobRequest) (*linuxjobs.Credential, error) {
+	switch {
+	case req.Uid == nil && req.Gid == nil:
+		if len(req.Groups) > 0 {
+			return nil, fmt.Errorf("supplementary groups require uid and gid")
+		}
+		return nil, nil
+	case req.Uid == nil || req.Gid == nil:
+		return nil, fmt.Errorf("uid and gid must be set together")
+	}
+	return &linuxjobs.Credential{UID: *req.Uid, GID: *req.Gid, Groups: req.Groups}, nil
+}
+
+// bindMountsFromRequest converts API bind mounts to their linuxjobs form.
+func bindMountsFromRequest(in []*lpaasv1alpha1.BindMount) []linuxjobs.BindMount {
+	var out []linuxjobs.BindMount
+	for _, m := range in {
+		out = append(out, linuxjobs.BindMount{Source: m.Source, Target: m.Target})
+	}
+	return out
+}
+
+// outputRetentionFromRequest converts the API output retention of a job to a
+// linuxjobs policy, or nil if the request sets none.
+func outputRetentionFromRequest(r *lpaasv1alpha1.OutputRetention) linuxjobs.RetentionPolicy {
+	if r == nil {
+		return nil
+	}
+	return linuxjobs.CombinedRetention(
+		linuxjobs.SizeRetention(int(min(r.MaxBytes, math.MaxInt))),
+		linuxjobs.TimeRetention(r.GetMaxAge().AsDuration()),
+	)
+}
+
+// StopJob stops a running job owned by the authenticated client, or whose
+// lease token the client presents.
+func (s *Server) StopJob(ctx context.Context, req *lpaasv1alpha1.StopJobRequest) (*lpaasv1alpha1.StopJobResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	var mgr *linuxjobs.JobManager
+	if req.LeaseToken != "" {
+		mgr, err = s.managerForLease(req.Id, req.LeaseToken)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var ok bool
+		mgr, ok = s.managerForOwner(owner)
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
+		}
+	}
+
+	if req.GracePeriod != nil {
+		err = mgr.StopJobWithGrace(req.Id, req.GracePeriod.AsDuration())
+	} else {
+		err = mgr.StopJob(req.Id)
+	}
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
+	if errors.Is(err, linuxjobs.ErrJobNotRunning) {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %s is not running", req.Id)
+	}
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to stop job %s: %v", req.Id, err)
 	}
 
 	return &lpaasv1alpha1.StopJobResponse{}, nil
 }
 
-// GetStatus returns the status of a job owned by the authenticated client.
-func (s *Server) GetStatus(ctx context.Context, req *lpaasv1alpha1.JobRequest) (*lpaasv1alpha1.StatusJobResponse, error) {
-	owner, err := extractOwnerFromTLS(ctx)
+// SendSignal delivers a signal to a running job owned by the authenticated client.
+func (s *Server) SendSignal(ctx context.Context, req *lpaasv1alpha1.SendSignalRequest) (*lpaasv1alpha1.SendSignalResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
 	}
 
+	sig, err := linuxjobs.ParseSignal(req.Signal)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signal: %v", err)
+	}
+
 	mgr, ok := s.managerForOwner(owner)
 	if !ok {
 		return nil, status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
 	}
 
-	if !mgr.JobExists(req.Id) {
+	err = mgr.Signal(req.Id, sig)
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
 		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
 	}
+	if errors.Is(err, linuxjobs.ErrJobNotRunning) {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %s is not running", req.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to signal job %s: %v", req.Id, err)
+	}
 
-	statusVal, code, jobErr := mgr.Status(req.Id)
+	return &lpaasv1alpha1.SendSignalResponse{}, nil
+}
+
+// GetStatus returns the status of a job owned by the authenticated client, or
+// whose lease token the client presents.
+func (s *Server) GetStatus(ctx context.Context, req *lpaasv1alpha1.JobRequest) (*lpaasv1alpha1.StatusJobResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	var mgr *linuxjobs.JobManager
+	if req.LeaseToken != "" {
+		mgr, err = s.managerForLease(req.Id, req.LeaseToken)
+	} else {
+		mgr, err = s.managerForJob(ctx, owner, req.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := mgr.JobStatus(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
 
 	resp := &lpaasv1alpha1.StatusJobResponse{
-		Id:     req.Id,
-		Status: statusVal,
+		Id:            req.Id,
+		Status:        st.Status,
+		ExitCode:      st.ExitCode,
+		ActiveStreams: uint32(st.ActiveStreams),
+	}
+	outputBytes := uint64(st.OutputBytes)
+	resp.OutputBytes = &outputBytes
+	resp.Error = errorMessage(st.Err)
+	resp.ExitError = errorMessage(st.ExitErr)
+	resp.CleanupError = errorMessage(st.CleanupErr)
+	if !st.StartedAt.IsZero() {
+		resp.StartedAt = timestamppb.New(st.StartedAt)
+	}
+	if !st.FinishedAt.IsZero() {
+		resp.FinishedAt = timestamppb.New(st.FinishedAt)
+	}
+	if st.Signal != 0 {
+		name, num := signalName(st.Signal), int32(st.Signal)
+		resp.Signal, resp.SignalNumber = &name, &num
 	}
-	if code != nil {
-		resp.ExitCode = code
+
+	if peak, ok, err := mgr.PeakMemory(req.Id); err == nil && ok {
+		resp.PeakMemoryBytes = &peak
+	}
+	if path, err := mgr.CommandPath(req.Id); err == nil {
+		resp.CommandPath = path
+	}
+	if spec, err := mgr.Spec(req.Id); err == nil {
+		resp.Invocation = invocationFromSpec(spec)
+		resp.Labels = spec.Labels
+	}
+	resp.Command = st.Command
+	resp.Args, resp.ArgsRedacted = s.jobArgs(st.Args)
+	if resp.ArgsRedacted && resp.Invocation != nil {
+		resp.Invocation.Args = nil
+		resp.Invocation.ArgsRedacted = true
+	}
+	if warnings, err := mgr.LimitWarnings(req.Id); err == nil {
+		resp.LimitWarnings = warnings
+	}
+	return resp, nil
+}
+
+// jobArgs returns the arguments of a job to return to clients, and whether
+// they were redacted.
+func (s *Server) jobArgs(args []string) ([]string, bool) {
+	if s.redactArgs && len(args) > 0 {
+		return nil, true
+	}
+	return args, false
+}
+
+// signalName returns the name of sig, e.g. "SIGKILL".
+func signalName(sig syscall.Signal) string {
+	if name := unix.SignalName(sig); name != "" {
+		return name
+	}
+	return fmt.Sprintf("signal %d", sig)
+}
+
+// WaitJob blocks until a job the authenticated client may see finished, the
+// client gives up or the wait times out, and returns the job's status.
+func (s *Server) WaitJob(ctx context.Context, req *lpaasv1alpha1.WaitJobRequest) (*lpaasv1alpha1.WaitJobResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	mgr, err := s.managerForJob(ctx, owner, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	wait := s.maxWait
+	if t := req.GetTimeout().AsDuration(); t > 0 && (wait <= 0 || t < wait) {
+		wait = t
 	}
-	if jobErr != nil {
-		msg := jobErr.Error()
-		resp.Error = &msg
+	waitCtx := ctx
+	if wait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
 	}
+
+	st, err := mgr.Wait(waitCtx, req.Id)
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case errors.Is(err, context.DeadlineExceeded):
+		// The job is still running; report where it is at.
+		st, err = mgr.JobStatus(req.Id)
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
+		}
+		return &lpaasv1alpha1.WaitJobResponse{Id: req.Id, Status: st.Status}, nil
+	default:
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
+
+	resp := &lpaasv1alpha1.WaitJobResponse{
+		Id:       req.Id,
+		Finished: true,
+		Status:   st.Status,
+		ExitCode: st.ExitCode,
+	}
+	if st.Signal != 0 {
+		name, num := signalName(st.Signal), int32(st.Signal)
+		resp.Signal, resp.SignalNumber = &name, &num
+	}
+	resp.Error = errorMessage(st.Err)
+	resp.ExitError = errorMessage(st.ExitErr)
+	resp.CleanupError = errorMessage(st.CleanupErr)
 	return resp, nil
 }
 
+// GetStats returns the resource usage of a job owned by the authenticated client.
+func (s *Server) GetStats(ctx context.Context, req *lpaasv1alpha1.JobRequest) (*lpaasv1alpha1.StatsResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	mgr, ok := s.managerForOwner(owner)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
+	}
+
+	st, err := mgr.Stats(req.Id)
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
+	if errors.Is(err, linuxjobs.ErrStatsUnavailable) {
+		return nil, status.Errorf(codes.FailedPrecondition, "stats of job %s unavailable: %v", req.Id, err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get stats of job %s: %v", req.Id, err)
+	}
+
+	return &lpaasv1alpha1.StatsResponse{
+		Id:                 req.Id,
+		CpuUsage:           durationpb.New(st.CPUUsage),
+		UserCpu:            durationpb.New(st.UserCPU),
+		SystemCpu:          durationpb.New(st.SystemCPU),
+		MemoryCurrentBytes: st.MemoryCurrent,
+		MemoryPeakBytes:    st.MemoryPeak,
+	}, nil
+}
+
+// GetOwnerStats returns the totals of the jobs owner has run, including
+// removed jobs. Only admins may call it.
+func (s *Server) GetOwnerStats(ctx context.Context, req *lpaasv1alpha1.OwnerStatsRequest) (*lpaasv1alpha1.OwnerStatsResponse, error) {
+	if _, err := extractOwnerFromTLS(ctx, s.identity); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	if !isAdmin(ctx) {
+		return nil, status.Errorf(codes.PermissionDenied, "owner stats require the %s OU", adminOU)
+	}
+
+	mgr, ok := s.managerForOwner(req.Owner)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "owner %s has not run any jobs", req.Owner)
+	}
+
+	totals := mgr.Totals()
+	return &lpaasv1alpha1.OwnerStatsResponse{
+		Owner:       req.Owner,
+		JobsStarted: totals.Started,
+		JobsRunning: totals.Running,
+		JobsFailed:  totals.Failed,
+		CpuUsage:    durationpb.New(totals.CPUUsage),
+	}, nil
+}
+
 // StreamOutput streams the stdout and stderr of a job owned by the
-// authenticated client.
+// authenticated client, or whose lease token the client presents. The request
+// may select a single stream; every chunk is tagged with the stream that
+// produced it.
 func (s *Server) StreamOutput(req *lpaasv1alpha1.StreamRequest, stream lpaasv1alpha1.Lpaas_StreamOutputServer) error {
-	owner, err := extractOwnerFromTLS(stream.Context())
+	owner, err := extractOwnerFromTLS(stream.Context(), s.identity)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	var mgr *linuxjobs.JobManager
+	if req.LeaseToken != "" {
+		mgr, err = s.managerForLease(req.Id, req.LeaseToken)
+	} else {
+		mgr, err = s.managerForJob(stream.Context(), owner, req.Id)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch req.Stream {
+	case lpaasv1alpha1.OutputStream_OUTPUT_STREAM_BOTH,
+		lpaasv1alpha1.OutputStream_OUTPUT_STREAM_STDOUT,
+		lpaasv1alpha1.OutputStream_OUTPUT_STREAM_STDERR:
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown output stream %v", req.Stream)
+	}
+
+	if req.TailLines > 0 && req.StartOffset > 0 {
+		return status.Errorf(codes.InvalidArgument, "tail_lines and start_offset cannot be combined")
+	}
+
+	chunkSize := s.streamChunkSize
+	if req.ChunkSize != 0 {
+		if req.ChunkSize < MinStreamChunkSize || req.ChunkSize > MaxStreamChunkSize {
+			return status.Errorf(codes.InvalidArgument, "chunk_size must be between %d and %d bytes, got %d", MinStreamChunkSize, MaxStreamChunkSize, req.ChunkSize)
+		}
+		chunkSize = int(req.ChunkSize)
+	}
+
+	// The reader stops waiting for output once the client disconnects.
+	reader, err := mgr.StreamJobOutput(stream.Context(), req.Id, linuxjobs.StreamOptions{
+		Stream:      linuxjobs.OutputStream(req.Stream),
+		TailLines:   int(req.TailLines),
+		StartOffset: int(min(req.StartOffset, math.MaxInt)),
+		NoFollow:    req.Follow != nil && !*req.Follow,
+		MaxLag:      int(min(req.MaxLagBytes, math.MaxInt)),
+	})
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
+		return status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to stream job %s: %v", req.Id, err)
+	}
+	defer reader.Close()
+
+	if req.Lines {
+		return streamLines(req.Id, linuxjobs.NewLineReader(reader, maxStreamLine), stream)
+	}
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := reader.Read(buf)
+		if n > 0 {
+			chunk := &lpaasv1alpha1.StreamChunk{
+				Data:        buf[:n],
+				Stream:      lpaasv1alpha1.OutputStream(reader.Source()),
+				Offset:      uint64(reader.Offset()),
+				Discarded:   reader.Skipped(),
+				LaggedBytes: uint64(reader.Lagged()),
+			}
+			if sendErr := stream.Send(chunk); sendErr != nil {
+				return status.Errorf(codes.Unavailable, "failed to send stream chunk: %v", sendErr)
+			}
+		}
+
+		if done, err := endOfStream(req.Id, readErr, stream); done {
+			return err
+		}
+	}
+}
+
+// streamLines sends the output of job id read by lines to stream, one line
+// per chunk.
+func streamLines(id string, lines *linuxjobs.LineReader, stream lpaasv1alpha1.Lpaas_StreamOutputServer) error {
+	for {
+		line, readErr := lines.ReadLine()
+		if readErr == nil {
+			chunk := &lpaasv1alpha1.StreamChunk{
+				Data:        line.Data,
+				Stream:      lpaasv1alpha1.OutputStream(line.Source),
+				Offset:      uint64(line.Offset),
+				Discarded:   line.Skipped,
+				Partial:     line.Partial,
+				LaggedBytes: uint64(line.Lagged),
+			}
+			if sendErr := stream.Send(chunk); sendErr != nil {
+				return status.Errorf(codes.Unavailable, "failed to send stream chunk: %v", sendErr)
+			}
+			continue
+		}
+
+		if done, err := endOfStream(id, readErr, stream); done {
+			return err
+		}
+	}
+}
+
+// endOfStream handles readErr returned while streaming the output of job id
+// to stream. It reports whether the stream is over, along with the error to
+// end it with.
+func endOfStream(id string, readErr error, stream lpaasv1alpha1.Lpaas_StreamOutputServer) (bool, error) {
+	if readErr == io.EOF {
+		return true, nil
+	}
+	if errors.Is(readErr, linuxjobs.ErrOutputClosed) {
+		if sendErr := stream.Send(&lpaasv1alpha1.StreamChunk{OutputClosed: true}); sendErr != nil {
+			return true, status.Errorf(codes.Unavailable, "failed to send stream chunk: %v", sendErr)
+		}
+		return false, nil
+	}
+	if errors.Is(readErr, linuxjobs.ErrStreamIdle) {
+		return true, status.Errorf(codes.DeadlineExceeded, "job %s is still running but %v; reconnect to keep streaming", id, readErr)
+	}
+	if ctxErr := stream.Context().Err(); ctxErr != nil {
+		return true, status.FromContextError(ctxErr).Err()
+	}
+	if readErr != nil {
+		return true, status.Errorf(codes.Internal, "stream error for job %s: %v", id, readErr)
+	}
+	return false, nil
+}
+
+// StreamJobs multiplexes the output of several jobs owned by the authenticated
+// client into a single stream. Every chunk is tagged with its job ID. IDs that
+// are not found are reported with an error chunk and skipped.
+func (s *Server) StreamJobs(req *lpaasv1alpha1.StreamJobsRequest, stream lpaasv1alpha1.Lpaas_StreamJobsServer) error {
+	owner, err := extractOwnerFromTLS(stream.Context(), s.identity)
 	if err != nil {
 		return status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
 	}
@@ -161,30 +1224,364 @@ func (s *Server) StreamOutput(req *lpaasv1alpha1.StreamRequest, stream lpaasv1al
 		return status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
 	}
 
-	if !mgr.JobExists(req.Id) {
-		return status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	// Readers are closed here only if the stream fails before they are handed
+	// over to their pumping goroutines, which close them on completion.
+	readers := make(map[string]io.ReadCloser)
+	pumping := false
+	defer func() {
+		if pumping {
+			return
+		}
+		for _, r := range readers {
+			r.Close()
+		}
+	}()
+
+	for _, id := range req.Ids {
+		if _, ok := readers[id]; ok {
+			continue
+		}
+
+		reader, err := mgr.StreamJobOutput(stream.Context(), id, linuxjobs.StreamOptions{})
+		if errors.Is(err, linuxjobs.ErrJobNotFound) {
+			err = fmt.Errorf("job %s not found", id)
+		}
+		if err != nil {
+			msg := err.Error()
+			if sendErr := stream.Send(&lpaasv1alpha1.JobStreamChunk{Id: id, Error: &msg}); sendErr != nil {
+				return status.Errorf(codes.Unavailable, "failed to send stream chunk: %v", sendErr)
+			}
+			continue
+		}
+		readers[id] = reader
 	}
 
-	reader, err := mgr.StreamJob(req.Id)
+	if len(readers) == 0 {
+		return status.Errorf(codes.NotFound, "none of the requested jobs were found")
+	}
+
+	// gRPC streams are not safe for concurrent Send, so readers fan in to a
+	// single channel drained by this goroutine.
+	chunks := make(chan *lpaasv1alpha1.JobStreamChunk)
+	quit := make(chan struct{})
+	defer close(quit)
+
+	pumping = true
+	var wg sync.WaitGroup
+	for id, reader := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer reader.Close()
+			pumpChunks(id, reader, chunks, quit)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(chunks)
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				return nil
+			}
+			if sendErr := stream.Send(chunk); sendErr != nil {
+				return status.Errorf(codes.Unavailable, "failed to send stream chunk: %v", sendErr)
+			}
+		}
+	}
+}
+
+// pumpChunks reads from reader until EOF and forwards tagged chunks to out.
+// A read failure is forwarded as an error chunk. A job closing its output early
+// is not a failure. It returns early once quit is closed.
+func pumpChunks(id string, reader io.Reader, out chan<- *lpaasv1alpha1.JobStreamChunk, quit <-chan struct{}) {
+	for {
+		buf := make([]byte, 4096)
+		n, readErr := reader.Read(buf)
+		if errors.Is(readErr, linuxjobs.ErrOutputClosed) {
+			continue
+		}
+
+		var chunk *lpaasv1alpha1.JobStreamChunk
+		switch {
+		case n > 0:
+			chunk = &lpaasv1alpha1.JobStreamChunk{Id: id, Data: buf[:n]}
+		case readErr != nil && readErr != io.EOF:
+			msg := fmt.Sprintf("stream error for job %s: %v", id, readErr)
+			chunk = &lpaasv1alpha1.JobStreamChunk{Id: id, Error: &msg}
+		}
+
+		if chunk != nil {
+			select {
+			case out <- chunk:
+			case <-quit:
+				return
+			}
+		}
+
+		if readErr != nil {
+			return
+		}
+	}
+}
+
+// RemoveJob removes a finished job owned by the authenticated client.
+func (s *Server) RemoveJob(ctx context.Context, req *lpaasv1alpha1.JobRequest) (*lpaasv1alpha1.RemoveJobResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
 	if err != nil {
-		return status.Errorf(codes.Internal, "failed to stream job %s: %v", req.Id, err)
+		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	mgr, ok := s.managerForOwner(owner)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
+	}
+
+	err = mgr.RemoveJob(req.Id)
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
+	if errors.Is(err, linuxjobs.ErrJobRunning) {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %s is still running", req.Id)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove job %s: %v", req.Id, err)
+	}
+
+	return &lpaasv1alpha1.RemoveJobResponse{}, nil
+}
+
+// DownloadOutput streams the complete output of a finished job owned by the
+// authenticated client from the requested offset on. The whole output is hashed
+// so the trailer lets clients verify a resumed download.
+func (s *Server) DownloadOutput(req *lpaasv1alpha1.DownloadOutputRequest, stream lpaasv1alpha1.Lpaas_DownloadOutputServer) error {
+	owner, err := extractOwnerFromTLS(stream.Context(), s.identity)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	mgr, ok := s.managerForOwner(owner)
+	if !ok {
+		return status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
+	}
+
+	reader, size, err := mgr.ReadOutput(req.Id)
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
+		return status.Errorf(codes.NotFound, "job %s not found", req.Id)
+	}
+	if errors.Is(err, linuxjobs.ErrJobRunning) {
+		return status.Errorf(codes.FailedPrecondition, "job %s is still running", req.Id)
+	}
+	if errors.Is(err, linuxjobs.ErrOutputTruncated) {
+		return status.Errorf(codes.FailedPrecondition, "output of job %s is incomplete: %v", req.Id, err)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to read output of job %s: %v", req.Id, err)
 	}
 	defer reader.Close()
 
+	if req.Offset > uint64(size) {
+		return status.Errorf(codes.OutOfRange, "offset %d beyond output size %d", req.Offset, size)
+	}
+
+	hash := sha256.New()
+	var offset uint64
 	buf := make([]byte, 4096)
 	for {
 		n, readErr := reader.Read(buf)
 		if n > 0 {
-			if sendErr := stream.Send(&lpaasv1alpha1.StreamChunk{Data: buf[:n]}); sendErr != nil {
-				return status.Errorf(codes.Unavailable, "failed to send stream chunk: %v", sendErr)
+			hash.Write(buf[:n])
+
+			// Skip the part of the output the client already has.
+			data := buf[:n]
+			if end := offset + uint64(n); end > req.Offset {
+				skip := uint64(0)
+				if offset < req.Offset {
+					skip = req.Offset - offset
+				}
+				chunk := &lpaasv1alpha1.DownloadChunk{
+					Data:   data[skip:],
+					Offset: offset + skip,
+				}
+				if sendErr := stream.Send(chunk); sendErr != nil {
+					return status.Errorf(codes.Unavailable, "failed to send download chunk: %v", sendErr)
+				}
 			}
+			offset += uint64(n)
 		}
 
 		if readErr == io.EOF {
-			return nil
+			break
 		}
 		if readErr != nil {
-			return status.Errorf(codes.Internal, "stream error for job %s: %v", req.Id, readErr)
+			return status.Errorf(codes.Internal, "read error for job %s: %v", req.Id, readErr)
 		}
 	}
+
+	trailer := &lpaasv1alpha1.DownloadChunk{
+		Offset:     offset,
+		TotalBytes: offset,
+		Sha256:     hash.Sum(nil),
+	}
+	if err := stream.Send(trailer); err != nil {
+		return status.Errorf(codes.Unavailable, "failed to send download trailer: %v", err)
+	}
+	return nil
+}
+
+// WriteStdin writes the data streamed by the client to the stdin of a job
+// owned by the authenticated client, closing the job's stdin once the client
+// closes the stream. A client that disconnects instead leaves stdin open, so it
+// can reconnect and continue writing.
+func (s *Server) WriteStdin(stream lpaasv1alpha1.Lpaas_WriteStdinServer) error {
+	ctx := stream.Context()
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	chunk, err := stream.Recv()
+	if err == io.EOF {
+		return status.Errorf(codes.InvalidArgument, "no job ID sent")
+	}
+	if err != nil {
+		return err
+	}
+	id := chunk.Id
+
+	mgr, ok := s.managerForOwner(owner)
+	if !ok {
+		return status.Errorf(codes.NotFound, "jobManager for owner %s not found", owner)
+	}
+
+	w, err := mgr.OpenStdin(ctx, id)
+	if errors.Is(err, linuxjobs.ErrJobNotFound) {
+		return status.Errorf(codes.NotFound, "job %s not found", id)
+	}
+	if errors.Is(err, linuxjobs.ErrStdinUnavailable) {
+		return status.Errorf(codes.FailedPrecondition, "cannot write to job %s: %v", id, err)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to open stdin of job %s: %v", id, err)
+	}
+
+	var written uint64
+	for {
+		if len(chunk.Data) > 0 {
+			n, err := w.Write(chunk.Data)
+			written += uint64(n)
+			switch {
+			case err == nil:
+			case errors.Is(err, linuxjobs.ErrStdinClosed):
+				w.Close()
+				return status.Errorf(codes.FailedPrecondition, "cannot write to job %s: %v after %d bytes", id, err, written)
+			case ctx.Err() != nil:
+				w.Release()
+				return status.FromContextError(ctx.Err()).Err()
+			default:
+				w.Release()
+				return status.Errorf(codes.Internal, "failed to write to stdin of job %s: %v", id, err)
+			}
+		}
+
+		chunk, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Release()
+			return err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return status.Errorf(codes.Internal, "failed to close stdin of job %s: %v", id, err)
+	}
+	return stream.SendAndClose(&lpaasv1alpha1.WriteStdinResponse{BytesWritten: written})
+}
+
+// ListJobs lists the jobs of the authenticated owner that match the request's
+// label selector, in the requested order and a page at a time if a page size
+// is set. Pages sorted by status may skip or repeat a job whose status changes
+// between them. Admins may list the jobs of all owners; other clients asking
+// for them are denied.
+func (s *Server) ListJobs(ctx context.Context, req *lpaasv1alpha1.ListJobsRequest) (*lpaasv1alpha1.ListJobsResponse, error) {
+	owner, err := extractOwnerFromTLS(ctx, s.identity)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "failed to extract identity: %v", err)
+	}
+
+	if req.AllOwners && !isAdmin(ctx) {
+		return nil, status.Errorf(codes.PermissionDenied, "listing the jobs of all owners requires the %s OU", adminOU)
+	}
+
+	var after *listKey
+	if req.PageToken != "" {
+		k, err := decodePageToken(req.PageToken, req.Sort)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v for sort %v", err, req.Sort)
+		}
+		after = &k
+	}
+
+	s.mu.RLock()
+	managers := make(map[string]*linuxjobs.JobManager)
+	for o, mgr := range s.managers {
+		if req.AllOwners || o == owner {
+			managers[o] = mgr
+		}
+	}
+	s.mu.RUnlock()
+
+	type listed struct {
+		key     listKey
+		summary *lpaasv1alpha1.JobSummary
+	}
+	var jobs []listed
+	for o, mgr := range managers {
+		for _, id := range mgr.JobIDs() {
+			// The job may have been removed since it was listed.
+			spec, err := mgr.Spec(id)
+			if err != nil || !linuxjobs.MatchLabels(spec.Labels, req.LabelSelector) {
+				continue
+			}
+			st, err := mgr.JobStatus(id)
+			if err != nil {
+				continue
+			}
+			key := listKey{Sort: req.Sort, Owner: o, Status: st.Status, Created: st.CreatedAt, ID: id}
+			if after != nil && key.compare(*after) <= 0 {
+				continue
+			}
+			path, _ := mgr.CommandPath(id)
+			args, redacted := s.jobArgs(st.Args)
+			jobs = append(jobs, listed{key: key, summary: &lpaasv1alpha1.JobSummary{
+				Id:           id,
+				Owner:        o,
+				Status:       st.Status,
+				CommandPath:  path,
+				Labels:       spec.Labels,
+				Command:      st.Command,
+				Args:         args,
+				ArgsRedacted: redacted,
+				CreatedAt:    timestamppb.New(st.CreatedAt),
+			}})
+		}
+	}
+	slices.SortFunc(jobs, func(a, b listed) int { return a.key.compare(b.key) })
+
+	resp := &lpaasv1alpha1.ListJobsResponse{}
+	if size := int(min(req.PageSize, maxListPageSize)); size > 0 && len(jobs) > size {
+		jobs = jobs[:size]
+		resp.NextPageToken = encodePageToken(jobs[size-1].key)
+	}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, job.summary)
+	}
+	return resp, nil
 }
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrCertRevoked is returned by CertReloader.CheckRevoked for client
+// certificates listed in the certificate revocation list.
+var ErrCertRevoked = errors.New("client certificate revoked")
+
+// CertReloader serves the server keypair and the CA pool verifying client
+// certificates from files, reloading them when the files change. Certificates
+// and CAs can so be rotated without restarting the worker, which would lose
+// its running jobs. Connections already established keep the certificates
+// they were set up with.
+type CertReloader struct {
+	certFile, keyFile, caFile, crlFile string
+	interval                           time.Duration
+	logger                             *slog.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	crl     *x509.RevocationList // nil without a CRL file
+	loaded  [4][]byte            // contents of the files the above were loaded from
+	checked time.Time            // last time the files were checked for changes
+}
+
+// NewCertReloader loads the server keypair from certFile and keyFile, the
+// client CAs from caFile and, unless crlFile is empty, the certificate
+// revocation list of a client CA from crlFile, in PEM or DER form. The files
+// are checked for changes on new connections and revocation checks, at most
+// once per interval, and reloaded if they changed. Files that fail to load,
+// e.g. while a keypair is half written, are logged and retried on the next
+// check; the ones loaded before stay in use.
+func NewCertReloader(certFile, keyFile, caFile, crlFile string, interval time.Duration, logger *slog.Logger) (*CertReloader, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, caFile: caFile, crlFile: crlFile, interval: interval, logger: logger}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	r.checked = time.Now()
+	return r, nil
+}
+
+// Config returns a copy of base serving the current keypair and client CAs to
+// each new connection.
+func (r *CertReloader) Config(base *tls.Config) *tls.Config {
+	cfg := base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.refresh()
+
+		c := base.Clone()
+		c.Certificates = []tls.Certificate{*r.cert}
+		c.ClientCAs = r.pool
+		return c, nil
+	}
+	return cfg
+}
+
+// CheckRevoked returns ErrCertRevoked if cert is listed in the current
+// certificate revocation list. Revoked certificates still complete the TLS
+// handshake, so that checking each call, e.g. with
+// UnaryRevocationInterceptor, rejects them with a clear UNAUTHENTICATED
+// status, including on connections set up before the revocation.
+func (r *CertReloader) CheckRevoked(cert *x509.Certificate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh()
+
+	if r.crl == nil || !bytes.Equal(cert.RawIssuer, r.crl.RawIssuer) {
+		return nil
+	}
+	for _, entry := range r.crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return fmt.Errorf("%w: serial %s revoked at %s", ErrCertRevoked, cert.SerialNumber, entry.RevocationTime.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// refresh reloads the files if they changed since they were loaded and the
+// check interval passed. Callers must hold r.mu.
+func (r *CertReloader) refresh() {
+	if time.Since(r.checked) < r.interval {
+		return
+	}
+	r.checked = time.Now()
+
+	changed, err := r.reload()
+	switch {
+	case err != nil:
+		r.logger.Warn("failed to reload TLS certificates, keeping the current ones", "error", err)
+	case changed:
+		r.logger.Info("reloaded TLS certificates", "cert", r.certFile, "ca", r.caFile, "crl", r.crlFile)
+	}
+}
+
+// reload loads the keypair, client CAs and CRL if the files differ from the
+// ones they were last loaded from, and reports whether they did. Callers must
+// hold r.mu, except while r is being constructed.
+func (r *CertReloader) reload() (bool, error) {
+	var files [4][]byte
+	for i, path := range []string{r.certFile, r.keyFile, r.caFile, r.crlFile} {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, fmt.Errorf("read TLS file: %w", err)
+		}
+		files[i] = data
+	}
+	if slices.EqualFunc(files[:], r.loaded[:], bytes.Equal) {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(files[0], files[1])
+	if err != nil {
+		return false, fmt.Errorf("load server keypair %q: %w", r.certFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(files[2]) {
+		return false, fmt.Errorf("no certificates found in CA file %q", r.caFile)
+	}
+	var crl *x509.RevocationList
+	if r.crlFile != "" {
+		crl, err = parseCRL(files[3], files[2])
+		if err != nil {
+			return false, fmt.Errorf("load CRL %q: %w", r.crlFile, err)
+		}
+		if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+			r.logger.Warn("CRL is past its next update, revocations since may be missed", "crl", r.crlFile, "next_update", crl.NextUpdate)
+		}
+	}
+
+	r.cert, r.pool, r.crl, r.loaded = &cert, pool, crl, files
+	return true, nil
+}
+
+// parseCRL parses a PEM or DER certificate revocation list and checks it was
+// signed by one of the CAs in caPEM.
+func parseCRL(data, caPEM []byte) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for block, rest := pem.Decode(caPEM); block != nil; block, rest = pem.Decode(rest) {
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil || !bytes.Equal(ca.RawSubject, crl.RawIssuer) {
+			continue
+		}
+		if err := crl.CheckSignatureFrom(ca); err == nil {
+			return crl, nil
+		}
+	}
+	return nil, errors.New("not signed by a CA of the CA file")
+}
@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
-	"crypto/x509"
+	"flag"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	lpaasv1alpha1 "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/rohitsakala/lpaas/pkg/linuxjobs"
+	"github.com/rohitsakala/lpaas/pkg/metrics"
 	"github.com/rohitsakala/lpaas/pkg/server"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 var (
@@ -18,51 +30,292 @@ var (
 	keyFile  = "certs/server.key"
 	caFile   = "certs/ca.crt"
 	addr     = ":8443"
+
+	// Finished jobs and their output are removed this long after they finish.
+	jobTTL = time.Hour
+
+	// The pre-flight probe must finish within this long.
+	probeTimeout = 10 * time.Second
+
+	// On shutdown, calls still running after this long are cancelled. Output
+	// streams of running jobs only end once the jobs are stopped.
+	rpcDrainTimeout = 5 * time.Second
+
+	probeCommand    = flag.String("probe", "true", "Command run as a job at startup to verify the worker can run jobs (empty disables the probe)")
+	maxJobsPerOwner = flag.Int("max-jobs-per-owner", 0, "Maximum number of jobs each client may run at the same time (0 means unlimited)")
+	maxJobs         = flag.Int("max-jobs", 0, "Maximum number of jobs all clients together may run at the same time (0 means unlimited)")
+	cgroupRoot      = flag.String("cgroup-root", "", "Cgroup v2 directory to create job cgroups under, e.g. a delegated sub-cgroup (default /sys/fs/cgroup)")
+	ownerCgroups    = flag.Bool("owner-cgroups", false, "Nest the cgroups of each client's jobs in a cgroup of the client's, lpaas/<owner>/<job-id> under the cgroup root, to read the usage of all their jobs from it")
+	checkCgroups    = flag.Bool("check-cgroups", true, "Check at startup that the cgroup root has the cpu, memory, io and pids controllers and can enable them for jobs, failing with what is missing (false only checks it is on cgroup v2)")
+	bestEffort      = flag.Bool("best-effort-limits", false, "Run jobs without resource limits if cgroup v2 is unavailable instead of refusing to start them")
+	maxPIDs         = flag.Uint64("default-max-pids", 512, "Maximum number of processes and threads of jobs that do not set their own limit")
+	cgroupDelete    = flag.Duration("cgroup-delete-timeout", 10*time.Second, "How long to wait for the processes of a finished job to exit before giving up on deleting its cgroup until the job is removed")
+	removeOrphans   = flag.Bool("remove-orphaned-cgroups", false, "Kill and remove job cgroups left behind by an earlier worker at startup instead of only reporting them")
+	streamChunk     = flag.Int("stream-chunk-size", 4096, "Size in bytes of the chunks job output is streamed in unless a client asks for another, between 1024 and 1048576; larger chunks stream bulk output with less overhead")
+	streamIdle      = flag.Duration("stream-idle-timeout", 0, "End output streams of running jobs that produce no output for this long (0 disables)")
+	maxOutputBytes  = flag.Int("max-output-bytes", 64<<20, "Most output retained per job for streaming; older output is discarded (0 means unlimited)")
+	outputRetention = flag.Duration("output-retention", 0, "Discard job output once it is older than this, e.g. for compliance, in addition to -max-output-bytes (0 keeps output regardless of age)")
+	tempRoot        = flag.String("job-tmp-root", "", "Directory to create the private temp directories of jobs in (default $TMPDIR or /tmp)")
+	metricsAddr     = flag.String("metrics-addr", ":9090", "Address to serve Prometheus metrics on at /metrics, over plain HTTP (empty disables)")
+	allowCommands   = flag.String("allow-commands", "", "Comma-separated binaries jobs may run, as basenames or absolute paths (empty allows all; the -probe command must be allowed too)")
+	denyCommands    = flag.String("deny-commands", "", "Comma-separated binaries jobs may not run, as basenames or absolute paths")
+	maxWait         = flag.Duration("max-wait", 5*time.Minute, "Longest a WaitJob call blocks before returning a job that has not finished, after which clients wait again (0 means unlimited)")
+	stateFile       = flag.String("state-file", "", "JSON file to keep job metadata in, so finished jobs can still be queried after a restart (empty disables)")
+	redactArgs      = flag.Bool("redact-job-args", false, "Leave job arguments out of status and list responses, for jobs that get secrets as arguments")
+	maxArgs         = flag.Int("max-args", 4096, "Most arguments a job may be started with (0 means unlimited)")
+	maxArgsBytes    = flag.Int("max-args-bytes", 256<<10, "Most bytes the arguments of a job may take in total, counting a NUL byte per argument (0 means unlimited)")
+	identitySource  = flag.String("identity-source", "cn", "Client certificate field naming the owner of jobs: cn, uri-san (e.g. a SPIFFE ID), dns-san or email-san; certificates without the field are named by their CN")
+	crlFile         = flag.String("crl", "", "Certificate revocation list of the client CA, in PEM or DER form; calls with a revoked client certificate fail with UNAUTHENTICATED (reloaded like the certificates, empty disables)")
+	certReload      = flag.Duration("cert-reload-interval", 10*time.Second, "How often new connections check the certificate, key and CA files for changes, so they can be rotated without a restart (0 checks on every connection)")
+	shutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "How long running jobs get to exit after SIGTERM or SIGINT stops the worker, after which they are killed; their grace period is shortened to fit")
+	auditStream     = flag.Bool("enable-audit-stream", false, "Let clients with the admin OU stream the output of all jobs of all owners as it is written, for auditing; jobs may write secrets and personal data to their output")
+	reflect         = flag.Bool("enable-reflection", false, "Register the gRPC reflection service for tools such as grpcurl (clients still need a valid certificate)")
 )
 
 func main() {
-	// Load server keypair
-	serverCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	// Must run first: the worker re-executes itself as a job shim.
+	linuxjobs.Init()
+
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	identity, err := server.ParseIdentitySource(*identitySource)
 	if err != nil {
-		log.Fatalf("failed loading server keypair: %v", err)
+		log.Fatalf("invalid -identity-source: %v", err)
+	}
+	if *streamChunk < server.MinStreamChunkSize || *streamChunk > server.MaxStreamChunkSize {
+		log.Fatalf("invalid -stream-chunk-size %d: must be between %d and %d", *streamChunk, server.MinStreamChunkSize, server.MaxStreamChunkSize)
 	}
 
-	// Load CA for client authentication
-	caPEM, err := os.ReadFile(caFile)
+	// Load the server keypair and the CA for client authentication, reloading
+	// them once rotated on disk.
+	certs, err := server.NewCertReloader(certFile, keyFile, caFile, *crlFile, *certReload, logger)
 	if err != nil {
-		log.Fatalf("failed reading CA file: %v", err)
-	}
-	certPool := x509.NewCertPool()
-	if !certPool.AppendCertsFromPEM(caPEM) {
-		log.Fatalf("failed to add CA certificate to pool")
+		log.Fatalf("failed loading TLS certificates: %v", err)
 	}
 
 	// TLS configuration for gRPC mTLS
-	tlsCfg := &tls.Config{
-		Certificates: []tls.Certificate{serverCert},
-		ClientCAs:    certPool,
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		MinVersion:   tls.VersionTLS13,
-		NextProtos:   []string{"h2"},
-	}
+	tlsCfg := certs.Config(&tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS13,
+		NextProtos: []string{"h2"},
+	})
 
-	// gRPC server with TLS
+	// gRPC server with TLS, logging every RPC
 	creds := credentials.NewTLS(tlsCfg)
-	grpcServer := grpc.NewServer(grpc.Creds(creds))
+	grpcServer := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(server.UnaryLoggingInterceptor(logger, identity), server.UnaryRevocationInterceptor(certs.CheckRevoked)),
+		grpc.ChainStreamInterceptor(server.StreamLoggingInterceptor(logger, identity), server.StreamRevocationInterceptor(certs.CheckRevoked)),
+	)
+
+	// Nothing is served until the listener is ready.
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthSrv)
+
+	// In best-effort mode the worker runs without working cgroups; the LPaaS
+	// service then reports NOT_SERVING as jobs run without resource limits.
+	lpaasStatus := healthpb.HealthCheckResponse_SERVING
+	checkCgroupRoot := linuxjobs.ValidateCgroupRoot
+	if *checkCgroups {
+		checkCgroupRoot = linuxjobs.VerifyCgroupRoot
+	}
+	if err := checkCgroupRoot(*cgroupRoot); err != nil {
+		if !*bestEffort {
+			log.Fatalf("invalid -cgroup-root: %v", err)
+		}
+		log.Printf("WARNING: jobs will run without resource limits: %v", err)
+		lpaasStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	if *tempRoot != "" {
+		if err := linuxjobs.ValidateTempRoot(*tempRoot); err != nil {
+			log.Fatalf("invalid -job-tmp-root: %v", err)
+		}
+	}
+
+	policy := linuxjobs.CommandPolicy{
+		Allow: splitList(*allowCommands),
+		Deny:  splitList(*denyCommands),
+	}
+	if err := policy.Validate(); err != nil {
+		log.Fatalf("invalid command policy: %v", err)
+	}
+
+	// Jobs of an earlier worker may have survived an unclean shutdown.
+	orphanPolicy := linuxjobs.KeepOrphans
+	if *removeOrphans {
+		orphanPolicy = linuxjobs.RemoveOrphans
+	}
+	orphans, err := linuxjobs.HandleOrphanedCgroups(*cgroupRoot, orphanPolicy)
+	for _, path := range orphans {
+		if *removeOrphans {
+			log.Printf("removing orphaned job cgroup %s", path)
+		} else {
+			log.Printf("WARNING: leaving orphaned job cgroup %s in place (see -remove-orphaned-cgroups)", path)
+		}
+	}
+	if err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+
+	managerOpts := []linuxjobs.Option{
+		linuxjobs.WithCgroupRoot(*cgroupRoot),
+		linuxjobs.WithPeakMemory(),
+		linuxjobs.WithJobTTL(jobTTL),
+		linuxjobs.WithMaxRunningJobs(*maxJobsPerOwner),
+		linuxjobs.WithStreamIdleTimeout(*streamIdle),
+		linuxjobs.WithOutputRetention(linuxjobs.CombinedRetention(linuxjobs.SizeRetention(*maxOutputBytes), linuxjobs.TimeRetention(*outputRetention))),
+		linuxjobs.WithTempRoot(*tempRoot),
+		linuxjobs.WithCommandPolicy(policy),
+		linuxjobs.WithCgroupDeleteTimeout(*cgroupDelete, 0),
+		linuxjobs.WithDefaultResources(linuxjobs.ResourceProfile{MaxPIDs: *maxPIDs}),
+	}
+	if *bestEffort {
+		managerOpts = append(managerOpts, linuxjobs.WithBestEffortLimits())
+	}
+	if *maxJobs > 0 {
+		managerOpts = append(managerOpts, linuxjobs.WithSharedSlots(linuxjobs.NewSlots(*maxJobs)))
+	}
+
+	// Fail fast if the worker is misconfigured, e.g. cannot create cgroups.
+	if fields := strings.Fields(*probeCommand); len(fields) > 0 {
+		if err := runProbe(fields, managerOpts); err != nil {
+			log.Fatalf("pre-flight probe failed: %v", err)
+		}
+		log.Printf("pre-flight probe %q succeeded", *probeCommand)
+	}
 
 	// Register your LPaaS service
-	srv := server.NewServer()
+	reg := metrics.NewRegistry()
+	serverOpts := []server.Option{
+		server.WithManagerOptions(managerOpts...),
+		server.WithMetrics(reg),
+		server.WithLogger(logger),
+		server.WithMaxWait(*maxWait),
+		server.WithIdentitySource(identity),
+		server.WithStreamChunkSize(*streamChunk),
+		server.WithMaxArgs(*maxArgs, *maxArgsBytes),
+	}
+	if *stateFile != "" {
+		store, err := linuxjobs.NewFileStore(*stateFile)
+		if err != nil {
+			log.Fatalf("invalid -state-file: %v", err)
+		}
+		serverOpts = append(serverOpts, server.WithJobStore(store))
+	}
+	if *ownerCgroups {
+		serverOpts = append(serverOpts, server.WithOwnerCgroups())
+	}
+	if *auditStream {
+		serverOpts = append(serverOpts, server.WithAuditStream())
+	}
+	if *redactArgs {
+		serverOpts = append(serverOpts, server.WithRedactedJobArgs())
+	}
+	srv := server.NewServer(serverOpts...)
 	lpaasv1alpha1.RegisterLpaasServer(grpcServer, srv)
 
+	// Reflection discloses the API, but like every other call it is only
+	// served to clients presenting a certificate signed by the CA.
+	if *reflect {
+		reflection.Register(grpcServer)
+		log.Printf("gRPC reflection enabled")
+	}
+
+	// Metrics are served on their own port, so scrapers need no client certificate.
+	if *metricsAddr != "" {
+		go serveMetrics(*metricsAddr, reg)
+	}
+
 	// Listen on TCP
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		log.Fatalf("failed to listen on %s: %v", addr, err)
 	}
 
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthSrv.SetServingStatus(lpaasv1alpha1.Lpaas_ServiceDesc.ServiceName, lpaasStatus)
+
 	log.Printf("gRPC worker listening on %s (mTLS required)", addr)
 
+	// Stop the jobs on SIGTERM or SIGINT, so their processes and cgroups do
+	// not outlive the worker.
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		shutdownOnSignal(grpcServer, healthSrv, srv)
+	}()
+
 	if err := grpcServer.Serve(ln); err != nil {
 		log.Fatalf("grpc Serve error: %v", err)
 	}
+	<-drained
+}
+
+// shutdownOnSignal waits for SIGTERM or SIGINT, then stops serving calls and
+// stops the jobs of all owners within the shutdown timeout.
+func shutdownOnSignal(grpcServer *grpc.Server, healthSrv *health.Server, srv *server.Server) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, os.Interrupt)
+	sig := <-sigs
+	log.Printf("received %s, shutting down", sig)
+
+	healthSrv.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(rpcDrainTimeout):
+		grpcServer.Stop()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("failed to stop all jobs: %v", err)
+		return
+	}
+	log.Printf("all jobs stopped")
+}
+
+// serveMetrics serves the metrics in reg on addr until the worker exits.
+func serveMetrics(addr string, reg *metrics.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg.Handler())
+
+	log.Printf("serving metrics on %s/metrics", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatalf("metrics server error: %v", err)
+	}
+}
+
+// runProbe runs the probe command as a job with the options jobs are run with.
+func runProbe(command []string, opts []linuxjobs.Option) error {
+	jm, err := linuxjobs.NewJobManager(opts...)
+	if err != nil {
+		return err
+	}
+	defer jm.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	return jm.Probe(ctx, linuxjobs.JobSpec{Command: command[0], Args: command[1:]})
+}
+
+// splitList splits a comma-separated flag value, ignoring empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
 }
@@ -1,7 +1,13 @@
 package test
 
 import (
+	"context"
 	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -13,10 +19,10 @@ import (
 // Test Start and Status of a running job
 func TestStartJobAndStatusRunning(t *testing.T) {
 	t.Parallel()
-	jm, err := linuxjobs.NewJobManager()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
 	require.NoError(t, err, "NewJobManager")
 
-	jobID, err := jm.StartJob("sleep", "3")
+	jobID, err := jm.StartJob(context.Background(), "sleep", "3")
 	require.NoError(t, err, "StartJob")
 
 	status, code, err := jm.Status(jobID)
@@ -29,10 +35,10 @@ func TestStartJobAndStatusRunning(t *testing.T) {
 // Test Stop Job
 func TestStopJob(t *testing.T) {
 	t.Parallel()
-	jm, err := linuxjobs.NewJobManager()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
 	require.NoError(t, err, "NewJobManager")
 
-	jobID, err := jm.StartJob("sleep", "2")
+	jobID, err := jm.StartJob(context.Background(), "sleep", "2")
 	require.NoError(t, err, "StartJob")
 
 	err = jm.StopJob(jobID)
@@ -54,10 +60,10 @@ func TestStopJob(t *testing.T) {
 func TestJobStatusExited(t *testing.T) {
 	t.Parallel()
 
-	jm, err := linuxjobs.NewJobManager()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
 	require.NoError(t, err, "NewJobManager")
 
-	jobID, err := jm.StartJob("bash", "-c", "exit 7")
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "exit 7")
 	require.NoError(t, err, "StartJob")
 
 	require.Eventually(t, func() bool {
@@ -69,10 +75,10 @@ func TestJobStatusExited(t *testing.T) {
 // Test Job Stream
 func TestStreamLiveOutput(t *testing.T) {
 	t.Parallel()
-	jm, err := linuxjobs.NewJobManager()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
 	require.NoError(t, err, "NewJobManager")
 
-	jobID, err := jm.StartJob("bash", "-c", "echo hello; sleep 0.2; echo world")
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "echo hello; sleep 0.2; echo world")
 	require.NoError(t, err, "StartJob")
 
 	r, err := jm.StreamJob(jobID)
@@ -90,10 +96,10 @@ func TestStreamLiveOutput(t *testing.T) {
 // Test Stream after exit
 func TestStreamAfterExit(t *testing.T) {
 	t.Parallel()
-	jm, err := linuxjobs.NewJobManager()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
 	require.NoError(t, err, "NewJobManager")
 
-	jobID, err := jm.StartJob("bash", "-c", "echo one; echo two")
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "echo one; echo two")
 	require.NoError(t, err, "StartJob")
 
 	require.Eventually(t, func() bool {
@@ -112,3 +118,528 @@ func TestStreamAfterExit(t *testing.T) {
 	require.Contains(t, out, "one", "stream output should include one")
 	require.Contains(t, out, "two", "stream output should include two")
 }
+
+// Test the nofile rlimit is applied to the job
+func TestNofileLimit(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command:     "bash",
+		Args:        []string{"-c", "ulimit -Sn"},
+		NofileLimit: 64,
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	r, err := jm.StreamJob(jobID)
+	require.NoError(t, err, "StreamJob")
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err, "ReadAll")
+	require.Equal(t, "64\n", string(data), "soft nofile limit must be applied")
+}
+
+// Test a job started through the shim may have args larger than exec
+// accepts for a single environment string
+func TestShimLargeArgs(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	arg := strings.Repeat("x", 100<<10)
+	_, r, err := jm.StartAndStream(context.Background(), linuxjobs.JobSpec{
+		Command:     "bash",
+		Args:        []string{"-c", `echo ${#1} ${#2}`, "bash", arg, arg},
+		NofileLimit: 64,
+	})
+	require.NoError(t, err, "StartAndStream")
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "102400 102400\n", string(data))
+}
+
+// Test the niceness is applied to the job
+func TestNice(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	for _, nice := range []int{5, -5} {
+		jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+			Command: "nice",
+			Nice:    nice,
+		})
+		require.NoError(t, err, "StartJobWithSpec")
+
+		r, err := jm.StreamJob(jobID)
+		require.NoError(t, err, "StreamJob")
+		data, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err, "ReadAll")
+		require.Equal(t, strconv.Itoa(nice)+"\n", string(data), "niceness must be applied")
+	}
+}
+
+// Test a job runs as the requested user, also when started through the shim
+func TestJobCredential(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	for _, nofile := range []uint64{0, 64} {
+		jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+			Command:     "bash",
+			Args:        []string{"-c", "id -u; id -g; id -G"},
+			NofileLimit: nofile,
+			Credential:  &linuxjobs.Credential{UID: 65534, GID: 65534, Groups: []uint32{65533}},
+		})
+		require.NoError(t, err, "StartJobWithSpec")
+
+		r, err := jm.StreamJob(jobID)
+		require.NoError(t, err, "StreamJob")
+		data, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err, "ReadAll")
+		require.Equal(t, "65534\n65534\n65534 65533\n", string(data), "job must run as the requested user and groups (nofile %d)", nofile)
+	}
+}
+
+// Test a job gets SIGTERM and can clean up before it is stopped
+func TestStopJobGraceful(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "trap 'echo cleanup; exit 0' TERM; echo ready; while true; do sleep 0.1; done")
+	require.NoError(t, err, "StartJob")
+
+	r, err := jm.StreamJob(jobID)
+	require.NoError(t, err, "StreamJob")
+	defer r.Close()
+
+	buf := make([]byte, len("ready\n"))
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err, "wait for job to be ready")
+
+	err = jm.StopJobWithGrace(jobID, 5*time.Second)
+	require.NoError(t, err, "StopJobWithGrace")
+
+	status, code, _ := jm.Status(jobID)
+	require.Equal(t, "Stopped", status, "job exiting during grace period must be Stopped")
+	require.NotNil(t, code)
+
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err, "ReadAll")
+	require.Contains(t, string(rest), "cleanup", "job must get a chance to clean up")
+}
+
+// Test a job ignoring SIGTERM is killed after the grace period
+func TestStopJobGraceEscalatesToKill(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c", "trap '' TERM; sleep 30")
+	require.NoError(t, err, "StartJob")
+
+	start := time.Now()
+	err = jm.StopJobWithGrace(jobID, 200*time.Millisecond)
+	require.NoError(t, err, "StopJobWithGrace")
+	require.Less(t, time.Since(start), 5*time.Second, "job must be killed after the grace period")
+
+	status, _, _ := jm.Status(jobID)
+	require.Equal(t, "Stopped", status)
+}
+
+// Test stopping a job kills the processes it started, including a daemon
+// that left its process group and still holds its output pipes
+func TestStopJobKillsProcessTree(t *testing.T) {
+	t.Parallel()
+	// Only cgroup.kill reaches processes outside the job's process group.
+	if err := linuxjobs.VerifyCgroupRoot(""); err != nil {
+		t.Skipf("no delegated cgroup v2 root: %v", err)
+	}
+	jm, err := linuxjobs.NewJobManager()
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, pids := startDaemonizingJob(t, jm)
+
+	start := time.Now()
+	require.NoError(t, jm.StopJobWithGrace(jobID, 200*time.Millisecond), "StopJobWithGrace")
+	require.Less(t, time.Since(start), 5*time.Second, "stop must not wait for the daemon to exit on its own")
+
+	status, _, _ := jm.Status(jobID)
+	require.Equal(t, "Stopped", status)
+	for _, pid := range pids {
+		require.Eventually(t, func() bool { return processGone(pid) }, 2*time.Second, 20*time.Millisecond, "process %d must be killed", pid)
+	}
+}
+
+// Test stopping a job without a cgroup does not wait for a daemon that left
+// its process group, and so survived the kill, to close its output pipes
+func TestStopJobWithoutCgroupIgnoresDaemon(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, pids := startDaemonizingJob(t, jm)
+	// The daemon is the grandchild, which nothing else kills.
+	t.Cleanup(func() { _ = syscall.Kill(pids[1], syscall.SIGKILL) })
+
+	start := time.Now()
+	require.NoError(t, jm.StopJobWithGrace(jobID, 200*time.Millisecond), "StopJobWithGrace")
+	require.Less(t, time.Since(start), 5*time.Second, "stop must not wait for the daemon to exit on its own")
+
+	status, _, _ := jm.Status(jobID)
+	require.Equal(t, "Stopped", status)
+}
+
+// startDaemonizingJob starts a job whose child starts a grandchild in a
+// session of its own, out of reach of signals sent to the job's process
+// group. It returns the job ID and the PIDs of the child and the grandchild.
+func startDaemonizingJob(t *testing.T, jm *linuxjobs.JobManager) (string, []int) {
+	t.Helper()
+	jobID, err := jm.StartJob(context.Background(), "bash", "-c",
+		`bash -c 'setsid sleep 30 & echo $!; sleep 30' & echo $!; wait`)
+	require.NoError(t, err, "StartJob")
+
+	r, err := jm.StreamJob(jobID)
+	require.NoError(t, err, "StreamJob")
+	defer r.Close()
+
+	var out []byte
+	buf := make([]byte, 64)
+	for strings.Count(string(out), "\n") < 2 {
+		n, err := r.Read(buf)
+		require.NoError(t, err, "wait for the job to report its children")
+		out = append(out, buf[:n]...)
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(out)) {
+		pid, err := strconv.Atoi(field)
+		require.NoError(t, err, "parse pid in %q", out)
+		pids = append(pids, pid)
+	}
+	return jobID, pids
+}
+
+// processGone reports whether pid has exited. A zombie waiting to be reaped by
+// its new parent counts as exited.
+func processGone(pid int) bool {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	// The state follows the command name, which is in parentheses.
+	_, rest, _ := strings.Cut(string(data), ") ")
+	return strings.HasPrefix(rest, "Z")
+}
+
+// Test job env overrides the worker env and the last duplicate key wins
+func TestJobEnvPrecedence(t *testing.T) {
+	t.Setenv("LPAAS_TEST_VAR", "worker")
+
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command: "bash",
+		Args:    []string{"-c", `echo "$LPAAS_TEST_VAR $LPAAS_DUP"`},
+		Env:     []string{"LPAAS_TEST_VAR=job", "LPAAS_DUP=first", "LPAAS_DUP=second"},
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	r, err := jm.StreamJob(jobID)
+	require.NoError(t, err, "StreamJob")
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err, "ReadAll")
+	require.Equal(t, "job second\n", string(data))
+}
+
+// Test malformed env entries are rejected
+func TestJobEnvMalformed(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	_, err = jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command: "true",
+		Env:     []string{"NO_EQUALS_SIGN"},
+	})
+	require.ErrorIs(t, err, linuxjobs.ErrInvalidSpec)
+}
+
+// Test a bind-mounted path is visible and read-only inside the job
+func TestBindMountReadOnly(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("bind mounts require root")
+	}
+	t.Parallel()
+
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "data.txt"), []byte("from host\n"), 0o644))
+	target := t.TempDir()
+
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command:    "bash",
+		Args:       []string{"-c", `cat "$0/data.txt"; touch "$0/new" 2>/dev/null || echo read-only`, target},
+		BindMounts: []linuxjobs.BindMount{{Source: src, Target: target}},
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	r, err := jm.StreamJob(jobID)
+	require.NoError(t, err, "StreamJob")
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err, "ReadAll")
+	require.Equal(t, "from host\nread-only\n", string(data))
+
+	// The mount must not leak into the worker's namespace.
+	_, err = os.Stat(filepath.Join(target, "data.txt"))
+	require.True(t, os.IsNotExist(err), "bind mount visible on the host")
+}
+
+// Test a job in its own PID namespace is PID 1 and sees only its own processes
+func TestPIDNamespace(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("namespaces require root")
+	}
+	t.Parallel()
+
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command:    "bash",
+		Args:       []string{"-c", `echo $$; ls /proc | grep -c '^[0-9]*$'`},
+		Namespaces: linuxjobs.Namespaces{PID: true},
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	r, err := jm.StreamJob(jobID)
+	require.NoError(t, err, "StreamJob")
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err, "ReadAll")
+	lines := strings.Fields(string(data))
+	require.Len(t, lines, 2, "unexpected output %q", data)
+	require.Equal(t, "1", lines[0], "job must be PID 1 in its namespace")
+	procs, err := strconv.Atoi(lines[1])
+	require.NoError(t, err)
+	require.LessOrEqual(t, procs, 3, "job must see only its own processes in /proc")
+}
+
+// Test a job that is PID 1 in its namespace is still stopped and reaped
+func TestPIDNamespaceStop(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("namespaces require root")
+	}
+	t.Parallel()
+
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	// As PID 1, sleep ignores SIGTERM, so stopping falls back to the kill.
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command:    "sleep",
+		Args:       []string{"30"},
+		Namespaces: linuxjobs.Namespaces{PID: true},
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	start := time.Now()
+	err = jm.StopJobWithGrace(jobID, 200*time.Millisecond)
+	require.NoError(t, err, "StopJobWithGrace")
+	require.Less(t, time.Since(start), 5*time.Second, "job must be killed after the grace period")
+
+	status, _, _ := jm.Status(jobID)
+	require.Equal(t, "Stopped", status)
+}
+
+// Test a job in its own network namespace only has a working loopback
+func TestNetworkNamespace(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("namespaces require root")
+	}
+	t.Parallel()
+
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	// Connecting to a closed port on a loopback that is up is refused,
+	// rather than failing as the network is unreachable.
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command:    "bash",
+		Args:       []string{"-c", `tail -n +3 /proc/net/dev | cut -d: -f1 | tr -d ' '; (echo > /dev/tcp/127.0.0.1/1) 2>&1 | grep -q refused && echo refused`},
+		Namespaces: linuxjobs.Namespaces{Network: true},
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	r, err := jm.StreamJob(jobID)
+	require.NoError(t, err, "StreamJob")
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err, "ReadAll")
+	require.Equal(t, "lo\nrefused\n", string(data))
+}
+
+// Test a job running past its timeout is killed and reported as TimedOut
+func TestJobTimeout(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command: "sleep",
+		Args:    []string{"10"},
+		Timeout: 200 * time.Millisecond,
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	var code *int32
+	require.Eventually(t, func() bool {
+		var status string
+		status, code, _ = jm.Status(jobID)
+		return status == "TimedOut"
+	}, 2*time.Second, 50*time.Millisecond, "job should move to TimedOut state")
+
+	require.NotNil(t, code, "exit code must be set")
+	require.Equal(t, int32(-1), *code, "exit code must reflect the kill")
+
+	// The cgroup is deleted once the job is reported finished.
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join("/sys/fs/cgroup/lpaas", jobID))
+		return os.IsNotExist(err)
+	}, 2*time.Second, 50*time.Millisecond, "cgroup must be removed")
+}
+
+// Test readers attached right as jobs finish never hang or miss output
+func TestStreamRaceWithCompletion(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	for i := 0; i < 20; i++ {
+		jobID, err := jm.StartJob(context.Background(), "echo", "done")
+		require.NoError(t, err, "StartJob")
+
+		results := make(chan string, 4)
+		for k := 0; k < cap(results); k++ {
+			go func() {
+				r, err := jm.StreamJob(jobID)
+				if err != nil {
+					results <- err.Error()
+					return
+				}
+				defer r.Close()
+				data, _ := io.ReadAll(r)
+				results <- string(data)
+			}()
+		}
+
+		for k := 0; k < cap(results); k++ {
+			select {
+			case got := <-results:
+				require.Equal(t, "done\n", got)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("reader of job %s hung after completion", jobID)
+			}
+		}
+	}
+}
+
+// Test StartAndStream sees all output of a job that writes and exits at once,
+// even if the job is reaped before the reader gets to it
+func TestStartAndStream(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithJobTTL(time.Millisecond))
+	require.NoError(t, err, "NewJobManager")
+	defer jm.Close()
+
+	for i := 0; i < 20; i++ {
+		jobID, r, err := jm.StartAndStream(context.Background(), linuxjobs.JobSpec{
+			Command: "bash",
+			Args:    []string{"-c", "echo first; echo second >&2"},
+		})
+		require.NoError(t, err, "StartAndStream")
+
+		time.Sleep(10 * time.Millisecond)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err, "read job %s", jobID)
+		require.Equal(t, "first\nsecond\n", string(data))
+		require.NoError(t, r.Close())
+	}
+}
+
+// Test a custom argv[0] reaches the command, also when it is started through the shim
+func TestJobArgv0(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	for _, nofile := range []uint64{0, 64} {
+		_, r, err := jm.StartAndStream(context.Background(), linuxjobs.JobSpec{
+			Command:     "cat",
+			Args:        []string{"/proc/self/cmdline"},
+			Argv0:       "my-cat",
+			NofileLimit: nofile,
+		})
+		require.NoError(t, err, "StartAndStream")
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "my-cat\x00/proc/self/cmdline\x00", string(data), "nofile limit %d", nofile)
+	}
+}
+
+// Test the pre-flight probe passes for a working command and reports failures
+func TestProbe(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	ctx := context.Background()
+	require.NoError(t, jm.Probe(ctx, linuxjobs.JobSpec{Command: "true"}))
+
+	err = jm.Probe(ctx, linuxjobs.JobSpec{Command: "bash", Args: []string{"-c", "echo broken; exit 3"}})
+	require.ErrorContains(t, err, "exit code 3")
+	require.ErrorContains(t, err, "broken")
+}
+
+// Test a job killed by the OOM killer is reported as OOMKilled
+func TestJobOOMKilled(t *testing.T) {
+	t.Parallel()
+	jm, err := linuxjobs.NewJobManager(linuxjobs.WithCgroupRoot("/tmp/afile/x"), linuxjobs.WithBestEffortLimits())
+	require.NoError(t, err, "NewJobManager")
+
+	// tail buffers its never-ending input line in memory until it is killed.
+	jobID, err := jm.StartJobWithSpec(context.Background(), linuxjobs.JobSpec{
+		Command:   "tail",
+		Args:      []string{"-n", "1", "/dev/zero"},
+		Resources: linuxjobs.ResourceProfile{MemoryBytes: 32 * 1024 * 1024},
+	})
+	require.NoError(t, err, "StartJobWithSpec")
+
+	require.Eventually(t, func() bool {
+		status, _, _ := jm.Status(jobID)
+		return status == "OOMKilled"
+	}, 10*time.Second, 100*time.Millisecond, "job should move to OOMKilled state")
+
+	sig, ok, err := jm.ExitSignal(jobID)
+	require.NoError(t, err)
+	require.True(t, ok, "OOM kill must be reported as a signal")
+	require.Equal(t, syscall.SIGKILL, sig)
+}
@@ -0,0 +1,14 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rohitsakala/lpaas/pkg/linuxjobs"
+)
+
+func TestMain(m *testing.M) {
+	// Jobs needing pre-exec setup re-execute the test binary as their shim.
+	linuxjobs.Init()
+	os.Exit(m.Run())
+}
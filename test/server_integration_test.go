@@ -3,24 +3,42 @@ package test
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	lpaasv1alpha1 "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/rohitsakala/lpaas/pkg/linuxjobs"
 	"github.com/rohitsakala/lpaas/pkg/server"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
-func ctxWithCN(cn string) context.Context {
+func ctxWithCN(cn string, ou ...string) context.Context {
 	cert := &x509.Certificate{
-		Subject: pkix.Name{CommonName: cn},
+		Subject:   pkix.Name{CommonName: cn, OrganizationalUnit: ou},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
 	}
 	info := credentials.TLSInfo{
 		State: tls.ConnectionState{
@@ -34,17 +52,30 @@ func ctxWithCN(cn string) context.Context {
 // Fake stream for StreamOutput
 type fakeStream struct {
 	lpaasv1alpha1.Lpaas_StreamOutputServer
-	ctx context.Context
-	buf bytes.Buffer
+	ctx    context.Context
+	buf    bytes.Buffer
+	chunks []*lpaasv1alpha1.StreamChunk
+
+	outputClosed bool // a chunk marked the output closed
 }
 
 func (f *fakeStream) Context() context.Context { return f.ctx }
 
 func (f *fakeStream) Send(c *lpaasv1alpha1.StreamChunk) error {
+	if c.GetOutputClosed() {
+		f.outputClosed = true
+	}
 	if len(c.GetData()) == 0 {
 		return nil
 	}
 	f.buf.Write(c.GetData())
+	f.chunks = append(f.chunks, &lpaasv1alpha1.StreamChunk{
+		Data:      bytes.Clone(c.GetData()),
+		Stream:    c.GetStream(),
+		Offset:    c.GetOffset(),
+		Partial:   c.GetPartial(),
+		Discarded: c.GetDiscarded(),
+	})
 	return nil
 }
 
@@ -99,7 +130,7 @@ func TestAuthorization_Isolation(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	_, err = s.StopJob(ctxJyoshna, &lpaasv1alpha1.JobRequest{Id: resp.Id})
+	_, err = s.StopJob(ctxJyoshna, &lpaasv1alpha1.StopJobRequest{Id: resp.Id})
 
 	require.Error(t, err)
 	require.Equal(t, codes.NotFound, status.Code(err))
@@ -132,3 +163,1677 @@ func TestServer_Start_Status_Stream(t *testing.T) {
 	require.Contains(t, output, "one")
 	require.Contains(t, output, "two")
 }
+
+// Fake stream for StreamJobs
+type fakeMultiStream struct {
+	lpaasv1alpha1.Lpaas_StreamJobsServer
+	ctx    context.Context
+	data   map[string]*bytes.Buffer
+	errors map[string]string
+}
+
+func (f *fakeMultiStream) Context() context.Context { return f.ctx }
+
+func (f *fakeMultiStream) Send(c *lpaasv1alpha1.JobStreamChunk) error {
+	if c.Error != nil {
+		f.errors[c.GetId()] = c.GetError()
+		return nil
+	}
+	if f.data[c.GetId()] == nil {
+		f.data[c.GetId()] = new(bytes.Buffer)
+	}
+	f.data[c.GetId()].Write(c.GetData())
+	return nil
+}
+
+// Test streaming two specific jobs at once
+func TestServer_StreamJobs(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	first, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "echo alpha; sleep 0.5; echo beta"},
+	})
+	require.NoError(t, err)
+
+	second, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "echo gamma; sleep 0.5; echo delta"},
+	})
+	require.NoError(t, err)
+
+	stream := &fakeMultiStream{
+		ctx:    ctx,
+		data:   make(map[string]*bytes.Buffer),
+		errors: make(map[string]string),
+	}
+	err = s.StreamJobs(&lpaasv1alpha1.StreamJobsRequest{
+		Ids: []string{first.Id, second.Id, "no-such-job"},
+	}, stream)
+	require.NoError(t, err)
+
+	require.Contains(t, stream.data[first.Id].String(), "alpha")
+	require.Contains(t, stream.data[first.Id].String(), "beta")
+	require.NotContains(t, stream.data[first.Id].String(), "gamma")
+	require.Contains(t, stream.data[second.Id].String(), "gamma")
+	require.Contains(t, stream.data[second.Id].String(), "delta")
+	require.Contains(t, stream.errors, "no-such-job")
+}
+
+// Test delivering a signal to a running job and to a finished one
+func TestServer_SendSignal(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "trap 'echo reloaded; exit 0' HUP; while true; do sleep 0.1; done"},
+	})
+	require.NoError(t, err)
+
+	// Give bash time to install the trap.
+	time.Sleep(200 * time.Millisecond)
+
+	_, err = s.SendSignal(ctx, &lpaasv1alpha1.SendSignalRequest{Id: start.Id, Signal: "HUP"})
+	require.NoError(t, err)
+
+	stream := &fakeStream{ctx: ctx}
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, stream)
+	require.NoError(t, err)
+	require.Contains(t, stream.all(), "reloaded")
+
+	_, err = s.SendSignal(ctx, &lpaasv1alpha1.SendSignalRequest{Id: start.Id, Signal: "HUP"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = s.SendSignal(ctx, &lpaasv1alpha1.SendSignalRequest{Id: start.Id, Signal: "SEGV"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Test stopping a finished job is a failed precondition
+func TestServer_StopFinishedJob(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.NoError(t, err)
+
+	stream := &fakeStream{ctx: ctx}
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, stream)
+	require.NoError(t, err)
+
+	_, err = s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: start.Id})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// Test start and finish timestamps are reported
+func TestServer_StatusTimestamps(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "sleep",
+		Args:    []string{"0.3"},
+	})
+	require.NoError(t, err)
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.NotNil(t, st.StartedAt)
+	require.Nil(t, st.FinishedAt, "running job must not have a finish time")
+
+	require.Eventually(t, func() bool {
+		st, err = s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+
+	runtime := st.FinishedAt.AsTime().Sub(st.StartedAt.AsTime())
+	require.GreaterOrEqual(t, runtime, 300*time.Millisecond)
+}
+
+// Test a job runs in the requested working directory and relative paths are rejected
+func TestServer_WorkingDir(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+	dir := t.TempDir()
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command:    "pwd",
+		WorkingDir: dir,
+	})
+	require.NoError(t, err)
+
+	stream := &fakeStream{ctx: ctx}
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, stream)
+	require.NoError(t, err)
+	require.Equal(t, dir+"\n", stream.all())
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(dir)+1), st.GetOutputBytes())
+
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command:    "pwd",
+		WorkingDir: "relative/dir",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Test only admins may raise the priority of a job with a negative nice
+func TestServer_StartJobNice(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	_, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "nice", Nice: -5})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "nice", Nice: 20})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	start, err := s.StartJob(ctxWithCN("rohit", "admin"), &lpaasv1alpha1.StartJobRequest{Command: "nice", Nice: -5})
+	require.NoError(t, err)
+
+	stream := &fakeStream{ctx: ctx}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, stream))
+	require.Equal(t, "-5\n", stream.all())
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(-5), st.GetInvocation().GetNice())
+
+	_, err = s.RestartJob(ctx, &lpaasv1alpha1.RestartJobRequest{Id: start.Id})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// Test a job writing more than its max_output_bytes is killed
+func TestServer_StartJobMaxOutputBytes(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "yes", MaxOutputBytes: 64 << 10})
+	require.NoError(t, err)
+
+	wait, err := s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id, Timeout: durationpb.New(10 * time.Second)})
+	require.NoError(t, err)
+	require.True(t, wait.Finished, "job must be killed")
+	require.Equal(t, "OutputLimitExceeded", wait.Status)
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Greater(t, st.GetOutputBytes(), uint64(64<<10))
+	require.Equal(t, uint64(64<<10), st.GetInvocation().GetMaxOutputBytes())
+}
+
+// Test oversized and NUL-containing args are rejected before a job starts
+func TestServer_StartJobRejectsInvalidArgs(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(server.WithMaxArgs(3, 16))
+	ctx := ctxWithCN("rohit")
+
+	tests := map[string][]string{
+		"too many args":   {"a", "b", "c", "d"},
+		"args too large":  {strings.Repeat("x", 16)},
+		"NUL in arg":      {"cut\x00short"},
+		"NUL after limit": {"ok", "a\x00"},
+	}
+	for name, args := range tests {
+		_, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "echo", Args: args})
+		require.Equal(t, codes.InvalidArgument, status.Code(err), name)
+	}
+
+	list, err := s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{})
+	require.NoError(t, err)
+	require.Empty(t, list.Jobs, "no job may be started for rejected args")
+
+	// Args just within the limits are accepted, counting a NUL byte each.
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "echo", Args: []string{"a", "b", strings.Repeat("x", 11)}})
+	require.NoError(t, err)
+
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "ech\x00o"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Test a single arg longer than exec accepts is rejected, even within the
+// limit on the size of all args
+func TestServer_StartJobRejectsOverlongArg(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	_, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "echo", Args: []string{strings.Repeat("x", 200<<10)}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "echo", Args: []string{strings.Repeat("x", 128<<10)}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err), "the arg's NUL byte counts")
+
+	// The longest arg exec accepts runs.
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true", Args: []string{strings.Repeat("x", 128<<10-1)}})
+	require.NoError(t, err)
+	wait, err := s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Exited", wait.Status)
+}
+
+// Test stdout and stderr can be streamed separately and are tagged
+func TestServer_StreamOutputSelectsStream(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "echo out; echo err >&2"},
+	})
+	require.NoError(t, err)
+
+	stdout := &fakeStream{ctx: ctx}
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{
+		Id:     start.Id,
+		Stream: lpaasv1alpha1.OutputStream_OUTPUT_STREAM_STDOUT,
+	}, stdout)
+	require.NoError(t, err)
+	require.Equal(t, "out\n", stdout.all())
+
+	stderr := &fakeStream{ctx: ctx}
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{
+		Id:     start.Id,
+		Stream: lpaasv1alpha1.OutputStream_OUTPUT_STREAM_STDERR,
+	}, stderr)
+	require.NoError(t, err)
+	require.Equal(t, "err\n", stderr.all())
+	for _, c := range stderr.chunks {
+		require.Equal(t, lpaasv1alpha1.OutputStream_OUTPUT_STREAM_STDERR, c.GetStream())
+	}
+}
+
+// Test a job can only be removed once it has finished
+func TestServer_RemoveJob(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "sleep",
+		Args:    []string{"0.3"},
+	})
+	require.NoError(t, err)
+
+	_, err = s.RemoveJob(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	require.Eventually(t, func() bool {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+
+	_, err = s.RemoveJob(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	_, err = s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// Fake stream for DownloadOutput
+type fakeDownloadStream struct {
+	lpaasv1alpha1.Lpaas_DownloadOutputServer
+	ctx    context.Context
+	chunks []*lpaasv1alpha1.DownloadChunk
+}
+
+func (f *fakeDownloadStream) Context() context.Context { return f.ctx }
+
+func (f *fakeDownloadStream) Send(c *lpaasv1alpha1.DownloadChunk) error {
+	f.chunks = append(f.chunks, &lpaasv1alpha1.DownloadChunk{
+		Data:       bytes.Clone(c.GetData()),
+		Offset:     c.GetOffset(),
+		TotalBytes: c.GetTotalBytes(),
+		Sha256:     c.GetSha256(),
+	})
+	return nil
+}
+
+// Test downloading the output of a finished job from an offset
+func TestServer_DownloadOutput(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "sleep 0.3; echo hello world"},
+	})
+	require.NoError(t, err)
+
+	err = s.DownloadOutput(&lpaasv1alpha1.DownloadOutputRequest{Id: start.Id}, &fakeDownloadStream{ctx: ctx})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	require.Eventually(t, func() bool {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+
+	stream := &fakeDownloadStream{ctx: ctx}
+	err = s.DownloadOutput(&lpaasv1alpha1.DownloadOutputRequest{Id: start.Id, Offset: 6}, stream)
+	require.NoError(t, err)
+	require.NotEmpty(t, stream.chunks)
+
+	var data []byte
+	for _, c := range stream.chunks[:len(stream.chunks)-1] {
+		data = append(data, c.Data...)
+	}
+	require.Equal(t, "world\n", string(data))
+	require.Equal(t, uint64(6), stream.chunks[0].Offset)
+
+	trailer := stream.chunks[len(stream.chunks)-1]
+	sum := sha256.Sum256([]byte("hello world\n"))
+	require.Equal(t, uint64(12), trailer.TotalBytes)
+	require.Equal(t, sum[:], trailer.Sha256)
+
+	err = s.DownloadOutput(&lpaasv1alpha1.DownloadOutputRequest{Id: start.Id, Offset: 13}, &fakeDownloadStream{ctx: ctx})
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+}
+
+// Test owners without explicit limits get their configured default profile
+func TestServer_OwnerDefaultResources(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(
+		server.WithDefaultResources(linuxjobs.ResourceProfile{CPUPercent: 20}),
+		server.WithOwnerResources("rohit", linuxjobs.ResourceProfile{CPUPercent: 150}),
+	)
+
+	for owner, want := range map[string]string{
+		"rohit":   "150000 100000\n",
+		"jyoshna": "20000 100000\n",
+	} {
+		start, err := s.StartJob(ctxWithCN(owner), &lpaasv1alpha1.StartJobRequest{
+			Command: "sleep",
+			Args:    []string{"1"},
+		})
+		require.NoError(t, err)
+
+		cpuMax, err := os.ReadFile(filepath.Join("/sys/fs/cgroup/lpaas", start.Id, "cpu.max"))
+		require.NoError(t, err)
+		require.Equal(t, want, string(cpuMax), "cpu.max of %s's job", owner)
+	}
+}
+
+// Test owner cgroups nest the cgroups of each owner's jobs and account their usage
+func TestServer_OwnerCgroups(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(server.WithOwnerCgroups())
+	ctx := ctxWithCN("cgroup-owner")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"10"}})
+	require.NoError(t, err)
+
+	ownerCgroup := filepath.Join("/sys/fs/cgroup/lpaas", "cgroup-owner")
+	procs, err := os.ReadFile(filepath.Join(ownerCgroup, start.Id, "cgroup.procs"))
+	require.NoError(t, err)
+	require.NotEmpty(t, procs, "job must run in the nested cgroup")
+
+	controllers, err := os.ReadFile(filepath.Join(ownerCgroup, "cgroup.subtree_control"))
+	require.NoError(t, err)
+	require.Contains(t, string(controllers), "memory")
+
+	_, err = s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: start.Id})
+	require.NoError(t, err)
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+	_, err = s.RemoveJob(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(ownerCgroup, start.Id))
+	require.True(t, os.IsNotExist(err), "job cgroup must be removed")
+	_, err = os.Stat(filepath.Join(ownerCgroup, "cpu.stat"))
+	require.NoError(t, err, "owner cgroup must outlive its jobs")
+}
+
+// Test the per-owner limit only counts running jobs
+func TestServer_MaxRunningJobs(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(server.WithManagerOptions(linuxjobs.WithMaxRunningJobs(1)))
+	ctx := ctxWithCN("rohit")
+
+	first, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"0.3"}})
+	require.NoError(t, err)
+
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Other owners have their own quota.
+	_, err = s.StartJob(ctxWithCN("jyoshna"), &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: first.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.NoError(t, err)
+}
+
+// Test a job killed by a signal reports the signal instead of just failing
+func TestServer_StatusSignal(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "kill -9 $$"},
+	})
+	require.NoError(t, err)
+
+	var st *lpaasv1alpha1.StatusJobResponse
+	require.Eventually(t, func() bool {
+		st, err = s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+
+	require.Equal(t, "Failed", st.Status)
+	require.Equal(t, "SIGKILL", st.GetSignal())
+	require.Equal(t, int32(9), st.GetSignalNumber())
+	require.Equal(t, int32(-1), st.GetExitCode())
+
+	start, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "exit 3"},
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		st, err = s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+	require.Nil(t, st.Signal, "normal exit must not report a signal")
+	require.Nil(t, st.SignalNumber)
+	require.Equal(t, "exit status 3", st.GetExitError())
+	require.Nil(t, st.CleanupError, "the job's cgroup must be cleaned up")
+}
+
+// Test resource usage is reported while running and after the job finished
+func TestServer_GetStats(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "end=$((SECONDS+1)); while [ $SECONDS -lt $end ]; do :; done"},
+	})
+	require.NoError(t, err)
+
+	time.Sleep(300 * time.Millisecond)
+	st, err := s.GetStats(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Positive(t, st.CpuUsage.AsDuration())
+	require.Positive(t, st.MemoryCurrentBytes)
+
+	require.Eventually(t, func() bool {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 3*time.Second, 50*time.Millisecond)
+
+	final, err := s.GetStats(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, final.CpuUsage.AsDuration(), st.CpuUsage.AsDuration())
+}
+
+// Test idempotency keys are scoped per owner
+func TestServer_IdempotencyKeyPerOwner(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctxRohit := ctxWithCN("rohit")
+	ctxJyoshna := ctxWithCN("jyoshna")
+	req := &lpaasv1alpha1.StartJobRequest{
+		Command:        "sleep",
+		Args:           []string{"1"},
+		IdempotencyKey: "nightly-backup",
+	}
+
+	first, err := s.StartJob(ctxRohit, req)
+	require.NoError(t, err)
+
+	retried, err := s.StartJob(ctxRohit, req)
+	require.NoError(t, err)
+	require.Equal(t, first.Id, retried.Id, "same owner reusing a key must get the existing job")
+
+	other, err := s.StartJob(ctxJyoshna, req)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Id, other.Id, "owners must not share idempotency keys")
+
+	_, err = s.GetStatus(ctxJyoshna, &lpaasv1alpha1.JobRequest{Id: other.Id})
+	require.NoError(t, err)
+
+	// Once its job is removed, the key starts a new job.
+	_, err = s.WaitJob(ctxRohit, &lpaasv1alpha1.WaitJobRequest{Id: first.Id})
+	require.NoError(t, err)
+	_, err = s.RemoveJob(ctxRohit, &lpaasv1alpha1.JobRequest{Id: first.Id})
+	require.NoError(t, err)
+	again, err := s.StartJob(ctxRohit, req)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Id, again.Id, "a removed job's key must start a new job")
+}
+
+// Test line mode sends one line per chunk and marks the unfinished last line
+func TestServer_StreamOutputLines(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", `printf 'one\ntwo\n'; sleep 0.1; printf 'thr'; sleep 0.1; printf 'ee\nlast'`},
+	})
+	require.NoError(t, err)
+
+	fs := &fakeStream{ctx: ctx}
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, Lines: true}, fs)
+	require.NoError(t, err)
+
+	var lines []string
+	for _, c := range fs.chunks {
+		lines = append(lines, string(c.Data))
+		require.Equal(t, c.Data[len(c.Data)-1] != '\n', c.Partial, "only a line without newline is partial: %q", c.Data)
+	}
+	require.Equal(t, []string{"one\n", "two\n", "three\n", "last"}, lines)
+	require.Equal(t, uint64(len("one\ntwo\nthree\n")), fs.chunks[3].Offset)
+}
+
+// Test a stream of a silent job ends after the idle timeout while the job keeps running
+func TestServer_StreamOutputIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(server.WithManagerOptions(linuxjobs.WithStreamIdleTimeout(300 * time.Millisecond)))
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "echo started; sleep 5"},
+	})
+	require.NoError(t, err)
+
+	fs := &fakeStream{ctx: ctx}
+	begin := time.Now()
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, fs)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err), "unexpected error: %v", err)
+	require.Less(t, time.Since(begin), 2*time.Second)
+	require.Equal(t, "started\n", fs.all())
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Running", st.Status, "idle stream must not end the job")
+
+	_, err = s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: start.Id})
+	require.NoError(t, err)
+}
+
+// Test tail_lines limits the history returned before following
+func TestServer_StreamOutputTail(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "seq", Args: []string{"1", "5"}})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+
+	for tail, want := range map[uint32]string{0: "1\n2\n3\n4\n5\n", 2: "4\n5\n"} {
+		fs := &fakeStream{ctx: ctx}
+		require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, TailLines: tail}, fs))
+		require.Equal(t, want, fs.all(), "tail_lines %d", tail)
+	}
+}
+
+// Test start_offset resumes the stream at a byte offset of the output
+func TestServer_StreamOutputStartOffset(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "seq", Args: []string{"1", "5"}})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		return err == nil && st.FinishedAt != nil
+	}, 2*time.Second, 50*time.Millisecond)
+
+	fs := &fakeStream{ctx: ctx}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, StartOffset: 4}, fs))
+	require.Equal(t, "3\n4\n5\n", fs.all())
+	require.Equal(t, uint64(4), fs.chunks[0].Offset)
+
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, StartOffset: 4, TailLines: 1}, &fakeStream{ctx: ctx})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Test follow=false returns the output so far of a running job without waiting for it
+func TestServer_StreamOutputNoFollow(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "echo started; sleep 5"},
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		fs := &fakeStream{ctx: ctx}
+		err := s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, Follow: proto.Bool(false)}, fs)
+		return err == nil && fs.all() == "started\n"
+	}, 2*time.Second, 50*time.Millisecond)
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Running", st.Status)
+
+	_, err = s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: start.Id})
+	require.NoError(t, err)
+}
+
+// Test a job closing its output while it keeps running is reported on the stream
+func TestServer_StreamOutputClosedWhileRunning(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "echo daemonizing; exec >&- 2>&-; sleep 1"},
+	})
+	require.NoError(t, err)
+
+	running := make(chan bool, 1)
+	time.AfterFunc(500*time.Millisecond, func() {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		running <- err == nil && st.Status == "Running"
+	})
+
+	fs := &fakeStream{ctx: ctx}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, fs))
+	require.Equal(t, "daemonizing\n", fs.all())
+	require.True(t, fs.outputClosed, "stream must report the closed output")
+	require.True(t, <-running, "job must keep running after closing its output")
+}
+
+// Test a client disconnecting mid-stream ends the handler and releases its reader
+func TestServer_StreamOutputClientDisconnect(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"5"}})
+	require.NoError(t, err)
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, &fakeStream{ctx: streamCtx})
+	require.Equal(t, codes.Canceled, status.Code(err), "unexpected error: %v", err)
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Zero(t, st.ActiveStreams, "reader must be closed after the client left")
+	require.Equal(t, "Running", st.Status)
+
+	_, err = s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: start.Id})
+	require.NoError(t, err)
+}
+
+// Test admins may inspect the jobs of all owners while other clients stay isolated
+func TestServer_AdminAccessAcrossOwners(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	rohit := ctxWithCN("rohit")
+	alice := ctxWithCN("alice")
+	admin := ctxWithCN("ops", "admin")
+
+	start, err := s.StartJob(rohit, &lpaasv1alpha1.StartJobRequest{Command: "echo", Args: []string{"hello"}})
+	require.NoError(t, err)
+	_, err = s.StartJob(alice, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.NoError(t, err)
+
+	// Another owner's job is not found, as before.
+	_, err = s.GetStatus(alice, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, &fakeStream{ctx: alice})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.ListJobs(alice, &lpaasv1alpha1.ListJobsRequest{AllOwners: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	own, err := s.ListJobs(rohit, &lpaasv1alpha1.ListJobsRequest{})
+	require.NoError(t, err)
+	require.Len(t, own.Jobs, 1)
+	require.Equal(t, start.Id, own.Jobs[0].Id)
+
+	// The admin has no jobs of their own but sees everyone's.
+	all, err := s.ListJobs(admin, &lpaasv1alpha1.ListJobsRequest{AllOwners: true})
+	require.NoError(t, err)
+	require.Len(t, all.Jobs, 2)
+	require.Equal(t, "alice", all.Jobs[0].Owner)
+	require.Equal(t, "rohit", all.Jobs[1].Owner)
+
+	_, err = s.GetStatus(admin, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		fs := &fakeStream{ctx: admin}
+		err := s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, Follow: proto.Bool(false)}, fs)
+		return err == nil && fs.all() == "hello\n"
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+// Test admins get the totals of an owner's jobs, including removed ones
+func TestServer_GetOwnerStats(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	rohit := ctxWithCN("rohit")
+	admin := ctxWithCN("ops", "admin")
+
+	failed, err := s.StartJob(rohit, &lpaasv1alpha1.StartJobRequest{Command: "false"})
+	require.NoError(t, err)
+	_, err = s.WaitJob(rohit, &lpaasv1alpha1.WaitJobRequest{Id: failed.Id})
+	require.NoError(t, err)
+	_, err = s.RemoveJob(rohit, &lpaasv1alpha1.JobRequest{Id: failed.Id})
+	require.NoError(t, err)
+
+	running, err := s.StartJob(rohit, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"10"}})
+	require.NoError(t, err)
+	defer s.StopJob(rohit, &lpaasv1alpha1.StopJobRequest{Id: running.Id})
+
+	_, err = s.GetOwnerStats(rohit, &lpaasv1alpha1.OwnerStatsRequest{Owner: "rohit"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = s.GetOwnerStats(admin, &lpaasv1alpha1.OwnerStatsRequest{Owner: "alice"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	resp, err := s.GetOwnerStats(admin, &lpaasv1alpha1.OwnerStatsRequest{Owner: "rohit"})
+	require.NoError(t, err)
+	require.Equal(t, "rohit", resp.Owner)
+	require.Equal(t, uint64(2), resp.JobsStarted)
+	require.Equal(t, uint64(1), resp.JobsRunning)
+	require.Equal(t, uint64(1), resp.JobsFailed)
+	require.NotNil(t, resp.CpuUsage)
+}
+
+type fakeAuditStream struct {
+	lpaasv1alpha1.Lpaas_StreamAllOutputServer
+	ctx context.Context
+
+	mu   sync.Mutex
+	data map[string]*bytes.Buffer // output by owner and job ID
+}
+
+func (f *fakeAuditStream) Context() context.Context { return f.ctx }
+
+func (f *fakeAuditStream) Send(c *lpaasv1alpha1.AuditChunk) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := c.GetOwner() + "/" + c.GetId()
+	if f.data[key] == nil {
+		f.data[key] = &bytes.Buffer{}
+	}
+	f.data[key].Write(c.GetData())
+	return nil
+}
+
+func (f *fakeAuditStream) output(owner, id string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if b := f.data[owner+"/"+id]; b != nil {
+		return b.String()
+	}
+	return ""
+}
+
+// Test admins can follow the output of all owners' jobs once the audit stream is enabled
+func TestServer_StreamAllOutput(t *testing.T) {
+	t.Parallel()
+
+	sink := linuxjobs.NewMemorySink()
+	s := server.NewServer(server.WithAuditStream(), server.WithOutputSink(sink))
+	rohit := ctxWithCN("rohit")
+	alice := ctxWithCN("alice")
+
+	err := s.StreamAllOutput(&lpaasv1alpha1.StreamAllOutputRequest{}, &fakeAuditStream{ctx: rohit})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	err = server.NewServer().StreamAllOutput(&lpaasv1alpha1.StreamAllOutputRequest{}, &fakeAuditStream{ctx: ctxWithCN("ops", "admin")})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	ctx, cancel := context.WithCancel(ctxWithCN("ops", "admin"))
+	stream := &fakeAuditStream{ctx: ctx, data: make(map[string]*bytes.Buffer)}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.StreamAllOutput(&lpaasv1alpha1.StreamAllOutputRequest{}, stream)
+	}()
+
+	// The stream only carries output written after it started, so the jobs
+	// keep writing until it is seen.
+	script := "while true; do echo $0; sleep 0.05; done"
+	first, err := s.StartJob(rohit, &lpaasv1alpha1.StartJobRequest{Command: "bash", Args: []string{"-c", script, "one"}})
+	require.NoError(t, err)
+	defer s.StopJob(rohit, &lpaasv1alpha1.StopJobRequest{Id: first.Id})
+	second, err := s.StartJob(alice, &lpaasv1alpha1.StartJobRequest{Command: "bash", Args: []string{"-c", script, "two"}})
+	require.NoError(t, err)
+	defer s.StopJob(alice, &lpaasv1alpha1.StopJobRequest{Id: second.Id})
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(stream.output("rohit", first.Id), "one\n") &&
+			strings.Contains(stream.output("alice", second.Id), "two\n")
+	}, 5*time.Second, 50*time.Millisecond)
+	require.NotContains(t, stream.output("rohit", first.Id), "two")
+
+	cancel()
+	require.Equal(t, codes.Canceled, status.Code(<-done))
+
+	// The configured sink still receives all output.
+	require.Contains(t, string(sink.Output(linuxjobs.SinkKey{Owner: "rohit", JobID: first.Id})), "one\n")
+}
+
+// Test GetStatus returns the job's invocation without secrets
+func TestServer_GetStatusInvocation(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(server.WithDefaultResources(linuxjobs.ResourceProfile{MemoryBytes: 64 << 20}))
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command:   "echo",
+		Args:      []string{"hello"},
+		Env:       []string{"MODE=test", "API_TOKEN=secret"},
+		Resources: &lpaasv1alpha1.ResourceProfile{CpuPercent: 20},
+	})
+	require.NoError(t, err)
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	inv := st.Invocation
+	require.Equal(t, "echo", inv.Command)
+	require.Equal(t, []string{"hello"}, inv.Args)
+	require.Equal(t, []string{"MODE=test"}, inv.Env)
+	require.Equal(t, []string{"API_TOKEN"}, inv.RedactedEnvKeys)
+	require.Equal(t, uint64(20), inv.Resources.CpuPercent)
+	require.Equal(t, uint64(64<<20), inv.Resources.MemoryBytes, "defaults in effect are recorded")
+}
+
+// sendStream is a grpc.ServerStream accepting every message sent on it.
+type sendStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *sendStream) Context() context.Context { return s.ctx }
+
+func (s *sendStream) SendMsg(any) error { return nil }
+
+func TestLoggingInterceptors(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	unary := server.UnaryLoggingInterceptor(logger, server.IdentityCN)
+	_, err := unary(ctxWithCN("rohit"), &lpaasv1alpha1.JobRequest{Id: "job-1"},
+		&grpc.UnaryServerInfo{FullMethod: "/lpaas.v1alpha1.Lpaas/GetStatus"},
+		func(context.Context, any) (any, error) {
+			return nil, status.Error(codes.NotFound, "job job-1 not found")
+		})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	stream := server.StreamLoggingInterceptor(logger, server.IdentityCN)
+	err = stream(nil, &sendStream{ctx: ctxWithCN("rohit")},
+		&grpc.StreamServerInfo{FullMethod: "/lpaas.v1alpha1.Lpaas/StreamOutput"},
+		func(_ any, ss grpc.ServerStream) error {
+			for range 3 {
+				require.NoError(t, ss.SendMsg(&lpaasv1alpha1.StreamChunk{Data: []byte("secret output")}))
+			}
+			return nil
+		})
+	require.NoError(t, err)
+
+	require.NotContains(t, buf.String(), "secret output", "stream messages must not be logged")
+
+	var entries []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]any
+		require.NoError(t, dec.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+
+	require.Equal(t, "/lpaas.v1alpha1.Lpaas/GetStatus", entries[0]["method"])
+	require.Equal(t, "rohit", entries[0]["owner"])
+	require.Equal(t, "NotFound", entries[0]["code"])
+	require.Equal(t, "WARN", entries[0]["level"])
+	require.Contains(t, entries[0], "duration")
+
+	require.Equal(t, "/lpaas.v1alpha1.Lpaas/StreamOutput", entries[1]["method"])
+	require.Equal(t, "OK", entries[1]["code"])
+	require.Equal(t, float64(3), entries[1]["messages_sent"])
+}
+
+// Test a command missing from PATH is rejected synchronously, and the status
+// and list of a started job report the resolved binary
+func TestServer_StartJobResolvesCommand(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	_, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "lpaas-no-such-command"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "lpaas-no-such-command")
+
+	want, err := exec.LookPath("true")
+	require.NoError(t, err)
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.NoError(t, err)
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, want, st.CommandPath)
+
+	list, err := s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Jobs, 1)
+	require.Equal(t, want, list.Jobs[0].CommandPath)
+}
+
+// Test WaitJob returns once the job finished, and the current status once the
+// wait times out first
+func TestServer_WaitJob(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer(server.WithMaxWait(time.Second))
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "sleep 0.2; exit 3"},
+	})
+	require.NoError(t, err)
+
+	resp, err := s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.True(t, resp.Finished)
+	require.Equal(t, "Failed", resp.Status)
+	require.Equal(t, int32(3), resp.GetExitCode())
+
+	// The server's maximum wait ends the call for a job still running.
+	start, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"10"}})
+	require.NoError(t, err)
+
+	begin := time.Now()
+	resp, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id, Timeout: durationpb.New(time.Minute)})
+	require.NoError(t, err)
+	require.False(t, resp.Finished)
+	require.Equal(t, "Running", resp.Status)
+	require.Less(t, time.Since(begin), 5*time.Second)
+
+	// Stopping the job ends a wait in progress with the signal that killed it.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: start.Id, GracePeriod: durationpb.New(0)})
+	}()
+	resp, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.True(t, resp.Finished)
+	require.Equal(t, "Stopped", resp.Status)
+	require.Equal(t, "SIGKILL", resp.GetSignal())
+	require.Equal(t, int32(9), resp.GetSignalNumber())
+
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: "no-such-job"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = s.WaitJob(cancelled, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err, "a finished job needs no wait")
+}
+
+// Test a lease token grants access to a job from a certificate with another CN
+func TestServer_LeaseToken(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	build := ctxWithCN("ci-build-1234")
+	deploy := ctxWithCN("ci-deploy-5678")
+
+	start, err := s.StartJob(build, &lpaasv1alpha1.StartJobRequest{
+		Command:        "bash",
+		Args:           []string{"-c", "echo leased; sleep 10"},
+		Lease:          true,
+		IdempotencyKey: "pipeline-42",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, start.LeaseToken)
+
+	retried, err := s.StartJob(build, &lpaasv1alpha1.StartJobRequest{Command: "true", Lease: true, IdempotencyKey: "pipeline-42"})
+	require.NoError(t, err)
+	require.Equal(t, start.LeaseToken, retried.LeaseToken, "a retried start must return the same lease")
+
+	// Without the token, or with a wrong one, the job belongs to someone else.
+	_, err = s.GetStatus(deploy, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.StopJob(deploy, &lpaasv1alpha1.StopJobRequest{Id: start.Id, LeaseToken: start.LeaseToken + "x"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	st, err := s.GetStatus(deploy, &lpaasv1alpha1.JobRequest{Id: start.Id, LeaseToken: start.LeaseToken})
+	require.NoError(t, err)
+	require.Equal(t, "Running", st.Status)
+
+	require.Eventually(t, func() bool {
+		stream := &fakeStream{ctx: deploy}
+		err := s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, LeaseToken: start.LeaseToken, Follow: proto.Bool(false)}, stream)
+		return err == nil && stream.all() == "leased\n"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	_, err = s.StopJob(deploy, &lpaasv1alpha1.StopJobRequest{Id: start.Id, LeaseToken: start.LeaseToken, GracePeriod: durationpb.New(0)})
+	require.NoError(t, err)
+
+	// A lease only covers the job it was issued for.
+	other, err := s.StartJob(build, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.NoError(t, err)
+	_, err = s.GetStatus(deploy, &lpaasv1alpha1.JobRequest{Id: other.Id, LeaseToken: start.LeaseToken})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// Test a server restarted with the same job store still reports earlier jobs
+func TestServer_RestoresJobsFromStore(t *testing.T) {
+	t.Parallel()
+
+	store, err := linuxjobs.NewFileStore(filepath.Join(t.TempDir(), "jobs.json"))
+	require.NoError(t, err)
+	ctx := ctxWithCN("rohit")
+
+	s := server.NewServer(server.WithJobStore(store))
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "bash", Args: []string{"-c", "exit 3"}})
+	require.NoError(t, err)
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+	running, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"10"}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: running.Id, GracePeriod: durationpb.New(0)})
+	})
+
+	restarted := server.NewServer(server.WithJobStore(store))
+
+	st, err := restarted.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Failed", st.Status)
+	require.Equal(t, int32(3), st.GetExitCode())
+
+	st, err = restarted.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: running.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Orphaned", st.Status)
+
+	list, err := restarted.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Jobs, 2)
+
+	_, err = restarted.GetStatus(ctxWithCN("alice"), &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.Equal(t, codes.NotFound, status.Code(err), "restored jobs keep their owner")
+}
+
+// Test ListJobs filters by labels, which status and list return
+func TestServer_JobLabels(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	build, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "true",
+		Labels:  map[string]string{"pipeline": "build", "commit": "abc"},
+	})
+	require.NoError(t, err)
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "true",
+		Labels:  map[string]string{"pipeline": "deploy", "commit": "abc"},
+	})
+	require.NoError(t, err)
+
+	_, err = s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true", Labels: map[string]string{"bad key": "x"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: build.Id})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"pipeline": "build", "commit": "abc"}, st.Labels)
+
+	list, err := s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{LabelSelector: map[string]string{"pipeline": "build"}})
+	require.NoError(t, err)
+	require.Len(t, list.Jobs, 1)
+	require.Equal(t, build.Id, list.Jobs[0].Id)
+	require.Equal(t, "build", list.Jobs[0].Labels["pipeline"])
+
+	list, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{LabelSelector: map[string]string{"commit": "abc"}})
+	require.NoError(t, err)
+	require.Len(t, list.Jobs, 2)
+}
+
+// Test ListJobs pages through jobs in a stable order as jobs come and go
+func TestServer_ListJobsPagination(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start := func() string {
+		resp, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+		require.NoError(t, err)
+		return resp.Id
+	}
+	var ids []string
+	for range 5 {
+		ids = append(ids, start())
+	}
+
+	listedIDs := func(list *lpaasv1alpha1.ListJobsResponse) []string {
+		var got []string
+		for _, job := range list.Jobs {
+			got = append(got, job.Id)
+		}
+		return got
+	}
+
+	// All jobs fit on one page, so there is no next page.
+	list, err := s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{PageSize: 5, Sort: lpaasv1alpha1.ListJobsSort_LIST_JOBS_SORT_CREATED})
+	require.NoError(t, err)
+	require.Equal(t, ids, listedIDs(list), "jobs must be listed oldest first")
+	require.Empty(t, list.NextPageToken)
+
+	list, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{PageSize: 2, Sort: lpaasv1alpha1.ListJobsSort_LIST_JOBS_SORT_CREATED})
+	require.NoError(t, err)
+	require.Equal(t, ids[:2], listedIDs(list))
+	require.NotEmpty(t, list.NextPageToken)
+
+	// A job removed from the next page and a new job leave the others in place.
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: ids[2]})
+	require.NoError(t, err)
+	_, err = s.RemoveJob(ctx, &lpaasv1alpha1.JobRequest{Id: ids[2]})
+	require.NoError(t, err)
+	added := start()
+
+	var rest []string
+	for token := list.NextPageToken; token != ""; token = list.NextPageToken {
+		list, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{PageSize: 2, PageToken: token, Sort: lpaasv1alpha1.ListJobsSort_LIST_JOBS_SORT_CREATED})
+		require.NoError(t, err)
+		require.NotEmpty(t, list.Jobs, "pages must not be empty")
+		rest = append(rest, listedIDs(list)...)
+	}
+	require.Equal(t, []string{ids[3], ids[4], added}, rest)
+
+	// Tokens only continue the sort they were issued for.
+	list, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{PageSize: 1})
+	require.NoError(t, err)
+	_, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{PageToken: list.NextPageToken, Sort: lpaasv1alpha1.ListJobsSort_LIST_JOBS_SORT_STATUS})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{PageToken: "not-a-token"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Sorting by status lists running jobs after exited ones.
+	running, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"10"}})
+	require.NoError(t, err)
+	defer s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: running.Id})
+	require.Eventually(t, func() bool {
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: added})
+		return err == nil && st.Status == "Exited"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	list, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{Sort: lpaasv1alpha1.ListJobsSort_LIST_JOBS_SORT_STATUS})
+	require.NoError(t, err)
+	require.Len(t, list.Jobs, 6)
+	require.Equal(t, running.Id, list.Jobs[5].Id)
+	require.Empty(t, list.NextPageToken, "without a page size, all jobs are listed at once")
+}
+
+// Fake stream for WriteStdin
+type fakeStdinStream struct {
+	lpaasv1alpha1.Lpaas_WriteStdinServer
+	ctx    context.Context
+	chunks []*lpaasv1alpha1.StdinChunk
+	resp   *lpaasv1alpha1.WriteStdinResponse
+}
+
+func (f *fakeStdinStream) Context() context.Context { return f.ctx }
+
+func (f *fakeStdinStream) Recv() (*lpaasv1alpha1.StdinChunk, error) {
+	if len(f.chunks) == 0 {
+		return nil, io.EOF
+	}
+	c := f.chunks[0]
+	f.chunks = f.chunks[1:]
+	return c, nil
+}
+
+func (f *fakeStdinStream) SendAndClose(resp *lpaasv1alpha1.WriteStdinResponse) error {
+	f.resp = resp
+	return nil
+}
+
+// Test writing to the stdin of a job
+func TestServer_WriteStdin(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "cat", Stdin: true})
+	require.NoError(t, err)
+
+	stream := &fakeStdinStream{ctx: ctx, chunks: []*lpaasv1alpha1.StdinChunk{
+		{Id: start.Id, Data: []byte("hello ")},
+		{Data: []byte("stdin")},
+	}}
+	require.NoError(t, s.WriteStdin(stream))
+	require.EqualValues(t, len("hello stdin"), stream.resp.BytesWritten)
+
+	// Closing the stream closed the job's stdin, so cat exits.
+	wait, err := s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id, Timeout: durationpb.New(5 * time.Second)})
+	require.NoError(t, err)
+	require.True(t, wait.Finished)
+	require.Equal(t, "Exited", wait.Status)
+
+	out := &fakeStream{ctx: ctx}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, out))
+	require.Equal(t, "hello stdin", out.all())
+
+	// The job finished, so its stdin can no longer be written.
+	err = s.WriteStdin(&fakeStdinStream{ctx: ctx, chunks: []*lpaasv1alpha1.StdinChunk{{Id: start.Id}}})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Other clients cannot write to the job.
+	err = s.WriteStdin(&fakeStdinStream{ctx: ctxWithCN("alice"), chunks: []*lpaasv1alpha1.StdinChunk{{Id: start.Id}}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// Test the command and args of jobs returned by GetStatus and ListJobs
+func TestServer_JobCommandAndArgs(t *testing.T) {
+	t.Parallel()
+
+	for _, redact := range []bool{false, true} {
+		var opts []server.Option
+		if redact {
+			opts = append(opts, server.WithRedactedJobArgs())
+		}
+		s := server.NewServer(opts...)
+		ctx := ctxWithCN("rohit")
+
+		start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "echo", Args: []string{"--password", "hunter2"}})
+		require.NoError(t, err)
+
+		wantArgs := []string{"--password", "hunter2"}
+		if redact {
+			wantArgs = nil
+		}
+
+		st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+		require.NoError(t, err)
+		require.Equal(t, "echo", st.Command)
+		require.Equal(t, wantArgs, st.Args)
+		require.Equal(t, redact, st.ArgsRedacted)
+		require.Equal(t, wantArgs, st.Invocation.Args)
+		require.Equal(t, redact, st.Invocation.ArgsRedacted)
+
+		list, err := s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{})
+		require.NoError(t, err)
+		require.Len(t, list.Jobs, 1)
+		require.Equal(t, "echo", list.Jobs[0].Command)
+		require.Equal(t, wantArgs, list.Jobs[0].Args)
+		require.Equal(t, redact, list.Jobs[0].ArgsRedacted)
+	}
+}
+
+// Test a job started as another user reports it in its invocation
+func TestServer_JobCredential(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	_, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "true", Uid: proto.Uint32(65534)})
+	require.Equal(t, codes.InvalidArgument, status.Code(err), "uid without gid")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "id", Args: []string{"-u"}, Uid: proto.Uint32(65534), Gid: proto.Uint32(65534)})
+	require.NoError(t, err)
+
+	out := &fakeStream{ctx: ctx}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, out))
+	require.Equal(t, "65534\n", out.all())
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, uint32(65534), st.Invocation.GetUid())
+	require.Equal(t, uint32(65534), st.Invocation.GetGid())
+}
+
+// Test shutting the server down stops the running jobs of all owners
+func TestServer_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	var jobs []string
+	for _, owner := range []string{"rohit", "jyoshna"} {
+		resp, err := s.StartJob(ctxWithCN(owner), &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"30"}})
+		require.NoError(t, err)
+		jobs = append(jobs, resp.Id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Shutdown(ctx))
+
+	for i, owner := range []string{"rohit", "jyoshna"} {
+		st, err := s.GetStatus(ctxWithCN(owner), &lpaasv1alpha1.JobRequest{Id: jobs[i]})
+		require.NoError(t, err)
+		require.Equal(t, "Stopped", st.Status)
+	}
+}
+
+// Test StreamOutput sends raw output in chunks of the requested size
+func TestServer_StreamOutputChunkSize(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "head", Args: []string{"-c", "100000", "/dev/zero"}})
+	require.NoError(t, err)
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	fs := &fakeStream{ctx: ctx}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, ChunkSize: 64 * 1024}, fs))
+	require.Len(t, fs.all(), 100000)
+	for _, c := range fs.chunks {
+		require.LessOrEqual(t, len(c.Data), 64*1024)
+	}
+	require.Greater(t, len(fs.chunks[0].Data), 4096, "a larger chunk size must send more per chunk")
+
+	err = s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, ChunkSize: 100}, &fakeStream{ctx: ctx})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_StartJobOutputRetention(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command:         "printf",
+		Args:            []string{"abcdefgh"},
+		OutputRetention: &lpaasv1alpha1.OutputRetention{MaxBytes: 4},
+	})
+	require.NoError(t, err)
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	// The stream skips to the oldest retained output and reports the gap.
+	fs := &fakeStream{ctx: ctx}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, fs))
+	require.Equal(t, "efgh", fs.all())
+	require.Equal(t, uint64(4), fs.chunks[0].Offset)
+	require.True(t, fs.chunks[0].Discarded)
+}
+
+// discardStream marshals the chunks sent to it like gRPC and discards them.
+type discardStream struct {
+	lpaasv1alpha1.Lpaas_StreamOutputServer
+	ctx context.Context
+}
+
+func (d *discardStream) Context() context.Context { return d.ctx }
+
+func (d *discardStream) Send(c *lpaasv1alpha1.StreamChunk) error {
+	_, err := proto.Marshal(c)
+	return err
+}
+
+// Benchmark streaming the output of a finished job in small and large chunks
+func BenchmarkStreamOutputChunkSize(b *testing.B) {
+	const size = 16 * 1024 * 1024
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "head", Args: []string{"-c", "16777216", "/dev/zero"}})
+	require.NoError(b, err)
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(b, err)
+
+	for _, chunkSize := range []uint32{4 * 1024, 64 * 1024} {
+		b.Run(fmt.Sprintf("%dKiB", chunkSize/1024), func(b *testing.B) {
+			b.SetBytes(size)
+			for b.Loop() {
+				req := &lpaasv1alpha1.StreamRequest{Id: start.Id, ChunkSize: chunkSize}
+				require.NoError(b, s.StreamOutput(req, &discardStream{ctx: ctx}))
+			}
+		})
+	}
+}
+
+// gzipStream marshals and gzips the chunks sent to it like gRPC with gzip
+// compression, counting the compressed bytes, and discards them.
+type gzipStream struct {
+	discardStream
+	wire int
+}
+
+func (g *gzipStream) Send(c *lpaasv1alpha1.StreamChunk) error {
+	data, err := proto.Marshal(c)
+	if err != nil {
+		return err
+	}
+	w := &countingWriter{}
+	zw, err := encoding.GetCompressor(gzip.Name).Compress(w)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	g.wire += w.n
+	return nil
+}
+
+// countingWriter counts and discards the bytes written to it.
+type countingWriter struct{ n int }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// Benchmark streaming text logs with and without gzip compression. Each chunk
+// is compressed on its own, as gRPC does, so the ratio grows with the chunk
+// size.
+func BenchmarkStreamOutputCompression(b *testing.B) {
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "awk",
+		Args:    []string{`BEGIN { for (i = 0; i < 200000; i++) printf "2026-10-15T12:00:%02dZ INFO request handled path=/api/v1/jobs/%d status=200 duration=%dus\n", i % 60, i, i % 977 }`},
+	})
+	require.NoError(b, err)
+	st, err := s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(b, err)
+	require.Equal(b, "Exited", st.Status)
+	fs := &fakeStream{ctx: ctx}
+	require.NoError(b, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id}, fs))
+	size := int64(len(fs.all()))
+
+	for _, chunkSize := range []uint32{4 * 1024, 64 * 1024} {
+		b.Run(fmt.Sprintf("none/%dKiB", chunkSize/1024), func(b *testing.B) {
+			b.SetBytes(size)
+			for b.Loop() {
+				req := &lpaasv1alpha1.StreamRequest{Id: start.Id, ChunkSize: chunkSize}
+				require.NoError(b, s.StreamOutput(req, &discardStream{ctx: ctx}))
+			}
+		})
+		b.Run(fmt.Sprintf("gzip/%dKiB", chunkSize/1024), func(b *testing.B) {
+			b.SetBytes(size)
+			var wire int
+			for b.Loop() {
+				req := &lpaasv1alpha1.StreamRequest{Id: start.Id, ChunkSize: chunkSize}
+				gs := &gzipStream{discardStream: discardStream{ctx: ctx}}
+				require.NoError(b, s.StreamOutput(req, gs))
+				wire = gs.wire
+			}
+			b.ReportMetric(float64(size)/float64(wire), "ratio")
+		})
+	}
+}
+
+// slowStream is a fakeStream taking delay to send each chunk, like a client
+// that cannot keep up.
+type slowStream struct {
+	fakeStream
+	delay  time.Duration
+	lagged uint64 // bytes reported as dropped
+}
+
+func (s *slowStream) Send(c *lpaasv1alpha1.StreamChunk) error {
+	time.Sleep(s.delay)
+	s.lagged += c.GetLaggedBytes()
+	return s.fakeStream.Send(c)
+}
+
+// Test a stream too slow for its job drops output to stay within its maximum lag
+func TestServer_StreamOutputMaxLag(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	// The job writes 1 MiB in a burst, then waits before exiting.
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", "head -c 1048576 /dev/zero; sleep 0.5; echo done"},
+	})
+	require.NoError(t, err)
+
+	const maxLag = 64 * 1024
+	stream := &slowStream{fakeStream: fakeStream{ctx: ctx}, delay: 20 * time.Millisecond}
+	require.NoError(t, s.StreamOutput(&lpaasv1alpha1.StreamRequest{Id: start.Id, ChunkSize: 16 * 1024, MaxLagBytes: maxLag}, stream))
+
+	// The output in between was dropped and reported, and the stream caught up
+	// with the end of the output.
+	require.Positive(t, stream.lagged)
+	require.Equal(t, uint64(1048576+len("done\n")), uint64(len(stream.all()))+stream.lagged)
+	require.True(t, strings.HasSuffix(stream.all(), "done\n"))
+}
+
+// Test restarting a finished job runs its command again as a new job
+func TestServer_RestartJob(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx := ctxWithCN("rohit")
+
+	start, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"0.2"}, Labels: map[string]string{"team": "infra"}})
+	require.NoError(t, err)
+
+	_, err = s.RestartJob(ctx, &lpaasv1alpha1.RestartJobRequest{Id: start.Id})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = s.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	// Other owners cannot restart the job.
+	_, err = s.RestartJob(ctxWithCN("jyoshna"), &lpaasv1alpha1.RestartJobRequest{Id: start.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	restarted, err := s.RestartJob(ctx, &lpaasv1alpha1.RestartJobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.NotEqual(t, start.Id, restarted.Id)
+
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: restarted.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Running", st.Status)
+	require.Equal(t, []string{"0.2"}, st.Invocation.Args)
+	require.Equal(t, "infra", st.Labels["team"])
+
+	// The finished job is kept.
+	st, err = s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Exited", st.Status)
+}
+
+func TestServer_StartJobCancelled(t *testing.T) {
+	t.Parallel()
+
+	s := server.NewServer()
+	ctx, cancel := context.WithCancel(ctxWithCN("rohit"))
+	cancel()
+
+	_, err := s.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"10"}})
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	// A job started by an RPC that returns keeps running.
+	rpcCtx, rpcCancel := context.WithCancel(ctxWithCN("rohit"))
+	start, err := s.StartJob(rpcCtx, &lpaasv1alpha1.StartJobRequest{Command: "sleep", Args: []string{"10"}, ConfirmRunning: true})
+	require.NoError(t, err)
+	rpcCancel()
+
+	ctx = ctxWithCN("rohit")
+	defer s.StopJob(ctx, &lpaasv1alpha1.StopJobRequest{Id: start.Id})
+
+	list, err := s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Jobs, 1)
+
+	time.Sleep(100 * time.Millisecond)
+	st, err := s.GetStatus(ctx, &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err)
+	require.Equal(t, "Running", st.Status)
+}
@@ -0,0 +1,443 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	lpaasv1alpha1 "github.com/rohitsakala/lpaas/api/gen/lpaas/v1alpha1"
+	"github.com/rohitsakala/lpaas/pkg/server"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// testCA issues certificates for TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, cn string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for cn signed by ca.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// crl returns a PEM certificate revocation list of ca revoking the
+// certificates with serials.
+func (ca *testCA) crl(t *testing.T, serials ...*big.Int) []byte {
+	t.Helper()
+	tmpl := &x509.RevocationList{
+		Number:     big.NewInt(time.Now().UnixNano()),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+	for _, serial := range serials {
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, ca.cert, ca.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+// ctxWithCert returns a context of a call from a client presenting the
+// certificate in certPEM.
+func ctxWithCert(t *testing.T, certPEM []byte) context.Context {
+	t.Helper()
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	info := credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: info})
+}
+
+// clientConfig returns the TLS config of a client trusting the server CA and
+// presenting a certificate signed by clientCA.
+func (ca *testCA) clientConfig(t *testing.T, clientCA *testCA) *tls.Config {
+	t.Helper()
+	certPEM, keyPEM := clientCA.issue(t, "rohit", x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}, ServerName: "localhost"}
+}
+
+// writeFile replaces the file at path atomically, as certificate rotation
+// tools do.
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	tmp := path + ".new"
+	require.NoError(t, os.WriteFile(tmp, data, 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+// handshake connects to ln with cfg and returns the CN of the server
+// certificate, along with the error of the server side of the handshake.
+func handshake(t *testing.T, ln net.Listener, cfg *tls.Config) (string, error) {
+	t.Helper()
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), cfg)
+	if err != nil {
+		return "", <-serverErr
+	}
+	defer conn.Close()
+	// With TLS 1.3 the client learns its certificate was rejected only once
+	// it reads, so the server's verdict decides.
+	if err := <-serverErr; err != nil {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+// Test rotated server certificates and client CAs are used by new connections
+func TestCertReloader_RotatesCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ca := newTestCA(t, "ca-1")
+	certPEM, keyPEM := ca.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, caFile, ca.pem)
+
+	certs, err := server.NewCertReloader(certFile, keyFile, caFile, "", 0, nil)
+	require.NoError(t, err, "NewCertReloader")
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", certs.Config(&tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS13,
+	}))
+	require.NoError(t, err, "Listen")
+	defer ln.Close()
+
+	cn, err := handshake(t, ln, ca.clientConfig(t, ca))
+	require.NoError(t, err, "handshake")
+	require.Equal(t, "server-1", cn)
+
+	// A new keypair is served to the next connection.
+	certPEM, keyPEM = ca.issue(t, "server-2", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	cn, err = handshake(t, ln, ca.clientConfig(t, ca))
+	require.NoError(t, err, "handshake after rotating the keypair")
+	require.Equal(t, "server-2", cn)
+
+	// A broken keypair is not loaded; the previous one stays in use.
+	writeFile(t, keyFile, []byte("not a key"))
+	cn, err = handshake(t, ln, ca.clientConfig(t, ca))
+	require.NoError(t, err, "handshake with a broken keypair on disk")
+	require.Equal(t, "server-2", cn)
+	writeFile(t, keyFile, keyPEM)
+
+	// Once the client CA is replaced, only clients of the new CA are accepted.
+	newCA := newTestCA(t, "ca-2")
+	writeFile(t, caFile, newCA.pem)
+
+	_, err = handshake(t, ln, ca.clientConfig(t, ca))
+	require.Error(t, err, "client certificate of the replaced CA must be rejected")
+	_, err = handshake(t, ln, ca.clientConfig(t, newCA))
+	require.NoError(t, err, "client certificate of the new CA must be accepted")
+}
+
+// Test files are not checked again before the reload interval passed
+func TestCertReloader_ChecksOncePerInterval(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ca := newTestCA(t, "ca-1")
+	certPEM, keyPEM := ca.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, caFile, ca.pem)
+
+	certs, err := server.NewCertReloader(certFile, keyFile, caFile, "", time.Hour, nil)
+	require.NoError(t, err, "NewCertReloader")
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", certs.Config(&tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS13,
+	}))
+	require.NoError(t, err, "Listen")
+	defer ln.Close()
+
+	certPEM, keyPEM = ca.issue(t, "server-2", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	cn, err := handshake(t, ln, ca.clientConfig(t, ca))
+	require.NoError(t, err, "handshake")
+	require.Equal(t, "server-1", cn)
+}
+
+// Test calls with a client certificate listed in the CRL are rejected
+func TestCertReloader_RejectsRevokedClients(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	crlFile := filepath.Join(dir, "ca.crl")
+
+	ca := newTestCA(t, "ca-1")
+	certPEM, keyPEM := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, caFile, ca.pem)
+	writeFile(t, crlFile, ca.crl(t))
+
+	certs, err := server.NewCertReloader(certFile, keyFile, caFile, crlFile, 0, nil)
+	require.NoError(t, err, "NewCertReloader")
+
+	clientPEM, _ := ca.issue(t, "rohit", x509.ExtKeyUsageClientAuth)
+	ctx := ctxWithCert(t, clientPEM)
+	intercept := server.UnaryRevocationInterceptor(certs.CheckRevoked)
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/lpaas.v1alpha1.Lpaas/ListJobs"}
+
+	resp, err := intercept(ctx, nil, info, handler)
+	require.NoError(t, err, "client not in the CRL must be accepted")
+	require.Equal(t, "ok", resp)
+
+	// A CRL revoking the client takes effect on the next call.
+	block, _ := pem.Decode(clientPEM)
+	client, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	writeFile(t, crlFile, ca.crl(t, client.SerialNumber))
+
+	_, err = intercept(ctx, nil, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err), "revoked client must be rejected: %v", err)
+	require.ErrorIs(t, certs.CheckRevoked(client), server.ErrCertRevoked)
+
+	// A CRL of another CA is not loaded.
+	writeFile(t, crlFile, newTestCA(t, "ca-1").crl(t))
+	_, err = intercept(ctx, nil, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err), "the CRL loaded before must stay in use")
+
+	_, err = server.NewCertReloader(certFile, keyFile, caFile, crlFile, 0, nil)
+	require.Error(t, err, "CRL not signed by the CA must be refused")
+}
+
+// Test calls with an expired client certificate are rejected
+func TestServer_RejectsExpiredClientCertificate(t *testing.T) {
+	ca := newTestCA(t, "ca-1")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "rohit"},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     time.Now().Add(-time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	ctx := ctxWithCert(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	s := server.NewServer()
+	_, err = s.ListJobs(ctx, &lpaasv1alpha1.ListJobsRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err), "expired client certificate must be rejected: %v", err)
+}
+
+// ctxWithSPIFFEID returns a context of a call from a client whose certificate
+// carries cn, the SPIFFE ID id as URI SAN, if set, and the OUs ou.
+func ctxWithSPIFFEID(t *testing.T, cn, id string, ou ...string) context.Context {
+	t.Helper()
+	cert := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: cn, OrganizationalUnit: ou},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	if id != "" {
+		u, err := url.Parse(id)
+		require.NoError(t, err)
+		cert.URIs = []*url.URL{u}
+	}
+	info := credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: info})
+}
+
+// Test the owner of jobs is the full SPIFFE ID with the URI SAN identity source
+func TestServer_IdentitySource(t *testing.T) {
+	s := server.NewServer(server.WithIdentitySource(server.IdentityURISAN))
+
+	runner := ctxWithSPIFFEID(t, "runner", "spiffe://example.org/ci/runner")
+	start, err := s.StartJob(runner, &lpaasv1alpha1.StartJobRequest{Command: "true"})
+	require.NoError(t, err, "StartJob")
+
+	// A certificate with the same CN but no URI SAN falls back to its CN,
+	// which is another owner.
+	_, err = s.GetStatus(ctxWithSPIFFEID(t, "runner", ""), &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.GetStatus(ctxWithSPIFFEID(t, "other", "spiffe://example.org/ci/runner"), &lpaasv1alpha1.JobRequest{Id: start.Id})
+	require.NoError(t, err, "the SPIFFE ID, not the CN, must name the owner")
+
+	all, err := s.ListJobs(ctxWithSPIFFEID(t, "ops", "spiffe://example.org/ops", "admin"), &lpaasv1alpha1.ListJobsRequest{AllOwners: true})
+	require.NoError(t, err, "ListJobs")
+	require.Len(t, all.Jobs, 1)
+	require.Equal(t, "spiffe://example.org/ci/runner", all.Jobs[0].Owner)
+}
+
+// Test identity source names are parsed
+func TestParseIdentitySource(t *testing.T) {
+	for _, name := range []string{"cn", "uri-san", "dns-san", "email-san"} {
+		src, err := server.ParseIdentitySource(name)
+		require.NoError(t, err)
+		require.Equal(t, server.IdentitySource(name), src)
+	}
+	_, err := server.ParseIdentitySource("serial")
+	require.Error(t, err)
+}
+
+// payloadStats sums the sizes of the messages a client received.
+type payloadStats struct {
+	mu           sync.Mutex
+	length, wire int // uncompressed and compressed bytes
+}
+
+func (p *payloadStats) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context   { return ctx }
+func (p *payloadStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context { return ctx }
+func (p *payloadStats) HandleConn(context.Context, stats.ConnStats)                       {}
+
+func (p *payloadStats) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if in, ok := s.(*stats.InPayload); ok {
+		p.mu.Lock()
+		p.length += in.Length
+		p.wire += in.CompressedLength
+		p.mu.Unlock()
+	}
+}
+
+// Test clients can stream output gzip compressed over a real connection
+func TestServer_StreamOutputGzip(t *testing.T) {
+	ca := newTestCA(t, "ca-1")
+	certPEM, keyPEM := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	s := server.NewServer()
+	lpaasv1alpha1.RegisterLpaasServer(grpcServer, s)
+	go grpcServer.Serve(ln)
+	defer grpcServer.Stop()
+
+	ps := &payloadStats{}
+	conn, err := grpc.NewClient(ln.Addr().String(),
+		grpc.WithTransportCredentials(credentials.NewTLS(ca.clientConfig(t, ca))),
+		grpc.WithStatsHandler(ps))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := lpaasv1alpha1.NewLpaasClient(conn)
+	ctx := context.Background()
+
+	// Log lines repeat much of their text, like typical logs.
+	start, err := client.StartJob(ctx, &lpaasv1alpha1.StartJobRequest{
+		Command: "bash",
+		Args:    []string{"-c", `for i in $(seq 1 2000); do echo "2026-10-15T12:00:00Z INFO request handled path=/api/v1/jobs/$i status=200"; done`},
+	})
+	require.NoError(t, err)
+	_, err = client.WaitJob(ctx, &lpaasv1alpha1.WaitJobRequest{Id: start.Id})
+	require.NoError(t, err)
+
+	stream, err := client.StreamOutput(ctx, &lpaasv1alpha1.StreamRequest{Id: start.Id}, grpc.UseCompressor(gzip.Name))
+	require.NoError(t, err)
+	var out bytes.Buffer
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		out.Write(chunk.Data)
+	}
+
+	require.Equal(t, 2000, strings.Count(out.String(), "\n"))
+	require.Contains(t, out.String(), "path=/api/v1/jobs/2000 status=200\n")
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	require.Less(t, ps.wire, ps.length/4, "output must be compressed on the wire")
+}